@@ -0,0 +1,72 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbort(t *testing.T) {
+	t.Parallel()
+
+	completer := func(Document) []Suggestion {
+		return []Suggestion{{Text: "git"}, {Text: "go"}}
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "ctrl+g closes the menu and keeps the input", input: "g\t\x07\r", want: "g"},
+		{name: "escape escape closes the menu", input: "g\t\x1b\x1b\r", want: "g"},
+		{name: "ctrl+g drops a numeric argument", input: "ab\x1b3\x07x\r", want: "abx"},
+		{name: "ctrl+g ends the history search", input: "ab\x12gi\x07\r", want: "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{Prefix: "$ ", Completer: completer}, tt.input)
+			p.SetHistory([]string{"git status"})
+			var out bytes.Buffer
+			p.renderer.output = &out
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+
+	t.Run("rings the bell", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		p, err := New("$ ", WithTerminal(newMockTerminal("ab\x07\r")), WithOutput(&out))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ab", result)
+		assert.Contains(t, out.String(), "\a")
+	})
+
+	t.Run("a rebound key ends the history search", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		keyMap.Bind('\x18', ActionAbort) // Ctrl+X
+		p := newForTestingWithConfig(t, Config{Prefix: "$ ", KeyMap: keyMap}, "ab\x12gi\x18\r")
+		p.SetHistory([]string{"git status"})
+		p.renderer.output = &bytes.Buffer{}
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ab", result)
+	})
+}
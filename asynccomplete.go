@@ -0,0 +1,177 @@
+package prompt
+
+import (
+	"context"
+	"time"
+)
+
+// defaultCompletionDebounce is how long the suggestions of an async completer
+// wait for typing to pause before they are refreshed.
+const defaultCompletionDebounce = 100 * time.Millisecond
+
+// AsyncCompleteFunc is a completion function that may block, for example on a
+// network request. It should return promptly with ctx.Err() once ctx is
+// cancelled, which happens when its result is no longer needed.
+type AsyncCompleteFunc func(ctx context.Context, d Document) ([]Suggestion, error)
+
+// WithAsyncCompleter sets a completer that runs off the event loop, for
+// completers that are slow or may block, such as ones calling a network API.
+// Keys keep being handled while it runs and a dimmed "loading…" row is shown
+// in the suggestion menu until the results arrive.
+//
+// Tab starts a request. While the menu is open or loading, typing or deleting
+// refreshes it once the user stops typing for the debounce interval (see
+// WithCompletionDebounce); the context of a request that has become stale is
+// cancelled. Any other key that closes the menu cancels the request in flight.
+// If the completer returns an error, no suggestions are shown.
+//
+// An async completer takes precedence over one set with WithCompleter for the
+// suggestion menu.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithAsyncCompleter(func(ctx context.Context, d prompt.Document) ([]prompt.Suggestion, error) {
+//		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.example.com/complete?q="+url.QueryEscape(d.GetWordBeforeCursor()), nil)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return fetchSuggestions(req)
+//	}))
+func WithAsyncCompleter(completer AsyncCompleteFunc) Option {
+	return func(c *Config) {
+		c.AsyncCompleter = completer
+	}
+}
+
+// WithCompletionDebounce sets how long typing must pause before the open
+// suggestion menu of an async completer is refreshed. The default is 100ms.
+func WithCompletionDebounce(d time.Duration) Option {
+	return func(c *Config) {
+		c.CompletionDebounce = d
+	}
+}
+
+// asyncResult is the outcome of one async completer request.
+type asyncResult struct {
+	doc         Document
+	suggestions []Suggestion
+	err         error
+	explicit    bool // The request was started by Tab rather than by typing
+}
+
+// asyncCompleter runs requests to the async completer for one RunWithContext
+// call. At most one request is in flight: starting a new one cancels the old
+// one and stops listening to it, so a stale result is never delivered.
+//
+// All methods are safe on a nil receiver, which stands for a prompt without an
+// async completer; the channels are then nil and never fire in a select.
+type asyncCompleter struct {
+	ctx      context.Context
+	complete AsyncCompleteFunc
+	debounce time.Duration
+	timer    *time.Timer        // Pending debounced refresh, nil when none
+	pending  Document           // Input the pending refresh completes
+	cancel   context.CancelFunc // Cancels the request in flight
+	results  chan asyncResult   // Delivers the result of the request in flight, nil when none
+}
+
+// newAsyncCompleter returns the async completer state for a run under ctx, or
+// nil when no async completer is configured.
+func (p *Prompt) newAsyncCompleter(ctx context.Context) *asyncCompleter {
+	if p.config.AsyncCompleter == nil {
+		return nil
+	}
+	debounce := p.config.CompletionDebounce
+	if debounce <= 0 {
+		debounce = defaultCompletionDebounce
+	}
+	return &asyncCompleter{ctx: ctx, complete: p.config.AsyncCompleter, debounce: debounce}
+}
+
+// start cancels any pending or running request and starts one for doc.
+func (a *asyncCompleter) start(doc Document, explicit bool) {
+	a.stop()
+	ctx, cancel := context.WithCancel(a.ctx)
+	results := make(chan asyncResult, 1) // Buffered so an abandoned request never blocks
+	go func() {
+		suggestions, err := a.complete(ctx, doc)
+		results <- asyncResult{doc: doc, suggestions: suggestions, err: err, explicit: explicit}
+	}()
+	a.cancel = cancel
+	a.results = results
+}
+
+// schedule starts a request for doc once the debounce interval passes without
+// another call. The request in flight is cancelled right away since its
+// result would be stale.
+func (a *asyncCompleter) schedule(doc Document) {
+	a.stop()
+	a.pending = doc
+	a.timer = time.NewTimer(a.debounce)
+}
+
+// fire starts the request scheduled by schedule when its timer expires.
+func (a *asyncCompleter) fire() {
+	a.timer = nil
+	a.start(a.pending, false)
+}
+
+// received marks the request in flight as done after its result was read.
+func (a *asyncCompleter) received() {
+	a.cancel()
+	a.cancel = nil
+	a.results = nil
+}
+
+// stop cancels the pending refresh and the request in flight.
+func (a *asyncCompleter) stop() {
+	if a == nil {
+		return
+	}
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	if a.cancel != nil {
+		a.cancel()
+		a.cancel = nil
+	}
+	a.results = nil
+}
+
+// loading reports whether suggestions are being fetched or about to be.
+func (a *asyncCompleter) loading() bool {
+	return a != nil && (a.timer != nil || a.results != nil)
+}
+
+// timerC returns the channel of the pending refresh, or nil.
+func (a *asyncCompleter) timerC() <-chan time.Time {
+	if a == nil || a.timer == nil {
+		return nil
+	}
+	return a.timer.C
+}
+
+// resultC returns the channel of the request in flight, or nil.
+func (a *asyncCompleter) resultC() <-chan asyncResult {
+	if a == nil {
+		return nil
+	}
+	return a.results
+}
+
+// renderMenu draws the prompt with the suggestion menu, showing a dimmed
+// loading row instead while an async request has not returned anything yet,
+// or the menu hint when one is set and there are no suggestions.
+func (p *Prompt) renderMenu(async *asyncCompleter, suggestions []Suggestion, selected, offset int) error {
+	hint := p.menuHint
+	if len(suggestions) == 0 && async.loading() {
+		hint = p.message(MsgCompletionLoading)
+	}
+	if len(suggestions) == 0 && hint != "" {
+		hintColor := p.config.ColorScheme.Suggestion.Description
+		hintRow := []Suggestion{{Text: hint, Color: &hintColor}}
+		return p.renderWithSuggestionsOffset(hintRow, -1, 0)
+	}
+	return p.renderWithSuggestionsOffset(suggestions, selected, offset)
+}
@@ -0,0 +1,175 @@
+package prompt
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// asyncPrompt runs a prompt over a pipe so a test can type keys while the
+// async completer is running.
+type asyncPrompt struct {
+	t      *testing.T
+	keys   *io.PipeWriter
+	rw     *session
+	result chan string
+}
+
+func runAsyncPrompt(t *testing.T, options ...Option) *asyncPrompt {
+	t.Helper()
+
+	reader, writer := io.Pipe()
+	rw := &session{Reader: reader}
+	options = append(options, WithStream(rw, WindowSize{Width: 80, Height: 24}, nil), WithMemoryHistory(10))
+	p, err := New("> ", options...)
+	require.NoError(t, err)
+
+	ap := &asyncPrompt{t: t, keys: writer, rw: rw, result: make(chan string, 1)}
+	go func() {
+		result, err := p.Run()
+		if err != nil {
+			result = "error: " + err.Error()
+		}
+		ap.result <- result
+	}()
+	t.Cleanup(func() {
+		writer.Close()
+		p.Close()
+	})
+	return ap
+}
+
+func (ap *asyncPrompt) typeKeys(keys string) {
+	ap.t.Helper()
+	_, err := ap.keys.Write([]byte(keys))
+	require.NoError(ap.t, err)
+}
+
+func (ap *asyncPrompt) waitForOutput(text string) {
+	ap.t.Helper()
+	require.Eventually(ap.t, func() bool {
+		return strings.Contains(ap.rw.out.String(), text)
+	}, 5*time.Second, time.Millisecond, "output never contained %q", text)
+}
+
+func (ap *asyncPrompt) wait() string {
+	ap.t.Helper()
+	select {
+	case result := <-ap.result:
+		return result
+	case <-time.After(5 * time.Second):
+		ap.t.Fatal("prompt did not return")
+		return ""
+	}
+}
+
+func TestAsyncCompleter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Tab shows a loading row until the results arrive", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		ap := runAsyncPrompt(t, WithAsyncCompleter(func(ctx context.Context, _ Document) ([]Suggestion, error) {
+			<-release
+			return []Suggestion{{Text: "status"}, {Text: "stash"}}, nil
+		}))
+
+		ap.typeKeys("st\t")
+		ap.waitForOutput("loading…")
+		close(release)
+		ap.waitForOutput("stash")
+		ap.typeKeys("\x1b[B\r\r")
+
+		assert.Equal(t, "stash", ap.wait())
+	})
+
+	t.Run("a single match completes the word like synchronous Tab", func(t *testing.T) {
+		t.Parallel()
+
+		ap := runAsyncPrompt(t, WithAsyncCompleter(func(context.Context, Document) ([]Suggestion, error) {
+			return []Suggestion{{Text: "checkout"}, {Text: "commit"}}, nil
+		}))
+
+		ap.typeKeys("ch\t")
+		ap.waitForOutput("checkout")
+		ap.typeKeys("\r")
+
+		assert.Equal(t, "checkout", ap.wait())
+	})
+
+	t.Run("typing cancels the stale request and refreshes after the debounce", func(t *testing.T) {
+		t.Parallel()
+
+		requests := make(chan string, 10)
+		cancelled := make(chan string, 10)
+		ap := runAsyncPrompt(t, WithCompletionDebounce(10*time.Millisecond), WithAsyncCompleter(func(ctx context.Context, d Document) ([]Suggestion, error) {
+			requests <- d.Text
+			if d.Text == "s" {
+				<-ctx.Done()
+				cancelled <- d.Text
+				return nil, ctx.Err()
+			}
+			return []Suggestion{{Text: "start"}, {Text: "stop"}}, nil
+		}))
+
+		ap.typeKeys("s\t")
+		assert.Equal(t, "s", <-requests)
+		ap.typeKeys("t")
+		assert.Equal(t, "s", <-cancelled, "the request for the old input is cancelled")
+		assert.Equal(t, "st", <-requests, "the menu is refreshed for the new input")
+		ap.waitForOutput("stop")
+		ap.typeKeys("\r\r")
+
+		assert.Equal(t, "start", ap.wait())
+	})
+
+	t.Run("complete while typing queries after the debounce without Tab", func(t *testing.T) {
+		t.Parallel()
+
+		requests := make(chan string, 10)
+		ap := runAsyncPrompt(t, WithCompleteWhileTyping(true), WithCompletionDebounce(time.Millisecond),
+			WithAsyncCompleter(func(_ context.Context, d Document) ([]Suggestion, error) {
+				requests <- d.Text
+				return []Suggestion{{Text: "deploy"}, {Text: "describe"}}, nil
+			}))
+
+		ap.typeKeys("de")
+		for text := range requests {
+			if text == "de" {
+				break // Earlier requests, if any, were for "d"
+			}
+		}
+		ap.waitForOutput("describe")
+		ap.typeKeys("\r")
+
+		assert.Equal(t, "de", ap.wait(), "nothing is selected, so Enter submits the input")
+	})
+
+	t.Run("an error shows no suggestions", func(t *testing.T) {
+		t.Parallel()
+
+		calls := make(chan struct{}, 1)
+		ap := runAsyncPrompt(t, WithAsyncCompleter(func(context.Context, Document) ([]Suggestion, error) {
+			calls <- struct{}{}
+			return []Suggestion{{Text: "ignored"}}, assert.AnError
+		}))
+
+		ap.typeKeys("x\t")
+		<-calls
+		require.Eventually(t, func() bool {
+			// The prompt is redrawn after the loading row once the error arrived
+			out := ap.rw.out.String()
+			i := strings.LastIndex(out, "loading…")
+			return i >= 0 && strings.Contains(out[i:], "> ")
+		}, 5*time.Second, time.Millisecond)
+		ap.typeKeys("\r")
+
+		assert.Equal(t, "x", ap.wait())
+	})
+}
@@ -0,0 +1,180 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoSuggestion(t *testing.T) {
+	t.Parallel()
+
+	completer := func(Document) []Suggestion {
+		return []Suggestion{{Text: "status"}, {Text: "stash"}}
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		history []string
+		buffer  string
+		cursor  int
+		want    string
+	}{
+		{
+			name:    "most recent matching history entry wins",
+			config:  Config{AutoSuggest: true},
+			history: []string{"git status", "git stash pop", "ls"},
+			buffer:  "git st",
+			cursor:  6,
+			want:    "ash pop",
+		},
+		{
+			name:    "completer is used when no history entry matches",
+			config:  Config{AutoSuggest: true, Completer: completer},
+			history: []string{"ls"},
+			buffer:  "git sta",
+			cursor:  7,
+			want:    "tus",
+		},
+		{
+			name:    "disabled by default",
+			history: []string{"git status"},
+			buffer:  "git",
+			cursor:  3,
+			want:    "",
+		},
+		{
+			name:    "nothing while the cursor is inside the input",
+			config:  Config{AutoSuggest: true},
+			history: []string{"git status"},
+			buffer:  "git",
+			cursor:  1,
+			want:    "",
+		},
+		{
+			name:    "nothing for an empty buffer",
+			config:  Config{AutoSuggest: true},
+			history: []string{"git status"},
+			want:    "",
+		},
+		{
+			name:    "an exact match has nothing to add",
+			config:  Config{AutoSuggest: true},
+			history: []string{"ls"},
+			buffer:  "ls",
+			cursor:  2,
+			want:    "",
+		},
+		{
+			name:    "multi-line entries are skipped",
+			config:  Config{AutoSuggest: true},
+			history: []string{"select 1", "select\n2"},
+			buffer:  "sel",
+			cursor:  3,
+			want:    "ect 1",
+		},
+		{
+			name:    "masked input is never completed from history",
+			config:  Config{AutoSuggest: true, DisplayTransform: maskAllButLast4},
+			history: []string{"4111 1111 1111 1111"},
+			buffer:  "4111",
+			cursor:  4,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, tt.config, "")
+			p.history = tt.history
+			p.buffer = []rune(tt.buffer)
+			p.cursor = tt.cursor
+
+			assert.Equal(t, tt.want, p.autoSuggestion())
+		})
+	}
+}
+
+func TestWithAutoSuggest(t *testing.T) {
+	t.Parallel()
+
+	history := []string{"git status --short"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "right arrow accepts the whole suggestion", input: "git\x1b[C\r", want: "git status --short"},
+		{name: "end accepts the whole suggestion", input: "git\x1b[F\r", want: "git status --short"},
+		{name: "ctrl+right accepts the next word", input: "git\x1b[1;5C\r", want: "git status"},
+		{name: "ctrl+right twice accepts two words", input: "git\x1b[1;5C\x1b[1;5C\r", want: "git status --short"},
+		{name: "enter submits only what was typed", input: "git\r", want: "git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := Config{Prefix: "$ "}
+			WithAutoSuggest()(&config)
+			p := newForTestingWithConfig(t, config, tt.input)
+			p.history = history
+			p.renderer.output = &bytes.Buffer{}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+
+	t.Run("suggestion is drawn dimmed and erased on submit", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Prefix: "$ ", AutoSuggest: true}
+		p := newForTestingWithConfig(t, config, "git\r")
+		p.history = history
+		var output bytes.Buffer
+		p.output = &output
+		p.renderer.output = &output
+
+		_, err := p.Run()
+
+		require.NoError(t, err)
+		dim := ThemeDefault.Suggestion.Description.ToANSI()
+		assert.Contains(t, output.String(), dim+" status --short"+Reset()+"\x1b[15D")
+		assert.True(t, strings.HasSuffix(output.String(), "\x1b[K\r\n"+bracketedPasteDisableSequence), "suggestion not erased: %q", output.String())
+	})
+
+	t.Run("redraws reuse the suggestion until the input changes", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		completer := func(Document) []Suggestion {
+			calls++
+			return []Suggestion{{Text: "status"}}
+		}
+		p := newForTestingWithConfig(t, Config{Prefix: "$ ", AutoSuggest: true, Completer: completer}, "")
+		p.renderer.output = &bytes.Buffer{}
+		p.buffer = []rune("git st")
+		p.cursor = len(p.buffer)
+
+		require.NoError(t, p.render())
+		require.NoError(t, p.render())
+		assert.Equal(t, 1, calls, "a redraw of the same input does not run the completer")
+		assert.Equal(t, "atus", p.renderer.autoSuggestion)
+
+		p.buffer = []rune("git sta")
+		p.cursor = len(p.buffer)
+		require.NoError(t, p.render())
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, "tus", p.renderer.autoSuggestion)
+	})
+}
@@ -0,0 +1,123 @@
+package prompt
+
+import "fmt"
+
+// BalanceCheck selects what Enter does with an input that has an unclosed
+// quote or bracket. See WithBalanceCheck.
+type BalanceCheck int
+
+const (
+	// BalanceOff submits the input as it is.
+	BalanceOff BalanceCheck = iota
+	// BalanceReject keeps editing instead of submitting: the unclosed quote or
+	// bracket, or a closing bracket without a match, is drawn in the error
+	// color and a message is shown below the input until it is fixed.
+	BalanceReject
+	// BalanceAutoClose appends the missing closing characters and submits.
+	// A closing bracket without a match is still rejected, since there is
+	// nothing to append that would fix it.
+	BalanceAutoClose
+)
+
+// WithBalanceCheck checks on Enter that the quotes and brackets of the input
+// are balanced, so that malformed commands do not reach the application. The
+// input is read like a shell line: nothing is special between single quotes,
+// a backslash escapes the next character outside them, and brackets ( [ { only
+// count outside quotes. The check runs before the Validator.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithBalanceCheck(prompt.BalanceReject))
+func WithBalanceCheck(check BalanceCheck) Option {
+	return func(c *Config) {
+		c.BalanceCheck = check
+	}
+}
+
+// imbalance is the first quote or bracket that checkBalance found out of
+// place.
+type imbalance struct {
+	pos     int    // Rune index of the unclosed opening or unmatched closing character
+	stray   bool   // The character at pos is a closing bracket without a match
+	closing []rune // Characters closing everything left open, innermost first
+}
+
+// checkBalance scans input for unclosed quotes and brackets and reports the
+// first problem, or ok when there is none.
+func checkBalance(input []rune) (problem imbalance, ok bool) {
+	closers := map[rune]rune{'(': ')', '[': ']', '{': '}', '"': '"', '\'': '\'', '`': '`'}
+	var open []int // Positions of the open quotes and brackets, innermost last
+	for i := 0; i < len(input); i++ {
+		ch := input[i]
+		inner := rune(0)
+		if len(open) > 0 {
+			inner = input[open[len(open)-1]]
+		}
+		switch {
+		case inner == '\'':
+			if ch == '\'' {
+				open = open[:len(open)-1]
+			}
+		case ch == '\\':
+			i++ // Escaped, including inside double quotes and backquotes
+		case inner == '"' || inner == '`':
+			if ch == inner {
+				open = open[:len(open)-1]
+			}
+		case ch == '"' || ch == '\'' || ch == '`' || ch == '(' || ch == '[' || ch == '{':
+			open = append(open, i)
+		case ch == ')' || ch == ']' || ch == '}':
+			if len(open) == 0 || closers[inner] != ch {
+				return imbalance{pos: i, stray: true}, false
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) == 0 {
+		return imbalance{}, true
+	}
+
+	problem = imbalance{pos: open[0]}
+	for i := len(open) - 1; i >= 0; i-- {
+		problem.closing = append(problem.closing, closers[input[open[i]]])
+	}
+	return problem, false
+}
+
+// rejectUnbalanced runs the BalanceCheck on the buffer and reports whether the
+// input has to stay on the line. On submit, BalanceAutoClose appends the
+// missing closing characters instead of rejecting the input. The problem is
+// kept to be marked in the input and described below it.
+func (p *Prompt) rejectUnbalanced(submit bool) bool {
+	p.unbalanced = nil
+	if p.config.BalanceCheck == BalanceOff {
+		return false
+	}
+	problem, ok := checkBalance(p.buffer)
+	if ok {
+		return false
+	}
+	if submit && p.config.BalanceCheck == BalanceAutoClose && !problem.stray {
+		p.buffer = append(p.buffer, problem.closing...)
+		p.cursor = len(p.buffer)
+		return false
+	}
+
+	p.unbalanced = &problem
+	if problem.stray {
+		p.invalid = fmt.Sprintf(p.message(MsgUnmatchedClose), p.buffer[problem.pos])
+	} else {
+		p.invalid = fmt.Sprintf(p.message(MsgUnclosed), p.buffer[problem.pos], problem.closing[len(problem.closing)-1])
+	}
+	return true
+}
+
+// balanceMarks returns the rune indexes of the input to draw in the error
+// color: the character the balance check stopped at, if any. Nothing is
+// marked when a DisplayTransform may move the characters around.
+func (p *Prompt) balanceMarks() []int {
+	if p.unbalanced == nil || p.config.DisplayTransform != nil {
+		return nil
+	}
+	return []int{p.unbalanced.pos}
+}
@@ -0,0 +1,166 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBalance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		ok      bool
+		pos     int
+		stray   bool
+		closing string
+	}{
+		{name: "empty input", input: "", ok: true},
+		{name: "balanced quotes and brackets", input: `echo "a (b" 'c [d' $(ls {x,y})`, ok: true},
+		{name: "unclosed double quote", input: `echo "hello`, pos: 5, closing: `"`},
+		{name: "unclosed single quote", input: `echo 'it`, pos: 5, closing: `'`},
+		{name: "unclosed bracket inside bracket", input: `f(a, [b`, pos: 1, closing: "])"},
+		{name: "quote inside bracket", input: `f("x`, pos: 1, closing: `")`},
+		{name: "stray closing bracket", input: `ls)`, pos: 2, stray: true},
+		{name: "mismatched closing bracket", input: `f(a]`, pos: 3, stray: true},
+		{name: "escaped quote outside quotes", input: `echo \"`, ok: true},
+		{name: "escaped quote inside double quotes", input: `echo "a\"b"`, ok: true},
+		{name: "backslash is literal inside single quotes", input: `echo 'a\'`, ok: true},
+		{name: "brackets are literal inside quotes", input: `echo ")" '('`, ok: true},
+		{name: "unclosed backquote", input: "echo `date", pos: 5, closing: "`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			problem, ok := checkBalance([]rune(tt.input))
+
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				return
+			}
+			assert.Equal(t, tt.pos, problem.pos)
+			assert.Equal(t, tt.stray, problem.stray)
+			assert.Equal(t, tt.closing, string(problem.closing))
+		})
+	}
+}
+
+func TestBalanceCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Enter is rejected until the quote is closed", func(t *testing.T) {
+		t.Parallel()
+
+		var validated []string
+		config := Config{Prefix: "> ", BalanceCheck: BalanceReject, Validator: func(input string) error {
+			validated = append(validated, input)
+			return nil
+		}}
+		p := newForTestingWithConfig(t, config, "echo \"hi\r\"\r")
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, `echo "hi"`, result)
+		assert.Equal(t, []string{`echo "hi"`}, validated, "the validator only sees balanced input")
+		errorColor := ThemeDefault.Error.ToANSI()
+		assert.Contains(t, out.String(), errorColor+`unclosed ": missing "`)
+		assert.Contains(t, out.String(), errorColor+`"`, "the unclosed quote is drawn in the error color")
+		assert.Nil(t, p.unbalanced)
+	})
+
+	t.Run("the mark follows the input while it is edited", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", BalanceCheck: BalanceReject}, "f(a]\r\x7f)\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "f(a)", result)
+	})
+
+	t.Run("a stray closing bracket is reported", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", BalanceCheck: BalanceReject}, "ls)\r\x03")
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+		_, err := p.Run()
+
+		require.ErrorIs(t, err, ErrInterrupted)
+		errorColor := ThemeDefault.Error.ToANSI()
+		assert.Contains(t, out.String(), errorColor+"unmatched )")
+		assert.Nil(t, p.unbalanced, "the mark is erased when the prompt is cancelled")
+		assert.NotContains(t, out.String()[strings.LastIndex(out.String(), "\x1b[0J"):], errorColor, "the last frame is drawn without the mark")
+	})
+
+	t.Run("auto-close appends the missing characters", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", BalanceCheck: BalanceAutoClose}, "f(\"x\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, `f("x")`, result)
+	})
+
+	t.Run("auto-close still rejects a stray closing bracket", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", BalanceCheck: BalanceAutoClose}, "a]\r\x7f\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "a", result)
+	})
+
+	t.Run("unbalanced input is submitted by default", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "echo \"hi\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, `echo "hi`, result)
+	})
+}
+
+func TestBalancedInput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "echo hi", want: true},
+		{input: "", want: true},
+		{input: "f(x", want: false},
+		{input: "if x {", want: false},
+		{input: "echo 'it''s", want: false},
+		{input: `echo "a\"`, want: false},
+		{input: "ls \\", want: false},
+		{input: "ls \\  ", want: false},
+		{input: `echo \\`, want: true},
+		{input: "echo '(' done", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, BalancedInput(tt.input))
+		})
+	}
+}
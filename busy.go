@@ -0,0 +1,113 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// busyInterval is how often the Busy line advances its spinner and elapsed time.
+const busyInterval = 100 * time.Millisecond
+
+// Busy draws a "running…" line below the last prompt while the application
+// handles the submitted input: a spinner, label and the time elapsed since the
+// call, redrawn in place until the returned stop function is called. stop
+// erases the line, leaving the cursor where the line was, so the output of the
+// command starts there. It is safe to call stop more than once and from
+// another goroutine, and Run calls it if the line is still drawn. Nothing is
+// drawn when the prompt reads non-interactive input.
+//
+// The line is drawn with the prompt's renderer and colors. Output the command
+// writes while the line is shown is overwritten by the next frame, so stop it
+// before printing results.
+//
+// Example:
+//
+//	input, err := p.Run()
+//	if err != nil {
+//		return err
+//	}
+//	stop := p.Busy("running " + input)
+//	result, err := execute(input)
+//	stop()
+//	fmt.Println(result)
+func (p *Prompt) Busy(label string) (stop func()) {
+	if p.busyStop != nil {
+		p.busyStop()
+	}
+	if p.readsLines() {
+		return func() {} // Nothing is drawn for non-interactive input
+	}
+
+	r := p.renderer
+	if err := r.hideCursor(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to hide the cursor: %v\n", err)
+	}
+	start := time.Now()
+	drawBusyLine(r, label, 0, 0)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(busyInterval)
+		defer ticker.Stop()
+		for frame := 1; ; frame++ {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				drawBusyLine(r, label, frame, time.Since(start))
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+			fmt.Fprint(r.output, "\r\x1b[K")
+			if err := r.showCursor(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to show the cursor: %v\n", err)
+			}
+		})
+	}
+	p.busyStop = stop
+	return stop
+}
+
+// drawBusyLine draws frame of the Busy spinner with label and the elapsed
+// time over the current row, cut to fit on it.
+func drawBusyLine(r *renderer, label string, frame int, elapsed time.Duration) {
+	spinner := []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+	clock := " " + formatElapsed(elapsed)
+	width := r.width() - 1 // Never fill the last column, which would wrap
+	text := truncateWidth(sanitizeText(label), max(width-2-len(clock), 0))
+
+	var b strings.Builder
+	b.WriteString("\r\x1b[K")
+	b.WriteString(r.ansi(r.colorScheme.Prefix))
+	b.WriteString(string(spinner[frame%len(spinner)]) + " ")
+	b.WriteString(Reset())
+	b.WriteString(r.ansi(r.colorScheme.Input))
+	b.WriteString(text)
+	b.WriteString(Reset())
+	if width >= 2+stringWidth(text)+len(clock) {
+		b.WriteString(r.ansi(r.colorScheme.Suggestion.Description))
+		b.WriteString(clock)
+		b.WriteString(Reset())
+	}
+	fmt.Fprint(r.output, b.String())
+}
+
+// formatElapsed formats d for the Busy line: tenths of a second below a
+// minute, such as "4.2s", and minutes and seconds above, such as "2m05s".
+func formatElapsed(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
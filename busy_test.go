@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("the line is drawn at once and erased on stop", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "")
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+		stop := p.Busy("running ls")
+		stop()
+		stop() // A second call does nothing
+
+		got := out.String()
+		assert.Contains(t, got, "⠋ "+Reset()+ThemeDefault.Input.ToANSI()+"running ls")
+		assert.Contains(t, got, " 0.0s")
+		assert.True(t, strings.HasSuffix(got, "\r\x1b[K\x1b[?25h"), "the line is erased and the cursor shown again: %q", got)
+		assert.Equal(t, 1, strings.Count(got, "\x1b[?25h"))
+	})
+
+	t.Run("the spinner advances until stopped", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "")
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+		stop := p.Busy("build")
+		time.Sleep(3 * busyInterval)
+		stop()
+
+		assert.Contains(t, out.String(), "⠙ ")
+	})
+
+	t.Run("Run stops a line still drawn", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "ok\r")
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+		p.Busy("waiting")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result)
+		got := out.String()
+		assert.Less(t, strings.LastIndex(got, "waiting"), strings.Index(got, "> "), "the line is gone before the prompt is drawn")
+	})
+
+	t.Run("a long label is cut to the terminal width", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "")
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+		p.Busy(strings.Repeat("x", 200))()
+
+		assert.NotContains(t, out.String(), strings.Repeat("x", 80))
+	})
+}
+
+func TestFormatElapsed(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "0.0s", formatElapsed(0))
+	assert.Equal(t, "4.2s", formatElapsed(4200*time.Millisecond))
+	assert.Equal(t, "2m05s", formatElapsed(125*time.Second))
+}
@@ -0,0 +1,194 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ColorProfile is the range of colors a terminal can display. Colors of a
+// ColorScheme are given in 24-bit RGB and converted to the nearest color the
+// profile has when they are drawn.
+type ColorProfile int
+
+const (
+	// ColorProfileAuto detects the profile from the environment of the process
+	// when the prompt uses its terminal (see DetectColorProfile). Prompts with
+	// a custom Terminal, such as remote sessions, draw true color. Colors
+	// converted with this profile are drawn in true color.
+	ColorProfileAuto ColorProfile = iota
+	// ColorProfileTrueColor draws 24-bit colors.
+	ColorProfileTrueColor
+	// ColorProfile256 draws the nearest color of the xterm 256-color palette.
+	ColorProfile256
+	// ColorProfile16 draws the nearest of the 16 standard ANSI colors.
+	ColorProfile16
+	// ColorProfileNone draws no colors, only text attributes such as bold and
+	// reverse video.
+	ColorProfileNone
+)
+
+// WithColorProfile sets the colors the terminal can display instead of
+// detecting them, for example ColorProfile256 for a terminal that does not
+// advertise its support, or ColorProfileNone to turn colors off.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithColorProfile(prompt.ColorProfile256))
+func WithColorProfile(profile ColorProfile) Option {
+	return func(c *Config) {
+		c.ColorProfile = profile
+	}
+}
+
+// DetectColorProfile guesses the colors the terminal of the process can
+// display from its environment:
+//
+//   - NO_COLOR set to anything but an empty string, or TERM=dumb, turn colors off
+//   - COLORTERM=truecolor or 24bit, a TERM ending in "-direct" or "-truecolor",
+//     and terminals known to support it (TERM_PROGRAM, WT_SESSION) give true color
+//   - a TERM containing "256color" gives 256 colors
+//   - anything else gives the 16 standard colors
+//
+// The terminfo database is not read; TERM names follow its naming conventions.
+func DetectColorProfile() ColorProfile {
+	return detectColorProfile(os.Getenv)
+}
+
+// detectColorProfile implements DetectColorProfile with getenv looking up the
+// environment.
+func detectColorProfile(getenv func(string) string) ColorProfile {
+	term := strings.ToLower(getenv("TERM"))
+	switch {
+	case getenv("NO_COLOR") != "" || term == "dumb":
+		return ColorProfileNone
+	case getenv("COLORTERM") == "truecolor" || getenv("COLORTERM") == "24bit":
+		return ColorProfileTrueColor
+	case strings.HasSuffix(term, "-direct") || strings.HasSuffix(term, "-truecolor"):
+		return ColorProfileTrueColor
+	case getenv("WT_SESSION") != "": // Windows Terminal
+		return ColorProfileTrueColor
+	}
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty":
+		return ColorProfileTrueColor
+	}
+	if strings.Contains(term, "256color") || getenv("TERM_PROGRAM") == "Apple_Terminal" {
+		return ColorProfile256
+	}
+	return ColorProfile16
+}
+
+// ToANSIWithProfile converts a Color to an ANSI escape sequence that profile
+// can display: 24-bit colors, including the background, are replaced by the
+// nearest color of the 256-color or 16-color palette, and ColorProfileNone
+// keeps only the text attributes. It returns an empty string for a color
+// without anything to draw.
+func (c Color) ToANSIWithProfile(profile ColorProfile) string {
+	var codes []string
+	for _, attr := range []struct {
+		set  bool
+		code string
+	}{{c.Bold, "1"}, {c.Dim, "2"}, {c.Italic, "3"}, {c.Underline, "4"}, {c.Reverse, "7"}} {
+		if attr.set {
+			codes = append(codes, attr.code)
+		}
+	}
+	if profile != ColorProfileNone {
+		codes = append(codes, c.colorCode(profile, false))
+		if c.Background != nil {
+			codes = append(codes, c.Background.colorCode(profile, true))
+		}
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\x1b[%sm", strings.Join(codes, ";"))
+}
+
+// colorCode returns the SGR parameters selecting c as the foreground color, or
+// as the background color when background is set, in profile.
+func (c Color) colorCode(profile ColorProfile, background bool) string {
+	switch profile {
+	case ColorProfile256:
+		if background {
+			return fmt.Sprintf("48;5;%d", c.nearest256())
+		}
+		return fmt.Sprintf("38;5;%d", c.nearest256())
+	case ColorProfile16:
+		if background {
+			return fmt.Sprint(c.nearest16() + 10)
+		}
+		return fmt.Sprint(c.nearest16())
+	default:
+		if background {
+			return fmt.Sprintf("48;2;%d;%d;%d", c.R, c.G, c.B)
+		}
+		return fmt.Sprintf("38;2;%d;%d;%d", c.R, c.G, c.B)
+	}
+}
+
+// nearest256 returns the index of the xterm 256-color palette entry closest to
+// c, from the color cube or the gray ramp (colors 232 to 255).
+func (c Color) nearest256() int {
+	// Channel values of the 6x6x6 color cube (colors 16 to 231)
+	cubeLevels := [6]int{0, 95, 135, 175, 215, 255}
+	cube := func(v uint8) int {
+		i := 0
+		for j, level := range cubeLevels {
+			if absInt(int(v)-level) < absInt(int(v)-cubeLevels[i]) {
+				i = j
+			}
+		}
+		return i
+	}
+	r, g, b := cube(c.R), cube(c.G), cube(c.B)
+	cubeColor := [3]int{cubeLevels[r], cubeLevels[g], cubeLevels[b]}
+
+	average := (int(c.R) + int(c.G) + int(c.B)) / 3
+	grayIndex := max(0, min((average-8+5)/10, 23))
+	grayLevel := 8 + 10*grayIndex
+
+	if c.distance(cubeColor) <= c.distance([3]int{grayLevel, grayLevel, grayLevel}) {
+		return 16 + 36*r + 6*g + b
+	}
+	return 232 + grayIndex
+}
+
+// nearest16 returns the SGR foreground code of the standard ANSI color closest
+// to c: 30 to 37, or 90 to 97 for the bright variants.
+func (c Color) nearest16() int {
+	// The 16 colors as xterm draws them: black, red, green, yellow, blue,
+	// magenta, cyan and white, then their bright variants
+	ansi16 := [16][3]int{
+		{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+		{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+		{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+		{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+	}
+	best := 0
+	for i, rgb := range ansi16 {
+		if c.distance(rgb) < c.distance(ansi16[best]) {
+			best = i
+		}
+	}
+	if best < 8 {
+		return 30 + best
+	}
+	return 90 + best - 8
+}
+
+// distance returns the squared distance between c and rgb, weighted for how
+// sensitive the eye is to each channel.
+func (c Color) distance(rgb [3]int) int {
+	dr, dg, db := int(c.R)-rgb[0], int(c.G)-rgb[1], int(c.B)-rgb[2]
+	return 2*dr*dr + 4*dg*dg + 3*db*db
+}
+
+// absInt returns the absolute value of v.
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,101 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectColorProfile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want ColorProfile
+	}{
+		{name: "NO_COLOR turns colors off", env: map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"}, want: ColorProfileNone},
+		{name: "an empty NO_COLOR is ignored", env: map[string]string{"NO_COLOR": "", "COLORTERM": "truecolor"}, want: ColorProfileTrueColor},
+		{name: "dumb terminal", env: map[string]string{"TERM": "dumb"}, want: ColorProfileNone},
+		{name: "COLORTERM=24bit", env: map[string]string{"TERM": "xterm", "COLORTERM": "24bit"}, want: ColorProfileTrueColor},
+		{name: "direct-color terminfo entry", env: map[string]string{"TERM": "xterm-direct"}, want: ColorProfileTrueColor},
+		{name: "Windows Terminal", env: map[string]string{"WT_SESSION": "1"}, want: ColorProfileTrueColor},
+		{name: "256-color terminfo entry", env: map[string]string{"TERM": "screen-256color"}, want: ColorProfile256},
+		{name: "Apple Terminal", env: map[string]string{"TERM": "xterm", "TERM_PROGRAM": "Apple_Terminal"}, want: ColorProfile256},
+		{name: "anything else", env: map[string]string{"TERM": "xterm"}, want: ColorProfile16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := detectColorProfile(func(key string) string { return tt.env[key] })
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestColorToANSIWithProfile(t *testing.T) {
+	t.Parallel()
+
+	green := Color{R: 0, G: 255, B: 0, Bold: true}
+	gray := Color{R: 128, G: 128, B: 128}
+
+	tests := []struct {
+		name    string
+		color   Color
+		profile ColorProfile
+		want    string
+	}{
+		{name: "true color", color: green, profile: ColorProfileTrueColor, want: "\x1b[1;38;2;0;255;0m"},
+		{name: "auto draws true color", color: gray, profile: ColorProfileAuto, want: "\x1b[38;2;128;128;128m"},
+		{name: "256 colors use the color cube", color: green, profile: ColorProfile256, want: "\x1b[1;38;5;46m"},
+		{name: "256 colors use the gray ramp", color: gray, profile: ColorProfile256, want: "\x1b[38;5;244m"},
+		{name: "16 colors", color: green, profile: ColorProfile16, want: "\x1b[1;92m"},
+		{name: "16 colors gray", color: gray, profile: ColorProfile16, want: "\x1b[90m"},
+		{name: "no colors keeps bold", color: green, profile: ColorProfileNone, want: "\x1b[1m"},
+		{name: "no colors without bold draws nothing", color: gray, profile: ColorProfileNone, want: ""},
+		{name: "attributes", color: Color{Italic: true, Underline: true, Dim: true, Reverse: true}, profile: ColorProfileNone, want: "\x1b[2;3;4;7m"},
+		{name: "true color background", color: Color{R: 1, G: 2, B: 3, Background: &Color{R: 4, G: 5, B: 6}}, profile: ColorProfileTrueColor, want: "\x1b[38;2;1;2;3;48;2;4;5;6m"},
+		{name: "256 colors background", color: Color{Reverse: true, Background: &gray}, profile: ColorProfile256, want: "\x1b[7;38;5;16;48;5;244m"},
+		{name: "16 colors background", color: Color{Underline: true, Background: &green}, profile: ColorProfile16, want: "\x1b[4;30;102m"},
+		{name: "no colors drops the background", color: Color{Background: &gray}, profile: ColorProfileNone, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.color.ToANSIWithProfile(tt.profile))
+		})
+	}
+
+	t.Run("ToANSI always draws true color", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, green.ToANSIWithProfile(ColorProfileTrueColor), green.ToANSI())
+	})
+}
+
+func TestWithColorProfile(t *testing.T) {
+	t.Parallel()
+
+	var output bytes.Buffer
+	p, err := New("$ ",
+		WithTerminal(newMockTerminal("ls\r")),
+		WithOutput(&output),
+		WithMemoryHistory(10),
+		WithColorProfile(ColorProfile256),
+	)
+	require.NoError(t, err)
+	defer p.Close()
+
+	_, err = p.Run()
+
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), "\x1b[1;38;5;46m$ ")
+	assert.NotContains(t, output.String(), "38;2;")
+}
@@ -1,10 +1,5 @@
 package prompt
 
-import (
-	"fmt"
-	"strings"
-)
-
 // ColorScheme defines the color configuration for the prompt.
 type ColorScheme struct {
 	Name       string           `json:"name"`
@@ -14,6 +9,7 @@ type ColorScheme struct {
 	Selected   Color            `json:"selected"`
 	Background *Color           `json:"background"` // nil for transparent
 	Cursor     Color            `json:"cursor"`
+	Error      Color            `json:"error"` // Validation messages (zero falls back to red)
 }
 
 // SuggestionColors defines colors for completion suggestions.
@@ -24,12 +20,20 @@ type SuggestionColors struct {
 	Background  *Color `json:"background"` // nil for transparent
 }
 
-// Color represents an RGB color with optional formatting.
+// Color represents an RGB color with optional formatting. Background is drawn
+// behind the text, using only its R, G and B; nil leaves the terminal's
+// background. Reverse swaps the foreground and background, which is how the
+// built-in themes highlight the selected suggestion.
 type Color struct {
-	R    uint8 `json:"r"`
-	G    uint8 `json:"g"`
-	B    uint8 `json:"b"`
-	Bold bool  `json:"bold"`
+	R          uint8  `json:"r"`
+	G          uint8  `json:"g"`
+	B          uint8  `json:"b"`
+	Bold       bool   `json:"bold"`
+	Italic     bool   `json:"italic,omitempty"`
+	Underline  bool   `json:"underline,omitempty"`
+	Dim        bool   `json:"dim,omitempty"`
+	Reverse    bool   `json:"reverse,omitempty"`
+	Background *Color `json:"background,omitempty"` // nil for transparent
 }
 
 // ThemeDefault is the default color scheme with green prefix and white text
@@ -43,9 +47,10 @@ var ThemeDefault = &ColorScheme{
 		Match:       Color{R: 255, G: 255, B: 0, Bold: true},
 		Background:  nil,
 	},
-	Selected:   Color{R: 0, G: 255, B: 255, Bold: true},
+	Selected:   Color{R: 0, G: 255, B: 255, Bold: true, Reverse: true},
 	Background: nil,
 	Cursor:     Color{R: 255, G: 255, B: 255, Bold: true},
+	Error:      Color{R: 255, G: 85, B: 85, Bold: false},
 }
 
 // ThemeDark is a dark theme with light blue prefix and off-white text
@@ -59,9 +64,10 @@ var ThemeDark = &ColorScheme{
 		Match:       Color{R: 255, G: 184, B: 108, Bold: true},
 		Background:  nil,
 	},
-	Selected:   Color{R: 80, G: 250, B: 123, Bold: true},
+	Selected:   Color{R: 80, G: 250, B: 123, Bold: true, Reverse: true},
 	Background: &Color{R: 40, G: 42, B: 54},
 	Cursor:     Color{R: 248, G: 248, B: 242, Bold: false},
+	Error:      Color{R: 255, G: 85, B: 85, Bold: false},
 }
 
 // ThemeLight is a light theme with blue prefix and dark gray text
@@ -75,9 +81,10 @@ var ThemeLight = &ColorScheme{
 		Match:       Color{R: 215, G: 58, B: 73, Bold: true},
 		Background:  nil,
 	},
-	Selected:   Color{R: 40, G: 167, B: 69, Bold: true},
+	Selected:   Color{R: 40, G: 167, B: 69, Bold: true, Reverse: true},
 	Background: &Color{R: 255, G: 255, B: 255},
 	Cursor:     Color{R: 36, G: 41, B: 46, Bold: false},
+	Error:      Color{R: 203, G: 36, B: 73, Bold: false},
 }
 
 // ThemeSolarizedDark is the Solarized Dark color scheme
@@ -91,9 +98,10 @@ var ThemeSolarizedDark = &ColorScheme{
 		Match:       Color{R: 181, G: 137, B: 0, Bold: true},
 		Background:  nil,
 	},
-	Selected:   Color{R: 38, G: 139, B: 210, Bold: true},
+	Selected:   Color{R: 38, G: 139, B: 210, Bold: true, Reverse: true},
 	Background: &Color{R: 0, G: 43, B: 54},
 	Cursor:     Color{R: 253, G: 246, B: 227, Bold: false},
+	Error:      Color{R: 220, G: 50, B: 47, Bold: false},
 }
 
 // ThemeAccessible is a colorblind-safe theme with high contrast
@@ -107,9 +115,10 @@ var ThemeAccessible = &ColorScheme{
 		Match:       Color{R: 240, G: 228, B: 66, Bold: true},
 		Background:  nil,
 	},
-	Selected:   Color{R: 230, G: 159, B: 0, Bold: true},
+	Selected:   Color{R: 230, G: 159, B: 0, Bold: true, Reverse: true},
 	Background: nil,
 	Cursor:     Color{R: 255, G: 255, B: 255, Bold: false},
+	Error:      Color{R: 255, G: 102, B: 102, Bold: true},
 }
 
 // ThemeVSCode is the VS Code dark theme colors
@@ -123,9 +132,10 @@ var ThemeVSCode = &ColorScheme{
 		Match:       Color{R: 255, G: 206, B: 84, Bold: true},
 		Background:  nil,
 	},
-	Selected:   Color{R: 0, G: 122, B: 204, Bold: true},
+	Selected:   Color{R: 0, G: 122, B: 204, Bold: true, Reverse: true},
 	Background: &Color{R: 30, G: 30, B: 30},
 	Cursor:     Color{R: 255, G: 255, B: 255, Bold: true},
+	Error:      Color{R: 244, G: 71, B: 71, Bold: false},
 }
 
 // ThemeNightOwl is the Night Owl color scheme
@@ -139,9 +149,10 @@ var ThemeNightOwl = &ColorScheme{
 		Match:       Color{R: 199, G: 146, B: 234, Bold: true},
 		Background:  nil,
 	},
-	Selected:   Color{R: 34, G: 218, B: 110, Bold: true},
+	Selected:   Color{R: 34, G: 218, B: 110, Bold: true, Reverse: true},
 	Background: &Color{R: 1, G: 22, B: 39},
 	Cursor:     Color{R: 214, G: 222, B: 235, Bold: true},
+	Error:      Color{R: 239, G: 83, B: 80, Bold: false},
 }
 
 // ThemeDracula is the Dracula color scheme
@@ -155,9 +166,10 @@ var ThemeDracula = &ColorScheme{
 		Match:       Color{R: 241, G: 250, B: 140, Bold: true},
 		Background:  nil,
 	},
-	Selected:   Color{R: 80, G: 250, B: 123, Bold: true},
+	Selected:   Color{R: 80, G: 250, B: 123, Bold: true, Reverse: true},
 	Background: &Color{R: 40, G: 42, B: 54},
 	Cursor:     Color{R: 248, G: 248, B: 242, Bold: false},
+	Error:      Color{R: 255, G: 85, B: 85, Bold: false},
 }
 
 // ThemeMonokai is the Monokai color scheme
@@ -171,24 +183,34 @@ var ThemeMonokai = &ColorScheme{
 		Match:       Color{R: 253, G: 151, B: 31, Bold: true},
 		Background:  nil,
 	},
-	Selected:   Color{R: 102, G: 217, B: 239, Bold: true},
+	Selected:   Color{R: 102, G: 217, B: 239, Bold: true, Reverse: true},
 	Background: &Color{R: 39, G: 40, B: 34},
 	Cursor:     Color{R: 248, G: 248, B: 242, Bold: false},
+	Error:      Color{R: 249, G: 38, B: 114, Bold: false},
 }
 
-// ToANSI converts a Color to an ANSI escape sequence.
-func (c Color) ToANSI() string {
-	var codes []string
-
-	// Bold formatting comes first
-	if c.Bold {
-		codes = append(codes, "1")
+// errorColor returns the color of validation errors, falling back to red for
+// schemes that leave Error unset.
+func (c *ColorScheme) errorColor() Color {
+	if c.Error == (Color{}) {
+		return Color{R: 255, G: 85, B: 85}
 	}
+	return c.Error
+}
 
-	// RGB color (true color support)
-	codes = append(codes, fmt.Sprintf("38;2;%d;%d;%d", c.R, c.G, c.B))
+// onRow returns c drawn on a row highlighted with row: it takes over the
+// reverse video and background of row, so that text drawn in another color,
+// such as a description or a matched character, does not break the highlight.
+func (c Color) onRow(row Color) Color {
+	c.Reverse = row.Reverse
+	c.Background = row.Background
+	return c
+}
 
-	return fmt.Sprintf("\x1b[%sm", strings.Join(codes, ";"))
+// ToANSI converts a Color to a 24-bit ANSI escape sequence. The prompt draws
+// colors with ToANSIWithProfile so that they suit the terminal.
+func (c Color) ToANSI() string {
+	return c.ToANSIWithProfile(ColorProfileTrueColor)
 }
 
 // Reset returns the ANSI reset sequence.
@@ -0,0 +1,290 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ErrUnknownCommand is passed to the error handler of RunCommands when the
+// input names no command, or a command that only groups subcommands.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Command describes a command of a CLI command tree, from which
+// NewCommandCompleter completes commands, subcommands and flags, and
+// RunCommands runs an interactive shell. An existing CLI, such as one built
+// with cobra or urfave/cli, maps its commands onto this tree to get an
+// interactive mode without duplicating their logic.
+type Command struct {
+	Name        string                           // Name typed to run the command
+	Aliases     []string                         // Other names that run the command, not suggested
+	Description string                           // Shown next to the command in the suggestion menu
+	Flags       []Flag                           // Flags the command accepts
+	Subcommands []*Command                       // Commands typed after this one, like "add" in "remote add"
+	Args        func(args []string) []Suggestion // Suggests the next argument, given the ones before it (nil for none)
+	Run         func(args []string) error        // Runs the command with the words after its name (nil for a group of subcommands)
+}
+
+// Flag describes a flag of a Command.
+type Flag struct {
+	Name        string   // Long name without dashes, completed as "--name"
+	Shorthand   string   // One-letter name without the dash, accepted as "-n" (empty for none)
+	Description string   // Shown next to the flag in the suggestion menu
+	ValueHint   string   // Placeholder of the flag's value shown in the menu, e.g. "FILE" (empty for a boolean flag)
+	Values      []string // Values suggested after the flag
+}
+
+// NewCommandCompleter returns a completer for a tree of commands. It suggests
+// the commands and subcommands that can follow the words before the cursor,
+// the flags of the command when the word starts with "-", leaving out those
+// already given, and the Values of a flag after it. Flags that take a value
+// show their ValueHint in the menu. Other words are completed by the
+// command's Args function.
+//
+// Example:
+//
+//	commands := []*prompt.Command{
+//		{Name: "deploy", Description: "Deploy the app", Flags: []prompt.Flag{
+//			{Name: "env", Shorthand: "e", ValueHint: "ENV", Values: []string{"staging", "production"}},
+//			{Name: "dry-run", Description: "Print the plan only"},
+//		}, Run: deploy},
+//		{Name: "logs", Description: "Show logs", Run: logs},
+//	}
+//	p, err := prompt.New("app> ", prompt.WithCompleterSource(prompt.NewCommandCompleter(commands...)))
+func NewCommandCompleter(commands ...*Command) Completer {
+	return CompleterFunc(func(d Document) []Suggestion {
+		before := d.TextBeforeCursor()
+		words := splitCommandLine(before)
+		word := ""
+		if len(words) > 0 && before != "" && !unicode.IsSpace(rune(before[len(before)-1])) {
+			word, words = words[len(words)-1], words[:len(words)-1]
+		}
+		return completeCommand(commands, words, word)
+	})
+}
+
+// completeCommand returns the suggestions for word, typed after words.
+func completeCommand(commands []*Command, words []string, word string) []Suggestion {
+	var cmd *Command
+	var args, used []string
+	var pending *Flag // Flag whose value is the next word
+	for _, w := range words {
+		switch {
+		case pending != nil:
+			pending = nil
+		case strings.HasPrefix(w, "-") && cmd != nil:
+			name, _, hasValue := strings.Cut(w, "=")
+			used = append(used, name)
+			if flag := cmd.flag(name); flag != nil && flag.ValueHint != "" && !hasValue {
+				pending = flag
+			}
+		case len(args) == 0 && findCommand(subcommands(cmd, commands), w) != nil:
+			cmd = findCommand(subcommands(cmd, commands), w)
+		default:
+			args = append(args, w)
+		}
+	}
+
+	switch {
+	case pending != nil:
+		return flagValues(*pending, "", word)
+	case cmd != nil && strings.HasPrefix(word, "-"):
+		if name, value, ok := strings.Cut(word, "="); ok {
+			if flag := cmd.flag(name); flag != nil {
+				return flagValues(*flag, name+"=", value)
+			}
+			return nil
+		}
+		return cmd.flagSuggestions(used, word)
+	}
+
+	var suggestions []Suggestion
+	if len(args) == 0 {
+		for _, c := range subcommands(cmd, commands) {
+			if strings.HasPrefix(c.Name, word) {
+				suggestions = append(suggestions, Suggestion{Text: c.Name, Description: c.Description})
+			}
+		}
+	}
+	if cmd != nil && cmd.Args != nil {
+		for _, s := range cmd.Args(args) {
+			if strings.HasPrefix(s.Text, word) {
+				suggestions = append(suggestions, s)
+			}
+		}
+	}
+	return suggestions
+}
+
+// subcommands returns the commands that can follow cmd, or the top-level
+// commands when no command was typed yet.
+func subcommands(cmd *Command, commands []*Command) []*Command {
+	if cmd == nil {
+		return commands
+	}
+	return cmd.Subcommands
+}
+
+// findCommand returns the command of commands named or aliased name, or nil.
+func findCommand(commands []*Command, name string) *Command {
+	for _, c := range commands {
+		if c.Name == name || slices.Contains(c.Aliases, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// flag returns the flag of c written as arg, "--name" or "-n", or nil.
+func (c *Command) flag(arg string) *Flag {
+	for i, flag := range c.Flags {
+		if arg == "--"+flag.Name || (flag.Shorthand != "" && arg == "-"+flag.Shorthand) {
+			return &c.Flags[i]
+		}
+	}
+	return nil
+}
+
+// flagSuggestions returns the flags of c starting with word, except those in
+// used.
+func (c *Command) flagSuggestions(used []string, word string) []Suggestion {
+	var suggestions []Suggestion
+	for _, flag := range c.Flags {
+		name := "--" + flag.Name
+		if !strings.HasPrefix(name, word) || slices.Contains(used, name) || (flag.Shorthand != "" && slices.Contains(used, "-"+flag.Shorthand)) {
+			continue
+		}
+		suggestion := Suggestion{Text: name, Description: flag.Description}
+		if flag.ValueHint != "" {
+			suggestion.DisplayText = name + " " + flag.ValueHint
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions
+}
+
+// flagValues returns the values of flag starting with word, each with prefix
+// in front, as needed to complete "--name=value".
+func flagValues(flag Flag, prefix, word string) []Suggestion {
+	var suggestions []Suggestion
+	for _, value := range flag.Values {
+		if strings.HasPrefix(value, word) {
+			suggestions = append(suggestions, Suggestion{Text: prefix + value, Description: flag.Description})
+		}
+	}
+	return suggestions
+}
+
+// splitCommandLine splits line into words at unquoted whitespace, like a
+// shell: single quotes keep their content as is, and inside double quotes or
+// outside quotes a backslash escapes the next character. A quote that is not
+// closed extends to the end of the line.
+func splitCommandLine(line string) []string {
+	var words []string
+	var word strings.Builder
+	inWord, escaped := false, false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// RunCommands runs an interactive shell for a tree of commands. It reads a
+// line with RunWithContext, splits it into words like a shell and runs the
+// command they name with the words after it. The error of a command, or one
+// wrapping ErrUnknownCommand when no command matches, is passed to onError
+// and the shell goes on with the next line; a nil onError prints it to the
+// prompt's output. Ctrl+C discards the line and empty lines are skipped.
+//
+// The shell ends at EOF (Ctrl+D) or the exit command of WithExitChecker and
+// returns nil, and returns other errors of RunWithContext, such as the one of
+// a canceled context. When the prompt has no completer, NewCommandCompleter
+// is installed for the commands.
+//
+// Example:
+//
+//	p, err := prompt.New("app> ", prompt.WithMemoryHistory(100))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer p.Close()
+//	if err := p.RunCommands(ctx, commands, nil); err != nil {
+//		log.Fatal(err)
+//	}
+func (p *Prompt) RunCommands(ctx context.Context, commands []*Command, onError func(error)) error {
+	if p.config.Completer == nil && p.config.AsyncCompleter == nil {
+		p.SetCompleter(NewCommandCompleter(commands...).Complete)
+	}
+	if onError == nil {
+		onError = func(err error) {
+			fmt.Fprintf(p.output, "Error: %v\n", err)
+		}
+	}
+	for {
+		line, err := p.RunWithContext(ctx)
+		switch {
+		case errors.Is(err, ErrEOF):
+			return nil // Ctrl+D, or the ErrExit of an exit command
+		case errors.Is(err, ErrInterrupted):
+			continue
+		case err != nil:
+			return err
+		}
+		words := splitCommandLine(line)
+		if len(words) == 0 {
+			continue
+		}
+		if err := runCommand(commands, words); err != nil {
+			onError(err)
+		}
+	}
+}
+
+// runCommand runs the command of commands named by words, descending into
+// subcommands as long as the next word names one.
+func runCommand(commands []*Command, words []string) error {
+	cmd := findCommand(commands, words[0])
+	if cmd == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, words[0])
+	}
+	path, args := slices.Clip(words[:1]), words[1:]
+	for len(args) > 0 {
+		sub := findCommand(cmd.Subcommands, args[0])
+		if sub == nil {
+			break
+		}
+		cmd, path, args = sub, append(path, args[0]), args[1:]
+	}
+	if cmd.Run == nil {
+		return fmt.Errorf("%w: %s needs a subcommand", ErrUnknownCommand, strings.Join(path, " "))
+	}
+	return cmd.Run(args)
+}
@@ -0,0 +1,233 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCommands returns a small command tree and the calls its commands
+// record.
+func testCommands() ([]*Command, *[]string) {
+	var calls []string
+	record := func(name string) func([]string) error {
+		return func(args []string) error {
+			calls = append(calls, name+" "+joinWords(args))
+			return nil
+		}
+	}
+	commands := []*Command{
+		{
+			Name:        "deploy",
+			Description: "Deploy the app",
+			Flags: []Flag{
+				{Name: "env", Shorthand: "e", Description: "Target", ValueHint: "ENV", Values: []string{"staging", "production"}},
+				{Name: "dry-run", Description: "Print the plan only"},
+			},
+			Args: func(args []string) []Suggestion {
+				if len(args) > 0 {
+					return nil
+				}
+				return []Suggestion{{Text: "web"}, {Text: "worker"}}
+			},
+			Run: record("deploy"),
+		},
+		{Name: "describe", Aliases: []string{"desc"}, Run: record("describe")},
+		{
+			Name: "remote",
+			Subcommands: []*Command{
+				{Name: "add", Description: "Add a remote", Run: record("remote add")},
+				{Name: "remove", Aliases: []string{"rm"}, Run: record("remote remove")},
+			},
+		},
+		{Name: "fail", Run: func([]string) error { return errors.New("boom") }},
+	}
+	return commands, &calls
+}
+
+// joinWords joins words with "|" so word boundaries show in assertions.
+func joinWords(words []string) string {
+	var b bytes.Buffer
+	for i, w := range words {
+		if i > 0 {
+			b.WriteString("|")
+		}
+		b.WriteString(w)
+	}
+	return b.String()
+}
+
+func TestCommandCompleter(t *testing.T) {
+	t.Parallel()
+
+	commands, _ := testCommands()
+	completer := NewCommandCompleter(commands...)
+	texts := func(suggestions []Suggestion) []string {
+		var result []string
+		for _, s := range suggestions {
+			result = append(result, s.Text)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "top-level commands", input: "", want: []string{"deploy", "describe", "remote", "fail"}},
+		{name: "commands by prefix", input: "de", want: []string{"deploy", "describe"}},
+		{name: "subcommands", input: "remote ", want: []string{"add", "remove"}},
+		{name: "subcommands by prefix", input: "remote rem", want: []string{"remove"}},
+		{name: "nothing after a complete subcommand", input: "remote add ", want: nil},
+		{name: "aliases are resolved but not suggested", input: "desc ", want: nil},
+		{name: "flags", input: "deploy -", want: []string{"--env", "--dry-run"}},
+		{name: "flags already given are left out", input: "deploy --dry-run --", want: []string{"--env"}},
+		{name: "shorthand flags count as given", input: "deploy -e staging --", want: []string{"--dry-run"}},
+		{name: "values after a flag", input: "deploy --env ", want: []string{"staging", "production"}},
+		{name: "values by prefix", input: "deploy -e pro", want: []string{"production"}},
+		{name: "values after =", input: "deploy --env=s", want: []string{"--env=staging"}},
+		{name: "arguments", input: "deploy w", want: []string{"web", "worker"}},
+		{name: "arguments after a flag value", input: "deploy --env staging ", want: []string{"web", "worker"}},
+		{name: "arguments see the ones before", input: "deploy web ", want: nil},
+		{name: "unknown commands get nothing", input: "nope ", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := completer.Complete(Document{Text: tt.input, CursorPosition: len([]rune(tt.input))})
+
+			assert.Equal(t, tt.want, texts(got))
+		})
+	}
+
+	t.Run("flags with a value show the hint", func(t *testing.T) {
+		t.Parallel()
+
+		got := completer.Complete(Document{Text: "deploy --", CursorPosition: 9})
+
+		require.Len(t, got, 2)
+		assert.Equal(t, "--env ENV", got[0].DisplayText)
+		assert.Equal(t, "Target", got[0].Description)
+		assert.Empty(t, got[1].DisplayText)
+	})
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{name: "whitespace", line: "  a  b\tc ", want: []string{"a", "b", "c"}},
+		{name: "double quotes", line: `say "hello world"`, want: []string{"say", "hello world"}},
+		{name: "single quotes keep backslashes", line: `echo 'a\b'`, want: []string{"echo", `a\b`}},
+		{name: "backslash escapes", line: `cd my\ dir`, want: []string{"cd", "my dir"}},
+		{name: "empty quotes are a word", line: `set x ""`, want: []string{"set", "x", ""}},
+		{name: "unclosed quote", line: `say "hi there`, want: []string{"say", "hi there"}},
+		{name: "empty", line: "", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, splitCommandLine(tt.line))
+		})
+	}
+}
+
+func TestRunCommands(t *testing.T) {
+	t.Parallel()
+
+	t.Run("runs commands until EOF", func(t *testing.T) {
+		t.Parallel()
+
+		commands, calls := testCommands()
+		var errs []error
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "deploy --env staging web\r\rremote rm origin\rdesc 'a b'\r\x04")
+
+		err := p.RunCommands(context.Background(), commands, func(err error) { errs = append(errs, err) })
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"deploy --env|staging|web", "remote remove origin", "describe a b"}, *calls)
+		assert.Empty(t, errs)
+	})
+
+	t.Run("passes errors to the handler and goes on", func(t *testing.T) {
+		t.Parallel()
+
+		commands, calls := testCommands()
+		var errs []error
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "nope\rremote\rfail\rremote add x\r\x04")
+
+		err := p.RunCommands(context.Background(), commands, func(err error) { errs = append(errs, err) })
+
+		require.NoError(t, err)
+		require.Len(t, errs, 3)
+		assert.ErrorIs(t, errs[0], ErrUnknownCommand)
+		assert.EqualError(t, errs[0], "unknown command: nope")
+		assert.EqualError(t, errs[1], "unknown command: remote needs a subcommand")
+		assert.EqualError(t, errs[2], "boom")
+		assert.Equal(t, []string{"remote add x"}, *calls)
+	})
+
+	t.Run("prints errors without a handler", func(t *testing.T) {
+		t.Parallel()
+
+		commands, _ := testCommands()
+		var out bytes.Buffer
+		p, err := New("> ", WithTerminal(newMockTerminal("fail\r\x04")), WithOutput(&out))
+		require.NoError(t, err)
+		defer p.Close()
+
+		err = p.RunCommands(context.Background(), commands, nil)
+
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), "Error: boom\n")
+	})
+
+	t.Run("ends on the exit command", func(t *testing.T) {
+		t.Parallel()
+
+		commands, calls := testCommands()
+		config := Config{Prefix: "> ", ExitChecker: func(input string, breakline bool) bool { return breakline && input == "exit" }}
+		p := newForTestingWithConfig(t, config, "exit\rdeploy\r")
+
+		err := p.RunCommands(context.Background(), commands, nil)
+
+		require.NoError(t, err)
+		assert.Empty(t, *calls)
+	})
+
+	t.Run("completes the commands when no completer is set", func(t *testing.T) {
+		t.Parallel()
+
+		commands, calls := testCommands()
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "remote a\t x\r\x04")
+
+		err := p.RunCommands(context.Background(), commands, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"remote add x"}, *calls)
+	})
+
+	t.Run("returns the error of a canceled context", func(t *testing.T) {
+		t.Parallel()
+
+		commands, _ := testCommands()
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := p.RunCommands(ctx, commands, nil)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
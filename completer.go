@@ -0,0 +1,239 @@
+package prompt
+
+import (
+	"slices"
+	"strings"
+)
+
+// Completer produces completion suggestions for the input. It is the
+// interface form of the completion function taken by WithCompleter, for
+// completers that carry state such as a command tree or a cache.
+//
+// Suggestions may set DisplayText, Category, Icon and per-item colors; the
+// suggestion menu lays them out in aligned columns.
+//
+// Example:
+//
+//	type gitCompleter struct{ branches []string }
+//
+//	func (g *gitCompleter) Complete(d prompt.Document) []prompt.Suggestion {
+//		var suggestions []prompt.Suggestion
+//		for _, b := range g.branches {
+//			suggestions = append(suggestions, prompt.Suggestion{Text: b, Category: "branch", Icon: "⎇"})
+//		}
+//		return suggestions
+//	}
+//
+//	p, err := prompt.New("git checkout ", prompt.WithCompleterSource(&gitCompleter{branches: branches}))
+type Completer interface {
+	Complete(d Document) []Suggestion
+}
+
+// CompleterFunc adapts a completion function to the Completer interface.
+type CompleterFunc func(Document) []Suggestion
+
+// Complete calls f(d).
+func (f CompleterFunc) Complete(d Document) []Suggestion {
+	return f(d)
+}
+
+// WithCompleterSource sets the completer from a Completer implementation.
+// It is equivalent to WithCompleter(completer.Complete).
+func WithCompleterSource(completer Completer) Option {
+	return func(c *Config) {
+		if completer == nil {
+			c.Completer = nil
+			return
+		}
+		c.Completer = completer.Complete
+	}
+}
+
+// display returns the text shown for the suggestion in menus.
+func (s Suggestion) display() string {
+	if s.DisplayText != "" {
+		return s.DisplayText
+	}
+	return s.Text
+}
+
+// suggestionColumns is the column layout of one page of the suggestion menu.
+// Each column is as wide as its widest entry on the page, so icons, texts,
+// categories and descriptions line up. Columns nobody uses take no space.
+type suggestionColumns struct {
+	icon        int // Width of the icon column, 0 when no suggestion has an icon
+	text        int // Width of the text column
+	category    int // Width of the category column, 0 when no suggestion has a category
+	description int // Width of the widest description cell, " - " included
+	row         int // Columns a row may take, selection marker included; 0 for no limit
+}
+
+// newSuggestionColumns measures the columns for the given page of suggestions.
+func newSuggestionColumns(page []Suggestion) suggestionColumns {
+	var columns suggestionColumns
+	for _, s := range page {
+		columns.icon = max(columns.icon, stringWidth(s.Icon))
+		columns.text = max(columns.text, stringWidth(s.display()))
+		columns.category = max(columns.category, stringWidth(s.Category))
+		if s.Description != "" {
+			columns.description = max(columns.description, 3+stringWidth(s.Description))
+		}
+	}
+	return columns
+}
+
+// fit caps the layout at rowWidth columns per row. When the widest row does
+// not fit, the text column is narrowed to leave room for the descriptions,
+// but to no less than half of the columns the icons and categories leave, so
+// the descriptions still start in one column. Texts and descriptions that do
+// not fit then end in an ellipsis.
+func (c suggestionColumns) fit(rowWidth int) suggestionColumns {
+	c.row = rowWidth
+	room := rowWidth - 2 // Selection marker
+	if c.icon > 0 {
+		room -= c.icon + 1
+	}
+	if c.category > 0 {
+		room -= c.category + 2
+	}
+	c.text = max(1, min(c.text, max(room-c.description, room/2)))
+	return c
+}
+
+// cells returns the plain text of each part of a menu row for s: the icon and
+// text cells padded to the column widths, then the category and description
+// cells, which are empty when s has none. Cells are only padded when something
+// follows them on the row, so rows have no trailing blanks. Texts wider than
+// their column and descriptions past the end of the row are cut short.
+func (c suggestionColumns) cells(s Suggestion) (icon, text, category, description string) {
+	if c.icon > 0 {
+		icon = padWidth(s.Icon, c.icon) + " "
+	}
+	text = ellipsize(s.display(), c.text)
+	if s.Category == "" && s.Description == "" {
+		return icon, text, "", ""
+	}
+	text = padWidth(text, c.text)
+	if c.category > 0 {
+		category = "  " + padWidth(s.Category, c.category)
+		if s.Description == "" {
+			category = strings.TrimRight(category, " ")
+		}
+	}
+	if s.Description != "" {
+		description = " - " + s.Description
+		if c.row > 0 {
+			description = ellipsize(description, c.row-2-stringWidth(icon+text+category))
+		}
+		if stringWidth(description) < 4 {
+			description = "" // No room for anything after the dash
+		}
+	}
+	return icon, text, category, description
+}
+
+// width returns the number of columns the menu row for s takes, including the
+// selection marker.
+func (c suggestionColumns) width(s Suggestion) int {
+	icon, text, category, description := c.cells(s)
+	return 2 + stringWidth(icon+text+category+description)
+}
+
+// padWidth pads s with spaces to n terminal columns.
+func padWidth(s string, n int) string {
+	if pad := n - stringWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// WithSuggestionDescriptionHidden leaves suggestion descriptions out of the
+// menu, which is then only as wide as the suggestion texts. Alt+/ shows them
+// for the open menu, and hides them again when they are shown by default.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithCompleter(completer), prompt.WithSuggestionDescriptionHidden(true))
+func WithSuggestionDescriptionHidden(hidden bool) Option {
+	return func(c *Config) {
+		c.HideDescriptions = hidden
+	}
+}
+
+// withoutDescriptions returns a copy of suggestions without their
+// descriptions, so the menu drawn from it is laid out without them.
+func withoutDescriptions(suggestions []Suggestion) []Suggestion {
+	stripped := slices.Clone(suggestions)
+	for i := range stripped {
+		stripped[i].Description = ""
+	}
+	return stripped
+}
+
+// CompletionMode selects what Tab does when several suggestions match.
+type CompletionMode int
+
+const (
+	// MenuComplete shows the suggestion menu right away. It is the default.
+	MenuComplete CompletionMode = iota
+	// PrefixComplete works like bash: Tab first inserts the longest prefix
+	// shared by all matching suggestions, and shows the menu only once there
+	// is nothing left to insert, usually on the second Tab.
+	PrefixComplete
+)
+
+// WithCompletionMode sets what Tab does when several suggestions match. See
+// CompletionMode.
+//
+// Example:
+//
+//	// With "status" and "stash", "st" + Tab inserts "sta" and a second Tab lists both
+//	prompt.New("$ ", prompt.WithCompleter(completer), prompt.WithCompletionMode(prompt.PrefixComplete))
+func WithCompletionMode(mode CompletionMode) Option {
+	return func(c *Config) {
+		c.CompletionMode = mode
+	}
+}
+
+// insertCommonPrefix completes the word before the cursor up to the longest
+// prefix shared by all suggestions, which all start with that word, and
+// reports whether it inserted anything.
+func (p *Prompt) insertCommonPrefix(doc Document, suggestions []Suggestion) bool {
+	if len(suggestions) == 0 {
+		return false
+	}
+	prefix := []rune(suggestions[0].Text)
+	for _, s := range suggestions[1:] {
+		text := []rune(s.Text)
+		n := 0
+		for n < len(prefix) && n < len(text) && prefix[n] == text[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	if len(prefix) <= len([]rune(p.completionWord(doc))) {
+		return false
+	}
+	p.acceptSuggestion(Suggestion{Text: string(prefix)})
+	return true
+}
+
+// WithMinCompletionChars keeps the completer from being queried until the word
+// before the cursor has at least n characters, for candidate sets too large to
+// list in full. Pressing Tab on a shorter word shows a hint row such as
+// "type 2+ chars to search" (MsgCompletionMinChars) instead of the menu, and
+// the menu does not open or refresh while typing until the word is long
+// enough, whatever WithCompleteWhileTypingTrigger allows. The trigger's
+// minimum only delays the menu that opens while typing.
+func WithMinCompletionChars(n int) Option {
+	return func(c *Config) {
+		c.MinCompletionChars = n
+	}
+}
+
+// belowMinCompletionChars reports whether the word before the cursor is
+// shorter than MinCompletionChars, so the completer is not queried yet.
+func (p *Prompt) belowMinCompletionChars() bool {
+	word := p.completionWord(Document{Text: string(p.buffer), CursorPosition: p.cursor})
+	return len([]rune(word)) < p.config.MinCompletionChars
+}
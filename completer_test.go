@@ -0,0 +1,303 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticCompleter is a Completer returning fixed suggestions.
+type staticCompleter struct {
+	suggestions []Suggestion
+	calls       int
+}
+
+func (c *staticCompleter) Complete(Document) []Suggestion {
+	c.calls++
+	return c.suggestions
+}
+
+func TestCompleter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("a Completer implementation drives completion", func(t *testing.T) {
+		t.Parallel()
+
+		completer := &staticCompleter{suggestions: []Suggestion{{Text: "checkout", DisplayText: "checkout (switch branches)"}}}
+		config := Config{}
+		WithCompleterSource(completer)(&config)
+		p := newForTestingWithConfig(t, config, "che\t\r")
+		p.renderer.output = &bytes.Buffer{}
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "checkout", result, "Text is inserted, not DisplayText")
+		assert.Equal(t, 1, completer.calls)
+	})
+
+	t.Run("CompleterFunc adapts a completion function", func(t *testing.T) {
+		t.Parallel()
+
+		var completer Completer = CompleterFunc(func(d Document) []Suggestion {
+			return []Suggestion{{Text: d.Text + "!"}}
+		})
+
+		assert.Equal(t, []Suggestion{{Text: "hi!"}}, completer.Complete(Document{Text: "hi"}))
+	})
+
+	t.Run("a nil Completer disables completion", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Completer: func(Document) []Suggestion { return nil }}
+		WithCompleterSource(nil)(&config)
+
+		assert.Nil(t, config.Completer)
+	})
+}
+
+func TestSuggestionColumns(t *testing.T) {
+	t.Parallel()
+
+	type row struct{ icon, text, category, description string }
+
+	tests := []struct {
+		name  string
+		page  []Suggestion
+		width int // Row width the columns are fitted to, 0 for none
+		want  []row
+	}{
+		{
+			name: "plain suggestions are not padded",
+			page: []Suggestion{{Text: "git"}, {Text: "gist"}},
+			want: []row{{text: "git"}, {text: "gist"}},
+		},
+		{
+			name: "descriptions line up after the widest text",
+			page: []Suggestion{{Text: "ls", Description: "list"}, {Text: "mkdir", Description: "make directory"}, {Text: "pwd"}},
+			want: []row{
+				{text: "ls   ", description: " - list"},
+				{text: "mkdir", description: " - make directory"},
+				{text: "pwd"},
+			},
+		},
+		{
+			name: "display text, icons and categories get their own columns",
+			page: []Suggestion{
+				{Text: "main.go", Icon: "📄", Category: "file", Description: "12 KB"},
+				{Text: "cmd", DisplayText: "cmd/", Icon: "📁", Category: "directory"},
+				{Text: "build", Description: "run the build"},
+			},
+			want: []row{
+				{icon: "📄 ", text: "main.go", category: "  file     ", description: " - 12 KB"},
+				{icon: "📁 ", text: "cmd/   ", category: "  directory"},
+				{icon: "   ", text: "build  ", category: "           ", description: " - run the build"}, // Emoji icons take two columns
+			},
+		},
+		{
+			name:  "a long text gives up columns so the descriptions fit",
+			page:  []Suggestion{{Text: "a-very-long-subcommand-name", Description: "does a thing"}, {Text: "ls", Description: "list"}},
+			width: 30,
+			want: []row{
+				{text: "a-very-long-s…", description: " - does a thi…"},
+				{text: "ls            ", description: " - list"},
+			},
+		},
+		{
+			name:  "descriptions are cut at the end of the row",
+			page:  []Suggestion{{Text: "ls", Description: "list directory contents"}, {Text: "cd"}},
+			width: 20,
+			want:  []row{{text: "ls", description: " - list directo…"}, {text: "cd"}},
+		},
+		{
+			name:  "a description without room is left out",
+			page:  []Suggestion{{Text: "kubectl", Description: "k8s"}},
+			width: 8,
+			want:  []row{{text: "ku…"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			columns := newSuggestionColumns(tt.page)
+			if tt.width > 0 {
+				columns = columns.fit(tt.width)
+			}
+			for i, s := range tt.page {
+				icon, text, category, description := columns.cells(s)
+				assert.Equal(t, tt.want[i], row{icon, text, category, description}, "row %d", i)
+				assert.Equal(t, 2+stringWidth(icon+text+category+description), columns.width(s))
+				if tt.width > 0 {
+					assert.LessOrEqual(t, columns.width(s), tt.width, "row %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestInsertCommonPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		buffer      string
+		suggestions []Suggestion
+		want        string
+		inserted    bool
+	}{
+		{
+			name:        "shared prefix longer than the word is inserted",
+			buffer:      "git st",
+			suggestions: []Suggestion{{Text: "status"}, {Text: "stash"}},
+			want:        "git sta",
+			inserted:    true,
+		},
+		{
+			name:        "nothing is inserted when the word is already the shared prefix",
+			buffer:      "gi",
+			suggestions: []Suggestion{{Text: "git"}, {Text: "gist"}},
+			want:        "gi",
+		},
+		{
+			name:        "empty word gets the shared prefix",
+			buffer:      "cd ",
+			suggestions: []Suggestion{{Text: "projects/"}, {Text: "projects-old/"}},
+			want:        "cd projects",
+			inserted:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{}, "")
+			p.setBuffer(tt.buffer)
+
+			inserted := p.insertCommonPrefix(Document{Text: tt.buffer, CursorPosition: len([]rune(tt.buffer))}, tt.suggestions)
+
+			assert.Equal(t, tt.inserted, inserted)
+			assert.Equal(t, tt.want, string(p.buffer))
+		})
+	}
+}
+
+func TestMinCompletionChars(t *testing.T) {
+	t.Parallel()
+
+	var queried []string
+	config := Config{
+		Prefix:             "> ",
+		MinCompletionChars: 3,
+		Completer: func(d Document) []Suggestion {
+			queried = append(queried, d.Text)
+			return []Suggestion{{Text: "alpha"}, {Text: "alpine"}}
+		},
+	}
+	p := newForTestingWithConfig(t, config, "al\tp\t\r\r")
+
+	result, err := p.Run()
+
+	require.NoError(t, err)
+	assert.Equal(t, "alpha", result)
+	assert.Equal(t, []string{"alp"}, queried, "the completer is not queried for a shorter word")
+}
+
+func TestCyclesInline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		rows   int
+		height int
+		want   bool
+	}{
+		{name: "a tall terminal uses the menu", rows: 0, height: 24, want: false},
+		{name: "the default threshold is 5 rows", rows: 0, height: 4, want: true},
+		{name: "a custom threshold", rows: 30, height: 24, want: true},
+		{name: "a negative threshold disables cycling", rows: -1, height: 2, want: false},
+		{name: "an unknown height uses the menu", rows: 0, height: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{InlineCycleRows: tt.rows}, "")
+			p.terminal.(*mockTerminal).terminalSize = [2]int{80, tt.height}
+
+			assert.Equal(t, tt.want, p.cyclesInline())
+		})
+	}
+}
+
+func TestInitialSuggestions(t *testing.T) {
+	t.Parallel()
+
+	initial := []Suggestion{{Text: "new"}, {Text: "open"}, {Text: "quit"}}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Enter takes the selected suggestion", input: "\x1b[B\r\r", want: "open"},
+		{name: "typing closes the menu", input: "n\r", want: "n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{Prefix: "> ", InitialSuggestions: initial}, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+
+			result, _ := p.Run()
+
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestMenuRemembersPositionPerWord(t *testing.T) {
+	t.Parallel()
+
+	completer := func(Document) []Suggestion {
+		suggestions := make([]Suggestion, 20)
+		for i := range suggestions {
+			suggestions[i] = Suggestion{Text: fmt.Sprintf("g%02d", i)}
+		}
+		return suggestions
+	}
+
+	t.Run("typing and deleting a character restores the selection", func(t *testing.T) {
+		t.Parallel()
+
+		// Select the 15th suggestion, narrow the menu with "0" and widen it
+		// again with Backspace, then accept the selection and submit
+		input := "g" + strings.Repeat("\x1b[B", 15) + "0\x7f\r\r"
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", Completer: completer, CompleteAsYouType: true}, input)
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "g14", result)
+	})
+
+	t.Run("a changed candidate set starts at the top", func(t *testing.T) {
+		t.Parallel()
+
+		positions := menuMemory{}
+		positions.save("g", completer(Document{}), 14, 5)
+
+		_, _, ok := positions.restore("g", completer(Document{})[:10])
+
+		assert.False(t, ok)
+	})
+}
@@ -1,10 +1,12 @@
 package prompt
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAcceptSuggestion(t *testing.T) {
@@ -489,3 +491,56 @@ func TestCompletionBehavior(t *testing.T) {
 		assert.NotContains(t, string(p.buffer), "file", "Buffer should not contain other suggestions")
 	})
 }
+
+func TestAcceptMultiLineSuggestion(t *testing.T) {
+	t.Parallel()
+
+	completer := func(Document) []Suggestion {
+		return []Suggestion{
+			{Text: "for {\n}", Description: "loop snippet"},
+			{Text: "func", Description: "function"},
+		}
+	}
+	p := newForTestingWithConfig(t, Config{Prefix: "> ", Completer: completer}, "f\t\t\r")
+	defer p.Close()
+
+	result, err := p.Run()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "for {\n}", result)
+	assert.Equal(t, p.renderer.lastLines-1, p.renderer.cursorRow, "cursor should end on the last input line")
+}
+
+func TestRightAcceptsAtEnd(t *testing.T) {
+	t.Parallel()
+
+	completer := func(Document) []Suggestion {
+		return []Suggestion{{Text: "git"}, {Text: "go"}}
+	}
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+		want   string
+	}{
+		{name: "accepts at the end of the input", input: "g\t\x1b[C!\r", want: "git!"},
+		{name: "moves the cursor inside the input", input: "gx\x1b[D\t\x1b[C\x07!\r", want: "gx!"},
+		{name: "always accepts when configured", config: Config{RightAlwaysAccepts: true}, input: "gx\x1b[D\t\x1b[C!\r", want: "git!x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := tt.config
+			config.Prefix = "$ "
+			config.Completer = completer
+			p := newForTestingWithConfig(t, config, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package prompt
+
+import "fmt"
+
+// Virtual key codes and control key state flags of Win32 console key events,
+// as documented for KEY_EVENT_RECORD. They are defined here rather than taken
+// from golang.org/x/sys/windows so the translation builds and is tested on
+// every platform.
+const (
+	vkBack   = 0x08
+	vkTab    = 0x09
+	vkMenu   = 0x12 // Alt
+	vkSpace  = 0x20
+	vkPrior  = 0x21 // PageUp
+	vkNext   = 0x22 // PageDown
+	vkEnd    = 0x23
+	vkHome   = 0x24
+	vkLeft   = 0x25
+	vkUp     = 0x26
+	vkRight  = 0x27
+	vkDown   = 0x28
+	vkInsert = 0x2d
+	vkDelete = 0x2e
+	vkF1     = 0x70
+	vkF12    = 0x7b
+
+	rightAltPressed  = 0x0001
+	leftAltPressed   = 0x0002
+	rightCtrlPressed = 0x0004
+	leftCtrlPressed  = 0x0008
+	shiftPressed     = 0x0010
+)
+
+// consoleKeySequence returns what an xterm-compatible terminal sends for a key
+// pressed in a Win32 console: the virtual key code vk, the character ch the
+// key produced (0 for none) and the state of the modifier keys. The native
+// Windows backend feeds the result to the prompt like terminal input, so the
+// KeyMap and its BindSequence bindings work the same on every terminal.
+// Cursor, editing and function keys become escape sequences with the xterm
+// modifier parameter, such as "\x1b[1;5C" for Ctrl+Right; characters typed
+// with Alt get an ESC in front. Keys that send nothing, such as Shift on its
+// own, return "".
+func consoleKeySequence(vk uint16, ch rune, state uint32) string {
+	alt := state&(leftAltPressed|rightAltPressed) != 0
+	ctrl := state&(leftCtrlPressed|rightCtrlPressed) != 0
+	modifier := 1
+	if state&shiftPressed != 0 {
+		modifier++
+	}
+	if alt {
+		modifier += 2
+	}
+	if ctrl {
+		modifier += 4
+	}
+
+	if final := cursorKeyFinal(vk); final != 0 {
+		if modifier == 1 {
+			return "\x1b[" + string(final)
+		}
+		return fmt.Sprintf("\x1b[1;%d%c", modifier, final)
+	}
+	if vk >= vkF1 && vk < vkF1+4 {
+		final := 'P' + rune(vk-vkF1)
+		if modifier == 1 {
+			return "\x1bO" + string(final)
+		}
+		return fmt.Sprintf("\x1b[1;%d%c", modifier, final)
+	}
+	if code := tildeKeyCode(vk); code != 0 {
+		if modifier == 1 {
+			return fmt.Sprintf("\x1b[%d~", code)
+		}
+		return fmt.Sprintf("\x1b[%d;%d~", code, modifier)
+	}
+
+	switch {
+	case vk == vkTab && state&shiftPressed != 0:
+		return "\x1b[Z"
+	case vk == vkBack:
+		ch = '\x7f' // What terminals send for Backspace, rather than Ctrl+H
+	case vk == vkSpace && ctrl:
+		return "\x00"
+	case ch == 0:
+		return ""
+	}
+	// Ctrl+Alt is AltGr on many layouts, whose character is already composed
+	if alt && !ctrl {
+		return "\x1b" + string(ch)
+	}
+	return string(ch)
+}
+
+// cursorKeyFinal returns the final character of the escape sequence of a
+// cursor key, or 0 for other keys.
+func cursorKeyFinal(vk uint16) rune {
+	switch vk {
+	case vkUp:
+		return 'A'
+	case vkDown:
+		return 'B'
+	case vkRight:
+		return 'C'
+	case vkLeft:
+		return 'D'
+	case vkHome:
+		return 'H'
+	case vkEnd:
+		return 'F'
+	}
+	return 0
+}
+
+// tildeKeyCode returns the number of the "\x1b[<n>~" sequence of an editing
+// key or a function key from F5 on, or 0 for other keys.
+func tildeKeyCode(vk uint16) int {
+	switch vk {
+	case vkInsert:
+		return 2
+	case vkDelete:
+		return 3
+	case vkPrior:
+		return 5
+	case vkNext:
+		return 6
+	}
+	if vk >= vkF1+4 && vk <= vkF12 {
+		// F5 to F12, with the gaps xterm leaves at 16 and 22
+		return []int{15, 17, 18, 19, 20, 21, 23, 24}[vk-vkF1-4]
+	}
+	return 0
+}
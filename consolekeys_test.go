@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleKeySequence(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		vk    uint16
+		ch    rune
+		state uint32
+		want  string
+	}{
+		{name: "letter", vk: 'A', ch: 'a', want: "a"},
+		{name: "shifted letter", vk: 'A', ch: 'A', state: shiftPressed, want: "A"},
+		{name: "non-ASCII character", vk: 0xde, ch: 'é', want: "é"},
+		{name: "control character", vk: 'A', ch: '\x01', state: leftCtrlPressed, want: "\x01"},
+		{name: "Alt+letter", vk: 'Y', ch: 'y', state: leftAltPressed, want: "\x1by"},
+		{name: "AltGr character", vk: 'Q', ch: '@', state: rightAltPressed | leftCtrlPressed, want: "@"},
+		{name: "Enter", vk: 0x0d, ch: '\r', want: "\r"},
+		{name: "Alt+Enter", vk: 0x0d, ch: '\r', state: leftAltPressed, want: "\x1b\r"},
+		{name: "Backspace", vk: vkBack, ch: '\b', want: "\x7f"},
+		{name: "Shift+Tab", vk: vkTab, ch: '\t', state: shiftPressed, want: "\x1b[Z"},
+		{name: "Ctrl+Space", vk: vkSpace, ch: ' ', state: rightCtrlPressed, want: "\x00"},
+		{name: "Up", vk: vkUp, want: "\x1b[A"},
+		{name: "Left", vk: vkLeft, want: "\x1b[D"},
+		{name: "Home", vk: vkHome, want: "\x1b[H"},
+		{name: "End", vk: vkEnd, want: "\x1b[F"},
+		{name: "Ctrl+Right", vk: vkRight, state: leftCtrlPressed, want: "\x1b[1;5C"},
+		{name: "Shift+Alt+Down", vk: vkDown, state: shiftPressed | leftAltPressed, want: "\x1b[1;4B"},
+		{name: "Delete", vk: vkDelete, want: "\x1b[3~"},
+		{name: "Ctrl+Delete", vk: vkDelete, state: leftCtrlPressed, want: "\x1b[3;5~"},
+		{name: "PageUp", vk: vkPrior, want: "\x1b[5~"},
+		{name: "Insert", vk: vkInsert, want: "\x1b[2~"},
+		{name: "F1", vk: vkF1, want: "\x1bOP"},
+		{name: "Shift+F4", vk: vkF1 + 3, state: shiftPressed, want: "\x1b[1;2S"},
+		{name: "F5", vk: vkF1 + 4, want: "\x1b[15~"},
+		{name: "F6 skips 16", vk: vkF1 + 5, want: "\x1b[17~"},
+		{name: "F11 skips 22", vk: vkF1 + 10, want: "\x1b[23~"},
+		{name: "Ctrl+F12", vk: vkF12, state: leftCtrlPressed, want: "\x1b[24;5~"},
+		{name: "Shift on its own", vk: 0x10, state: shiftPressed, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, consoleKeySequence(tt.vk, tt.ch, tt.state))
+		})
+	}
+}
+
+func TestConsoleKeySequenceBindings(t *testing.T) {
+	t.Parallel()
+
+	km := NewDefaultKeyMap()
+	tests := []struct {
+		name  string
+		vk    uint16
+		state uint32
+		want  KeyAction
+	}{
+		{name: "Up moves up", vk: vkUp, want: ActionMoveUp},
+		{name: "Home moves home", vk: vkHome, want: ActionMoveHome},
+		{name: "Ctrl+Left moves a word left", vk: vkLeft, state: leftCtrlPressed, want: ActionMoveWordLeft},
+		{name: "Delete deletes", vk: vkDelete, want: ActionDeleteChar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			seq := consoleKeySequence(tt.vk, 0, tt.state)
+
+			// The KeyMap is looked up without the leading ESC
+			assert.Equal(t, tt.want, km.GetSequenceAction(strings.TrimPrefix(seq, "\x1b")))
+		})
+	}
+}
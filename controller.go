@@ -0,0 +1,79 @@
+package prompt
+
+// PromptController gives callbacks safe access to a running prompt.
+//
+// Callbacks such as the idle hook registered with WithOnIdle and the key
+// handlers bound with KeyMap.BindFunc run on the event loop goroutine between
+// key presses, so they can read and change the buffer,
+// the cursor and the prefix without racing the editor. Changes made through the
+// controller are not drawn immediately; the event loop repaints the prompt once
+// after the callback returns, so a callback that updates several things still
+// produces a single frame.
+//
+// A controller is only valid for the duration of the callback it was passed to.
+// Do not keep it or use it from another goroutine.
+type PromptController struct {
+	p *Prompt
+}
+
+// newPromptController returns a controller bound to the given prompt.
+func newPromptController(p *Prompt) *PromptController {
+	return &PromptController{p: p}
+}
+
+// Text returns the current contents of the input buffer.
+func (c *PromptController) Text() string {
+	return string(c.p.buffer)
+}
+
+// SetText replaces the input buffer and moves the cursor to the end of it.
+func (c *PromptController) SetText(text string) {
+	c.p.setBuffer(text)
+}
+
+// InsertText inserts text at the cursor and moves the cursor past it.
+func (c *PromptController) InsertText(text string) {
+	c.p.insertText(text)
+}
+
+// CursorPosition returns the cursor position as a rune index into the buffer.
+func (c *PromptController) CursorPosition() int {
+	return c.p.cursor
+}
+
+// SetCursorPosition moves the cursor to the given rune index. Out of range
+// positions are clamped to the start or end of the buffer.
+func (c *PromptController) SetCursorPosition(pos int) {
+	c.p.cursor = max(0, min(pos, len(c.p.buffer)))
+}
+
+// Document returns the current input state in the same form completers receive.
+func (c *PromptController) Document() Document {
+	return Document{
+		Text:           string(c.p.buffer),
+		CursorPosition: c.p.cursor,
+	}
+}
+
+// Prefix returns the prompt prefix.
+func (c *PromptController) Prefix() string {
+	return c.p.config.Prefix
+}
+
+// SetPrefix changes the prompt prefix. It is typically used by an idle hook to
+// refresh dynamic data such as a clock or the current git branch.
+func (c *PromptController) SetPrefix(prefix string) {
+	c.p.SetPrefix(prefix)
+}
+
+// SetPrefixSegments changes the prompt prefix to colored segments, like
+// Prompt.SetPrefixSegments.
+func (c *PromptController) SetPrefixSegments(segments ...PrefixSegment) {
+	c.p.SetPrefixSegments(segments...)
+}
+
+// SetMode switches to the mode registered under name, like Prompt.SetMode.
+// The prompt is repainted in the new mode once the callback returns.
+func (c *PromptController) SetMode(name string) error {
+	return c.p.SetMode(name)
+}
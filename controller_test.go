@@ -0,0 +1,215 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chanTerminal is a mock terminal whose input arrives over a channel, so tests
+// can hold the prompt idle between key presses.
+type chanTerminal struct {
+	*mockTerminal
+	keys chan rune
+}
+
+func newChanTerminal() *chanTerminal {
+	return &chanTerminal{
+		mockTerminal: newMockTerminal(""),
+		keys:         make(chan rune, 16),
+	}
+}
+
+func (c *chanTerminal) ReadRune() (rune, int, error) {
+	r, ok := <-c.keys
+	if !ok {
+		return 0, 0, io.EOF
+	}
+	return r, 1, nil
+}
+
+func TestPromptController(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SetText replaces the buffer and moves the cursor to the end", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "$ "}, "")
+		c := newPromptController(p)
+
+		c.SetText("git status")
+
+		assert.Equal(t, "git status", c.Text())
+		assert.Equal(t, 10, c.CursorPosition())
+	})
+
+	t.Run("InsertText inserts at the cursor", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "$ "}, "")
+		c := newPromptController(p)
+		c.SetText("gitstatus")
+		c.SetCursorPosition(3)
+
+		c.InsertText(" ")
+
+		assert.Equal(t, "git status", c.Text())
+		assert.Equal(t, 4, c.CursorPosition())
+		assert.Equal(t, Document{Text: "git status", CursorPosition: 4}, c.Document())
+	})
+
+	t.Run("SetCursorPosition clamps out of range positions", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "$ "}, "")
+		c := newPromptController(p)
+		c.SetText("abc")
+
+		c.SetCursorPosition(-5)
+		assert.Equal(t, 0, c.CursorPosition())
+
+		c.SetCursorPosition(99)
+		assert.Equal(t, 3, c.CursorPosition())
+	})
+
+	t.Run("SetPrefix changes the prompt prefix", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "$ "}, "")
+		c := newPromptController(p)
+
+		c.SetPrefix("12:00 $ ")
+
+		assert.Equal(t, "12:00 $ ", c.Prefix())
+		assert.Equal(t, "12:00 $ ", p.config.Prefix)
+	})
+}
+
+func TestWithOnIdle(t *testing.T) {
+	t.Parallel()
+
+	t.Run("option sets the interval and the hook", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{}
+		WithOnIdle(time.Second, func(*PromptController) {})(&config)
+
+		assert.Equal(t, time.Second, config.IdleInterval)
+		assert.NotNil(t, config.OnIdle)
+	})
+
+	t.Run("hook refreshes the prefix while no key is pressed", func(t *testing.T) {
+		t.Parallel()
+
+		fired := make(chan struct{}, 8)
+		calls := 0
+		config := Config{
+			Prefix:       "$ ",
+			IdleInterval: 10 * time.Millisecond,
+			OnIdle: func(c *PromptController) {
+				calls++
+				c.SetPrefix("idle $ ")
+				fired <- struct{}{}
+			},
+		}
+		p := newForTestingWithConfig(t, config, "")
+		terminal := newChanTerminal()
+		p.terminal = terminal
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, terminal)
+
+		terminal.keys <- 'l'
+		terminal.keys <- 's'
+		go func() {
+			<-fired
+			terminal.keys <- '\r'
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		result, err := p.RunWithContext(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, "ls", result)
+		assert.GreaterOrEqual(t, calls, 1)
+		assert.Contains(t, out.String(), "idle $ ")
+	})
+
+	t.Run("no hook runs when the interval is zero", func(t *testing.T) {
+		t.Parallel()
+
+		called := false
+		config := Config{
+			Prefix: "$ ",
+			OnIdle: func(*PromptController) { called = true },
+		}
+		p := newForTestingWithConfig(t, config, "ok\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result)
+		assert.False(t, called)
+	})
+}
+
+func TestPromptRedrawsOnResize(t *testing.T) {
+	t.Parallel()
+
+	p := newForTestingWithConfig(t, Config{Prefix: "$ "}, "")
+	terminal := newChanTerminal()
+	p.terminal = terminal
+	var out syncBuffer
+	p.renderer = newRenderer(&out, ThemeDefault, terminal)
+
+	for _, r := range "hello world" {
+		terminal.keys <- r
+	}
+	go func() {
+		// Wait until the input has been drawn, then shrink the terminal
+		for !strings.Contains(out.String(), "hello world") {
+			time.Sleep(time.Millisecond)
+		}
+		terminal.resize(5, 24)
+		for !strings.Contains(out.String(), "\x1b[2A") {
+			time.Sleep(time.Millisecond)
+		}
+		terminal.keys <- '\r'
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result, err := p.RunWithContext(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", result)
+	// The redraw after the resize moves up over the two rows the old line now
+	// wraps into before clearing
+	assert.Contains(t, out.String(), "\x1b[2A\r\x1b[0J")
+}
+
+// syncBuffer is a bytes.Buffer that can be written by the event loop while a
+// test goroutine reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"slices"
+	"strings"
+)
+
+// DedupeMode selects what happens to suggestions that are the same candidate,
+// for example when a completer combines several sources that each return it.
+type DedupeMode int
+
+const (
+	// DedupeOff shows every suggestion the completer returns. It is the
+	// default.
+	DedupeOff DedupeMode = iota
+	// DedupeKeepFirst keeps the first of the duplicate suggestions and drops
+	// the others.
+	DedupeKeepFirst
+	// DedupeMergeDescriptions keeps the first of the duplicate suggestions and
+	// joins the different descriptions of all of them into its description,
+	// separated by ", ", so no source's hint is lost.
+	DedupeMergeDescriptions
+)
+
+// WithDedupe removes duplicate suggestions before they are sorted, matched
+// against the word being completed and shown. Suggestions are duplicates when
+// key returns the same string for them; a nil key compares their Text. The
+// kept suggestion stays where the first of them was.
+//
+// Example:
+//
+//	// "git" from both the builtin and the PATH completer is listed once,
+//	// described as "builtin, /usr/bin/git"
+//	prompt.New("$ ",
+//		prompt.WithCompleter(func(d prompt.Document) []prompt.Suggestion {
+//			return append(builtins(d), pathCommands(d)...)
+//		}),
+//		prompt.WithDedupe(prompt.DedupeMergeDescriptions, nil),
+//	)
+func WithDedupe(mode DedupeMode, key func(Suggestion) string) Option {
+	return func(c *Config) {
+		c.Dedupe = mode
+		c.DedupeKey = key
+	}
+}
+
+// dedupeSuggestions returns suggestions without the duplicates the Dedupe
+// config removes. The completer's slice is not modified.
+func (p *Prompt) dedupeSuggestions(suggestions []Suggestion) []Suggestion {
+	if p.config.Dedupe == DedupeOff || len(suggestions) < 2 {
+		return suggestions
+	}
+	key := p.config.DedupeKey
+	if key == nil {
+		key = func(s Suggestion) string { return s.Text }
+	}
+
+	kept := make([]Suggestion, 0, len(suggestions))
+	index := make(map[string]int, len(suggestions)) // Position in kept of each key
+	descriptions := make(map[int][]string)          // Different descriptions of each merged suggestion
+	for _, suggestion := range suggestions {
+		k := key(suggestion)
+		i, seen := index[k]
+		if !seen {
+			index[k] = len(kept)
+			kept = append(kept, suggestion)
+			continue
+		}
+		if p.config.Dedupe != DedupeMergeDescriptions || suggestion.Description == "" {
+			continue
+		}
+		if descriptions[i] == nil && kept[i].Description != "" {
+			descriptions[i] = []string{kept[i].Description}
+		}
+		if !slices.Contains(descriptions[i], suggestion.Description) {
+			descriptions[i] = append(descriptions[i], suggestion.Description)
+		}
+	}
+	for i, merged := range descriptions {
+		kept[i].Description = strings.Join(merged, ", ")
+	}
+	return kept
+}
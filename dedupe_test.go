@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupe(t *testing.T) {
+	t.Parallel()
+
+	suggestions := []Suggestion{
+		{Text: "git", Description: "builtin"},
+		{Text: "go"},
+		{Text: "git", Description: "/usr/bin/git"},
+		{Text: "git", Description: "builtin"},
+		{Text: "Go", Description: "/usr/local/bin/go"},
+	}
+	tests := []struct {
+		name string
+		mode DedupeMode
+		key  func(Suggestion) string
+		want []Suggestion
+	}{
+		{name: "off keeps every suggestion", mode: DedupeOff, want: suggestions},
+		{
+			name: "keep first drops later duplicates",
+			mode: DedupeKeepFirst,
+			want: []Suggestion{{Text: "git", Description: "builtin"}, {Text: "go"}, {Text: "Go", Description: "/usr/local/bin/go"}},
+		},
+		{
+			name: "merge joins the different descriptions",
+			mode: DedupeMergeDescriptions,
+			want: []Suggestion{{Text: "git", Description: "builtin, /usr/bin/git"}, {Text: "go"}, {Text: "Go", Description: "/usr/local/bin/go"}},
+		},
+		{
+			name: "custom key",
+			mode: DedupeMergeDescriptions,
+			key:  func(s Suggestion) string { return strings.ToLower(s.Text) },
+			want: []Suggestion{{Text: "git", Description: "builtin, /usr/bin/git"}, {Text: "go", Description: "/usr/local/bin/go"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			original := append([]Suggestion(nil), suggestions...)
+			p := newForTestingWithConfig(t, Config{Prefix: "$ ", Dedupe: tt.mode, DedupeKey: tt.key}, "")
+
+			got := p.matchSuggestions(Document{}, suggestions)
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, original, suggestions, "the completer's slice is not modified")
+		})
+	}
+
+	t.Run("a candidate from two sources completes on the first Tab", func(t *testing.T) {
+		t.Parallel()
+
+		completer := func(Document) []Suggestion {
+			return []Suggestion{{Text: "status"}, {Text: "status"}}
+		}
+		p := newForTestingWithConfig(t, Config{Prefix: "$ ", Completer: completer}, "st\t\r")
+		p.renderer.output = &bytes.Buffer{}
+		WithDedupe(DedupeKeepFirst, nil)(&p.config)
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "status", result)
+	})
+}
@@ -0,0 +1,37 @@
+package prompt
+
+// maxReportedSequences caps how many different unknown sequences are passed to
+// OnUnknownSequence, so a terminal sending garbage cannot flood the handler.
+const maxReportedSequences = 32
+
+// WithUnknownSequenceHandler sets a function that is told about escape
+// sequences with no key binding, which are otherwise dropped silently. When a
+// user reports that a key such as F5 does nothing, logging these shows what the
+// terminal sends for it. The handler receives the sequence without the leading
+// ESC, in the form KeyMap.BindSequence accepts, and is called once for each
+// different sequence, for at most 32 of them. It runs on the event loop
+// between key presses.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithUnknownSequenceHandler(func(seq string) {
+//		log.Printf("unbound key: ESC %q", seq)
+//	}))
+func WithUnknownSequenceHandler(handler func(seq string)) Option {
+	return func(c *Config) {
+		c.OnUnknownSequence = handler
+	}
+}
+
+// reportUnknownSequence passes seq to the OnUnknownSequence handler unless it
+// was reported before or the limit of reported sequences is reached.
+func (p *Prompt) reportUnknownSequence(seq string) {
+	if p.config.OnUnknownSequence == nil || p.reportedSequences[seq] || len(p.reportedSequences) >= maxReportedSequences {
+		return
+	}
+	if p.reportedSequences == nil {
+		p.reportedSequences = make(map[string]bool)
+	}
+	p.reportedSequences[seq] = true
+	p.config.OnUnknownSequence(seq)
+}
@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnknownSequenceHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unbound sequences are reported once each", func(t *testing.T) {
+		t.Parallel()
+
+		var reported []string
+		config := Config{Prefix: "> ", OnUnknownSequence: func(seq string) {
+			reported = append(reported, seq)
+		}}
+		// F5 twice, Left (bound), F6
+		p := newForTestingWithConfig(t, config, "a\x1b[15~\x1b[15~\x1b[D\x1b[17~\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "a", result, "unknown sequences are still dropped")
+		assert.Equal(t, []string{"[15~", "[17~"}, reported)
+	})
+
+	t.Run("bound sequences are not reported", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		keyMap.BindSequence("[15~", ActionDeleteLine)
+		reported := 0
+		config := Config{Prefix: "> ", KeyMap: keyMap, OnUnknownSequence: func(string) { reported++ }}
+		p := newForTestingWithConfig(t, config, "ab\x1b[15~c\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "c", result)
+		assert.Zero(t, reported)
+	})
+
+	t.Run("the number of reported sequences is capped", func(t *testing.T) {
+		t.Parallel()
+
+		reported := 0
+		p := newForTestingWithConfig(t, Config{OnUnknownSequence: func(string) { reported++ }}, "")
+		for i := range maxReportedSequences + 10 {
+			p.reportUnknownSequence(fmt.Sprintf("[%d~", 100+i))
+		}
+
+		assert.Equal(t, maxReportedSequences, reported)
+	})
+}
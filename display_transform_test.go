@@ -0,0 +1,96 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// maskAllButLast4 hides every digit except the last four, keeping the length.
+func maskAllButLast4(text string) string {
+	runes := []rune(text)
+	for i := range max(0, len(runes)-4) {
+		if runes[i] != ' ' {
+			runes[i] = '*'
+		}
+	}
+	return string(runes)
+}
+
+func TestDisplayText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		transform  func(string) string
+		buffer     string
+		cursor     int
+		wantText   string
+		wantCursor int
+	}{
+		{
+			name:       "no transform shows the buffer as is",
+			buffer:     "4111 1111",
+			cursor:     4,
+			wantText:   "4111 1111",
+			wantCursor: 4,
+		},
+		{
+			name:       "masking keeps the cursor in place",
+			transform:  maskAllButLast4,
+			buffer:     "4111 1111 1234",
+			cursor:     5,
+			wantText:   "**** **** 1234",
+			wantCursor: 5,
+		},
+		{
+			name:       "shortening transform clamps the cursor to the displayed text",
+			transform:  func(string) string { return "***" },
+			buffer:     "secret",
+			cursor:     6,
+			wantText:   "***",
+			wantCursor: 3,
+		},
+		{
+			name:       "expanding transform maps the cursor after the displayed prefix",
+			transform:  func(s string) string { return strings.ReplaceAll(s, "\t", "    ") },
+			buffer:     "\tx",
+			cursor:     1,
+			wantText:   "    x",
+			wantCursor: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{DisplayTransform: tt.transform}, "")
+			p.buffer = []rune(tt.buffer)
+			p.cursor = tt.cursor
+
+			text, cursor := p.displayText()
+
+			assert.Equal(t, tt.wantText, text)
+			assert.Equal(t, tt.wantCursor, cursor)
+		})
+	}
+}
+
+func TestWithDisplayTransform(t *testing.T) {
+	t.Parallel()
+
+	p := newForTestingWithConfig(t, Config{Prefix: "card: "}, "4111111111111234\r")
+	WithDisplayTransform(maskAllButLast4)(&p.config)
+	var out bytes.Buffer
+	p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+	result, err := p.Run()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "4111111111111234", result, "the real text is returned")
+	assert.Contains(t, out.String(), "************1234")
+	assert.NotContains(t, out.String(), "41111111")
+}
@@ -68,7 +68,7 @@
 //
 //   - Enter: Submit input (Shift+Enter for multi-line in appropriate contexts)
 //   - Ctrl+C: Cancel and return ErrInterrupted
-//   - Ctrl+D: EOF when buffer is empty
+//   - Ctrl+D: EOF when buffer is empty, else delete the character at the cursor
 //   - Arrow keys: Navigate history (up/down) and move cursor (left/right)
 //   - Ctrl+A / Home: Move to beginning of line
 //   - Ctrl+E / End: Move to end of line
@@ -114,7 +114,8 @@
 // The library provides specific error types for different scenarios:
 //
 //   - prompt.ErrInterrupted: User pressed Ctrl+C
-//   - io.EOF: User pressed Ctrl+D with empty buffer
+//   - prompt.ErrEOF: User pressed Ctrl+D with empty buffer, or input ended; it wraps io.EOF
+//   - prompt.ErrExit: The ExitChecker ended the session; it wraps prompt.ErrEOF
 //   - context.DeadlineExceeded: Timeout reached (when using context)
 //   - context.Canceled: Context was cancelled
 //
@@ -0,0 +1,80 @@
+package prompt
+
+// Draft is the unfinished input of a prompt, saved with SaveDraft and put back
+// with RestoreDraft. Its fields are exported and tagged for encoding/json, so
+// a draft can be written to a file and restored by the next process.
+type Draft struct {
+	Text         string `json:"text"`                    // Input as typed
+	Cursor       int    `json:"cursor"`                  // Cursor position in runes
+	HistoryIndex int    `json:"history_index"`           // History entry being browsed, -1 while editing a new line
+	HistoryEntry string `json:"history_entry,omitempty"` // Text of that entry, to find it again if the history changed
+	TypedLine    string `json:"typed_line,omitempty"`    // Line typed before browsing history, restored past the newest entry
+}
+
+// SaveDraft returns the input, the cursor and the history position of the
+// prompt, to resume them later with RestoreDraft. Call it after Run returns,
+// for example when the context of RunWithContext was canceled to show an alert
+// in the middle of typing.
+//
+// Example:
+//
+//	_, err := p.RunWithContext(ctx) // ctx is canceled when an alert comes in
+//	if errors.Is(err, context.Canceled) {
+//		draft := p.SaveDraft()
+//		showAlert()
+//		p.RestoreDraft(draft)
+//		line, err = p.Run() // Continues where the user left off
+//	}
+func (p *Prompt) SaveDraft() Draft {
+	draft := Draft{Text: string(p.buffer), Cursor: p.cursor, HistoryIndex: -1}
+	if p.historyIndex >= 0 && p.historyIndex < len(p.history) {
+		draft.HistoryIndex = p.historyIndex
+		draft.HistoryEntry = p.history[p.historyIndex]
+		draft.TypedLine = p.historyLine
+	}
+	return draft
+}
+
+// RestoreDraft makes the next Run start with the input, cursor and history
+// position of draft instead of an empty line. The cursor is kept within the
+// input. When the history changed since the draft was saved, the entry being
+// browsed is looked up by its text, and the draft continues as a new line if
+// it is gone. Edits made to other history entries before the draft was saved
+// are not kept.
+func (p *Prompt) RestoreDraft(draft Draft) {
+	p.draft = &draft
+}
+
+// applyDraft replaces the input of a starting Run with the draft set by
+// RestoreDraft, if any.
+func (p *Prompt) applyDraft() {
+	if p.draft == nil {
+		return
+	}
+	draft := *p.draft
+	p.draft = nil
+	p.buffer = []rune(draft.Text)
+	p.cursor = max(0, min(draft.Cursor, len(p.buffer)))
+	if draft.HistoryIndex < 0 {
+		return
+	}
+	index := draft.HistoryIndex
+	if index >= len(p.history) || p.history[index] != draft.HistoryEntry {
+		index = lastIndex(p.history, draft.HistoryEntry)
+	}
+	if index < 0 {
+		return // The entry is gone, so the draft is a new line
+	}
+	p.historyIndex = index
+	p.historyLine = draft.TypedLine
+}
+
+// lastIndex returns the index of the last entry of s equal to v, or -1.
+func lastIndex(s []string, v string) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == v {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,95 @@
+package prompt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDraft(t *testing.T) {
+	t.Parallel()
+
+	// run runs a prompt with history on input, after restoring draft when it
+	// is not nil, and returns the result and the prompt.
+	run := func(t *testing.T, history []string, draft *Draft, input string) (string, *Prompt) {
+		t.Helper()
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, input)
+		p.SetHistory(history)
+		if draft != nil {
+			p.RestoreDraft(*draft)
+		}
+		result, _ := p.Run()
+		return result, p
+	}
+
+	t.Run("resumes the input and the cursor", func(t *testing.T) {
+		t.Parallel()
+
+		_, p := run(t, nil, nil, "hello world\x1bb") // Input ends in the middle of typing
+		draft := p.SaveDraft()
+		require.Equal(t, Draft{Text: "hello world", Cursor: 6, HistoryIndex: -1}, draft)
+
+		result, _ := run(t, nil, &draft, "big \r")
+
+		assert.Equal(t, "hello big world", result)
+	})
+
+	t.Run("survives JSON encoding", func(t *testing.T) {
+		t.Parallel()
+
+		history := []string{"a", "b", "c"}
+		_, p := run(t, history, nil, "typed\x1b[A\x1b[A")
+		data, err := json.Marshal(p.SaveDraft())
+		require.NoError(t, err)
+		var draft Draft
+		require.NoError(t, json.Unmarshal(data, &draft))
+		assert.Equal(t, Draft{Text: "b", Cursor: 1, HistoryIndex: 1, HistoryEntry: "b", TypedLine: "typed"}, draft)
+
+		result, _ := run(t, history, &draft, "\x1b[B\x1b[B\r")
+
+		assert.Equal(t, "typed", result, "down goes through the newer entry back to the typed line")
+	})
+
+	t.Run("finds the browsed entry in a changed history", func(t *testing.T) {
+		t.Parallel()
+
+		draft := Draft{Text: "b", Cursor: 1, HistoryIndex: 1, HistoryEntry: "b", TypedLine: "typed"}
+
+		result, _ := run(t, []string{"b", "x", "b", "c", "d"}, &draft, "\x1b[B\r")
+
+		assert.Equal(t, "c", result)
+	})
+
+	t.Run("continues as a new line when the entry is gone", func(t *testing.T) {
+		t.Parallel()
+
+		draft := Draft{Text: "b", Cursor: 1, HistoryIndex: 1, HistoryEntry: "b", TypedLine: "typed"}
+
+		result, _ := run(t, []string{"z"}, &draft, "\x1b[A\r")
+
+		assert.Equal(t, "z", result)
+	})
+
+	t.Run("keeps the cursor within the input", func(t *testing.T) {
+		t.Parallel()
+
+		result, _ := run(t, nil, &Draft{Text: "ab", Cursor: 10, HistoryIndex: -1}, "!\r")
+
+		assert.Equal(t, "ab!", result)
+	})
+
+	t.Run("is used by one run only", func(t *testing.T) {
+		t.Parallel()
+
+		result, p := run(t, nil, &Draft{Text: "ab", Cursor: 2, HistoryIndex: -1}, "\r")
+		require.Equal(t, "ab", result)
+		p.terminal = newMockTerminal("x\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "x", result)
+	})
+}
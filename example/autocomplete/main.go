@@ -121,6 +121,7 @@ func main() {
 	p, err := prompt.New("app> ",
 		prompt.WithCompleter(scrollTestCompleter),
 		prompt.WithColorScheme(prompt.ThemeNightOwl),
+		prompt.WithTrimSpace(true),
 	)
 	if err != nil {
 		log.Fatal(err)
@@ -138,7 +139,6 @@ func main() {
 			continue
 		}
 
-		result = strings.TrimSpace(result)
 		if result == "" {
 			continue
 		}
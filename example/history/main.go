@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/nao1215/prompt"
 )
@@ -29,6 +28,7 @@ func main() {
 	// - Relative path: "./app_history" (converted to absolute)
 	p, err := prompt.New("history> ",
 		prompt.WithFileHistory(prompt.GetDefaultHistoryFile(), 1000), // XDG compliant: ~/.config/prompt/history
+		prompt.WithTrimSpace(true),                                   // Results and history entries come without surrounding spaces
 	)
 	if err != nil {
 		log.Fatal(err)
@@ -47,8 +47,6 @@ func main() {
 			continue
 		}
 
-		// Trim whitespace
-		result = strings.TrimSpace(result)
 		if result == "" {
 			continue
 		}
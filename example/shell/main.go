@@ -33,6 +33,7 @@ func main() {
 	p, err := prompt.New("shell> ",
 		prompt.WithCompleter(completer),
 		prompt.WithMemoryHistory(1000),
+		prompt.WithTrimSpace(true),
 	)
 	if err != nil {
 		log.Fatalf("failed to create prompt: %v", err)
@@ -53,8 +54,6 @@ func main() {
 			break
 		}
 
-		result = strings.TrimSpace(result)
-
 		// Handle exit commands
 		if result == "exit" || result == "quit" {
 			fmt.Println("Goodbye!")
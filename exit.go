@@ -0,0 +1,53 @@
+package prompt
+
+import "fmt"
+
+// WithExitChecker sets a function that decides whether the input ends the
+// session, like go-prompt's option of the same name, so a REPL stops on words
+// such as "exit" or "quit" without checking every result of Run itself.
+//
+// The checker is called with breakline true when Enter submits the input: if
+// it returns true, the input is handled as usual, recorded in history and
+// returned together with ErrExit. It is also called with breakline false after
+// every edit: if it returns true there, the line ends at once, without Enter
+// and without a history entry, and Run returns the input with ErrExit.
+// The checker receives the input after any InputSanitizer, and is consulted
+// before the Validator, so an exit command is never rejected.
+//
+// ErrExit wraps ErrEOF, so a loop that stops on errors.Is(err, prompt.ErrEOF)
+// stops on an exit command too.
+//
+// Example:
+//
+//	prompt.New("> ", prompt.WithExitChecker(func(input string, breakline bool) bool {
+//		return breakline && (input == "exit" || input == "quit")
+//	}))
+func WithExitChecker(checker func(input string, breakline bool) bool) Option {
+	return func(c *Config) {
+		c.ExitChecker = checker
+	}
+}
+
+// submission returns the input submitted with Enter and whether the
+// ExitChecker ends the session with it.
+func (p *Prompt) submission() (string, bool) {
+	input := p.submittedInput()
+	return input, p.config.ExitChecker != nil && p.config.ExitChecker(input, true)
+}
+
+// exitsWhileTyping reports whether the ExitChecker ends the session with the
+// input as it is after an edit.
+func (p *Prompt) exitsWhileTyping() bool {
+	return p.config.ExitChecker != nil && p.config.ExitChecker(p.submittedInput(), false)
+}
+
+// exitLine draws the final input and leaves the prompt line for an exit
+// requested while typing, returning what Run returns.
+func (p *Prompt) exitLine() (string, error) {
+	if err := p.render(); err != nil {
+		return "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+	p.clearGhost()
+	fmt.Fprint(p.output, "\r\n")
+	return p.submittedInput(), ErrExit
+}
@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitChecker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Enter on an exit command returns ErrExit with the input", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Prefix: "> ", ExitChecker: func(input string, breakline bool) bool {
+			return breakline && input == "exit"
+		}}
+		p := newForTestingWithConfig(t, config, "exit\r")
+
+		result, err := p.Run()
+
+		require.ErrorIs(t, err, ErrExit)
+		assert.ErrorIs(t, err, ErrEOF, "loops stopping on ErrEOF stop too")
+		assert.Equal(t, "exit", result)
+		assert.Equal(t, []string{"exit"}, p.GetHistory())
+	})
+
+	t.Run("other input is submitted as usual", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+		config := Config{Prefix: "> ", ExitChecker: func(input string, breakline bool) bool {
+			calls = append(calls, fmt.Sprintf("%s/%t", input, breakline))
+			return false
+		}}
+		p := newForTestingWithConfig(t, config, "ls\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ls", result)
+		assert.Equal(t, []string{"l/false", "ls/false", "ls/true"}, calls)
+	})
+
+	t.Run("the line ends while typing without Enter", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Prefix: "> ", ExitChecker: func(input string, breakline bool) bool {
+			return !breakline && strings.HasSuffix(input, ";;")
+		}}
+		p := newForTestingWithConfig(t, config, "done;;more\r")
+
+		result, err := p.Run()
+
+		require.True(t, errors.Is(err, ErrExit))
+		assert.Equal(t, "done;;", result)
+		assert.Empty(t, p.GetHistory())
+	})
+
+	t.Run("an exit command skips the validator", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{
+			Prefix:      "> ",
+			Validator:   func(string) error { return errors.New("statements end with ;") },
+			ExitChecker: func(input string, breakline bool) bool { return breakline && input == "quit" },
+		}
+		p := newForTestingWithConfig(t, config, "quit\r")
+
+		result, err := p.Run()
+
+		require.ErrorIs(t, err, ErrExit)
+		assert.Equal(t, "quit", result)
+	})
+}
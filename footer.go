@@ -0,0 +1,43 @@
+package prompt
+
+import "fmt"
+
+// WithFooter shows the rows returned by footer below the input and the menu,
+// dimmed, for information such as key hints. It is called on every redraw on
+// the event loop and must return quickly. Each row is cut to fit the terminal
+// width, so the footer never wraps. The footer is erased when the line is
+// submitted or cancelled, and is not shown during the reverse history search.
+//
+// Example:
+//
+//	p, err := prompt.New("$ ", prompt.WithFooter(func() []string {
+//		return []string{"Tab: complete • Ctrl+R: search"}
+//	}))
+func WithFooter(footer func() []string) Option {
+	return func(c *Config) {
+		c.Footer = footer
+	}
+}
+
+// footer returns the rows of the Footer config for the next frame (nil when
+// none is set).
+func (p *Prompt) footer() []string {
+	if p.config.Footer == nil {
+		return nil
+	}
+	return p.config.Footer()
+}
+
+// renderFooter draws the footer rows below the cursor row, each on a new row
+// and cut to fit on it, and returns how many it drew. The cursor is left on
+// the last of them.
+func (r *renderer) renderFooter() (int, error) {
+	color := r.ansi(r.colorScheme.Suggestion.Description)
+	for _, row := range r.footer {
+		text := truncateWidth(sanitizeText(row), r.width()-1)
+		if _, err := fmt.Fprint(r.output, "\r\n\x1b[K", color, text, Reset()); err != nil {
+			return 0, err
+		}
+	}
+	return len(r.footer), nil
+}
@@ -0,0 +1,100 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFooter(t *testing.T) {
+	t.Parallel()
+
+	newFooter := func(output *bytes.Buffer, rows ...string) *renderer {
+		terminal := newMockTerminal("")
+		terminal.terminalSize = [2]int{20, 24}
+		r := newRenderer(output, ThemeDefault, terminal)
+		r.footer = rows
+		return r
+	}
+	suggestions := []Suggestion{{Text: "alpha"}, {Text: "beta"}}
+
+	t.Run("is drawn below the input", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		r := newFooter(&output, "Tab: complete", "Ctrl+R: search")
+
+		require.NoError(t, r.render("$ ", "ls", 2))
+
+		assert.Equal(t, 3, r.lastLines)
+		assert.Equal(t, 0, r.cursorRow, "the cursor goes back to the input row")
+		assert.Contains(t, output.String(), "Tab: complete")
+		assert.Contains(t, output.String(), "Ctrl+R: search"+Reset()+"\x1b[2A\x1b[5G")
+		assert.Equal(t, []int{4, 13, 14}, r.frameRows)
+	})
+
+	t.Run("is drawn below the menu", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		r := newFooter(&output, "hint")
+
+		require.NoError(t, r.renderWithSuggestionsOffset("$ ", "a", 1, suggestions, 0, 0))
+
+		assert.Equal(t, 4, r.lastLines)
+		assert.Equal(t, 3, r.cursorRow)
+		assert.True(t, strings.HasSuffix(output.String(), "hint"+Reset()))
+		assert.Less(t, strings.Index(output.String(), "beta"), strings.Index(output.String(), "hint"))
+	})
+
+	t.Run("is cut to the terminal width", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		r := newFooter(&output, strings.Repeat("x", 30))
+
+		require.NoError(t, r.render("$ ", "", 0))
+
+		assert.Contains(t, output.String(), strings.Repeat("x", 19)+Reset())
+		assert.NotContains(t, output.String(), strings.Repeat("x", 20))
+		assert.Equal(t, 2, r.lastLines)
+	})
+
+	t.Run("stays at the bottom of the reserved rows", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		r := newFooter(&output, "hint")
+		r.reservedRows = 5
+
+		require.NoError(t, r.render("$ ", "ls", 2))
+
+		assert.Equal(t, 5, r.lastLines)
+		assert.Contains(t, output.String(), strings.Repeat("\r\n\x1b[K", 3)+r.ansi(r.colorScheme.Suggestion.Description)+"hint")
+		assert.Equal(t, 2, r.menuRows(), "the menu leaves room for the footer")
+	})
+
+	t.Run("is erased when the line is submitted", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		calls := 0
+		p, err := New("$ ", WithTerminal(newMockTerminal("ls\r")), WithOutput(&output), WithFooter(func() []string {
+			calls++
+			return []string{"Tab: complete"}
+		}))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ls", result)
+		assert.Positive(t, calls)
+		last := output.String()[strings.LastIndex(output.String(), "\r\x1b[0J"):]
+		assert.NotContains(t, last, "Tab: complete", "the last frame has no footer")
+	})
+}
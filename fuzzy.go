@@ -0,0 +1,112 @@
+package prompt
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ScoreOption configures FuzzyScore.
+type ScoreOption func(*scoreConfig)
+
+// scoreConfig holds the settings of a FuzzyScore call.
+type scoreConfig struct {
+	caseSensitive bool
+}
+
+// WithScoreCaseSensitive makes FuzzyScore tell upper and lower case apart.
+// By default case is ignored, as in NewFuzzyCompleter and Palette.
+func WithScoreCaseSensitive() ScoreOption {
+	return func(c *scoreConfig) {
+		c.caseSensitive = true
+	}
+}
+
+// FuzzyScore rates how well candidate matches query, using the ranking of
+// NewFuzzyCompleter and Palette, so an application can order results of its
+// own, such as a search subcommand, the same way. Higher scores are better
+// matches: an exact match scores 1000, a prefix match 800 and more, a
+// substring match 500 and more, and a match of the query's characters in
+// order 10 for each matched character. A score of 0 means no match. An empty
+// query matches everything with a score of 1.
+//
+// positions holds the rune indexes of the candidate characters the query
+// matched, in increasing order, for highlighting them.
+//
+// Example:
+//
+//	score, positions := prompt.FuzzyScore("gst", "git status")
+//	// score == 30, positions == []int{0, 4, 5}
+func FuzzyScore(query, candidate string, opts ...ScoreOption) (score int, positions []int) {
+	config := scoreConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if query == "" {
+		return 1, nil
+	}
+	if candidate == "" {
+		return 0, nil
+	}
+
+	q, c := []rune(query), []rune(candidate)
+	if !config.caseSensitive {
+		// Lower-case rune by rune so indexes still line up with candidate
+		q, c = toLowerRunes(q), toLowerRunes(c)
+	}
+	searchQuery, searchCandidate := string(q), string(c)
+
+	// Exact match gets highest score
+	if searchQuery == searchCandidate {
+		return 1000, runeRange(0, len(c))
+	}
+
+	// Prefix match gets high score
+	if strings.HasPrefix(searchCandidate, searchQuery) {
+		return 800 + len(q)*10, runeRange(0, len(q))
+	}
+
+	// Contains match gets medium score
+	if i := strings.Index(searchCandidate, searchQuery); i >= 0 {
+		start := len([]rune(searchCandidate[:i]))
+		return 500 + len(q)*5, runeRange(start, start+len(q))
+	}
+
+	// Character-by-character fuzzy matching
+	candidateIdx := 0
+	for _, queryChar := range q {
+		for candidateIdx < len(c) {
+			if c[candidateIdx] == queryChar {
+				score += 10
+				positions = append(positions, candidateIdx)
+				candidateIdx++
+				break
+			}
+			candidateIdx++
+		}
+		if candidateIdx >= len(c) {
+			break
+		}
+	}
+	if score == 0 {
+		return 0, nil
+	}
+	return score, positions
+}
+
+// toLowerRunes returns runes mapped to lower case, one rune for one rune.
+func toLowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// runeRange returns the indexes from start up to but not including end.
+func runeRange(start, end int) []int {
+	positions := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		positions = append(positions, i)
+	}
+	return positions
+}
@@ -6,13 +6,14 @@ require (
 	github.com/mattn/go-colorable v0.1.14
 	github.com/mattn/go-tty v0.0.7
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/sys v0.36.0
 	golang.org/x/term v0.35.0
+	golang.org/x/text v0.29.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.36.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
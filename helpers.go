@@ -3,69 +3,28 @@ package prompt
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
-)
-
-// calculateFuzzyScore calculates a fuzzy matching score between input and candidate.
-// Returns 0 if no match, higher scores for better matches.
-// Supports case-insensitive matching when ignoreCase is true.
-func calculateFuzzyScore(input, candidate string, ignoreCase bool) int {
-	if input == "" {
-		return 1
-	}
-	if candidate == "" {
-		return 0
-	}
-
-	// Normalize case if requested
-	searchInput := input
-	searchCandidate := candidate
-	if ignoreCase {
-		searchInput = strings.ToLower(input)
-		searchCandidate = strings.ToLower(candidate)
-	}
 
-	// Exact match gets highest score
-	if searchInput == searchCandidate {
-		return 1000
-	}
-
-	// Prefix match gets high score
-	if strings.HasPrefix(searchCandidate, searchInput) {
-		return 800 + len(searchInput)*10
-	}
+	"golang.org/x/text/collate"
+)
 
-	// Contains match gets medium score
-	if strings.Contains(searchCandidate, searchInput) {
-		return 500 + len(searchInput)*5
+// NewFileCompleter creates a completer that provides file and directory suggestions
+func NewFileCompleter(options ...FileCompleterOption) func(Document) []Suggestion {
+	config := fileCompleterConfig{}
+	for _, option := range options {
+		option(&config)
 	}
-
-	// Character-by-character fuzzy matching
-	score := 0
-	candidateIdx := 0
-
-	for _, inputChar := range searchInput {
-		for candidateIdx < len(searchCandidate) {
-			if rune(searchCandidate[candidateIdx]) == inputChar {
-				score += 10
-				candidateIdx++
-				break
+	return func(d Document) []Suggestion {
+		text := config.escape.Unescape(d.TextBeforeCursor())
+		suggestions := completeFilePath(text)
+		for i, s := range suggestions {
+			if escaped := config.escape.Escape(s.Text); escaped != s.Text {
+				suggestions[i].Text = escaped
+				suggestions[i].DisplayText = s.Text
 			}
-			candidateIdx++
-		}
-		if candidateIdx >= len(searchCandidate) {
-			break
 		}
-	}
-
-	return score
-}
-
-// NewFileCompleter creates a completer that provides file and directory suggestions
-func NewFileCompleter() func(Document) []Suggestion {
-	return func(d Document) []Suggestion {
-		text := d.TextBeforeCursor()
-		return completeFilePath(text)
+		return suggestions
 	}
 }
 
@@ -132,3 +91,21 @@ func completeFilePath(path string) []Suggestion {
 
 	return suggestions
 }
+
+// sortSuggestions orders suggestions by the configured collation. Without a
+// collation the completer order is returned unchanged. The sort is stable so
+// suggestions that collate equally keep their relative order.
+func (p *Prompt) sortSuggestions(suggestions []Suggestion) []Suggestion {
+	if p.config.Collation == nil || len(suggestions) < 2 {
+		return suggestions
+	}
+	// A collator keeps internal buffers and is not safe for concurrent use, so
+	// create one per sort rather than sharing it across prompts
+	c := collate.New(*p.config.Collation)
+	// Sort a copy so a completer returning a shared slice is not reordered
+	sorted := slices.Clone(suggestions)
+	slices.SortStableFunc(sorted, func(a, b Suggestion) int {
+		return c.CompareString(a.Text, b.Text)
+	})
+	return sorted
+}
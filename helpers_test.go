@@ -3,6 +3,9 @@ package prompt
 import (
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
 )
 
 // Test only public APIs - internal functions are tested indirectly through public APIs
@@ -79,3 +82,55 @@ func TestNewHistorySearcher(t *testing.T) {
 		t.Errorf("Expected 2 results for 'git', got %d", len(results))
 	}
 }
+
+func TestWithCollation(t *testing.T) {
+	t.Parallel()
+
+	texts := func(suggestions []Suggestion) []string {
+		result := make([]string, len(suggestions))
+		for i, s := range suggestions {
+			result[i] = s.Text
+		}
+		return result
+	}
+
+	t.Run("German collation sorts umlauts next to their base letter", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{}
+		WithCollation(language.German)(&config)
+		p := newForTestingWithConfig(t, config, "")
+		input := []Suggestion{{Text: "Zucker"}, {Text: "Äpfel"}, {Text: "apfel"}, {Text: "Birne"}}
+
+		sorted := p.sortSuggestions(input)
+
+		assert.Equal(t, []string{"apfel", "Äpfel", "Birne", "Zucker"}, texts(sorted))
+		assert.Equal(t, "Zucker", input[0].Text, "the completer's slice should not be reordered")
+	})
+
+	t.Run("without a collation the completer order is kept", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{}, "")
+		input := []Suggestion{{Text: "b"}, {Text: "a"}}
+
+		assert.Equal(t, []string{"b", "a"}, texts(p.sortSuggestions(input)))
+	})
+
+	t.Run("menu shows collated order after Tab", func(t *testing.T) {
+		t.Parallel()
+
+		completer := func(Document) []Suggestion {
+			return []Suggestion{{Text: "ñu"}, {Text: "oso"}, {Text: "nube"}}
+		}
+		config := Config{Completer: completer}
+		WithCollation(language.Spanish)(&config)
+		// Tab opens the menu, Tab accepts the first (collated) entry
+		p := newForTestingWithConfig(t, config, "\t\t\r")
+
+		result, err := p.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "nube", result)
+	})
+}
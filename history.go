@@ -2,21 +2,53 @@ package prompt
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+)
+
+// ErrInsecureHistoryFile is returned by LoadHistory when the history file can be
+// read by other users and InsecurePermissions is HistoryPermissionError.
+var ErrInsecureHistoryFile = errors.New("history file is readable by group or others")
+
+// HistoryPermissionPolicy decides how LoadHistory reacts to a history file that
+// is readable by group or others. History often contains secrets typed on the
+// command line, so such a file is worth pointing out. The check is skipped on
+// Windows, where Unix permission bits do not apply.
+type HistoryPermissionPolicy int
+
+const (
+	// HistoryPermissionWarn prints a warning to stderr and loads the file.
+	HistoryPermissionWarn HistoryPermissionPolicy = iota
+	// HistoryPermissionError refuses to load the file and returns ErrInsecureHistoryFile.
+	HistoryPermissionError
+	// HistoryPermissionIgnore loads the file without checking its permissions.
+	HistoryPermissionIgnore
+)
+
+const (
+	defaultHistoryFileMode os.FileMode = 0600
+	defaultHistoryDirMode  os.FileMode = 0700
 )
 
 // DefaultHistoryConfig returns a default history configuration following XDG Base Directory Specification
 func DefaultHistoryConfig() *HistoryConfig {
 	return &HistoryConfig{
 		Enabled:     true,
-		MaxEntries:  1000,        // Default memory limit
+		MaxEntries:  DefaultHistoryEntries,
 		File:        "",          // Empty by default, can be set to use XDG config directory
 		MaxFileSize: 1024 * 1024, // 1MB
 		MaxBackups:  3,
+		FileMode:    defaultHistoryFileMode,
+		DirMode:     defaultHistoryDirMode,
 	}
 }
 
@@ -34,10 +66,76 @@ func GetDefaultHistoryFile() string {
 	return filepath.Join(configDir, "prompt", "history")
 }
 
+// GetDefaultHistoryFileFor returns the conventional history file path of the
+// application appName, so that different programs built on this library keep
+// separate histories:
+//
+//   - $XDG_STATE_HOME/appName/history when XDG_STATE_HOME is set (not on Windows)
+//   - %APPDATA%\appName\history on Windows
+//   - ~/Library/Application Support/appName/history on macOS
+//   - ~/.local/state/appName/history elsewhere
+//
+// appName is used as a directory name; an empty one means "prompt". It returns
+// an empty string when the home directory cannot be determined.
+func GetDefaultHistoryFileFor(appName string) string {
+	home, _ := os.UserHomeDir()
+	return defaultHistoryFileFor(runtime.GOOS, appName, os.Getenv, home)
+}
+
+// defaultHistoryFileFor implements GetDefaultHistoryFileFor for the operating
+// system goos, reading environment variables with getenv.
+func defaultHistoryFileFor(goos, appName string, getenv func(string) string, home string) string {
+	if appName == "" {
+		appName = "prompt"
+	}
+	var dir string
+	switch {
+	case goos == windowsOS:
+		dir = getenv("APPDATA")
+		if dir == "" && home != "" {
+			dir = filepath.Join(home, "AppData", "Roaming")
+		}
+	case getenv("XDG_STATE_HOME") != "":
+		dir = getenv("XDG_STATE_HOME")
+	case home == "":
+		return ""
+	case goos == "darwin":
+		dir = filepath.Join(home, "Library", "Application Support")
+	default:
+		dir = filepath.Join(home, ".local", "state")
+	}
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, appName, "history")
+}
+
+// HistoryEntry is a history entry with the context it was added in.
+type HistoryEntry struct {
+	Text    string // The entry as submitted
+	Dir     string // Working directory when it was added (empty unless HistoryConfig.RecordDir is set)
+	Context string // Custom context from HistoryConfig.Context (empty when there is none)
+	Pinned  bool   // Pinned with HistoryManager.PinEntry
+}
+
+// historyContext is the context recorded with one history entry.
+type historyContext struct {
+	dir     string
+	context string
+	pinned  bool
+	added   int64 // When the entry was added, in Unix seconds (0 when unknown)
+}
+
 // HistoryManager manages command history persistence and rotation
 type HistoryManager struct {
-	config  *HistoryConfig
-	history []string
+	config   *HistoryConfig
+	history  []string
+	contexts []historyContext // Context of each entry in history, index for index
+	unsaved  int              // Entries at the end of history added since the file was last read or written
+	replaced bool             // SetHistory or ClearHistory replaced the history, so the next save overwrites the file
+	exclude  historyExclusion // Entries that are never recorded
+	pins     map[string]bool  // Pin state set since the file was last read or written, by normalized entry
+	now      func() time.Time // Clock stamping new entries
 }
 
 // NewHistoryManager creates a new history manager with the given configuration
@@ -51,6 +149,12 @@ func NewHistoryManager(config *HistoryConfig) *HistoryManager {
 	if config.MaxBackups < 0 {
 		config.MaxBackups = 3
 	}
+	if config.FileMode == 0 {
+		config.FileMode = defaultHistoryFileMode
+	}
+	if config.DirMode == 0 {
+		config.DirMode = defaultHistoryDirMode
+	}
 
 	// Expand and convert file path to absolute path if specified
 	if config.File != "" {
@@ -59,9 +163,15 @@ func NewHistoryManager(config *HistoryConfig) *HistoryManager {
 		}
 	}
 
+	// Invalid patterns are reported by New; here they are skipped
+	exclude, _ := newHistoryExclusion(config)
+
 	return &HistoryManager{
-		config:  config,
-		history: make([]string, 0),
+		config:   config,
+		history:  make([]string, 0),
+		contexts: make([]historyContext, 0),
+		exclude:  exclude,
+		now:      time.Now,
 	}
 }
 
@@ -85,67 +195,490 @@ func (hm *HistoryManager) LoadHistory() error {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	if err := hm.checkPermissions(file); err != nil {
+		return err
+	}
+
+	entries, contexts, err := hm.readHistory(file)
+	if err != nil {
+		return err
+	}
+	hm.history = append(hm.history, entries...)
+	hm.contexts = append(hm.contexts, contexts...)
+	return nil
+}
+
+// readHistory reads the entries of a history file and their contexts.
+func (hm *HistoryManager) readHistory(r io.Reader) ([]string, []historyContext, error) {
+	var entries []string
+	var contexts []historyContext
+	scanner := bufio.NewScanner(r)
+	// Escaped multi-line entries can be much longer than the default 64KB token
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), int(hm.config.MaxFileSize)+bufio.MaxScanTokenSize)
+	escaped := false
+	withContext := false
+	first := true
+	var pending historyContext // Context line read for the next entry
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		raw := scanner.Text()
+		if first {
+			first = false
+			switch raw {
+			case historyFileHeader:
+				escaped = true
+				continue
+			case historyContextFileHeader:
+				escaped = true
+				withContext = true
+				continue
+			}
+		}
+		if withContext && strings.HasPrefix(raw, historyContextPrefix) {
+			pending = parseHistoryContext(raw[len(historyContextPrefix):])
+			continue
+		}
+		if escaped {
+			// Escaped entries are stored verbatim, so only skip blank lines
+			if raw != "" {
+				entries = append(entries, unescapeHistoryEntry(raw))
+				contexts = append(contexts, pending)
+				pending = historyContext{}
+			}
+			continue
+		}
+		line := strings.TrimSpace(raw)
 		if line != "" {
-			hm.history = append(hm.history, line)
+			entries = append(entries, line)
+			contexts = append(contexts, historyContext{})
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("failed to read history file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	return entries, contexts, nil
+}
+
+// checkPermissions applies the InsecurePermissions policy to an open history file.
+func (hm *HistoryManager) checkPermissions(file *os.File) error {
+	if hm.config.InsecurePermissions == HistoryPermissionIgnore || runtime.GOOS == windowsOS {
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat history file: %w", err)
+	}
+	if info.Mode().Perm()&0044 == 0 {
+		return nil
 	}
 
+	if hm.config.InsecurePermissions == HistoryPermissionError {
+		return fmt.Errorf("%w: %s has mode %04o", ErrInsecureHistoryFile, hm.config.File, info.Mode().Perm())
+	}
+	fmt.Fprintf(os.Stderr, "Warning: history file %s is readable by group or others (mode %04o); consider chmod 600\n",
+		hm.config.File, info.Mode().Perm())
 	return nil
 }
 
-// SaveHistory saves the current history to the configured file
+// SaveHistory saves the current history to the configured file.
+//
+// The history is written to a temporary file in the same directory, synced to
+// disk and then renamed over the history file, so a crash or a full disk in the
+// middle of a save leaves the previous file intact instead of a truncated one.
+// The file keeps its permissions. When the file has grown past MaxFileSize,
+// backups are rotated only after the new contents are safely on disk.
+//
+// Several processes can share one history file. A save holds an advisory lock
+// on a ".lock" file next to it, re-reads the file and adds the entries added
+// since it was last read or written after the ones other processes saved in
+// the meantime, so no process overwrites the others' commands. Every entry is
+// stored with the time it was added, and the merge orders the entries by it,
+// so the commands of processes running side by side are interleaved as they
+// were typed. Entries without a time, as in files written by older versions,
+// keep their place in front of the new ones. The merged entries become the
+// in-memory history. After SetHistory or ClearHistory the file is overwritten
+// instead.
 func (hm *HistoryManager) SaveHistory() error {
 	if !hm.config.Enabled || hm.config.File == "" {
 		return nil
 	}
 
+	// Create directory if it doesn't exist
+	dir := filepath.Dir(hm.config.File)
+	if hm.config.DisableDirCreation {
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("history directory is not available: %w", err)
+		}
+	} else if dir != "." {
+		if err := os.MkdirAll(dir, hm.config.DirMode); err != nil {
+			return fmt.Errorf("failed to create history directory: %w", err)
+		}
+	}
+
+	unlock, err := hm.lockHistoryFile()
+	if err != nil {
+		return fmt.Errorf("failed to lock history file: %w", err)
+	}
+	defer unlock()
+	merged, err := hm.mergeHistoryFile()
+	if err != nil {
+		return err
+	}
+
 	// Check if rotation is needed
-	if err := hm.rotateIfNeeded(); err != nil {
+	rotate, err := hm.needsRotation()
+	if err != nil {
 		return fmt.Errorf("failed to rotate history file: %w", err)
 	}
+	rotate = rotate && hm.config.MaxBackups > 0 // Without backups the file is simply replaced
 
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(hm.config.File)
-	if dir != "." {
-		if err := os.MkdirAll(dir, 0750); err != nil {
-			return fmt.Errorf("failed to create history directory: %w", err)
+	entries, contexts := merged.history, merged.contexts
+	if rotate {
+		entries, contexts = merged.recent()
+	}
+
+	tmp, err := writeHistoryTemp(hm.config.File, entries, contexts, hm.config.FileMode)
+	if err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	if rotate {
+		if err := hm.shiftBackups(); err != nil {
+			_ = os.Remove(tmp)
+			return fmt.Errorf("failed to rotate history file: %w", err)
 		}
 	}
+	if err := os.Rename(tmp, hm.config.File); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to replace history file: %w", err)
+	}
+
+	// Keep in-memory history in line with the rotated file
+	hm.history, hm.contexts = entries, contexts
+	hm.unsaved, hm.replaced, hm.pins = 0, false, nil
+	return nil
+}
 
-	file, err := os.Create(hm.config.File)
+// appendLatest writes the entry just added to the history file right away, for
+// HistoryConfig.AppendOnSubmit. The entry is appended to the file when its
+// format can hold it; otherwise, or when the file is due for rotation or other
+// entries are still unsaved, the whole history is saved.
+func (hm *HistoryManager) appendLatest() error {
+	if !hm.config.Enabled || hm.config.File == "" || hm.unsaved == 0 {
+		return nil
+	}
+	rotate, err := hm.needsRotation()
+	if err != nil || rotate || hm.unsaved > 1 || hm.replaced || len(hm.pins) > 0 {
+		return hm.SaveHistory()
+	}
+	if _, err := os.Stat(hm.config.File); err != nil {
+		return hm.SaveHistory() // Create the file and its directory
+	}
+
+	appended, err := hm.appendToFile(hm.history[len(hm.history)-1], hm.contexts[len(hm.contexts)-1])
+	if err != nil {
+		return fmt.Errorf("failed to append to history file: %w", err)
+	}
+	if !appended {
+		return hm.SaveHistory()
+	}
+	hm.unsaved = 0
+	return nil
+}
+
+// appendToFile appends entry to the history file under the save lock. It
+// reports false, writing nothing, when the file's format cannot hold the entry,
+// such as a multi-line entry in a plain file.
+func (hm *HistoryManager) appendToFile(entry string, c historyContext) (bool, error) {
+	unlock, err := hm.lockHistoryFile()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(hm.config.File, os.O_RDWR|os.O_APPEND, 0)
 	if err != nil {
-		return fmt.Errorf("failed to create history file: %w", err)
+		return false, err
 	}
 	defer file.Close()
+	header, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
 
-	for _, entry := range hm.history {
-		if _, err := fmt.Fprintln(file, entry); err != nil {
-			return fmt.Errorf("failed to write history entry: %w", err)
+	escape := strings.TrimSuffix(header, "\n") == historyFileHeader
+	withContext := strings.TrimSuffix(header, "\n") == historyContextFileHeader
+	switch {
+	case withContext:
+		escape = true
+	case c != (historyContext{}):
+		return false, nil // Only a context file stores contexts
+	case !escape && strings.ContainsAny(entry, "\n\r"):
+		return false, nil // A plain file has no escapes for line breaks
+	case !escape && isHistoryFileHeader(entry):
+		return false, nil // Nor for an entry that reads as a header
+	}
+	if _, err := file.WriteString(formatHistoryEntry(entry, c, escape, withContext)); err != nil {
+		return false, err
+	}
+	return true, file.Sync()
+}
+
+// lockHistoryFile takes the advisory lock that serializes saves of the history
+// file between processes and returns the function releasing it.
+func (hm *HistoryManager) lockHistoryFile() (func(), error) {
+	file, err := os.OpenFile(hm.config.File+".lock", os.O_CREATE|os.O_RDWR, hm.config.FileMode)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unlockFile(file)
+		_ = file.Close()
+	}, nil
+}
+
+// mergeHistoryFile re-reads the history file and returns the history to save:
+// the file's entries and the ones added since it was last read or written,
+// ordered by the time they were added, so that entries other processes saved
+// in the meantime are kept. A file entry without a time stays in front of the
+// new entries after it. The new entries are added with the same duplicate
+// checks as AddEntry, and entries pinned or unpinned in the meantime keep
+// their new state. hm itself is left unchanged until the result is written.
+func (hm *HistoryManager) mergeHistoryFile() (*HistoryManager, error) {
+	merged := &HistoryManager{config: hm.config, history: hm.history, contexts: hm.contexts, pins: hm.pins}
+	if hm.replaced {
+		return merged, nil
+	}
+	file, err := os.Open(hm.config.File)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return merged, nil
 		}
+		return nil, fmt.Errorf("failed to open history file: %w", err)
 	}
+	defer file.Close()
 
-	return nil
+	entries, contexts, err := hm.readHistory(file)
+	if err != nil {
+		return nil, err
+	}
+	merged.history, merged.contexts = nil, nil
+	start := len(hm.history) - hm.unsaved
+	next := 0 // First file entry not merged yet
+	for i, entry := range hm.history[start:] {
+		c := hm.contexts[start+i]
+		for ; next < len(entries) && (c.added == 0 || contexts[next].added <= c.added); next++ {
+			merged.history = append(merged.history, entries[next])
+			merged.contexts = append(merged.contexts, contexts[next])
+		}
+		if !isDuplicateHistoryEntry(hm.config, merged.history, entry) {
+			merged.push(entry, c)
+		}
+	}
+	merged.history = append(merged.history, entries[next:]...)
+	merged.contexts = append(merged.contexts, contexts[next:]...)
+	if hm.config.EraseDups {
+		merged.eraseOlderDuplicates() // Entries appended by AppendOnSubmit or other processes
+	}
+	merged.applyPins()
+	return merged, nil
+}
+
+// eraseOlderDuplicates removes every entry that is repeated later in the
+// history, compared after HistoryConfig.Normalize. The copy kept is pinned
+// when any of them was.
+func (hm *HistoryManager) eraseOlderDuplicates() {
+	last := make(map[string]int, len(hm.history))
+	pinned := make(map[string]bool)
+	for i, h := range hm.history {
+		normalized := normalizeHistoryEntry(hm.config, h)
+		last[normalized] = i
+		if hm.contexts[i].pinned {
+			pinned[normalized] = true
+		}
+	}
+	firstUnsaved := len(hm.history) - hm.unsaved
+	kept := 0
+	for i, h := range hm.history {
+		normalized := normalizeHistoryEntry(hm.config, h)
+		if last[normalized] != i {
+			if i >= firstUnsaved {
+				hm.unsaved--
+			}
+			continue
+		}
+		hm.history[kept], hm.contexts[kept] = h, hm.contexts[i]
+		hm.contexts[kept].pinned = pinned[normalized]
+		kept++
+	}
+	hm.history = hm.history[:kept]
+	hm.contexts = hm.contexts[:kept]
+}
+
+// writeHistoryTemp writes entries, with their contexts, to a new temporary
+// file next to path and syncs it to disk. The temporary file gets the
+// permissions of path, or mode if path does not exist yet. It returns the name
+// of the temporary file.
+func writeHistoryTemp(path string, entries []string, contexts []historyContext, mode os.FileMode) (string, error) {
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	tmp := file.Name()
+
+	err = writeHistoryEntries(file, entries, contexts)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, mode)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
 }
 
-// AddEntry adds a new entry to the history
+// AddEntry adds a new entry to the history. Blank entries and entries that
+// repeat the previous one, compared after HistoryConfig.Normalize, are skipped,
+// like bash's ignoredups. With HistoryConfig.IgnoreSpace, entries starting with
+// a space are skipped too, as are entries matching HistoryConfig.ExcludePatterns
+// or HistoryConfig.ExcludeFunc, and with HistoryConfig.EraseDups older entries
+// equal to the new one are removed. The working directory and custom context
+// are recorded with the entry when HistoryConfig.RecordDir and
+// HistoryConfig.Context ask for them.
 func (hm *HistoryManager) AddEntry(entry string) {
 	if !hm.config.Enabled || entry == "" {
 		return
 	}
-
-	// Avoid duplicate consecutive entries
-	if len(hm.history) > 0 && hm.history[len(hm.history)-1] == entry {
+	if isDuplicateHistoryEntry(hm.config, hm.history, entry) || hm.exclude.excludes(entry) {
 		return
 	}
 
+	hm.push(entry, hm.currentContext())
+	hm.unsaved++
+}
+
+// push appends entry with its context, first removing older copies of it when
+// HistoryConfig.EraseDups is set. The entry is pinned when an older copy is.
+func (hm *HistoryManager) push(entry string, context historyContext) {
+	context.pinned = context.pinned || hm.isPinned(entry)
+	if hm.config.EraseDups {
+		hm.eraseDuplicates(entry)
+	}
 	hm.history = append(hm.history, entry)
+	hm.contexts = append(hm.contexts, context)
+}
+
+// eraseDuplicates removes the entries equal to entry, compared after
+// HistoryConfig.Normalize, together with their context.
+func (hm *HistoryManager) eraseDuplicates(entry string) {
+	normalized := normalizeHistoryEntry(hm.config, entry)
+	firstUnsaved := len(hm.history) - hm.unsaved
+	kept := 0
+	for i, h := range hm.history {
+		if normalizeHistoryEntry(hm.config, h) == normalized {
+			if i >= firstUnsaved {
+				hm.unsaved--
+			}
+			continue
+		}
+		hm.history[kept], hm.contexts[kept] = h, hm.contexts[i]
+		kept++
+	}
+	hm.history = hm.history[:kept]
+	hm.contexts = hm.contexts[:kept]
+}
+
+// currentContext returns the context to record with an entry added now.
+func (hm *HistoryManager) currentContext() historyContext {
+	c := historyContext{added: hm.now().Unix()}
+	if hm.config.RecordDir {
+		if dir, err := os.Getwd(); err == nil {
+			c.dir = dir
+		}
+	}
+	if hm.config.Context != nil {
+		c.context = hm.config.Context()
+	}
+	return c
+}
+
+// normalizeHistoryEntry returns entry in the form used to compare it with the
+// previous entry: the result of config.Normalize, or entry without surrounding
+// whitespace by default.
+func normalizeHistoryEntry(config *HistoryConfig, entry string) string {
+	if config != nil && config.Normalize != nil {
+		return config.Normalize(entry)
+	}
+	return strings.TrimSpace(entry)
+}
+
+// isDuplicateHistoryEntry reports whether entry should not be added after
+// history: it normalizes to nothing, such as a line of spaces, or to the same
+// form as the last entry, such as "ls " after "ls", or it starts with a space
+// while config.IgnoreSpace is set.
+func isDuplicateHistoryEntry(config *HistoryConfig, history []string, entry string) bool {
+	if config != nil && config.IgnoreSpace && strings.HasPrefix(entry, " ") {
+		return true
+	}
+	normalized := normalizeHistoryEntry(config, entry)
+	if normalized == "" {
+		return true
+	}
+	return len(history) > 0 && normalizeHistoryEntry(config, history[len(history)-1]) == normalized
+}
+
+// historyExclusion decides which entries are never recorded in history, from
+// HistoryConfig.ExcludePatterns and HistoryConfig.ExcludeFunc.
+type historyExclusion struct {
+	patterns []*regexp.Regexp
+	match    func(string) bool
+}
+
+// newHistoryExclusion compiles the exclusion rules of config. The returned
+// exclusion holds the valid patterns even when an invalid one is reported.
+func newHistoryExclusion(config *HistoryConfig) (historyExclusion, error) {
+	var e historyExclusion
+	if config == nil {
+		return e, nil
+	}
+	e.match = config.ExcludeFunc
+	var errs []error
+	for _, pattern := range config.ExcludePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid history exclude pattern %q: %w", pattern, err))
+			continue
+		}
+		e.patterns = append(e.patterns, re)
+	}
+	return e, errors.Join(errs...)
+}
+
+// excludes reports whether entry must not be recorded.
+func (e historyExclusion) excludes(entry string) bool {
+	if e.match != nil && e.match(entry) {
+		return true
+	}
+	for _, re := range e.patterns {
+		if re.MatchString(entry) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetHistory returns a copy of the current history
@@ -156,12 +689,54 @@ func (hm *HistoryManager) GetHistory() []string {
 	return append([]string{}, hm.history...)
 }
 
-// SetHistory replaces the current history
+// GetEntries returns a copy of the current history with the context each
+// entry was added in.
+func (hm *HistoryManager) GetEntries() []HistoryEntry {
+	if !hm.config.Enabled {
+		return []HistoryEntry{}
+	}
+	entries := make([]HistoryEntry, len(hm.history))
+	for i, text := range hm.history {
+		c := hm.contexts[i]
+		entries[i] = HistoryEntry{Text: text, Dir: c.dir, Context: c.context, Pinned: c.pinned}
+	}
+	return entries
+}
+
+// SetHistory replaces the current history. The new entries have no context
+// and are not pinned.
 func (hm *HistoryManager) SetHistory(history []string) {
 	if !hm.config.Enabled {
 		return
 	}
 	hm.history = append([]string{}, history...)
+	hm.contexts = make([]historyContext, len(history))
+	hm.unsaved, hm.replaced, hm.pins = 0, true, nil
+}
+
+// trim drops the oldest entries beyond maxEntries, keeping the context of the
+// remaining ones. Pinned entries are never dropped, so more than maxEntries
+// are left when that many are pinned.
+func (hm *HistoryManager) trim(maxEntries int) {
+	drop := len(hm.history) - maxEntries
+	if drop <= 0 {
+		return
+	}
+	firstUnsaved := len(hm.history) - hm.unsaved
+	history := make([]string, 0, maxEntries)
+	contexts := make([]historyContext, 0, maxEntries)
+	for i, h := range hm.history {
+		if drop > 0 && !hm.contexts[i].pinned {
+			drop--
+			if i >= firstUnsaved {
+				hm.unsaved--
+			}
+			continue
+		}
+		history = append(history, h)
+		contexts = append(contexts, hm.contexts[i])
+	}
+	hm.history, hm.contexts = history, contexts
 }
 
 // ClearHistory clears the current history
@@ -170,37 +745,31 @@ func (hm *HistoryManager) ClearHistory() {
 		return
 	}
 	hm.history = []string{}
+	hm.contexts = []historyContext{}
+	hm.unsaved, hm.replaced, hm.pins = 0, true, nil
 }
 
-// rotateIfNeeded checks if the history file needs rotation and performs it
-func (hm *HistoryManager) rotateIfNeeded() error {
+// needsRotation reports whether the history file has reached MaxFileSize.
+func (hm *HistoryManager) needsRotation() (bool, error) {
 	if hm.config.File == "" {
-		return nil
+		return false, nil
 	}
 
 	info, err := os.Stat(hm.config.File)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil // File doesn't exist, no rotation needed
+			return false, nil // File doesn't exist, no rotation needed
 		}
-		return err
-	}
-
-	if info.Size() < hm.config.MaxFileSize {
-		return nil // File is small enough, no rotation needed
+		return false, err
 	}
 
-	// Perform rotation
-	return hm.rotateHistoryFile()
+	return info.Size() >= hm.config.MaxFileSize, nil
 }
 
-// rotateHistoryFile performs the actual file rotation
-func (hm *HistoryManager) rotateHistoryFile() error {
-	if hm.config.MaxBackups <= 0 {
-		// If no backups allowed, just truncate the file
-		return os.Truncate(hm.config.File, 0)
-	}
-
+// shiftBackups moves every backup one number up, dropping the oldest, and
+// makes the current history file .1 as well. The file itself stays in place
+// until the new one is renamed over it, so a crash in between loses nothing.
+func (hm *HistoryManager) shiftBackups() error {
 	// Remove the oldest backup if it exists
 	oldestBackup := hm.config.File + "." + strconv.Itoa(hm.config.MaxBackups)
 	if _, err := os.Stat(oldestBackup); err == nil {
@@ -221,49 +790,214 @@ func (hm *HistoryManager) rotateHistoryFile() error {
 		}
 	}
 
-	// Move current file to .1
+	// Link the current file as .1, or copy it where hard links are not supported
 	backup := hm.config.File + ".1"
-	if err := os.Rename(hm.config.File, backup); err != nil {
-		return fmt.Errorf("failed to create backup: %w", err)
+	if err := os.Link(hm.config.File, backup); err != nil {
+		if err := copyFile(hm.config.File, backup, hm.config.FileMode); err != nil {
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
 	}
+	return nil
+}
 
-	// Keep only the most recent entries in the new file
-	if err := hm.createRotatedFile(); err != nil {
-		return fmt.Errorf("failed to create rotated file: %w", err)
+// copyFile copies src to a new file dst created with mode.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return err
 	}
-
-	return nil
+	defer in.Close()
+	out, err := os.OpenFile(filepath.Clean(dst), os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	return out.Close()
 }
 
-// createRotatedFile creates a new history file with the most recent entries
-func (hm *HistoryManager) createRotatedFile() error {
+// recentStart returns the index of the first entry kept in a freshly rotated
+// file: the most recent half of the history is kept, or all of it when there
+// are fewer than 100 entries to keep.
+func (hm *HistoryManager) recentStart() int {
 	// Keep only half of the history entries to avoid immediate rotation
 	keepEntries := len(hm.history) / 2
 	if keepEntries < 100 {
 		keepEntries = len(hm.history) // Keep all if less than 100 entries
 	}
 
-	startIndex := len(hm.history) - keepEntries
-	if startIndex < 0 {
-		startIndex = 0
-	}
+	return max(0, len(hm.history)-keepEntries)
+}
 
-	file, err := os.Create(hm.config.File)
-	if err != nil {
-		return err
+// recent returns the entries kept in a freshly rotated file with their
+// contexts: the ones from recentStart on, preceded by the older pinned ones.
+func (hm *HistoryManager) recent() ([]string, []historyContext) {
+	start := hm.recentStart()
+	var entries []string
+	var contexts []historyContext
+	for i, c := range hm.contexts[:start] {
+		if c.pinned {
+			entries = append(entries, hm.history[i])
+			contexts = append(contexts, c)
+		}
 	}
-	defer file.Close()
+	return append(entries, hm.history[start:]...), append(contexts, hm.contexts[start:]...)
+}
+
+// historyFileHeader marks a history file whose entries are escaped so that
+// multi-line commands survive the newline-delimited format. Files without it are
+// read as plain lines, which keeps files written by older versions loadable.
+const historyFileHeader = "#prompt-history-v2"
+
+// historyContextFileHeader marks an escaped history file in which an entry
+// can be preceded by a line starting with historyContextPrefix that holds the
+// working directory and custom context it was added in, and whether it is
+// pinned, and when it was added. Entries starting with "#" are escaped so they
+// are not taken for such a line.
+const historyContextFileHeader = "#prompt-history-v3"
 
-	for i := startIndex; i < len(hm.history); i++ {
-		if _, err := fmt.Fprintln(file, hm.history[i]); err != nil {
+// historyContextPrefix starts a context line in a historyContextFileHeader
+// file. The escaped directory and context follow, separated by a tab, and
+// then, each after another tab, the Unix time the entry was added when it is
+// known and historyPinnedField when the entry is pinned.
+const historyContextPrefix = "#@"
+
+// historyPinnedField ends the context line of a pinned entry.
+const historyPinnedField = "pinned"
+
+// writeHistoryEntries writes one entry per line. Plain lines are used when no
+// entry needs escaping, so simple histories stay readable by older versions and
+// other tools; otherwise, or when an entry equals a header, the file starts
+// with historyFileHeader and every entry is escaped. When any entry has a
+// context, such as the time it was added, historyContextFileHeader is used and
+// each context is written on a line before its entry.
+func writeHistoryEntries(w io.Writer, entries []string, contexts []historyContext) error {
+	withContext := slices.ContainsFunc(contexts, func(c historyContext) bool {
+		return c != historyContext{}
+	})
+	escape := withContext || slices.ContainsFunc(entries, func(entry string) bool {
+		return strings.ContainsAny(entry, "\n\r") || isHistoryFileHeader(entry)
+	})
+	switch {
+	case withContext:
+		if _, err := fmt.Fprintln(w, historyContextFileHeader); err != nil {
+			return err
+		}
+	case escape:
+		if _, err := fmt.Fprintln(w, historyFileHeader); err != nil {
+			return err
+		}
+	}
+	for i, entry := range entries {
+		if _, err := io.WriteString(w, formatHistoryEntry(entry, contexts[i], escape, withContext)); err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
-	// Update in-memory history to match the rotated file
-	hm.history = hm.history[startIndex:]
+// formatHistoryEntry returns the lines that store entry in a history file,
+// escaped when escape is set and preceded by its context line when withContext
+// is set and it has one.
+func formatHistoryEntry(entry string, c historyContext, escape, withContext bool) string {
+	var lines string
+	if withContext && c != (historyContext{}) {
+		lines = historyContextPrefix + escapeHistoryField(c.dir) + "\t" + escapeHistoryField(c.context)
+		if c.added != 0 {
+			lines += "\t" + strconv.FormatInt(c.added, 10)
+		}
+		if c.pinned {
+			lines += "\t" + historyPinnedField
+		}
+		lines += "\n"
+	}
+	if escape {
+		entry = escapeHistoryEntry(entry)
+	}
+	if (withContext && strings.HasPrefix(entry, "#")) || (escape && isHistoryFileHeader(entry)) {
+		entry = `\` + entry
+	}
+	return lines + entry + "\n"
+}
 
-	return nil
+// isHistoryFileHeader reports whether entry reads as one of the headers on the
+// first line of a history file. Such an entry needs the escaped format, where
+// it is written with a leading backslash.
+func isHistoryFileHeader(entry string) bool {
+	return entry == historyFileHeader || entry == historyContextFileHeader
+}
+
+// escapeHistoryField escapes a context field like an entry, and also its tabs,
+// which separate the fields of a context line.
+func escapeHistoryField(field string) string {
+	return strings.ReplaceAll(escapeHistoryEntry(field), "\t", `\t`)
+}
+
+// parseHistoryContext reads the fields of a context line after its prefix.
+func parseHistoryContext(line string) historyContext {
+	dir, rest, _ := strings.Cut(line, "\t")
+	context, fields, _ := strings.Cut(rest, "\t")
+	c := historyContext{dir: unescapeHistoryEntry(dir), context: unescapeHistoryEntry(context)}
+	for _, field := range strings.Split(fields, "\t") {
+		if field == historyPinnedField {
+			c.pinned = true
+		} else if added, err := strconv.ParseInt(field, 10, 64); err == nil {
+			c.added = added
+		}
+	}
+	return c
+}
+
+// escapeHistoryEntry encodes backslashes, newlines and carriage returns so an
+// entry fits on a single line.
+func escapeHistoryEntry(entry string) string {
+	var b strings.Builder
+	for _, r := range entry {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// unescapeHistoryEntry reverses escapeHistoryEntry. Unknown escapes and a
+// trailing lone backslash are kept as written.
+func unescapeHistoryEntry(line string) string {
+	var b strings.Builder
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' || i == len(runes)-1 {
+			b.WriteRune(runes[i])
+			continue
+		}
+		i++
+		switch runes[i] {
+		case '\\':
+			b.WriteRune('\\')
+		case 'n':
+			b.WriteRune('\n')
+		case 'r':
+			b.WriteRune('\r')
+		case 't':
+			b.WriteRune('\t')
+		case '#':
+			b.WriteRune('#')
+		default:
+			b.WriteRune('\\')
+			b.WriteRune(runes[i])
+		}
+	}
+	return b.String()
 }
 
 // expandHistoryPath expands and validates the history file path
@@ -0,0 +1,73 @@
+package prompt
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ExportAnonymized writes the history to w in the history file format, with
+// every entry and context passed through redactor first, so users can attach
+// it to a bug report about completion or search ranking without sharing the
+// paths, hosts and secrets they typed. The entries keep their order and
+// duplicates, which is what ranking depends on. A nil redactor uses
+// DefaultHistoryRedactor. Nothing is written when history is disabled.
+//
+// Example:
+//
+//	hm := prompt.NewHistoryManager(config)
+//	if err := hm.LoadHistory(); err != nil {
+//		return err
+//	}
+//	return hm.ExportAnonymized(os.Stdout, nil)
+func (hm *HistoryManager) ExportAnonymized(w io.Writer, redactor func(string) string) error {
+	if !hm.config.Enabled {
+		return nil
+	}
+	if redactor == nil {
+		redactor = DefaultHistoryRedactor()
+	}
+	entries := make([]string, len(hm.history))
+	contexts := make([]historyContext, len(hm.history))
+	for i, entry := range hm.history {
+		entries[i] = redactor(entry)
+		c := hm.contexts[i]
+		contexts[i].pinned = c.pinned
+		if c.dir != "" {
+			contexts[i].dir = redactor(c.dir)
+		}
+		if c.context != "" {
+			contexts[i].context = redactor(c.context)
+		}
+	}
+	return writeHistoryEntries(w, entries, contexts)
+}
+
+// DefaultHistoryRedactor returns the redactor ExportAnonymized uses when none
+// is given. It replaces the values of secret-looking options and assignments
+// (token, secret, password, api key) with <secret>, email addresses with
+// <email>, IPv4 addresses with <ip>, absolute and home-relative paths with
+// <path>, and long strings of letters and digits, such as keys, with <token>.
+// Command names, subcommands and flags are kept. It is a starting point:
+// wrap it to mask the names your application knows to be private.
+func DefaultHistoryRedactor() func(string) string {
+	secret := regexp.MustCompile(`(?i)((?:^|[\s-])[\w-]*(?:token|secret|password|passwd|api[_-]?key)[\w-]*(?:[=:]|\s+))("[^"]*"|'[^']*'|\S+)`)
+	email := regexp.MustCompile(`[\w.+-]+@[\w-]+(?:\.[\w-]+)+`)
+	ip := regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d+)?\b`)
+	path := regexp.MustCompile(`(^|[\s="'])(?:~|[A-Za-z]:\\|/)[^\s"']*`)
+	token := regexp.MustCompile(`\b[\w-]{32,}\b`)
+
+	return func(s string) string {
+		s = secret.ReplaceAllString(s, "$1<secret>")
+		s = email.ReplaceAllString(s, "<email>")
+		s = ip.ReplaceAllString(s, "<ip>")
+		s = path.ReplaceAllString(s, "$1<path>")
+		return token.ReplaceAllStringFunc(s, func(word string) string {
+			// Long words of letters only are left alone, such as --some-very-long-option-names
+			if !strings.ContainsAny(word, "0123456789") {
+				return word
+			}
+			return "<token>"
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package prompt
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultHistoryRedactor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "commands without private data are kept", input: "git status --short", want: "git status --short"},
+		{name: "absolute path", input: "cat /home/alice/notes.txt", want: "cat <path>"},
+		{name: "home-relative path", input: "cd ~/src/secret-project", want: "cd <path>"},
+		{name: "path in an option value", input: "make --file=/srv/app/Makefile", want: "make --file=<path>"},
+		{name: "windows path", input: `type C:\Users\alice\todo.txt`, want: "type <path>"},
+		{name: "ip address with port", input: "ssh 192.168.1.20 -p 22 && curl 10.0.0.1:8080", want: "ssh <ip> -p 22 && curl <ip>"},
+		{name: "email address", input: "git config user.email alice@example.com", want: "git config user.email <email>"},
+		{name: "secret assignment", input: "export GITHUB_TOKEN=ghp_abc", want: "export GITHUB_TOKEN=<secret>"},
+		{name: "secret option", input: "login --password 'hunter 2' --user bob", want: "login --password <secret> --user bob"},
+		{name: "long key", input: "curl -H X-Key:a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7", want: "curl -H X-Key:<token>"},
+		{name: "long option names are kept", input: "app --some-really-long-option-name-here", want: "app --some-really-long-option-name-here"},
+		{name: "url paths are not taken for file paths", input: "open https://example.com/docs", want: "open https://example.com/docs"},
+	}
+	redact := DefaultHistoryRedactor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, redact(tt.input))
+		})
+	}
+}
+
+func TestHistoryManagerExportAnonymized(t *testing.T) {
+	t.Parallel()
+
+	t.Run("entries are written through the redactor in order", func(t *testing.T) {
+		t.Parallel()
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true})
+		hm.SetHistory([]string{"ls", "cat /etc/hosts", "ls", "ping 8.8.8.8"})
+		var out bytes.Buffer
+
+		err := hm.ExportAnonymized(&out, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "ls\ncat <path>\nls\nping <ip>\n", out.String())
+		assert.Equal(t, []string{"ls", "cat /etc/hosts", "ls", "ping 8.8.8.8"}, hm.GetHistory(), "the history itself is unchanged")
+	})
+
+	t.Run("a custom redactor also sees the context", func(t *testing.T) {
+		t.Parallel()
+
+		host := "db-prod-1"
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, Context: func() string { return host }})
+		hm.AddEntry("select 1")
+		var out bytes.Buffer
+
+		err := hm.ExportAnonymized(&out, func(s string) string {
+			return strings.ReplaceAll(s, "prod", "xxxx")
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, historyContextFileHeader+"\n"+historyContextPrefix+"\tdb-xxxx-1\nselect 1\n", out.String())
+	})
+
+	t.Run("the export loads back as a history file", func(t *testing.T) {
+		t.Parallel()
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true})
+		hm.SetHistory([]string{"for f in ~/logs/*\ndo\n  gzip $f\ndone"})
+		var out bytes.Buffer
+		require.NoError(t, hm.ExportAnonymized(&out, nil))
+
+		loaded := NewHistoryManager(&HistoryConfig{Enabled: true, File: filepath.Join(t.TempDir(), "history")})
+		entries, _, err := loaded.readHistory(&out)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"for f in <path>\ndo\n  gzip $f\ndone"}, entries)
+	})
+
+	t.Run("nothing is written when history is disabled", func(t *testing.T) {
+		t.Parallel()
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: false})
+		var out bytes.Buffer
+
+		require.NoError(t, hm.ExportAnonymized(&out, nil))
+		assert.Empty(t, out.String())
+	})
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package prompt
+
+import "os"
+
+// lockFile does nothing on platforms without file locking; saves from
+// concurrent processes are still merged, but may race.
+func lockFile(*os.File) error {
+	return nil
+}
+
+// unlockFile does nothing on platforms without file locking.
+func unlockFile(*os.File) error {
+	return nil
+}
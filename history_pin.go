@@ -0,0 +1,118 @@
+package prompt
+
+// PinEntry pins every copy of entry in the history, compared after
+// HistoryConfig.Normalize. Pinned entries are listed first in the reverse
+// history search and are never dropped when the history is trimmed to
+// HistoryConfig.MaxEntries or a full history file is rotated. The pin is
+// stored in the history file, and copies of the entry added later are pinned
+// too. It reports whether the history has the entry.
+func (hm *HistoryManager) PinEntry(entry string) bool {
+	return hm.setPinned(entry, true)
+}
+
+// UnpinEntry unpins every copy of entry in the history, compared after
+// HistoryConfig.Normalize. It reports whether the history has the entry.
+func (hm *HistoryManager) UnpinEntry(entry string) bool {
+	return hm.setPinned(entry, false)
+}
+
+// setPinned pins or unpins the copies of entry and remembers the change for
+// the next save, which merges it into the entries read from the file.
+func (hm *HistoryManager) setPinned(entry string, pinned bool) bool {
+	if !hm.config.Enabled {
+		return false
+	}
+	normalized := normalizeHistoryEntry(hm.config, entry)
+	found := false
+	for i, h := range hm.history {
+		if normalizeHistoryEntry(hm.config, h) == normalized {
+			hm.contexts[i].pinned = pinned
+			found = true
+		}
+	}
+	if found {
+		if hm.pins == nil {
+			hm.pins = make(map[string]bool)
+		}
+		hm.pins[normalized] = pinned
+	}
+	return found
+}
+
+// isPinned reports whether a copy of entry is pinned.
+func (hm *HistoryManager) isPinned(entry string) bool {
+	normalized := normalizeHistoryEntry(hm.config, entry)
+	for i, h := range hm.history {
+		if hm.contexts[i].pinned && normalizeHistoryEntry(hm.config, h) == normalized {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPins sets the pin state changed since the file was last read or
+// written on the entries it applies to.
+func (hm *HistoryManager) applyPins() {
+	if len(hm.pins) == 0 {
+		return
+	}
+	for i, h := range hm.history {
+		if pinned, ok := hm.pins[normalizeHistoryEntry(hm.config, h)]; ok {
+			hm.contexts[i].pinned = pinned
+		}
+	}
+}
+
+// PinHistory pins every copy of entry in the history, as
+// HistoryManager.PinEntry does. It reports whether the history has the entry;
+// with history disabled nothing can be pinned.
+func (p *Prompt) PinHistory(entry string) bool {
+	return p.historyManager != nil && p.historyManager.PinEntry(entry)
+}
+
+// UnpinHistory unpins every copy of entry in the history. It reports whether
+// the history has the entry.
+func (p *Prompt) UnpinHistory(entry string) bool {
+	return p.historyManager != nil && p.historyManager.UnpinEntry(entry)
+}
+
+// pinnedHistory returns the pinned history entries.
+func (p *Prompt) pinnedHistory() map[string]bool {
+	pinned := make(map[string]bool)
+	for _, entry := range p.historyEntries() {
+		if entry.Pinned {
+			pinned[entry.Text] = true
+		}
+	}
+	return pinned
+}
+
+// pinnedFirst returns results with the pinned entries moved to the front,
+// keeping the order within the pinned and the other entries.
+func (p *Prompt) pinnedFirst(results []string) []string {
+	pinned := p.pinnedHistory()
+	if len(pinned) == 0 {
+		return results
+	}
+	sorted := make([]string, 0, len(results))
+	for _, result := range results {
+		if pinned[result] {
+			sorted = append(sorted, result)
+		}
+	}
+	for _, result := range results {
+		if !pinned[result] {
+			sorted = append(sorted, result)
+		}
+	}
+	return sorted
+}
+
+// togglePinned pins entry when it is not pinned and unpins it otherwise.
+func (p *Prompt) togglePinned(entry string) {
+	if p.pinnedHistory()[entry] {
+		p.UnpinHistory(entry)
+	} else {
+		p.PinHistory(entry)
+	}
+}
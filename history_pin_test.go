@@ -0,0 +1,180 @@
+package prompt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryPin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pins survive save and load", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: historyFile})
+		hm.now = func() time.Time { return time.Unix(1700000000, 0) }
+		hm.AddEntry("make deploy")
+		hm.AddEntry("ls")
+		require.True(t, hm.PinEntry("make deploy "), "entries are compared after normalization")
+		assert.False(t, hm.PinEntry("missing"))
+		require.NoError(t, hm.SaveHistory())
+
+		content, err := os.ReadFile(filepath.Clean(historyFile)) // #nosec G304 - test file path is controlled
+		require.NoError(t, err)
+		assert.Equal(t, historyContextFileHeader+"\n#@\t\t1700000000\tpinned\nmake deploy\n#@\t\t1700000000\nls\n", string(content))
+
+		loaded := NewHistoryManager(&HistoryConfig{Enabled: true, File: historyFile})
+		require.NoError(t, loaded.LoadHistory())
+		assert.Equal(t, []HistoryEntry{{Text: "make deploy", Pinned: true}, {Text: "ls"}}, loaded.GetEntries())
+	})
+
+	t.Run("a pin changed after loading is kept by the merging save", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		first := NewHistoryManager(&HistoryConfig{Enabled: true, File: historyFile})
+		first.AddEntry("make deploy")
+		require.True(t, first.PinEntry("make deploy"))
+		require.NoError(t, first.SaveHistory())
+
+		second := NewHistoryManager(&HistoryConfig{Enabled: true, File: historyFile})
+		require.NoError(t, second.LoadHistory())
+		require.True(t, second.UnpinEntry("make deploy"))
+		second.AddEntry("ls")
+		require.NoError(t, second.SaveHistory())
+
+		loaded := NewHistoryManager(&HistoryConfig{Enabled: true, File: historyFile})
+		require.NoError(t, loaded.LoadHistory())
+		assert.Equal(t, []HistoryEntry{{Text: "make deploy"}, {Text: "ls"}}, loaded.GetEntries())
+	})
+
+	t.Run("trimming never drops pinned entries", func(t *testing.T) {
+		t.Parallel()
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true})
+		for _, entry := range []string{"a", "b", "c", "d"} {
+			hm.AddEntry(entry)
+		}
+		require.True(t, hm.PinEntry("a"))
+
+		hm.trim(2)
+		assert.Equal(t, []string{"a", "d"}, hm.GetHistory())
+
+		require.True(t, hm.PinEntry("d"))
+		hm.trim(1)
+		assert.Equal(t, []string{"a", "d"}, hm.GetHistory(), "more entries than the limit are pinned")
+	})
+
+	t.Run("the prompt keeps pinned entries beyond MaxEntries", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := New("$ ", WithTerminal(newMockTerminal("")), WithOutput(&bytes.Buffer{}), WithMemoryHistory(2))
+		require.NoError(t, err)
+		defer p.Close()
+		p.AddHistory("ssh prod")
+		require.True(t, p.PinHistory("ssh prod"))
+		for _, entry := range []string{"a", "b", "c"} {
+			p.AddHistory(entry)
+		}
+
+		assert.Equal(t, []string{"ssh prod", "c"}, p.GetHistory())
+	})
+
+	t.Run("rotation keeps older pinned entries", func(t *testing.T) {
+		t.Parallel()
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true})
+		for i := range 300 {
+			hm.AddEntry("echo " + strconv.Itoa(i))
+		}
+		pinned := hm.GetHistory()[0]
+		require.True(t, hm.PinEntry(pinned))
+
+		entries, contexts := hm.recent()
+
+		assert.Len(t, entries, 151)
+		assert.Equal(t, pinned, entries[0])
+		assert.True(t, contexts[0].pinned)
+	})
+
+	t.Run("new copies of a pinned entry are pinned", func(t *testing.T) {
+		t.Parallel()
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, EraseDups: true})
+		hm.AddEntry("make")
+		require.True(t, hm.PinEntry("make"))
+		hm.AddEntry("ls")
+		hm.AddEntry("make")
+
+		assert.Equal(t, []HistoryEntry{{Text: "ls"}, {Text: "make", Pinned: true}}, hm.GetEntries())
+	})
+
+	t.Run("pinned entries come first in the search", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := New("$ ", WithTerminal(newMockTerminal("")), WithOutput(&bytes.Buffer{}), WithMemoryHistory(100))
+		require.NoError(t, err)
+		defer p.Close()
+		for _, entry := range []string{"git push", "git status", "git log"} {
+			p.AddHistory(entry)
+		}
+		require.True(t, p.PinHistory("git push"))
+
+		search := p.historySearcher()
+
+		assert.Equal(t, []string{"git push", "git log", "git status"}, search(""))
+		assert.Equal(t, []string{"git push", "git log", "git status"}, search("git"))
+	})
+
+	t.Run("ctrl+t pins the selected match", func(t *testing.T) {
+		t.Parallel()
+
+		// Select the second match, pin it, move on and come back to it
+		p, err := New("$ ", WithTerminal(newMockTerminal("\x12\x14\x12\x13\r")), WithOutput(&bytes.Buffer{}), WithMemoryHistory(100))
+		require.NoError(t, err)
+		defer p.Close()
+		for _, entry := range []string{"git push", "git status", "git log"} {
+			p.AddHistory(entry)
+		}
+
+		result, err := p.searchHistory()
+
+		require.NoError(t, err)
+		assert.Equal(t, "git status", result, "the pinned match moved to the top and stayed selected")
+		assert.Equal(t, []HistoryEntry{{Text: "git push"}, {Text: "git status", Pinned: true}, {Text: "git log"}}, p.historyManager.GetEntries())
+	})
+
+	t.Run("pinned matches are marked with a star", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		p, err := New("$ ", WithTerminal(newMockTerminal("")), WithOutput(&out), WithMemoryHistory(100))
+		require.NoError(t, err)
+		defer p.Close()
+		p.AddHistory("git push")
+		p.AddHistory("git log")
+		require.True(t, p.PinHistory("git push"))
+
+		require.NoError(t, p.renderHistorySearch("", []string{"git push", "git log"}, 1))
+
+		assert.Contains(t, out.String(), "★ git push")
+		assert.NotContains(t, out.String(), "★ git log")
+	})
+
+	t.Run("disabled history pins nothing", func(t *testing.T) {
+		t.Parallel()
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: false})
+		hm.AddEntry("ls")
+
+		assert.False(t, hm.PinEntry("ls"))
+	})
+}
@@ -7,10 +7,15 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDefaultHistoryConfig(t *testing.T) {
@@ -526,7 +531,7 @@ func TestHistoryRotationEdgeCases(t *testing.T) {
 	})
 }
 
-func TestCreateRotatedFile(t *testing.T) {
+func TestHistoryRotationReload(t *testing.T) {
 	if os.Getenv("GITHUB_ACTIONS") == "" {
 		t.Skip("Skipping slow test in local development")
 	}
@@ -543,7 +548,7 @@ func TestCreateRotatedFile(t *testing.T) {
 
 	hm := NewHistoryManager(config)
 
-	// Add more than 100 entries to ensure trimming occurs (createRotatedFile keeps all if < 100)
+	// Add more than 100 entries to ensure trimming occurs (rotation keeps all if < 100)
 	for i := range 150 {
 		hm.AddEntry(fmt.Sprintf("initial_entry_%d_%s", i, strings.Repeat("X", 10)))
 	}
@@ -710,6 +715,48 @@ func TestHistorySearch(t *testing.T) {
 		}
 	})
 
+	t.Run("SearchWithCtrlG", func(t *testing.T) {
+		// Simulate typing "git" and aborting with Ctrl+G
+		mockInput := "git\x07"
+		p := createPromptWithHistory(history, mockInput)
+
+		result, err := p.searchHistory()
+		if err != nil {
+			t.Fatalf("searchHistory failed: %v", err)
+		}
+
+		// Should return empty string when aborted
+		if result != "" {
+			t.Errorf("Expected empty result when aborted, got %q", result)
+		}
+	})
+
+	t.Run("SearchCyclesWithCtrlRAndCtrlS", func(t *testing.T) {
+		// "git" matches the git commands newest first and, fuzzily, the grep
+		// command last; Ctrl+S from the first match wraps around to the last
+		// and Ctrl+R back to the first
+		tests := []struct {
+			input string
+			want  string
+		}{
+			{input: "git\r", want: "git push origin main"},
+			{input: "git\x12\r", want: "git commit -m 'test'"},
+			{input: "git\x13\r", want: "grep pattern file.txt"},
+			{input: "git\x13\x12\r", want: "git push origin main"},
+		}
+		for _, tt := range tests {
+			p := createPromptWithHistory(history, tt.input)
+
+			result, err := p.searchHistory()
+			if err != nil {
+				t.Fatalf("searchHistory failed: %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("input %q: expected %q, got %q", tt.input, tt.want, result)
+			}
+		}
+	})
+
 	t.Run("SearchWithBackspace", func(t *testing.T) {
 		// Simulate typing "gitx", backspace, then Enter
 		mockInput := "gitx\x7f\r"
@@ -813,6 +860,7 @@ func TestHistorySearch(t *testing.T) {
 func TestRenderHistorySearch(t *testing.T) {
 	// Create a buffer to capture output
 	var output bytes.Buffer
+	terminal := newMockTerminal("")
 	p := &Prompt{
 		config: Config{
 			Prefix: "test> ",
@@ -822,7 +870,8 @@ func TestRenderHistorySearch(t *testing.T) {
 			},
 		},
 		output:   &output,
-		terminal: newMockTerminal(""),
+		terminal: terminal,
+		renderer: newRenderer(&output, ThemeDefault, terminal),
 		keyMap:   NewDefaultKeyMap(),
 		history:  []string{"cmd1", "cmd2", "cmd3"},
 	}
@@ -832,7 +881,7 @@ func TestRenderHistorySearch(t *testing.T) {
 		results := []string{"git status", "git commit", "git push"}
 		p.renderHistorySearch("git", results, 0)
 
-		outputStr := output.String()
+		outputStr := removeANSICodes(output.String())
 		if !strings.Contains(outputStr, "git") {
 			t.Error("Expected output to contain search query 'git'")
 		}
@@ -846,12 +895,49 @@ func TestRenderHistorySearch(t *testing.T) {
 		results := []string{"git status", "git commit", "git push"}
 		p.renderHistorySearch("git", results, 1)
 
-		outputStr := output.String()
+		outputStr := removeANSICodes(output.String())
 		if !strings.Contains(outputStr, "git commit") {
 			t.Error("Expected output to contain selected result 'git commit'")
 		}
 	})
 
+	t.Run("RenderHighlightsMatches", func(t *testing.T) {
+		output.Reset()
+		p.renderHistorySearch("gs", []string{"git status"}, 0)
+
+		// The match is selected: its matched characters are underlined on the
+		// reverse video of the row
+		matchColor := ThemeDefault.Suggestion.Match.onRow(ThemeDefault.Selected)
+		matchColor.Underline = true
+		match := matchColor.ToANSI()
+		outputStr := output.String()
+		if !strings.Contains(outputStr, match+"g"+Reset()) || !strings.Contains(outputStr, match+"s"+Reset()) {
+			t.Errorf("Expected the matched characters to be highlighted, got %q", outputStr)
+		}
+		if !strings.Contains(outputStr, "[1/1]") {
+			t.Errorf("Expected a match counter, got %q", outputStr)
+		}
+	})
+
+	t.Run("RenderHighlightsQueryInSearchLine", func(t *testing.T) {
+		output.Reset()
+		p.renderHistorySearch("gs", []string{"git status"}, 0)
+
+		// The query and the characters of the selected match it matched are
+		// drawn in the match color on the search line
+		match := ThemeDefault.Suggestion.Match.onRow(ThemeDefault.Input).ToANSI()
+		searchLine := strings.Split(output.String(), "\r\n")[1]
+		if !strings.Contains(searchLine, match+"gs"+Reset()) {
+			t.Errorf("Expected the query in the match color, got %q", searchLine)
+		}
+		if !strings.Contains(searchLine, match+"g"+Reset()) || !strings.Contains(searchLine, match+"s"+Reset()) {
+			t.Errorf("Expected the matched characters of the selected match highlighted, got %q", searchLine)
+		}
+		if !strings.Contains(removeANSICodes(searchLine), "gs -> git status") {
+			t.Errorf("Expected the search line text, got %q", removeANSICodes(searchLine))
+		}
+	})
+
 	t.Run("RenderEmptyResults", func(t *testing.T) {
 		output.Reset()
 		results := []string{}
@@ -897,6 +983,7 @@ func TestHistorySearchErrorCases(t *testing.T) {
 			},
 			output:   &bytes.Buffer{},
 			terminal: &errorMockTerminal{},
+			renderer: newRenderer(&bytes.Buffer{}, ThemeDefault, &errorMockTerminal{}),
 			keyMap:   NewDefaultKeyMap(),
 			history:  []string{"test"},
 		}
@@ -911,6 +998,8 @@ func TestHistorySearchErrorCases(t *testing.T) {
 // Helper functions for testing
 
 func createPromptWithHistory(history []string, mockInput string) *Prompt {
+	output := &bytes.Buffer{}
+	terminal := newMockTerminal(mockInput)
 	return &Prompt{
 		config: Config{
 			Prefix: "test> ",
@@ -919,8 +1008,9 @@ func createPromptWithHistory(history []string, mockInput string) *Prompt {
 				MaxEntries: 100,
 			},
 		},
-		output:   &bytes.Buffer{},
-		terminal: newMockTerminal(mockInput),
+		output:   output,
+		terminal: terminal,
+		renderer: newRenderer(output, ThemeDefault, terminal),
 		keyMap:   NewDefaultKeyMap(),
 		history:  history,
 	}
@@ -1176,84 +1266,40 @@ func TestGetDefaultHistoryFile(t *testing.T) {
 	}
 }
 
-func TestRotateHistoryFile(t *testing.T) {
-	if os.Getenv("GITHUB_ACTIONS") == "" {
-		t.Skip("Skipping slow test in local development")
-	}
-
-	tmpDir := t.TempDir()
-	historyFile := filepath.Join(tmpDir, "rotate_test_history")
-
-	// Create initial history file
-	initialContent := []byte("line1\nline2\nline3\n")
-	err := os.WriteFile(historyFile, initialContent, 0600)
-	if err != nil {
-		t.Fatalf("Failed to create initial file: %v", err)
-	}
-
-	config := &HistoryConfig{
-		Enabled:     true,
-		File:        historyFile,
-		MaxFileSize: 100,
-		MaxBackups:  2,
-	}
+func TestGetDefaultHistoryFileFor(t *testing.T) {
+	t.Parallel()
 
-	hm := NewHistoryManager(config)
-
-	// Test rotation
-	err = hm.rotateHistoryFile()
-	if err != nil {
-		t.Fatalf("rotateHistoryFile failed: %v", err)
+	home := filepath.Join(string(filepath.Separator)+"home", "ann")
+	tests := []struct {
+		name string
+		goos string
+		app  string
+		env  map[string]string
+		want string
+	}{
+		{name: "XDG_STATE_HOME is honored", goos: "linux", app: "mycli", env: map[string]string{"XDG_STATE_HOME": "/state"}, want: filepath.Join("/state", "mycli", "history")},
+		{name: "Linux defaults to ~/.local/state", goos: "linux", app: "mycli", want: filepath.Join(home, ".local", "state", "mycli", "history")},
+		{name: "macOS uses Application Support", goos: "darwin", app: "mycli", want: filepath.Join(home, "Library", "Application Support", "mycli", "history")},
+		{name: "XDG_STATE_HOME wins on macOS", goos: "darwin", app: "mycli", env: map[string]string{"XDG_STATE_HOME": "/state"}, want: filepath.Join("/state", "mycli", "history")},
+		{name: "Windows uses APPDATA", goos: "windows", app: "mycli", env: map[string]string{"APPDATA": "/appdata", "XDG_STATE_HOME": "/state"}, want: filepath.Join("/appdata", "mycli", "history")},
+		{name: "Windows without APPDATA", goos: "windows", app: "mycli", want: filepath.Join(home, "AppData", "Roaming", "mycli", "history")},
+		{name: "an empty app name means prompt", goos: "linux", want: filepath.Join(home, ".local", "state", "prompt", "history")},
 	}
 
-	// Check backup file was created
-	backupFile := historyFile + ".1"
-	if _, err := os.Stat(backupFile); os.IsNotExist(err) {
-		t.Error("Backup file .1 should have been created")
-	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
 
-	// Check backup content matches original
-	backupContent, err := os.ReadFile(filepath.Clean(backupFile)) // #nosec G304 - test file path is controlled
-	if err != nil {
-		t.Fatalf("Failed to read backup file: %v", err)
-	}
-	if !bytes.Equal(backupContent, initialContent) {
-		t.Error("Backup content doesn't match original")
-	}
-
-	// Test multiple rotations
-	err = os.WriteFile(historyFile, []byte("new content\n"), 0600)
-	if err != nil {
-		t.Fatalf("Failed to write new content: %v", err)
-	}
-
-	err = hm.rotateHistoryFile()
-	if err != nil {
-		t.Fatalf("Second rotation failed: %v", err)
+			getenv := func(key string) string { return tt.env[key] }
+			assert.Equal(t, tt.want, defaultHistoryFileFor(tt.goos, tt.app, getenv, home))
+		})
 	}
 
-	// Check .2 file was created
-	backup2File := historyFile + ".2"
-	if _, err := os.Stat(backup2File); os.IsNotExist(err) {
-		t.Error("Backup file .2 should have been created")
-	}
+	t.Run("no home directory", func(t *testing.T) {
+		t.Parallel()
 
-	// Test rotation with max backups reached
-	err = os.WriteFile(historyFile, []byte("third content\n"), 0600)
-	if err != nil {
-		t.Fatalf("Failed to write third content: %v", err)
-	}
-
-	err = hm.rotateHistoryFile()
-	if err != nil {
-		t.Fatalf("Third rotation failed: %v", err)
-	}
-
-	// Check that we don't have more than MaxBackups
-	backup3File := historyFile + ".3"
-	if _, err := os.Stat(backup3File); !os.IsNotExist(err) {
-		t.Error("Backup file .3 should NOT exist (MaxBackups=2)")
-	}
+		assert.Empty(t, defaultHistoryFileFor("linux", "mycli", func(string) string { return "" }, ""))
+	})
 }
 
 func TestLoadHistoryCorruptedFile(t *testing.T) {
@@ -1433,39 +1479,945 @@ func TestHistoryFileExpansionErrors(t *testing.T) {
 	})
 }
 
-func TestRotationWithZeroMaxBackups(t *testing.T) {
-	tmpDir := t.TempDir()
-	historyFile := filepath.Join(tmpDir, "zero_backups_test")
+func TestHistoryMultiLineEntriesRoundTrip(t *testing.T) {
+	t.Parallel()
 
-	// Create initial file
-	err := os.WriteFile(historyFile, []byte("initial content\n"), 0600)
-	if err != nil {
-		t.Fatalf("Failed to create initial file: %v", err)
+	t.Run("multi-line entries survive save and load as single entries", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		config := &HistoryConfig{Enabled: true, MaxEntries: 100, File: historyFile}
+		entries := []string{
+			"ls",
+			"for i in 1 2 3\ndo\n  echo $i\ndone",
+			`printf 'a\nb'`,
+			"trailing backslash \\",
+		}
+
+		hm := NewHistoryManager(config)
+		hm.SetHistory(entries)
+		require.NoError(t, hm.SaveHistory())
+
+		loaded := NewHistoryManager(config)
+		require.NoError(t, loaded.LoadHistory())
+
+		assert.Equal(t, entries, loaded.GetHistory())
+	})
+
+	t.Run("files without multi-line entries keep the plain format", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: historyFile})
+		hm.SetHistory([]string{"git status", `echo a\b`})
+		require.NoError(t, hm.SaveHistory())
+
+		content, err := os.ReadFile(filepath.Clean(historyFile)) // #nosec G304 - test file path is controlled
+		require.NoError(t, err)
+		assert.Equal(t, "git status\necho a\\b\n", string(content))
+	})
+
+	t.Run("entries equal to a file header are escaped", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		config := &HistoryConfig{Enabled: true, File: historyFile}
+		entries := []string{historyFileHeader, historyContextFileHeader, "ls"}
+
+		hm := NewHistoryManager(config)
+		hm.SetHistory(entries)
+		require.NoError(t, hm.SaveHistory())
+
+		content, err := os.ReadFile(filepath.Clean(historyFile)) // #nosec G304 - test file path is controlled
+		require.NoError(t, err)
+		assert.Equal(t, historyFileHeader+"\n\\"+historyFileHeader+"\n\\"+historyContextFileHeader+"\nls\n", string(content))
+
+		loaded := NewHistoryManager(config)
+		require.NoError(t, loaded.LoadHistory())
+		assert.Equal(t, entries, loaded.GetHistory())
+	})
+
+	t.Run("legacy files are read without unescaping", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(historyFile, []byte("echo a\\nb\n"), 0600))
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: historyFile})
+		require.NoError(t, hm.LoadHistory())
+
+		assert.Equal(t, []string{`echo a\nb`}, hm.GetHistory())
+	})
+}
+
+func TestEscapeHistoryEntry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		entry   string
+		escaped string
+	}{
+		{name: "plain text is unchanged", entry: "git status", escaped: "git status"},
+		{name: "newline becomes backslash n", entry: "a\nb", escaped: `a\nb`},
+		{name: "carriage return becomes backslash r", entry: "a\r\nb", escaped: `a\r\nb`},
+		{name: "backslash is doubled", entry: `C:\tmp`, escaped: `C:\\tmp`},
+		{name: "escaped-looking text is not confused with a newline", entry: `a\nb`, escaped: `a\\nb`},
 	}
 
-	config := &HistoryConfig{
-		Enabled:     true,
-		File:        historyFile,
-		MaxFileSize: 10, // Very small to trigger rotation
-		MaxBackups:  0,  // No backups
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.escaped, escapeHistoryEntry(tt.entry))
+			assert.Equal(t, tt.entry, unescapeHistoryEntry(tt.escaped))
+		})
 	}
+}
 
-	hm := NewHistoryManager(config)
+func TestSaveHistoryMerge(t *testing.T) {
+	t.Parallel()
 
-	// Try to rotate
-	err = hm.rotateHistoryFile()
-	if err != nil {
-		t.Fatalf("rotateHistoryFile failed: %v", err)
+	t.Run("entries saved by another process are kept", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(path, []byte("ls\n"), 0o600))
+		first := NewHistoryManager(&HistoryConfig{Enabled: true, File: path})
+		second := NewHistoryManager(&HistoryConfig{Enabled: true, File: path})
+		require.NoError(t, first.LoadHistory())
+		require.NoError(t, second.LoadHistory())
+
+		first.AddEntry("make")
+		second.AddEntry("go test ./...")
+		require.NoError(t, first.SaveHistory())
+		require.NoError(t, second.SaveHistory())
+
+		assert.Equal(t, []string{"ls", "make", "go test ./..."}, historyFileEntries(t, path))
+		assert.Equal(t, []string{"ls", "make", "go test ./..."}, second.GetHistory(), "the merged entries are loaded")
+	})
+
+	t.Run("entries of processes running side by side are ordered by time", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "history")
+		var clock int64
+		tick := func() time.Time {
+			clock++
+			return time.Unix(1700000000+clock, 0)
+		}
+		first := NewHistoryManager(&HistoryConfig{Enabled: true, File: path})
+		second := NewHistoryManager(&HistoryConfig{Enabled: true, File: path})
+		first.now, second.now = tick, tick
+
+		first.AddEntry("vim main.go")
+		second.AddEntry("git pull")
+		first.AddEntry("go build")
+		second.AddEntry("git log")
+		require.NoError(t, first.SaveHistory())
+		require.NoError(t, second.SaveHistory())
+
+		want := []string{"vim main.go", "git pull", "go build", "git log"}
+		assert.Equal(t, want, historyFileEntries(t, path))
+		assert.Equal(t, want, second.GetHistory())
+	})
+
+	t.Run("entries of a file without times keep their order before new ones", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "history")
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: path})
+		hm.AddEntry("make")
+		require.NoError(t, os.WriteFile(path, []byte("ls\npwd\n"), 0o600)) // Written by an older version
+
+		require.NoError(t, hm.SaveHistory())
+
+		assert.Equal(t, []string{"ls", "pwd", "make"}, historyFileEntries(t, path))
+	})
+
+	t.Run("saving twice does not add entries again", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "history")
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: path})
+		hm.AddEntry("ls")
+		require.NoError(t, hm.SaveHistory())
+		hm.AddEntry("pwd")
+		require.NoError(t, hm.SaveHistory())
+		require.NoError(t, hm.SaveHistory())
+
+		assert.Equal(t, []string{"ls", "pwd"}, historyFileEntries(t, path))
+	})
+
+	t.Run("a cleared history overwrites the file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(path, []byte("ls\npwd\n"), 0o600))
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: path})
+		require.NoError(t, hm.LoadHistory())
+
+		hm.ClearHistory()
+		hm.AddEntry("make")
+		require.NoError(t, hm.SaveHistory())
+
+		assert.Equal(t, []string{"make"}, historyFileEntries(t, path))
+	})
+
+	t.Run("concurrent saves lose no entries", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "history")
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: path})
+				hm.AddEntry("cmd " + strconv.Itoa(i))
+				assert.NoError(t, hm.SaveHistory())
+			}()
+		}
+		wg.Wait()
+
+		lines := historyFileEntries(t, path)
+		slices.Sort(lines)
+		assert.Equal(t, []string{"cmd 0", "cmd 1", "cmd 2", "cmd 3", "cmd 4", "cmd 5", "cmd 6", "cmd 7"}, lines)
+	})
+}
+
+func TestAppendOnSubmit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("a submitted entry is in the file before Close", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(path, []byte("ls\n"), 0o600))
+		history := &HistoryConfig{Enabled: true, File: path, AppendOnSubmit: true}
+		p := newForTestingWithConfig(t, Config{HistoryConfig: history}, "make\r")
+		p.renderer.output = &bytes.Buffer{}
+		p.historyManager = NewHistoryManager(history) // Loaded as New does, not replaced
+		require.NoError(t, p.historyManager.LoadHistory())
+
+		_, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ls", "make"}, historyFileEntries(t, path))
+	})
+
+	tests := []struct {
+		name    string
+		initial string
+		entry   string
+		context string
+		want    []HistoryEntry
+	}{
+		{name: "plain file", initial: "ls\n", entry: "pwd", want: []HistoryEntry{{Text: "ls"}, {Text: "pwd"}}},
+		{name: "multi-line entry in a plain file", initial: "ls\n", entry: "for x\ndone", want: []HistoryEntry{{Text: "ls"}, {Text: "for x\ndone"}}},
+		{name: "escaped file", initial: historyFileHeader + "\nls\n", entry: "for x\ndone", want: []HistoryEntry{{Text: "ls"}, {Text: "for x\ndone"}}},
+		{name: "header entry in an empty file", initial: "", entry: historyFileHeader, want: []HistoryEntry{{Text: historyFileHeader}}},
+		{name: "context in a plain file", initial: "ls\n", entry: "pwd", context: "prod", want: []HistoryEntry{{Text: "ls"}, {Text: "pwd", Context: "prod"}}},
+		{
+			name:    "context file",
+			initial: historyContextFileHeader + "\n#@\tdev\nls\n",
+			entry:   "#tag",
+			context: "prod",
+			want:    []HistoryEntry{{Text: "ls", Context: "dev"}, {Text: "#tag", Context: "prod"}},
+		},
 	}
 
-	// No backup files should exist
-	backupFile := historyFile + ".1"
-	if _, err := os.Stat(backupFile); !os.IsNotExist(err) {
-		t.Error("No backup files should be created when MaxBackups is 0")
+	for _, tt := range tests {
+		t.Run("the file stays readable: "+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "history")
+			require.NoError(t, os.WriteFile(path, []byte(tt.initial), 0o600))
+			config := &HistoryConfig{Enabled: true, File: path}
+			if tt.context != "" {
+				config.Context = func() string { return tt.context }
+			}
+			hm := NewHistoryManager(config)
+			require.NoError(t, hm.LoadHistory())
+
+			hm.AddEntry(tt.entry)
+			require.NoError(t, hm.appendLatest())
+
+			loaded := NewHistoryManager(&HistoryConfig{Enabled: true, File: path})
+			require.NoError(t, loaded.LoadHistory())
+			assert.Equal(t, tt.want, loaded.GetEntries())
+		})
 	}
 
-	// Original file should still exist
-	if _, err := os.Stat(historyFile); os.IsNotExist(err) {
-		t.Error("Original file should still exist")
+	t.Run("older duplicates appended earlier are erased on save", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "history")
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: path, EraseDups: true})
+		for _, entry := range []string{"ls", "pwd", "ls"} {
+			hm.AddEntry(entry)
+			require.NoError(t, hm.appendLatest())
+		}
+		require.Equal(t, []string{"ls", "pwd", "ls"}, historyFileEntries(t, path))
+
+		require.NoError(t, hm.SaveHistory())
+
+		assert.Equal(t, []string{"pwd", "ls"}, historyFileEntries(t, path))
+		assert.Equal(t, []string{"pwd", "ls"}, hm.GetHistory())
+	})
+}
+
+func TestSaveHistoryAtomic(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no temporary files are left behind", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: filepath.Join(dir, "history")})
+		hm.AddEntry("ls")
+		require.NoError(t, hm.SaveHistory())
+		hm.AddEntry("pwd")
+		require.NoError(t, hm.SaveHistory())
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		assert.Equal(t, []string{"history", "history.lock"}, names, "only the history and its lock file remain")
+		assert.Equal(t, []string{"ls", "pwd"}, historyFileEntries(t, filepath.Join(dir, "history")))
+	})
+
+	t.Run("existing permissions are kept", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == windowsOS {
+			t.Skip("file permissions are not supported on Windows")
+		}
+
+		file := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(file, []byte("old\n"), 0600))
+		require.NoError(t, os.Chmod(file, 0640))
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: file})
+		hm.AddEntry("new")
+		require.NoError(t, hm.SaveHistory())
+
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	})
+
+	t.Run("new files are private", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == windowsOS {
+			t.Skip("file permissions are not supported on Windows")
+		}
+
+		file := filepath.Join(t.TempDir(), "history")
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: file})
+		hm.AddEntry("secret")
+		require.NoError(t, hm.SaveHistory())
+
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("rotation backs up the old file after writing the new one", func(t *testing.T) {
+		t.Parallel()
+
+		file := filepath.Join(t.TempDir(), "history")
+		old := strings.Repeat("old command\n", 10)
+		require.NoError(t, os.WriteFile(file, []byte(old), 0600))
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: file, MaxFileSize: 50, MaxBackups: 2})
+		require.NoError(t, hm.LoadHistory())
+		hm.AddEntry("new command")
+		require.NoError(t, hm.SaveHistory())
+
+		backup, err := os.ReadFile(file + ".1") // #nosec G304 - test file path is controlled
+		require.NoError(t, err)
+		assert.Equal(t, old, string(backup))
+		want := append(slices.Repeat([]string{"old command"}, 10), "new command")
+		assert.Equal(t, want, historyFileEntries(t, file))
+	})
+
+	t.Run("the history file stays in place while backups are made", func(t *testing.T) {
+		t.Parallel()
+
+		file := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(file, []byte("current\n"), 0600))
+		require.NoError(t, os.WriteFile(file+".1", []byte("older\n"), 0600))
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: file, MaxBackups: 2})
+		require.NoError(t, hm.shiftBackups())
+
+		for name, want := range map[string]string{file: "current\n", file + ".1": "current\n", file + ".2": "older\n"} {
+			content, err := os.ReadFile(name) // #nosec G304 - test file path is controlled
+			require.NoError(t, err)
+			assert.Equal(t, want, string(content), name)
+		}
+	})
+}
+
+func TestHistoryFilePermissions(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == windowsOS {
+		t.Skip("file permissions are not supported on Windows")
+	}
+
+	t.Run("defaults are applied", func(t *testing.T) {
+		t.Parallel()
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true})
+
+		assert.Equal(t, os.FileMode(0600), hm.config.FileMode)
+		assert.Equal(t, os.FileMode(0700), hm.config.DirMode)
+		assert.Equal(t, os.FileMode(0600), DefaultHistoryConfig().FileMode)
+	})
+
+	t.Run("new files and directories use the configured modes", func(t *testing.T) {
+		t.Parallel()
+
+		dir := filepath.Join(t.TempDir(), "app")
+		file := filepath.Join(dir, "history")
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: file, FileMode: 0640, DirMode: 0750})
+		hm.AddEntry("ls")
+		require.NoError(t, hm.SaveHistory())
+
+		fileInfo, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), fileInfo.Mode().Perm())
+		dirInfo, err := os.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0750), dirInfo.Mode().Perm()&^umask(t))
+	})
+
+	t.Run("directory creation can be disabled", func(t *testing.T) {
+		t.Parallel()
+
+		file := filepath.Join(t.TempDir(), "missing", "history")
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: file, DisableDirCreation: true})
+		hm.AddEntry("ls")
+
+		require.Error(t, hm.SaveHistory())
+		_, err := os.Stat(filepath.Dir(file))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("readable files are rejected with the error policy", func(t *testing.T) {
+		t.Parallel()
+
+		file := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(file, []byte("ls\n"), 0600))
+		require.NoError(t, os.Chmod(file, 0644))
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: file, InsecurePermissions: HistoryPermissionError})
+		err := hm.LoadHistory()
+
+		require.ErrorIs(t, err, ErrInsecureHistoryFile)
+		assert.Empty(t, hm.GetHistory())
+	})
+
+	t.Run("New closes the terminal when the history cannot be loaded", func(t *testing.T) {
+		t.Parallel()
+
+		file := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(file, []byte("ls\n"), 0600))
+		require.NoError(t, os.Chmod(file, 0644))
+		terminal := newMockTerminal("")
+
+		_, err := New("> ", WithTerminal(terminal), WithOutput(io.Discard),
+			WithHistory(&HistoryConfig{Enabled: true, File: file, InsecurePermissions: HistoryPermissionError}))
+
+		require.ErrorIs(t, err, ErrInsecureHistoryFile)
+		assert.True(t, terminal.closed)
+	})
+
+	t.Run("readable files are loaded with the ignore policy", func(t *testing.T) {
+		t.Parallel()
+
+		file := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(file, []byte("ls\n"), 0600))
+		require.NoError(t, os.Chmod(file, 0644))
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: file, InsecurePermissions: HistoryPermissionIgnore})
+
+		require.NoError(t, hm.LoadHistory())
+		assert.Equal(t, []string{"ls"}, hm.GetHistory())
+	})
+
+	t.Run("private files pass the check", func(t *testing.T) {
+		t.Parallel()
+
+		file := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(file, []byte("ls\n"), 0600))
+
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: file, InsecurePermissions: HistoryPermissionError})
+
+		require.NoError(t, hm.LoadHistory())
+		assert.Equal(t, []string{"ls"}, hm.GetHistory())
+	})
+}
+
+// umask returns the process umask by creating a directory with full permissions.
+func umask(t *testing.T) os.FileMode {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "umask")
+	require.NoError(t, os.Mkdir(dir, 0777))
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	return 0777 &^ info.Mode().Perm()
+}
+
+func TestHistoryDuplicateNormalization(t *testing.T) {
+	t.Parallel()
+
+	exact := func(entry string) string { return entry }
+
+	tests := []struct {
+		name      string
+		normalize func(string) string
+		entries   []string
+		want      []string
+	}{
+		{
+			name:    "trailing whitespace variant of the previous entry is skipped",
+			entries: []string{"ls", "ls ", "ls\t"},
+			want:    []string{"ls"},
+		},
+		{
+			name:    "leading whitespace variant of the previous entry is skipped",
+			entries: []string{"git status", "  git status"},
+			want:    []string{"git status"},
+		},
+		{
+			name:    "entries are stored as typed",
+			entries: []string{"ls  ", "pwd"},
+			want:    []string{"ls  ", "pwd"},
+		},
+		{
+			name:    "whitespace-only entries are skipped",
+			entries: []string{"ls", "   "},
+			want:    []string{"ls"},
+		},
+		{
+			name:    "only consecutive duplicates are skipped",
+			entries: []string{"ls", "pwd", "ls "},
+			want:    []string{"ls", "pwd", "ls "},
+		},
+		{
+			name:      "an identity normalizer compares entries exactly",
+			normalize: exact,
+			entries:   []string{"ls", "ls ", "ls "},
+			want:      []string{"ls", "ls "},
+		},
+		{
+			name:      "a custom normalizer decides what counts as a duplicate",
+			normalize: strings.ToLower,
+			entries:   []string{"LS", "ls"},
+			want:      []string{"LS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hm := NewHistoryManager(&HistoryConfig{Enabled: true, Normalize: tt.normalize})
+			for _, entry := range tt.entries {
+				hm.AddEntry(entry)
+			}
+			assert.Equal(t, tt.want, hm.GetHistory())
+		})
+	}
+
+	t.Run("submitted input goes through the same check", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{}, "ls\r")
+		p.renderer.output = &bytes.Buffer{}
+		p.AddHistory("ls ")
+
+		_, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ls "}, p.GetHistory())
+	})
+}
+
+func TestHistoryControl(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  HistoryConfig
+		entries []string
+		want    []string
+	}{
+		{
+			name:    "older duplicates are kept by default",
+			entries: []string{"ls", "pwd", "ls"},
+			want:    []string{"ls", "pwd", "ls"},
+		},
+		{
+			name:    "EraseDups removes older duplicates anywhere",
+			config:  HistoryConfig{EraseDups: true},
+			entries: []string{"ls", "pwd", "ls ", "make", "pwd"},
+			want:    []string{"ls ", "make", "pwd"},
+		},
+		{
+			name:    "entries starting with a space are kept by default",
+			entries: []string{"ls", " export TOKEN=x"},
+			want:    []string{"ls", " export TOKEN=x"},
+		},
+		{
+			name:    "IgnoreSpace skips entries starting with a space",
+			config:  HistoryConfig{IgnoreSpace: true},
+			entries: []string{"ls", " export TOKEN=x", "pwd"},
+			want:    []string{"ls", "pwd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := tt.config
+			config.Enabled = true
+			hm := NewHistoryManager(&config)
+			for _, entry := range tt.entries {
+				hm.AddEntry(entry)
+			}
+			assert.Equal(t, tt.want, hm.GetHistory())
+		})
+	}
+
+	t.Run("EraseDups keeps the context of the remaining entries", func(t *testing.T) {
+		t.Parallel()
+
+		context := ""
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, EraseDups: true, Context: func() string { return context }})
+		for _, entry := range []string{"ls", "pwd", "ls"} {
+			context = "after " + entry
+			hm.AddEntry(entry)
+		}
+
+		assert.Equal(t, []HistoryEntry{{Text: "pwd", Context: "after pwd"}, {Text: "ls", Context: "after ls"}}, hm.GetEntries())
+	})
+
+	t.Run("submitted input follows the policy", func(t *testing.T) {
+		t.Parallel()
+
+		history := &HistoryConfig{Enabled: true, EraseDups: true, IgnoreSpace: true}
+		p := newForTestingWithConfig(t, Config{HistoryConfig: history}, " secret\r")
+		p.renderer.output = &bytes.Buffer{}
+		p.AddHistory("ls")
+		p.AddHistory("pwd")
+		p.AddHistory("ls")
+
+		_, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pwd", "ls"}, p.GetHistory())
+	})
+}
+
+func TestHistoryPrefixSearch(t *testing.T) {
+	t.Parallel()
+
+	keyMap := NewDefaultKeyMap()
+	keyMap.BindSequence("[5~", ActionHistoryPrefixUp)   // PageUp
+	keyMap.BindSequence("[6~", ActionHistoryPrefixDown) // PageDown
+
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+		want   string
+	}{
+		{name: "Up visits entries with the typed prefix", config: Config{HistoryPrefix: true}, input: "git\x1b[A\r", want: "git push"},
+		{name: "Up again skips other entries", config: Config{HistoryPrefix: true}, input: "git\x1b[A\x1b[A\r", want: "git status"},
+		{name: "Up stops at the oldest match", config: Config{HistoryPrefix: true}, input: "git\x1b[A\x1b[A\x1b[A\r", want: "git status"},
+		{name: "Down past the newest match restores the typed line", config: Config{HistoryPrefix: true}, input: "git\x1b[A\x1b[B\r", want: "git"},
+		{name: "the cursor stays after the prefix", config: Config{HistoryPrefix: true}, input: "git\x1b[Ax\r", want: "gitx push"},
+		{name: "an empty input browses all entries", config: Config{HistoryPrefix: true}, input: "\x1b[A\x1b[A\r", want: "ls"},
+		{name: "Up ignores the prefix by default", input: "l\x1b[A\r", want: "git push"},
+		{name: "the prefix actions work without the option", config: Config{KeyMap: keyMap}, input: "l\x1b[5~\r", want: "ls"},
+		{name: "PageDown returns to the typed line", config: Config{KeyMap: keyMap}, input: "g\x1b[5~\x1b[6~\r", want: "g"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, tt.config, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+			for _, entry := range []string{"git status", "ls", "git push"} {
+				p.AddHistory(entry)
+			}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestHistoryArrowPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options []Option
+		input   string
+		want    string
+	}{
+		{name: "Up replaces typed input by default", input: "mak\x1b[A\r", want: "git push"},
+		{name: "Up moves to the start of typed input", options: []Option{WithDisableHistoryArrowOnNonEmptyBuffer(true)}, input: "mak\x1b[Ax\r", want: "xmak"},
+		{name: "Down moves to the end of typed input", options: []Option{WithHistoryArrowPolicy(HistoryArrowMoveCursor)}, input: "mak\x1b[D\x1b[D\x1b[Bx\r", want: "makx"},
+		{name: "ignore leaves the cursor in place", options: []Option{WithHistoryArrowPolicy(HistoryArrowIgnore)}, input: "mak\x1b[D\x1b[Ax\r", want: "maxk"},
+		{name: "an empty input still browses history", options: []Option{WithHistoryArrowPolicy(HistoryArrowIgnore)}, input: "\x1b[A\x1b[A\r", want: "ls"},
+		{name: "Down after browsing returns to the empty line", options: []Option{WithHistoryArrowPolicy(HistoryArrowIgnore)}, input: "\x1b[A\x1b[B\r", want: ""},
+		{name: "history actions on other keys are not affected", options: []Option{WithHistoryArrowPolicy(HistoryArrowIgnore)}, input: "mak\x10\r", want: "git push"},
+		{name: "the option can be turned off again", options: []Option{WithDisableHistoryArrowOnNonEmptyBuffer(true), WithDisableHistoryArrowOnNonEmptyBuffer(false)}, input: "mak\x1b[A\r", want: "git push"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			keyMap := NewDefaultKeyMap()
+			keyMap.Bind('\x10', ActionHistoryUp) // Ctrl+P
+			options := append([]Option{WithTerminal(newMockTerminal(tt.input)), WithOutput(&bytes.Buffer{}), WithKeyMap(keyMap), WithMemoryHistory(10)}, tt.options...)
+			p, err := New("> ", options...)
+			require.NoError(t, err)
+			defer p.Close()
+			for _, entry := range []string{"ls", "git push"} {
+				p.AddHistory(entry)
+			}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
 	}
 }
+
+func TestHistoryEdits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "an edited entry keeps its edits when browsed back to", input: "\x1b[A!\x1b[A\x1b[B\r", want: "git push!"},
+		{name: "other entries are recalled unchanged", input: "\x1b[A!\x1b[A\r", want: "ls"},
+		{name: "several entries keep their own edits", input: "\x1b[A1\x1b[A2\x1b[B\x1b[A\r", want: "ls2"},
+		{name: "Down past the newest entry restores the typed line", input: "pw\x1b[A\x1b[B\r", want: "pw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{}, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+			for _, entry := range []string{"git status", "ls", "git push"} {
+				p.AddHistory(entry)
+			}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+			assert.Equal(t, []string{"git status", "ls", "git push", tt.want}, p.GetHistory())
+		})
+	}
+
+	t.Run("edits are dropped when Run returns", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{}, "\x1b[A!\x1b[A\r\x1b[A\x1b[A\r")
+		p.renderer.output = &bytes.Buffer{}
+		for _, entry := range []string{"ls", "git push"} {
+			p.AddHistory(entry)
+		}
+
+		first, err := p.Run()
+		require.NoError(t, err)
+		second, err := p.Run()
+		require.NoError(t, err)
+
+		assert.Equal(t, "ls", first)
+		assert.Equal(t, "git push", second)
+	})
+}
+
+func TestHistoryExclusion(t *testing.T) {
+	t.Parallel()
+
+	secret := func(entry string) bool { return strings.Contains(entry, "--password") }
+
+	tests := []struct {
+		name    string
+		config  HistoryConfig
+		entries []string
+		want    []string
+	}{
+		{
+			name:    "ExcludePatterns skip matching entries",
+			config:  HistoryConfig{ExcludePatterns: []string{`^export \w*SECRET`, `^pass `}},
+			entries: []string{"ls", "export AWS_SECRET=abc", "pass show", "export PATH=/bin"},
+			want:    []string{"ls", "export PATH=/bin"},
+		},
+		{
+			name:    "ExcludeFunc skips entries it reports",
+			config:  HistoryConfig{ExcludeFunc: secret},
+			entries: []string{"login --password hunter2", "pwd"},
+			want:    []string{"pwd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := tt.config
+			config.Enabled = true
+			hm := NewHistoryManager(&config)
+			for _, entry := range tt.entries {
+				hm.AddEntry(entry)
+			}
+			assert.Equal(t, tt.want, hm.GetHistory())
+		})
+	}
+
+	t.Run("excluded entries are not written to the file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "history")
+		history := &HistoryConfig{Enabled: true, File: path, AppendOnSubmit: true, ExcludeFunc: secret}
+		p := newForTestingWithConfig(t, Config{HistoryConfig: history}, "login --password hunter2\r")
+		p.renderer.output = &bytes.Buffer{}
+		p.historyManager = NewHistoryManager(history)
+		p.AddHistory("ls")
+
+		_, err := p.Run()
+		require.NoError(t, err)
+		require.NoError(t, p.historyManager.SaveHistory())
+
+		assert.Equal(t, []string{"ls"}, historyFileEntries(t, path))
+		assert.Equal(t, []string{"ls"}, p.GetHistory())
+	})
+
+	t.Run("New reports an invalid pattern", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := New("> ", WithTerminal(newMockTerminal("")), WithHistory(&HistoryConfig{Enabled: true, ExcludePatterns: []string{"("}}))
+
+		assert.ErrorContains(t, err, `invalid history exclude pattern "("`)
+	})
+
+	t.Run("New reports an invalid pattern before opening the terminal", func(t *testing.T) {
+		t.Parallel()
+
+		// The device does not exist, so opening it first would report that instead
+		tty := filepath.Join(t.TempDir(), "tty")
+		_, err := New("> ", WithTTYPath(tty), WithOutput(io.Discard), WithHistory(&HistoryConfig{Enabled: true, ExcludePatterns: []string{"("}}))
+
+		assert.ErrorContains(t, err, `invalid history exclude pattern "("`)
+	})
+}
+
+func TestHistoryEntryContext(t *testing.T) {
+	t.Parallel()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	t.Run("directory and context are recorded and survive save and load", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		host := "db-1"
+		config := &HistoryConfig{Enabled: true, File: historyFile, RecordDir: true, Context: func() string { return host }}
+		hm := NewHistoryManager(config)
+		hm.AddEntry("make test")
+		host = "db\t2"
+		hm.AddEntry("#not a context line")
+		require.NoError(t, hm.SaveHistory())
+
+		loaded := NewHistoryManager(&HistoryConfig{Enabled: true, File: historyFile})
+		require.NoError(t, loaded.LoadHistory())
+
+		assert.Equal(t, []HistoryEntry{
+			{Text: "make test", Dir: wd, Context: "db-1"},
+			{Text: "#not a context line", Dir: wd, Context: "db\t2"},
+		}, loaded.GetEntries())
+		assert.Equal(t, []string{"make test", "#not a context line"}, loaded.GetHistory())
+	})
+
+	t.Run("entries without context or time keep the older formats", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: historyFile})
+		hm.SetHistory([]string{"ls"})
+		require.NoError(t, hm.SaveHistory())
+
+		content, err := os.ReadFile(filepath.Clean(historyFile)) // #nosec G304 - test file path is controlled
+		require.NoError(t, err)
+		assert.Equal(t, "ls\n", string(content))
+		assert.Equal(t, []HistoryEntry{{Text: "ls"}}, hm.GetEntries())
+	})
+
+	t.Run("trimming keeps each context with its entry", func(t *testing.T) {
+		t.Parallel()
+
+		n := 0
+		hm := NewHistoryManager(&HistoryConfig{Enabled: true, Context: func() string {
+			n++
+			return strconv.Itoa(n)
+		}})
+		for _, entry := range []string{"a", "b", "c"} {
+			hm.AddEntry(entry)
+		}
+
+		hm.trim(2)
+
+		assert.Equal(t, []HistoryEntry{{Text: "b", Context: "2"}, {Text: "c", Context: "3"}}, hm.GetEntries())
+	})
+
+	t.Run("cwd: in Ctrl+R only searches entries from that directory", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{HistoryConfig: &HistoryConfig{Enabled: true, MaxEntries: 100, RecordDir: true}}
+		p := newForTestingWithConfig(t, config, "")
+		p.AddHistory("ls -la")
+		p.historyManager.contexts[0].dir = filepath.Join(wd, "elsewhere")
+		p.AddHistory("pwd")
+		p.AddHistory("cat notes")
+
+		search := p.historySearcher()
+
+		assert.Equal(t, []string{"ls -la"}, search("ls"))
+		assert.Empty(t, search("cwd: ls"))
+		assert.Equal(t, []string{"pwd"}, search("cwd: pwd"))
+		assert.Equal(t, []string{"cat notes", "pwd"}, search("cwd:"), "newest first")
+		assert.Equal(t, []string{"ls -la"}, search("cwd:"+filepath.Join(wd, "elsewhere")))
+	})
+}
+
+// historyFileEntries returns the entries stored in the history file at path.
+func historyFileEntries(t *testing.T, path string) []string {
+	t.Helper()
+	hm := NewHistoryManager(&HistoryConfig{Enabled: true, File: path})
+	require.NoError(t, hm.LoadHistory())
+	return hm.GetHistory()
+}
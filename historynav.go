@@ -0,0 +1,191 @@
+package prompt
+
+import "strings"
+
+// WithHistoryPrefixSearch makes Up and Down search the history by prefix
+// while the input is not empty, like zsh's history-beginning-search: only
+// entries starting with the text before the cursor are visited, and the cursor
+// stays where it is so the typed prefix is kept. Going down past the newest
+// match brings back the line that was being typed. With an empty input, Up and
+// Down browse the whole history as usual.
+//
+// ActionHistoryPrefixUp and ActionHistoryPrefixDown always search by prefix
+// and can be bound to other keys instead.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithHistoryPrefixSearch(true))
+func WithHistoryPrefixSearch(enabled bool) Option {
+	return func(c *Config) {
+		c.HistoryPrefix = enabled
+	}
+}
+
+// HistoryArrowPolicy selects when Up and Down may replace the input with a
+// history entry. See WithHistoryArrowPolicy.
+type HistoryArrowPolicy int
+
+const (
+	// HistoryArrowAlways browses history with Up and Down whatever the input.
+	HistoryArrowAlways HistoryArrowPolicy = iota
+	// HistoryArrowMoveCursor browses history only from an empty input. With
+	// typed text, Up moves the cursor to the start of the input and Down to
+	// its end.
+	HistoryArrowMoveCursor
+	// HistoryArrowIgnore browses history only from an empty input. With typed
+	// text, Up and Down do nothing.
+	HistoryArrowIgnore
+)
+
+// WithHistoryArrowPolicy sets when Up and Down may replace the input with a
+// history entry, so a half-typed command is not swapped out by accident. The
+// policies other than HistoryArrowAlways only start browsing from an empty
+// input; once browsing, Up and Down keep moving through the entries. Moving
+// between the rows of long or multi-line input, prefix search with
+// WithHistoryPrefixSearch and ActionHistoryUp and ActionHistoryDown bound to
+// other keys are not affected.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithHistoryArrowPolicy(prompt.HistoryArrowIgnore))
+func WithHistoryArrowPolicy(policy HistoryArrowPolicy) Option {
+	return func(c *Config) {
+		c.HistoryArrows = policy
+	}
+}
+
+// WithDisableHistoryArrowOnNonEmptyBuffer keeps Up and Down from replacing
+// typed input with history when disabled is true: they move the cursor to the
+// start or end of the input instead, as with HistoryArrowMoveCursor. With an
+// empty input they browse history as usual.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithDisableHistoryArrowOnNonEmptyBuffer(true))
+func WithDisableHistoryArrowOnNonEmptyBuffer(disabled bool) Option {
+	return func(c *Config) {
+		c.HistoryArrows = HistoryArrowAlways
+		if disabled {
+			c.HistoryArrows = HistoryArrowMoveCursor
+		}
+	}
+}
+
+// keepTypedLine applies the HistoryArrows policy before Up (direction < 0) or
+// Down browses history, and reports whether the input has to stay as it is.
+// Only typed text is kept: an empty input and an entry already being browsed
+// go on to the history.
+func (p *Prompt) keepTypedLine(direction int) bool {
+	if p.config.HistoryArrows == HistoryArrowAlways || len(p.buffer) == 0 ||
+		p.historyIndex < len(p.history) || p.prefixBrowsing() {
+		return false
+	}
+	if p.config.HistoryArrows == HistoryArrowMoveCursor {
+		p.cursor = 0
+		if direction > 0 {
+			p.cursor = len(p.buffer)
+		}
+	}
+	return true
+}
+
+// prefixBrowsing reports whether Up and Down search the history by prefix:
+// HistoryPrefix is set and the line typed before browsing started is not
+// empty.
+func (p *Prompt) prefixBrowsing() bool {
+	line := string(p.buffer)
+	if p.historyIndex < len(p.history) {
+		line = p.historyLine
+	}
+	return p.config.HistoryPrefix && line != ""
+}
+
+// historyUp moves to the previous history entry and reports whether it did.
+// With prefixSearch, it moves to the previous entry starting with the text
+// before the cursor and leaves the cursor in place.
+func (p *Prompt) historyUp(prefixSearch bool) bool {
+	if !prefixSearch {
+		if p.historyIndex <= 0 {
+			return false
+		}
+		p.recallHistory(p.historyIndex - 1)
+		return true
+	}
+
+	prefix, current := string(p.buffer[:p.cursor]), string(p.buffer)
+	for i := min(p.historyIndex, len(p.history)) - 1; i >= 0; i-- {
+		if p.history[i] == current || !strings.HasPrefix(p.history[i], prefix) {
+			continue // Skip entries that would not change the input
+		}
+		if p.historyIndex >= len(p.history) {
+			p.historyLine = current
+		}
+		p.historyIndex = i
+		p.buffer = []rune(p.history[i])
+		return true
+	}
+	return false
+}
+
+// historyDown moves to the next history entry and reports whether it did.
+// Past the newest entry it restores the line that was typed before browsing
+// started. With prefixSearch, it moves to the next entry starting with the
+// text before the cursor, leaving the cursor in place.
+func (p *Prompt) historyDown(prefixSearch bool) bool {
+	if p.historyIndex >= len(p.history) {
+		return false
+	}
+	if !prefixSearch {
+		p.recallHistory(p.historyIndex + 1)
+		return true
+	}
+
+	prefix, current := string(p.buffer[:p.cursor]), string(p.buffer)
+	for i := p.historyIndex + 1; i < len(p.history); i++ {
+		if p.history[i] != current && strings.HasPrefix(p.history[i], prefix) {
+			p.historyIndex = i
+			p.buffer = []rune(p.history[i])
+			return true
+		}
+	}
+	p.historyIndex = len(p.history)
+	p.buffer = []rune(p.historyLine)
+	p.cursor = min(p.cursor, len(p.buffer))
+	return true
+}
+
+// recallHistory moves the input to history entry i, or back to the line being
+// typed when i is len(history). Edits made to the entry being left are kept in
+// historyEdits until Run returns, and an entry edited earlier comes back with
+// its edits, as in readline.
+func (p *Prompt) recallHistory(i int) {
+	p.saveHistoryEdit()
+	p.historyIndex = i
+	if i >= len(p.history) {
+		p.setBuffer(p.historyLine)
+		return
+	}
+	if edited, ok := p.historyEdits[i]; ok {
+		p.setBuffer(edited)
+		return
+	}
+	p.setBuffer(p.history[i])
+}
+
+// saveHistoryEdit records the input as the edited text of the history entry
+// being browsed, or as the line being typed while no entry is recalled. An
+// entry whose edits were all reverted is forgotten.
+func (p *Prompt) saveHistoryEdit() {
+	line := string(p.buffer)
+	switch {
+	case p.historyIndex >= len(p.history):
+		p.historyLine = line
+	case line != p.history[p.historyIndex]:
+		if p.historyEdits == nil {
+			p.historyEdits = map[int]string{}
+		}
+		p.historyEdits[p.historyIndex] = line
+	default:
+		delete(p.historyEdits, p.historyIndex)
+	}
+}
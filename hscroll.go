@@ -0,0 +1,88 @@
+package prompt
+
+import "strings"
+
+// WithHorizontalScroll keeps input that is longer than the terminal is wide on
+// the prompt row instead of wrapping it onto the next rows. The row shows a
+// window of the input that scrolls sideways as the cursor moves, with a
+// dimmed "<" or ">" at the edge where text is hidden. Input with several lines
+// still wraps.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithHorizontalScroll(true))
+func WithHorizontalScroll(enabled bool) Option {
+	return func(c *Config) {
+		c.HorizontalScroll = enabled
+	}
+}
+
+// scroll returns the window of a single-line input that fits on the prompt row
+// and the cursor within it, for horizontal scrolling. The window starts where
+// the previous one did, so the text only moves when the cursor leaves it. The
+// colors of the window, with the "<" and ">" edge indicators, are kept for
+// renderLines. Input that fits, that has several lines, or that leaves no room
+// next to a long prefix is returned as it is.
+func (r *renderer) scroll(prefix, input string, cursor int) (string, int) {
+	runes := []rune(input)
+	avail := r.width() - 1 - stringWidth(prefix) // Never fill the last column
+	if strings.Contains(input, "\n") || avail < 3 || runesWidth(runes) <= avail {
+		r.scrollStart = 0
+		return input, cursor
+	}
+
+	cursor = max(0, min(cursor, len(runes)))
+	start := max(0, min(r.scrollStart, cursor))
+	var end int
+	for {
+		left := 0
+		if start > 0 {
+			left = 1 // Room for "<"
+		}
+		end = fitWidth(runes, start, avail-left)
+		if end < len(runes) {
+			end = fitWidth(runes, start, avail-left-1) // Room for ">"
+		}
+		// The cursor may sit after the last character only at the end of the input
+		if cursor < end || (end == len(runes) && cursor == end) || start >= cursor {
+			break
+		}
+		start++
+	}
+	r.scrollStart = start
+
+	var window []rune
+	indicator := r.colorScheme.Suggestion.Description
+	colors := r.highlight(input)
+	if start > 0 {
+		window = append(window, '<')
+		r.window = append(r.window, &indicator)
+	}
+	window = append(window, runes[start:end]...)
+	if colors != nil {
+		r.window = append(r.window, colors[start:end]...)
+	} else {
+		r.window = append(r.window, make([]*Color, end-start)...)
+	}
+	if end < len(runes) {
+		window = append(window, '>')
+		r.window = append(r.window, &indicator)
+	}
+	if start > 0 {
+		cursor++
+	}
+	return string(window), cursor - start
+}
+
+// fitWidth returns the index just past the runes of runes[start:] that fit in
+// n columns, keeping characters whole.
+func fitWidth(runes []rune, start, n int) int {
+	used := 0
+	for _, g := range graphemes(runes[start:]) {
+		if used+g.width > n {
+			return start + g.start
+		}
+		used += g.width
+	}
+	return len(runes)
+}
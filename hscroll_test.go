@@ -0,0 +1,82 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHorizontalScroll(t *testing.T) {
+	t.Parallel()
+
+	newScrollRenderer := func(width int) *renderer {
+		terminal := newMockTerminal("")
+		terminal.terminalSize = [2]int{width, 24}
+		r := newRenderer(&bytes.Buffer{}, ThemeDefault, terminal)
+		r.hscroll = true
+		return r
+	}
+	text := "0123456789abcdefghij" // 20 runes
+
+	t.Run("input that fits is shown as it is", func(t *testing.T) {
+		t.Parallel()
+
+		r := newScrollRenderer(20)
+
+		window, cursor := r.scroll("> ", "hello", 3)
+
+		assert.Equal(t, "hello", window)
+		assert.Equal(t, 3, cursor)
+		assert.Nil(t, r.window)
+	})
+
+	t.Run("the window follows the cursor with edge indicators", func(t *testing.T) {
+		t.Parallel()
+
+		// 12 columns minus the prefix and the last column leave 9 for the input
+		r := newScrollRenderer(12)
+
+		window, cursor := r.scroll("> ", text, 20)
+		assert.Equal(t, "<cdefghij", window)
+		assert.Equal(t, 9, cursor, "the cursor sits after the last character")
+		assert.Len(t, r.window, 9)
+		assert.Equal(t, ThemeDefault.Suggestion.Description, *r.window[0], "the indicator is dimmed")
+
+		window, cursor = r.scroll("> ", text, 15)
+		assert.Equal(t, "<cdefghij", window, "the window does not move while the cursor is inside it")
+		assert.Equal(t, 4, cursor)
+
+		window, cursor = r.scroll("> ", text, 0)
+		assert.Equal(t, "01234567>", window)
+		assert.Equal(t, 0, cursor)
+
+		window, cursor = r.scroll("> ", text, 10)
+		assert.Equal(t, "<456789a>", window, "moving right scrolls just enough to show the cursor")
+		assert.Equal(t, 7, cursor)
+	})
+
+	t.Run("multi-line input still wraps", func(t *testing.T) {
+		t.Parallel()
+
+		r := newScrollRenderer(12)
+
+		window, cursor := r.scroll("> ", text+"\nx", 3)
+
+		assert.Equal(t, text+"\nx", window)
+		assert.Equal(t, 3, cursor)
+	})
+
+	t.Run("scrolled input takes a single row", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", HorizontalScroll: true}, strings.Repeat("x", 200)+"\r")
+
+		result, err := p.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Repeat("x", 200), result)
+		assert.Equal(t, 1, p.renderer.lastLines)
+	})
+}
@@ -0,0 +1,55 @@
+package prompt
+
+// defaultInlineCycleRows is the terminal height below which Tab cycles
+// suggestions in the input line when InlineCycleRows is 0.
+const defaultInlineCycleRows = 5
+
+// inlineCycle is the state of Tab cycling suggestions in the input line on a
+// terminal too short for the menu.
+type inlineCycle struct {
+	start       editState    // Buffer before the first suggestion was inserted
+	suggestions []Suggestion // Suggestions being cycled
+	index       int          // Suggestion currently in the buffer
+}
+
+// WithInlineCycling sets the terminal height below which Tab cycles the
+// suggestions in the input line instead of opening the menu, like readline's
+// menu-complete: the first Tab inserts the first suggestion and each further
+// Tab replaces it with the next one, wrapping around. This keeps completion
+// usable in short tmux panes and embedded consoles, where a menu has no room.
+// The default is 5 rows; a negative value always uses the menu.
+func WithInlineCycling(belowRows int) Option {
+	return func(c *Config) {
+		c.InlineCycleRows = belowRows
+	}
+}
+
+// cyclesInline reports whether the terminal is too short for the suggestion
+// menu, so Tab cycles suggestions in the input line instead.
+func (p *Prompt) cyclesInline() bool {
+	rows := p.config.InlineCycleRows
+	if rows == 0 {
+		rows = defaultInlineCycleRows
+	}
+	if rows < 0 {
+		return false
+	}
+	_, height, err := p.terminal.Size()
+	return err == nil && height > 0 && height < rows
+}
+
+// startCycle inserts the first of suggestions and returns the state for
+// cycling to the next ones.
+func (p *Prompt) startCycle(suggestions []Suggestion) *inlineCycle {
+	cycle := &inlineCycle{start: p.snapshot(), suggestions: suggestions}
+	p.acceptSuggestion(suggestions[0])
+	return cycle
+}
+
+// advance replaces the suggestion in the buffer with the next one, wrapping
+// around after the last.
+func (c *inlineCycle) advance(p *Prompt) {
+	c.index = (c.index + 1) % len(c.suggestions)
+	p.restore(c.start)
+	p.acceptSuggestion(c.suggestions[c.index])
+}
@@ -0,0 +1,130 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputSanitizer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("the sanitized input is returned and recorded in history", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", InputSanitizer: strings.TrimSpace}, "  ls -l \r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ls -l", result)
+		assert.Equal(t, []string{"ls -l"}, p.GetHistory())
+	})
+
+	t.Run("the validator sees the sanitized input", func(t *testing.T) {
+		t.Parallel()
+
+		var validated string
+		config := Config{
+			Prefix:         "> ",
+			InputSanitizer: strings.ToLower,
+			Validator: func(input string) error {
+				validated = input
+				return nil
+			},
+		}
+		p := newForTestingWithConfig(t, config, "YES\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "yes", result)
+		assert.Equal(t, "yes", validated)
+	})
+}
+
+func TestLiveInputSanitizer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("the buffer is rewritten after every edit", func(t *testing.T) {
+		t.Parallel()
+
+		var buffers []string
+		config := Config{Prefix: "> ", LiveInputSanitizer: func(input string) string {
+			buffers = append(buffers, input)
+			return strings.ToUpper(input)
+		}}
+		// Type "ab", move before "b" and insert "x"
+		p := newForTestingWithConfig(t, config, "ab\x1b[Dx\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "AXB", result, "the cursor stays in place for a length-preserving transform")
+		assert.Equal(t, []string{"a", "Ab", "AxB"}, buffers, "cursor movements do not run it")
+	})
+
+	t.Run("the cursor keeps its distance from the end", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{}, "")
+		p.config.LiveInputSanitizer = func(input string) string { return strings.TrimLeft(input, " ") }
+		p.buffer = []rune("   abc")
+		p.cursor = 4
+
+		p.applyLiveSanitizer()
+
+		assert.Equal(t, "abc", string(p.buffer))
+		assert.Equal(t, 1, p.cursor)
+	})
+
+	t.Run("a bracketed paste is rewritten once it is complete", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		config := Config{Prefix: "> ", LiveInputSanitizer: func(input string) string {
+			calls++
+			return strings.ReplaceAll(input, "\n", " ")
+		}}
+		p := newForTestingWithConfig(t, config, "\x1b[200~a\nb\x1b[201~\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "a b", result)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestTrimSpace(t *testing.T) {
+	t.Parallel()
+
+	t.Run("the result and history entry are trimmed", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", TrimSpace: true}, "  ls -l \r")
+		p.AddHistory("ls -l")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ls -l", result)
+		assert.Equal(t, []string{"ls -l"}, p.GetHistory(), "the trimmed form is not a new entry")
+	})
+
+	t.Run("trimming comes before the submit transform", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Prefix: "> "}
+		WithTrimSpace(true)(&config)
+		WithSubmitTransform(func(input string) string { return "[" + input + "]" })(&config)
+		p := newForTestingWithConfig(t, config, " a \r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "[a]", result)
+	})
+}
@@ -0,0 +1,452 @@
+package prompt
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Modifiers is the set of modifier keys held while a Key was pressed.
+type Modifiers uint8
+
+// Modifier keys of a Key. Combine them with |, as in ModCtrl | ModShift.
+const (
+	ModShift Modifiers = 1 << iota
+	ModAlt
+	ModCtrl
+)
+
+// KeyCode identifies a key: the character it types for keys that type one,
+// such as 'b' or '/', or one of the KeyUp to KeyF12 constants for the others.
+// Letters are lowercase unless Shift produced an uppercase letter, which is
+// reported as the letter itself without ModShift.
+type KeyCode rune
+
+// Codes of keys whose character is a control character.
+const (
+	KeyTab       KeyCode = '\t'
+	KeyEnter     KeyCode = '\r'
+	KeyEscape    KeyCode = '\x1b'
+	KeySpace     KeyCode = ' '
+	KeyBackspace KeyCode = '\x7f'
+)
+
+// Codes of keys that type no character. They are above the Unicode range so
+// they never collide with a character.
+const (
+	KeyUp KeyCode = unicode.MaxRune + 1 + iota
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyInsert
+	KeyDelete
+	KeyPageUp
+	KeyPageDown
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+)
+
+// Key is a key press with its modifiers, decoded from whatever the terminal
+// sent for it. The same Key is reported whether the terminal used the classic
+// encoding (Ctrl+A as 0x01, Alt+B as ESC b, Shift+Tab as ESC [Z) or one of
+// the extended ones, the kitty keyboard protocol ("CSI-u") and xterm's
+// modifyOtherKeys, which can also tell keys such as Ctrl+Enter apart. Bind
+// keys with KeyMap.BindKey.
+type Key struct {
+	Code KeyCode
+	Mod  Modifiers
+}
+
+const (
+	// xterm's modifyOtherKeys level 2, then the kitty keyboard protocol with
+	// the disambiguate flag. Terminals ignore the one they do not support.
+	extendedKeysEnableSequence  = "\x1b[>4;2m\x1b[>1u"
+	extendedKeysDisableSequence = "\x1b[<u\x1b[>4;0m"
+)
+
+// WithExtendedKeys asks the terminal to report keys with all their modifiers
+// while the prompt runs, using the kitty keyboard protocol or xterm's
+// modifyOtherKeys, whichever it supports. Keys bound with KeyMap.BindKey can
+// then include ones a terminal normally sends like another key, such as
+// Ctrl+Enter, Shift+Enter or Ctrl+I. Keys without such a binding behave as
+// they do without the option. Terminals that support neither ignore the
+// request.
+//
+// Example:
+//
+//	keyMap := prompt.NewDefaultKeyMap()
+//	keyMap.BindKey(prompt.Key{Code: prompt.KeyEnter, Mod: prompt.ModShift}, prompt.ActionNewLine)
+//	prompt.New("$ ", prompt.WithKeyMap(keyMap), prompt.WithExtendedKeys(true))
+func WithExtendedKeys(enabled bool) Option {
+	return func(c *Config) {
+		c.ExtendedKeys = enabled
+	}
+}
+
+// BindKey adds or updates the binding of a decoded key. It takes precedence
+// over bindings of the rune or escape sequence the key arrives as. Keys such as
+// Ctrl+Enter, which most terminals send like Enter, can only be told apart when
+// the terminal uses an extended encoding; see WithExtendedKeys.
+//
+// Example:
+//
+//	keyMap := prompt.NewDefaultKeyMap()
+//	keyMap.BindKey(prompt.Key{Code: 'b', Mod: prompt.ModAlt}, prompt.ActionMoveWordLeft)
+//	keyMap.BindKey(prompt.Key{Code: prompt.KeyTab, Mod: prompt.ModShift}, prompt.ActionHistoryUp)
+//	keyMap.BindKey(prompt.Key{Code: prompt.KeyEnter, Mod: prompt.ModCtrl}, prompt.ActionNewLine)
+func (km *KeyMap) BindKey(key Key, action KeyAction) {
+	if km.keys == nil {
+		km.keys = make(map[Key]KeyAction)
+	}
+	km.keys[key] = action
+}
+
+// actionFunc is the action of keys bound with BindFunc, whose handler is kept
+// in KeyMap.funcs.
+const actionFunc KeyAction = -1
+
+// BindFunc binds a key to a function of the application, for what no
+// KeyAction does, such as refreshing a cache on F5. The handler runs on the
+// event loop with a PromptController, through which it can read and change the
+// buffer, the cursor, the prefix and the mode; the prompt is repainted once it
+// returns, and its changes are undone together by Ctrl+_. An open suggestion
+// menu is closed. If the handler returns an error, Run returns that error and
+// the input is discarded.
+//
+// Like BindKey, the binding takes precedence over bindings of the rune or
+// escape sequence the key arrives as, and a later BindKey of the key replaces
+// it.
+//
+// Example:
+//
+//	keyMap := prompt.NewDefaultKeyMap()
+//	keyMap.BindFunc(prompt.Key{Code: prompt.KeyF5}, func(c *prompt.PromptController) error {
+//		cache.Refresh()
+//		c.SetPrefix(fmt.Sprintf("[%d] > ", cache.Len()))
+//		return nil
+//	})
+func (km *KeyMap) BindFunc(key Key, handler func(*PromptController) error) {
+	if handler == nil {
+		km.BindKey(key, ActionNone)
+		return
+	}
+	if km.funcs == nil {
+		km.funcs = make(map[Key]func(*PromptController) error)
+	}
+	km.funcs[key] = handler
+	km.BindKey(key, actionFunc)
+}
+
+// BindKeyInContext adds or updates the binding of a decoded key that applies
+// only in the given context, overriding the binding made with BindKey there.
+func (km *KeyMap) BindKeyInContext(context KeyContext, key Key, action KeyAction) {
+	if context == KeyContextEditing {
+		km.BindKey(key, action)
+		return
+	}
+	if km.contextKeys == nil {
+		km.contextKeys = make(map[KeyContext]map[Key]KeyAction)
+	}
+	if km.contextKeys[context] == nil {
+		km.contextKeys[context] = make(map[Key]KeyAction)
+	}
+	km.contextKeys[context][key] = action
+}
+
+// GetKeyActionInContext returns the action bound to a decoded key in the given
+// context: the binding made for the context, or else the one made with
+// BindKey. It returns ActionNone when the key has no binding of its own, even
+// if the rune or sequence it arrives as is bound.
+func (km *KeyMap) GetKeyActionInContext(context KeyContext, key Key) KeyAction {
+	if km == nil {
+		return ActionNone
+	}
+	if action, exists := km.contextKeys[context][key]; exists {
+		return action
+	}
+	return km.GetKeyAction(key)
+}
+
+// GetKeyAction returns the action bound to a decoded key with BindKey, or
+// ActionNone if it is not bound.
+func (km *KeyMap) GetKeyAction(key Key) KeyAction {
+	if km == nil || km.keys == nil {
+		return ActionNone
+	}
+	return km.keys[key]
+}
+
+// runeAction returns the action for the key that arrived as r. Bindings made
+// for the context come first, by Key and then by rune, followed by the ones
+// for every context in the same order.
+func (km *KeyMap) runeAction(context KeyContext, r rune) KeyAction {
+	if km == nil {
+		return ActionNone
+	}
+	key := runeKey(r)
+	if action, exists := km.contextKeys[context][key]; exists {
+		return action
+	}
+	if action, exists := km.contextBindings[context][r]; exists {
+		return action
+	}
+	if action := km.GetKeyAction(key); action != ActionNone {
+		return action
+	}
+	return km.GetAction(r)
+}
+
+// sequenceAction returns the action for the key that arrived as the escape
+// sequence seq, in the same order as runeAction.
+func (km *KeyMap) sequenceAction(context KeyContext, seq string) KeyAction {
+	if km == nil {
+		return ActionNone
+	}
+	key, isKey := decodeSequence(seq)
+	if action, exists := km.contextKeys[context][key]; exists && isKey {
+		return action
+	}
+	if action, exists := km.contextSequences[context][seq]; exists {
+		return action
+	}
+	if action := km.GetKeyAction(key); action != ActionNone && isKey {
+		return action
+	}
+	if action, exists := km.sequences[seq]; exists {
+		return action
+	}
+	return km.patternAction(seq)
+}
+
+// runeKey decodes a key that arrived as a single rune.
+func runeKey(r rune) Key {
+	switch {
+	case r == '\t' || r == '\r' || r == '\x1b' || r == '\x7f':
+		return Key{Code: KeyCode(r)}
+	case r == '\n':
+		return Key{Code: KeyEnter}
+	case r == '\b':
+		return Key{Code: KeyBackspace}
+	case r == 0:
+		return Key{Code: KeySpace, Mod: ModCtrl}
+	case r <= 26:
+		return Key{Code: KeyCode(r + 'a' - 1), Mod: ModCtrl} // Ctrl+A is 0x01
+	case r < 0x20:
+		return Key{Code: KeyCode(r + '@'), Mod: ModCtrl} // Ctrl+\ to Ctrl+_
+	}
+	return Key{Code: KeyCode(r)}
+}
+
+// decodeSequence decodes the escape sequence seq, read after ESC, into the key
+// it stands for. It returns false for sequences that are not keys, such as the
+// bracketed paste markers, and for ones it does not know.
+func decodeSequence(seq string) (Key, bool) {
+	if seq == "" {
+		return Key{}, false
+	}
+	if runes := []rune(seq); len(runes) == 1 {
+		// Alt+key arrives as ESC followed by the key itself
+		key := runeKey(runes[0])
+		key.Mod |= ModAlt
+		return key, true
+	}
+	if len(seq) == 2 && seq[0] == 'O' {
+		// SS3, sent for F1 to F4 and, in application mode, the cursor keys
+		return finalKey(seq[1], 0)
+	}
+	if seq[0] != '[' || len(seq) < 2 {
+		return Key{}, false
+	}
+	if strings.HasPrefix(seq, "[[") {
+		// The Linux console sends "[[A" to "[[E" for F1 to F5
+		if len(seq) == 3 && seq[2] >= 'A' && seq[2] <= 'E' {
+			return Key{Code: KeyF1 + KeyCode(seq[2]-'A')}, true
+		}
+		return Key{}, false
+	}
+
+	final := seq[len(seq)-1]
+	var params [][]string // Parameters split at ';', each split at ':' into sub-parameters
+	if body := seq[1 : len(seq)-1]; body != "" {
+		for _, param := range strings.Split(body, ";") {
+			params = append(params, strings.Split(param, ":"))
+		}
+	}
+	number := func(i, sub int) int {
+		if i >= len(params) || sub >= len(params[i]) {
+			return 0
+		}
+		n, err := strconv.Atoi(params[i][sub])
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+	mod := modifiersParam(number(1, 0))
+
+	switch final {
+	case 'u':
+		// CSI-u: "[code;modifiers u", where code may be followed by the
+		// shifted character as ":shifted", and the modifiers by ":event"
+		code, shifted := number(0, 0), number(0, 1)
+		if !isCharCode(code) {
+			return Key{}, false
+		}
+		if mod&ModShift != 0 && isCharCode(shifted) {
+			code = shifted
+		}
+		return textKey(rune(code), mod), true
+	case '~':
+		if number(0, 0) == 27 {
+			// modifyOtherKeys: "[27;modifiers;code~"
+			code := number(2, 0)
+			if !isCharCode(code) {
+				return Key{}, false
+			}
+			return textKey(rune(code), mod), true
+		}
+		code, ok := tildeKey(number(0, 0))
+		return Key{Code: code, Mod: mod}, ok
+	case 'Z':
+		return Key{Code: KeyTab, Mod: ModShift | mod}, true
+	}
+	return finalKey(final, mod)
+}
+
+// isCharCode reports whether n, a number read from a sequence, is the code of
+// a character, which keeps it from being taken for one of the KeyUp to KeyF12
+// codes above the Unicode range.
+func isCharCode(n int) bool {
+	return n > 0 && n <= unicode.MaxRune && utf8.ValidRune(rune(n))
+}
+
+// modifiersParam decodes the xterm modifier parameter, which is 1 plus 1 for
+// Shift, 2 for Alt and 4 for Ctrl. Meta and the other modifiers are dropped.
+func modifiersParam(n int) Modifiers {
+	if n < 2 {
+		return 0
+	}
+	return Modifiers(n-1) & (ModShift | ModAlt | ModCtrl)
+}
+
+// textKey returns the key of character code typed with mod in an extended
+// encoding. Shift with a letter gives the uppercase letter, and Shift with
+// another character is dropped since the character is already the shifted
+// one.
+func textKey(code rune, mod Modifiers) Key {
+	if mod&ModShift != 0 && code >= 0x20 && code != 0x7f {
+		if unicode.IsLower(code) {
+			code = unicode.ToUpper(code)
+		}
+		if mod&(ModCtrl|ModAlt) == 0 || !unicode.IsLetter(code) {
+			mod &^= ModShift
+		}
+	}
+	if code == '\n' {
+		code = '\r'
+	}
+	return Key{Code: KeyCode(code), Mod: mod}
+}
+
+// finalKey decodes a cursor key or one of F1 to F4 by the final character of
+// its sequence.
+func finalKey(final byte, mod Modifiers) (Key, bool) {
+	var code KeyCode
+	switch final {
+	case 'A':
+		code = KeyUp
+	case 'B':
+		code = KeyDown
+	case 'C':
+		code = KeyRight
+	case 'D':
+		code = KeyLeft
+	case 'H':
+		code = KeyHome
+	case 'F':
+		code = KeyEnd
+	case 'P', 'Q', 'R', 'S':
+		code = KeyF1 + KeyCode(final-'P')
+	default:
+		return Key{}, false
+	}
+	return Key{Code: code, Mod: mod}, true
+}
+
+// tildeKey decodes the number of a "[n~" sequence, the reverse of
+// tildeKeyCode.
+func tildeKey(n int) (KeyCode, bool) {
+	switch n {
+	case 1, 7:
+		return KeyHome, true
+	case 2:
+		return KeyInsert, true
+	case 3:
+		return KeyDelete, true
+	case 4, 8:
+		return KeyEnd, true
+	case 5:
+		return KeyPageUp, true
+	case 6:
+		return KeyPageDown, true
+	case 11, 12, 13, 14:
+		return KeyF1 + KeyCode(n-11), true
+	}
+	// F5 to F12, with the gaps xterm leaves at 16 and 22
+	for i, code := range []int{15, 17, 18, 19, 20, 21, 23, 24} {
+		if n == code {
+			return KeyF5 + KeyCode(i), true
+		}
+	}
+	return 0, false
+}
+
+// isExtendedSequence reports whether seq is a key in one of the extended
+// encodings, CSI-u or modifyOtherKeys, rather than the classic one.
+func isExtendedSequence(seq string) bool {
+	if strings.HasPrefix(seq, "[27;") && strings.HasSuffix(seq, "~") {
+		return true
+	}
+	return strings.HasPrefix(seq, "[") && strings.HasSuffix(seq, "u")
+}
+
+// classicInput returns what a terminal without the extended encodings sends
+// for key: a single rune, or an escape sequence to read after ESC. Modifiers
+// that encoding cannot express are dropped, so Ctrl+Enter becomes Enter. It
+// returns false for keys it cannot express at all, such as Ctrl+1.
+func classicInput(key Key) (r rune, seq string, ok bool) {
+	code := rune(key.Code)
+	switch {
+	case key.Code >= KeyUp:
+		return 0, "", false // Extended encodings leave these keys as they were
+	case key.Code == KeyTab && key.Mod&ModShift != 0 && key.Mod&ModCtrl == 0:
+		return 0, "[Z", true
+	case key.Mod&ModCtrl == 0, key.Code == KeyEnter, key.Code == KeyTab, key.Code == KeyEscape, key.Code == KeyBackspace:
+		r = code
+	case code == ' ' || code == '@':
+		r = 0
+	case code >= 'a' && code <= 'z', code >= '@' && code <= '_':
+		r = code & 0x1f // Ctrl+A is 0x01, and Ctrl+_ is 0x1f
+	case code == '?':
+		r = '\x7f'
+	default:
+		return 0, "", false
+	}
+	if key.Mod&ModAlt != 0 {
+		return 0, string(r), true
+	}
+	return r, "", true
+}
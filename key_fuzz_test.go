@@ -0,0 +1,136 @@
+package prompt
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keyParserCase is a key as a terminal sends it, ESC included, and the key it
+// decodes to.
+type keyParserCase struct {
+	terminal string
+	name     string
+	input    string
+	want     Key
+	ok       bool // false for sequences that are not keys
+}
+
+// keyParserCorpus returns sequences captured from real terminals. They are
+// checked by TestKeyParserCorpus and seed FuzzKeyParser.
+func keyParserCorpus() []keyParserCase {
+	return []keyParserCase{
+		{terminal: "xterm", name: "Up", input: "\x1b[A", want: Key{Code: KeyUp}, ok: true},
+		{terminal: "xterm", name: "Ctrl+Right", input: "\x1b[1;5C", want: Key{Code: KeyRight, Mod: ModCtrl}, ok: true},
+		{terminal: "xterm", name: "Ctrl+Shift+Up", input: "\x1b[1;6A", want: Key{Code: KeyUp, Mod: ModCtrl | ModShift}, ok: true},
+		{terminal: "xterm", name: "Home", input: "\x1b[H", want: Key{Code: KeyHome}, ok: true},
+		{terminal: "xterm", name: "End", input: "\x1b[F", want: Key{Code: KeyEnd}, ok: true},
+		{terminal: "xterm", name: "Delete", input: "\x1b[3~", want: Key{Code: KeyDelete}, ok: true},
+		{terminal: "xterm", name: "F1", input: "\x1bOP", want: Key{Code: KeyF1}, ok: true},
+		{terminal: "xterm", name: "F5", input: "\x1b[15~", want: Key{Code: KeyF5}, ok: true},
+		{terminal: "xterm", name: "Shift+F5", input: "\x1b[15;2~", want: Key{Code: KeyF5, Mod: ModShift}, ok: true},
+		{terminal: "xterm", name: "Shift+Tab", input: "\x1b[Z", want: Key{Code: KeyTab, Mod: ModShift}, ok: true},
+		{terminal: "xterm", name: "Alt+b", input: "\x1bb", want: Key{Code: 'b', Mod: ModAlt}, ok: true},
+		{terminal: "xterm", name: "modifyOtherKeys Ctrl+Enter", input: "\x1b[27;5;13~", want: Key{Code: KeyEnter, Mod: ModCtrl}, ok: true},
+		{terminal: "xterm", name: "bracketed paste start", input: "\x1b[200~", ok: false},
+		{terminal: "kitty", name: "Ctrl+Enter", input: "\x1b[13;5u", want: Key{Code: KeyEnter, Mod: ModCtrl}, ok: true},
+		{terminal: "kitty", name: "Shift+Enter", input: "\x1b[13;2u", want: Key{Code: KeyEnter, Mod: ModShift}, ok: true},
+		{terminal: "kitty", name: "Ctrl+a", input: "\x1b[97;5u", want: Key{Code: 'a', Mod: ModCtrl}, ok: true},
+		{terminal: "kitty", name: "Escape", input: "\x1b[27u", want: Key{Code: KeyEscape}, ok: true},
+		{terminal: "kitty", name: "Ctrl+i apart from Tab", input: "\x1b[105;5u", want: Key{Code: 'i', Mod: ModCtrl}, ok: true},
+		{terminal: "kitty", name: "shifted key", input: "\x1b[49:33;2u", want: Key{Code: '!'}, ok: true},
+		{terminal: "kitty", name: "press event", input: "\x1b[97;5:1u", want: Key{Code: 'a', Mod: ModCtrl}, ok: true},
+		{terminal: "kitty", name: "code past Unicode", input: "\x1b[1114112u", ok: false},
+		{terminal: "tmux", name: "Up in application mode", input: "\x1bOA", want: Key{Code: KeyUp}, ok: true},
+		{terminal: "tmux", name: "Home", input: "\x1b[1~", want: Key{Code: KeyHome}, ok: true},
+		{terminal: "tmux", name: "End", input: "\x1b[4~", want: Key{Code: KeyEnd}, ok: true},
+		{terminal: "tmux", name: "Ctrl+Left", input: "\x1b[1;5D", want: Key{Code: KeyLeft, Mod: ModCtrl}, ok: true},
+		{terminal: "tmux", name: "focus in", input: "\x1b[I", ok: false},
+		{terminal: "Windows Terminal", name: "Alt+Enter", input: "\x1b\r", want: Key{Code: KeyEnter, Mod: ModAlt}, ok: true},
+		{terminal: "Windows Terminal", name: "Ctrl+Delete", input: "\x1b[3;5~", want: Key{Code: KeyDelete, Mod: ModCtrl}, ok: true},
+		{terminal: "Windows Terminal", name: "F12", input: "\x1b[24~", want: Key{Code: KeyF12}, ok: true},
+		{terminal: "Windows Terminal", name: "win32-input-mode record", input: "\x1b[65;30;97;1;0;1_", ok: false},
+		{terminal: "Linux console", name: "F1", input: "\x1b[[A", want: Key{Code: KeyF1}, ok: true},
+		{terminal: "Linux console", name: "F5", input: "\x1b[[E", want: Key{Code: KeyF5}, ok: true},
+	}
+}
+
+// readKeys reads input the way the event loop does and returns the escape
+// sequences in it, each read after its ESC.
+func readKeys(t *testing.T, input string) []string {
+	t.Helper()
+	p := newForTestingWithConfig(t, Config{Prefix: "> "}, input)
+	var seqs []string
+	for {
+		r, err := p.readRune()
+		if err != nil {
+			return seqs
+		}
+		if r != '\x1b' {
+			continue
+		}
+		seq, err := p.readEscapeSequence()
+		if err != nil {
+			return seqs
+		}
+		seqs = append(seqs, seq)
+	}
+}
+
+func TestKeyParserCorpus(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range keyParserCorpus() {
+		t.Run(tt.terminal+" "+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			seqs := readKeys(t, tt.input)
+			require.Len(t, seqs, 1, "read as one sequence")
+			key, ok := decodeSequence(seqs[0])
+
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, key)
+			}
+		})
+	}
+}
+
+// FuzzKeyParser feeds arbitrary input to the escape sequence reader and
+// decoder, then to a whole prompt, which must neither panic nor hang. Run it
+// with go test -fuzz=FuzzKeyParser.
+func FuzzKeyParser(f *testing.F) {
+	for _, tt := range keyParserCorpus() {
+		f.Add(tt.input)
+	}
+	f.Add("\x1b[" + strings.Repeat("9", 40) + "u")
+	f.Add("\x1b[27;;~\x1b[;;;u\x1b[:::A")
+	f.Add("ab\x1b[1;5\x1b[B\x1b")
+	f.Add("\x1b[\xff\xfe~\x1bO")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		maxLen := Limits{}.withDefaults().EscapeSequenceLen
+		for _, seq := range readKeys(t, input) {
+			if n := utf8.RuneCountInString(seq); n > maxLen {
+				t.Fatalf("sequence %q is %d keys long, more than %d", seq, n, maxLen)
+			}
+			key, ok := decodeSequence(seq)
+			if !ok {
+				continue
+			}
+			if key.Code > KeyF12 || (key.Code < KeyUp && !utf8.ValidRune(rune(key.Code))) {
+				t.Fatalf("sequence %q decodes to invalid key code %d", seq, key.Code)
+			}
+			classicInput(key)
+			_ = key.String()
+		}
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, input)
+		p.renderer.output = io.Discard
+		_, _ = p.Run()
+	})
+}
@@ -0,0 +1,283 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSequence(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		seq  string
+		want Key
+		ok   bool
+	}{
+		{name: "Alt+letter", seq: "b", want: Key{Code: 'b', Mod: ModAlt}, ok: true},
+		{name: "Alt+Enter", seq: "\r", want: Key{Code: KeyEnter, Mod: ModAlt}, ok: true},
+		{name: "Alt+Ctrl+letter", seq: "\x02", want: Key{Code: 'b', Mod: ModAlt | ModCtrl}, ok: true},
+		{name: "cursor key", seq: "[A", want: Key{Code: KeyUp}, ok: true},
+		{name: "cursor key in application mode", seq: "OD", want: Key{Code: KeyLeft}, ok: true},
+		{name: "Ctrl+Right", seq: "[1;5C", want: Key{Code: KeyRight, Mod: ModCtrl}, ok: true},
+		{name: "Shift+Alt+Down", seq: "[1;4B", want: Key{Code: KeyDown, Mod: ModShift | ModAlt}, ok: true},
+		{name: "F1", seq: "OP", want: Key{Code: KeyF1}, ok: true},
+		{name: "Shift+F4", seq: "[1;2S", want: Key{Code: KeyF4, Mod: ModShift}, ok: true},
+		{name: "Delete", seq: "[3~", want: Key{Code: KeyDelete}, ok: true},
+		{name: "Ctrl+PageDown", seq: "[6;5~", want: Key{Code: KeyPageDown, Mod: ModCtrl}, ok: true},
+		{name: "F6", seq: "[17~", want: Key{Code: KeyF6}, ok: true},
+		{name: "F12", seq: "[24~", want: Key{Code: KeyF12}, ok: true},
+		{name: "Shift+Tab", seq: "[Z", want: Key{Code: KeyTab, Mod: ModShift}, ok: true},
+		{name: "CSI-u Ctrl+letter", seq: "[97;5u", want: Key{Code: 'a', Mod: ModCtrl}, ok: true},
+		{name: "CSI-u Shift+Enter", seq: "[13;2u", want: Key{Code: KeyEnter, Mod: ModShift}, ok: true},
+		{name: "CSI-u Escape", seq: "[27u", want: Key{Code: KeyEscape}, ok: true},
+		{name: "CSI-u shifted key", seq: "[49:33;2u", want: Key{Code: '!'}, ok: true},
+		{name: "CSI-u Ctrl+Shift+letter", seq: "[97;6u", want: Key{Code: 'A', Mod: ModCtrl | ModShift}, ok: true},
+		{name: "CSI-u event type is ignored", seq: "[97;5:1u", want: Key{Code: 'a', Mod: ModCtrl}, ok: true},
+		{name: "CSI-u Meta is dropped", seq: "[97;37u", want: Key{Code: 'a', Mod: ModCtrl}, ok: true},
+		{name: "modifyOtherKeys Ctrl+Enter", seq: "[27;5;13~", want: Key{Code: KeyEnter, Mod: ModCtrl}, ok: true},
+		{name: "modifyOtherKeys Shift+letter", seq: "[27;2;65~", want: Key{Code: 'A'}, ok: true},
+		{name: "bracketed paste is not a key", seq: "[200~", ok: false},
+		{name: "unknown final", seq: "[5X", ok: false},
+		{name: "empty", seq: "", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			key, ok := decodeSequence(tt.seq)
+
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, key)
+			}
+		})
+	}
+}
+
+func TestRuneKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Key{Code: 'x'}, runeKey('x'))
+	assert.Equal(t, Key{Code: 'a', Mod: ModCtrl}, runeKey('\x01'))
+	assert.Equal(t, Key{Code: '_', Mod: ModCtrl}, runeKey('\x1f'))
+	assert.Equal(t, Key{Code: KeySpace, Mod: ModCtrl}, runeKey(0))
+	assert.Equal(t, Key{Code: KeyEnter}, runeKey('\n'))
+	assert.Equal(t, Key{Code: KeyTab}, runeKey('\t'))
+	assert.Equal(t, Key{Code: KeyBackspace}, runeKey('\b'))
+}
+
+func TestClassicInput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		key     Key
+		wantR   rune
+		wantSeq string
+		ok      bool
+	}{
+		{name: "character", key: Key{Code: 'é'}, wantR: 'é', ok: true},
+		{name: "Ctrl+letter", key: Key{Code: 'w', Mod: ModCtrl}, wantR: '\x17', ok: true},
+		{name: "Ctrl+Shift+letter", key: Key{Code: 'W', Mod: ModCtrl | ModShift}, wantR: '\x17', ok: true},
+		{name: "Ctrl+Enter drops Ctrl", key: Key{Code: KeyEnter, Mod: ModCtrl}, wantR: '\r', ok: true},
+		{name: "Shift+Enter drops Shift", key: Key{Code: KeyEnter, Mod: ModShift}, wantR: '\r', ok: true},
+		{name: "Alt+letter is a sequence", key: Key{Code: 'y', Mod: ModAlt}, wantSeq: "y", ok: true},
+		{name: "Shift+Tab", key: Key{Code: KeyTab, Mod: ModShift}, wantSeq: "[Z", ok: true},
+		{name: "Ctrl+digit has no classic form", key: Key{Code: '1', Mod: ModCtrl}, ok: false},
+		{name: "cursor keys keep their sequences", key: Key{Code: KeyUp, Mod: ModCtrl}, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, seq, ok := classicInput(tt.key)
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.wantR, r)
+			assert.Equal(t, tt.wantSeq, seq)
+		})
+	}
+}
+
+func TestBindKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "a classic sequence finds the Key binding", input: "abc\x1b[1;3D!\r", want: "!abc"},
+		{name: "a Key binding overrides the rune binding", input: "ab\x05!\r", want: "!ab"},
+		{name: "CSI-u Shift+Enter is bound apart from Enter", input: "a\x1b[13;2ub\r", want: "a\nb"},
+		{name: "modifyOtherKeys Ctrl+Enter falls back to Enter", input: "ab\x1b[27;5;13~", want: "ab"},
+		{name: "unbound CSI-u Ctrl+letter runs the rune binding", input: "abc\x1b[97;5ux\r", want: "xabc"},
+		{name: "unbound CSI-u Alt+letter runs the sequence binding", input: "ab\x19\x1b[121;3u\r", want: "ab"},
+		{name: "unbound CSI-u character is typed", input: "a\x1b[98ub\r", want: "abb"},
+		{name: "rune bindings keep working", input: "ab\x01x\r", want: "xab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			keyMap := NewDefaultKeyMap()
+			keyMap.BindKey(Key{Code: KeyLeft, Mod: ModAlt}, ActionMoveHome)
+			keyMap.BindKey(Key{Code: 'e', Mod: ModCtrl}, ActionMoveHome)
+			keyMap.BindKey(Key{Code: KeyEnter, Mod: ModShift}, ActionNewLine)
+			p, err := New("> ", WithTerminal(newMockTerminal(tt.input)), WithOutput(&bytes.Buffer{}), WithKeyMap(keyMap))
+			require.NoError(t, err)
+			defer p.Close()
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestBindKeyInContext(t *testing.T) {
+	t.Parallel()
+
+	keyMap := NewDefaultKeyMap()
+	keyMap.BindKey(Key{Code: KeyF2}, ActionMoveHome)
+	keyMap.BindKeyInContext(KeyContextMenu, Key{Code: KeyF2}, ActionMenuLast)
+	keyMap.BindKeyInContext(KeyContextEditing, Key{Code: KeyF3}, ActionMoveEnd)
+
+	assert.Equal(t, ActionMoveHome, keyMap.GetKeyActionInContext(KeyContextEditing, Key{Code: KeyF2}))
+	assert.Equal(t, ActionMenuLast, keyMap.GetKeyActionInContext(KeyContextMenu, Key{Code: KeyF2}))
+	assert.Equal(t, ActionMoveEnd, keyMap.GetKeyActionInContext(KeyContextMenu, Key{Code: KeyF3}))
+	assert.Equal(t, ActionNone, keyMap.GetKeyAction(Key{Code: KeyF4}))
+
+	var nilMap *KeyMap
+	assert.Equal(t, ActionNone, nilMap.GetKeyActionInContext(KeyContextEditing, Key{Code: KeyF2}))
+}
+
+func TestExtendedKeysMode(t *testing.T) {
+	t.Parallel()
+
+	t.Run("is requested and reset around Run", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		p, err := New("> ", WithTerminal(newMockTerminal("\r")), WithOutput(&out), WithExtendedKeys(true))
+		require.NoError(t, err)
+		defer p.Close()
+
+		_, err = p.Run()
+
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), extendedKeysEnableSequence)
+		assert.Contains(t, out.String(), extendedKeysDisableSequence)
+	})
+
+	t.Run("is not requested by default", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		p, err := New("> ", WithTerminal(newMockTerminal("\r")), WithOutput(&out))
+		require.NoError(t, err)
+		defer p.Close()
+
+		_, err = p.Run()
+
+		require.NoError(t, err)
+		assert.NotContains(t, out.String(), extendedKeysEnableSequence)
+	})
+}
+
+func TestBindFunc(t *testing.T) {
+	t.Parallel()
+
+	t.Run("the handler edits through the controller", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		keyMap.BindFunc(Key{Code: KeyF5}, func(c *PromptController) error {
+			c.SetText(strings.ToUpper(c.Text()))
+			c.SetPrefix("$ ")
+			return nil
+		})
+		keyMap.BindFunc(Key{Code: 't', Mod: ModCtrl}, func(c *PromptController) error {
+			c.SetCursorPosition(0)
+			return nil
+		})
+		var out bytes.Buffer
+		p, err := New("> ", WithTerminal(newMockTerminal("ab\x1b[15~c\x14!\r")), WithOutput(&out), WithKeyMap(keyMap))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "!ABc", result)
+		assert.Contains(t, out.String(), "$ ")
+	})
+
+	t.Run("the changes are undone together", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		keyMap.BindFunc(Key{Code: KeyF2}, func(c *PromptController) error {
+			c.InsertText("one ")
+			c.InsertText("two")
+			return nil
+		})
+		p, err := New("> ", WithTerminal(newMockTerminal("x \x1bOQ\x1f\r")), WithOutput(&bytes.Buffer{}), WithKeyMap(keyMap))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "x ", result)
+	})
+
+	t.Run("an error ends Run", func(t *testing.T) {
+		t.Parallel()
+
+		errRefresh := errors.New("refresh failed")
+		keyMap := NewDefaultKeyMap()
+		keyMap.BindFunc(Key{Code: KeyF5}, func(*PromptController) error { return errRefresh })
+		p, err := New("> ", WithTerminal(newMockTerminal("ab\x1b[15~\r")), WithOutput(&bytes.Buffer{}), WithKeyMap(keyMap))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.ErrorIs(t, err, errRefresh)
+		assert.Empty(t, result)
+	})
+
+	t.Run("BindKey replaces the handler", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		keyMap.BindFunc(Key{Code: 'e', Mod: ModCtrl}, func(*PromptController) error { return errors.New("not called") })
+		keyMap.BindKey(Key{Code: 'e', Mod: ModCtrl}, ActionMoveHome)
+		p, err := New("> ", WithTerminal(newMockTerminal("ab\x05!\r")), WithOutput(&bytes.Buffer{}), WithKeyMap(keyMap))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "!ab", result)
+	})
+
+	t.Run("is listed by DumpBindings", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		keyMap.BindFunc(Key{Code: KeyF5}, func(*PromptController) error { return nil })
+
+		assert.Contains(t, keyMap.DumpBindings(), KeyBinding{Name: "f5", Action: actionFunc})
+		assert.Equal(t, "func", actionFunc.String())
+	})
+}
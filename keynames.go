@@ -0,0 +1,332 @@
+package prompt
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalidKeyName is returned by ParseKey and BindName for a key name they
+// cannot read.
+var ErrInvalidKeyName = errors.New("invalid key name")
+
+// ErrInvalidActionName is returned by ParseKeyAction for a name that is not
+// the name of a KeyAction.
+var ErrInvalidActionName = errors.New("invalid action name")
+
+// ParseKey reads a key name such as "ctrl+w", "alt+b", "ctrl+alt+left",
+// "shift+tab" or "f5", as found in a configuration file. A name is any number
+// of modifiers, "ctrl", "alt" (or "meta") and "shift", followed by the key,
+// all joined with "+" and in any case. The key is a single character, such as
+// "a" or "/", or one of up, down, left, right, home, end, insert, delete,
+// pageup, pagedown, tab, enter, esc, space, backspace, plus and f1 to f12.
+// Shift with a letter names the uppercase letter; other shifted characters
+// are named by the character they type, such as "!" rather than "shift+1".
+//
+// The returned error wraps ErrInvalidKeyName and says what is wrong.
+func ParseKey(name string) (Key, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(name)), "+")
+	if n := len(parts); n >= 2 && parts[n-1] == "" && parts[n-2] == "" {
+		parts = append(parts[:n-2], "+") // "ctrl++" is Ctrl and the + key
+	}
+
+	var mod Modifiers
+	for _, part := range parts[:len(parts)-1] {
+		var m Modifiers
+		switch part {
+		case "ctrl", "control":
+			m = ModCtrl
+		case "alt", "meta":
+			m = ModAlt
+		case "shift":
+			m = ModShift
+		default:
+			return Key{}, fmt.Errorf("%w: %q: unknown modifier %q", ErrInvalidKeyName, name, part)
+		}
+		if mod&m != 0 {
+			return Key{}, fmt.Errorf("%w: %q: %s is given twice", ErrInvalidKeyName, name, part)
+		}
+		mod |= m
+	}
+
+	last := parts[len(parts)-1]
+	code, ok := parseKeyCode(last)
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %q: unknown key %q", ErrInvalidKeyName, name, last)
+	}
+	if code < KeyUp && code >= KeySpace && code != KeyBackspace {
+		if mod&ModShift != 0 && !unicode.IsLetter(rune(code)) {
+			return Key{}, fmt.Errorf("%w: %q: name the character shift types instead", ErrInvalidKeyName, name)
+		}
+		return textKey(rune(code), mod), nil
+	}
+	return Key{Code: code, Mod: mod}, nil
+}
+
+// parseKeyCode returns the code of the lowercase key name s.
+func parseKeyCode(s string) (KeyCode, bool) {
+	if r, size := utf8.DecodeRuneInString(s); size == len(s) && r != utf8.RuneError && unicode.IsPrint(r) && r != ' ' {
+		return KeyCode(r), true
+	}
+	codes := []KeyCode{KeyTab, KeyEnter, KeyEscape, KeySpace, KeyBackspace, '+'}
+	for code := KeyUp; code <= KeyF12; code++ {
+		codes = append(codes, code)
+	}
+	for _, code := range codes {
+		if keyCodeName(code) == s {
+			return code, true
+		}
+	}
+	switch s {
+	case "return":
+		return KeyEnter, true
+	case "escape":
+		return KeyEscape, true
+	case "del":
+		return KeyDelete, true
+	case "ins":
+		return KeyInsert, true
+	case "pgup":
+		return KeyPageUp, true
+	case "pgdn":
+		return KeyPageDown, true
+	}
+	return 0, false
+}
+
+// keyCodeName returns the name ParseKey reads for code.
+func keyCodeName(code KeyCode) string {
+	switch code {
+	case KeyUp:
+		return "up"
+	case KeyDown:
+		return "down"
+	case KeyRight:
+		return "right"
+	case KeyLeft:
+		return "left"
+	case KeyHome:
+		return "home"
+	case KeyEnd:
+		return "end"
+	case KeyInsert:
+		return "insert"
+	case KeyDelete:
+		return "delete"
+	case KeyPageUp:
+		return "pageup"
+	case KeyPageDown:
+		return "pagedown"
+	case KeyTab:
+		return "tab"
+	case KeyEnter:
+		return "enter"
+	case KeyEscape:
+		return "esc"
+	case KeySpace:
+		return "space"
+	case KeyBackspace:
+		return "backspace"
+	case '+':
+		return "plus"
+	}
+	if code >= KeyF1 && code <= KeyF12 {
+		return "f" + strconv.Itoa(int(code-KeyF1)+1)
+	}
+	if code < ' ' {
+		return fmt.Sprintf("U+%04X", rune(code))
+	}
+	return string(rune(code))
+}
+
+// String returns the name of the key in the form ParseKey reads, with the
+// modifiers in the order ctrl, alt, shift, such as "ctrl+alt+left" or
+// "shift+a".
+func (k Key) String() string {
+	code, mod := k.Code, k.Mod
+	if unicode.IsUpper(rune(code)) {
+		code, mod = KeyCode(unicode.ToLower(rune(code))), mod|ModShift
+	}
+	var b strings.Builder
+	for _, m := range []struct {
+		mod  Modifiers
+		name string
+	}{{ModCtrl, "ctrl+"}, {ModAlt, "alt+"}, {ModShift, "shift+"}} {
+		if mod&m.mod != 0 {
+			b.WriteString(m.name)
+		}
+	}
+	b.WriteString(keyCodeName(code))
+	return b.String()
+}
+
+// BindName binds the key named name, in the form ParseKey reads, to action.
+// It makes applications able to take their key bindings from a configuration
+// file. The binding is made with BindKey, so it takes precedence over Bind and
+// BindSequence bindings of the same key. The returned error wraps
+// ErrInvalidKeyName, and nothing is bound, when the name cannot be read.
+//
+// Example:
+//
+//	keyMap := prompt.NewDefaultKeyMap()
+//	for name, action := range config.Keys { // e.g. "ctrl+w": "delete-word-back"
+//		a, err := prompt.ParseKeyAction(action)
+//		if err != nil {
+//			return err
+//		}
+//		if err := keyMap.BindName(name, a); err != nil {
+//			return err
+//		}
+//	}
+func (km *KeyMap) BindName(name string, action KeyAction) error {
+	key, err := ParseKey(name)
+	if err != nil {
+		return err
+	}
+	km.BindKey(key, action)
+	return nil
+}
+
+// keyActionNames returns the names of the actions, indexed by KeyAction.
+func keyActionNames() []string {
+	return []string{
+		ActionNone:               "none",
+		ActionSubmit:             "submit",
+		ActionCancel:             "cancel",
+		ActionMoveLeft:           "move-left",
+		ActionMoveRight:          "move-right",
+		ActionMoveUp:             "move-up",
+		ActionMoveDown:           "move-down",
+		ActionMoveHome:           "move-home",
+		ActionMoveEnd:            "move-end",
+		ActionMoveWordLeft:       "move-word-left",
+		ActionMoveWordRight:      "move-word-right",
+		ActionDeleteChar:         "delete-char",
+		ActionDeleteLine:         "delete-line",
+		ActionDeleteToEnd:        "delete-to-end",
+		ActionDeleteWordBack:     "delete-word-back",
+		ActionComplete:           "complete",
+		ActionHistoryUp:          "history-up",
+		ActionHistoryDown:        "history-down",
+		ActionHistorySearch:      "history-search",
+		ActionNewLine:            "new-line",
+		ActionPasteStart:         "paste-start",
+		ActionPasteEnd:           "paste-end",
+		ActionClearScreen:        "clear-screen",
+		ActionYank:               "yank",
+		ActionYankPop:            "yank-pop",
+		ActionUndo:               "undo",
+		ActionRedo:               "redo",
+		ActionMenuFirst:          "menu-first",
+		ActionMenuLast:           "menu-last",
+		ActionToggleMultiline:    "toggle-multiline",
+		ActionHistoryPrefixUp:    "history-prefix-up",
+		ActionHistoryPrefixDown:  "history-prefix-down",
+		ActionToggleDescriptions: "toggle-descriptions",
+		ActionMenuPageUp:         "menu-page-up",
+		ActionMenuPageDown:       "menu-page-down",
+		ActionPreview:            "preview",
+		ActionDeleteWordForward:  "delete-word-forward",
+		ActionUpcaseWord:         "upcase-word",
+		ActionDowncaseWord:       "downcase-word",
+		ActionCapitalizeWord:     "capitalize-word",
+		ActionTransposeChars:     "transpose-chars",
+		ActionTransposeWords:     "transpose-words",
+		ActionDigitArgument:      "digit-argument",
+		ActionAbort:              "abort",
+		ActionSuspend:            "suspend",
+	}
+}
+
+// String returns the name of the action, its constant name in kebab case
+// without "Action", such as "delete-word-back" for ActionDeleteWordBack.
+func (a KeyAction) String() string {
+	if a == actionFunc {
+		return "func" // Bound with BindFunc
+	}
+	if names := keyActionNames(); a >= 0 && int(a) < len(names) {
+		return names[a]
+	}
+	return "KeyAction(" + strconv.Itoa(int(a)) + ")"
+}
+
+// ParseKeyAction returns the action named name, as KeyAction.String returns
+// it. The returned error wraps ErrInvalidActionName for other names.
+func ParseKeyAction(name string) (KeyAction, error) {
+	if i := slices.Index(keyActionNames(), strings.ToLower(strings.TrimSpace(name))); i >= 0 {
+		return KeyAction(i), nil
+	}
+	return ActionNone, fmt.Errorf("%w: %q", ErrInvalidActionName, name)
+}
+
+// DumpBindings lists the bindings of the key map, for example to show them on
+// a help screen or to check a configuration. Every binding made with Bind,
+// BindSequence, BindKey and their InContext variants is listed with the name
+// of its key, sorted by context and name; BindSequencePattern bindings are
+// listed in the editing context with the pattern as Seq. A binding hidden by a
+// BindKey binding of the same key in the same context, and a key that arrives
+// in two forms bound to the same action, such as Enter as "\r" and "\n", are
+// listed once. Bindings to ActionNone are left out.
+func (km *KeyMap) DumpBindings() []KeyBinding {
+	if km == nil {
+		return nil
+	}
+	var bindings []KeyBinding
+	add := func(context KeyContext, runes map[rune]KeyAction, sequences map[string]KeyAction, keys map[Key]KeyAction) {
+		for key, action := range keys {
+			bindings = append(bindings, KeyBinding{Name: key.String(), Context: context, Action: action})
+		}
+		for r, action := range runes {
+			if _, hidden := keys[runeKey(r)]; !hidden {
+				bindings = append(bindings, KeyBinding{Key: r, Name: runeKey(r).String(), Context: context, Action: action})
+			}
+		}
+		for seq, action := range sequences {
+			key, isKey := decodeSequence(seq)
+			if _, hidden := keys[key]; hidden && isKey {
+				continue
+			}
+			binding := KeyBinding{Seq: seq, Context: context, Action: action}
+			if isKey {
+				binding.Name = key.String()
+			}
+			bindings = append(bindings, binding)
+		}
+	}
+	add(KeyContextEditing, km.bindings, km.sequences, km.keys)
+	contexts := make(map[KeyContext]bool)
+	for context := range km.contextBindings {
+		contexts[context] = true
+	}
+	for context := range km.contextSequences {
+		contexts[context] = true
+	}
+	for context := range km.contextKeys {
+		contexts[context] = true
+	}
+	for context := range contexts {
+		add(context, km.contextBindings[context], km.contextSequences[context], km.contextKeys[context])
+	}
+	// Patterns apply while editing and are sorted in with its other bindings
+	for _, p := range km.patterns {
+		bindings = append(bindings, KeyBinding{Seq: p.pattern, Context: KeyContextEditing, Action: p.action})
+	}
+
+	bindings = slices.DeleteFunc(bindings, func(b KeyBinding) bool { return b.Action == ActionNone })
+	slices.SortFunc(bindings, func(a, b KeyBinding) int {
+		return cmp.Or(
+			cmp.Compare(a.Context, b.Context),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Seq, b.Seq),
+			cmp.Compare(a.Key, b.Key),
+		)
+	})
+	return slices.CompactFunc(bindings, func(a, b KeyBinding) bool {
+		return a.Name != "" && a.Name == b.Name && a.Context == b.Context && a.Action == b.Action
+	})
+}
@@ -0,0 +1,218 @@
+package prompt
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want Key
+	}{
+		{name: "ctrl+w", want: Key{Code: 'w', Mod: ModCtrl}},
+		{name: "Ctrl+W", want: Key{Code: 'w', Mod: ModCtrl}},
+		{name: "alt+b", want: Key{Code: 'b', Mod: ModAlt}},
+		{name: "meta+b", want: Key{Code: 'b', Mod: ModAlt}},
+		{name: "ctrl+alt+left", want: Key{Code: KeyLeft, Mod: ModCtrl | ModAlt}},
+		{name: "alt+ctrl+left", want: Key{Code: KeyLeft, Mod: ModCtrl | ModAlt}},
+		{name: "shift+tab", want: Key{Code: KeyTab, Mod: ModShift}},
+		{name: "shift+a", want: Key{Code: 'A'}},
+		{name: "ctrl+shift+a", want: Key{Code: 'A', Mod: ModCtrl | ModShift}},
+		{name: "shift+enter", want: Key{Code: KeyEnter, Mod: ModShift}},
+		{name: " f5 ", want: Key{Code: KeyF5}},
+		{name: "f12", want: Key{Code: KeyF12}},
+		{name: "pgdn", want: Key{Code: KeyPageDown}},
+		{name: "escape", want: Key{Code: KeyEscape}},
+		{name: "ctrl+space", want: Key{Code: KeySpace, Mod: ModCtrl}},
+		{name: "/", want: Key{Code: '/'}},
+		{name: "alt+/", want: Key{Code: '/', Mod: ModAlt}},
+		{name: "ctrl++", want: Key{Code: '+', Mod: ModCtrl}},
+		{name: "ctrl+plus", want: Key{Code: '+', Mod: ModCtrl}},
+		{name: "é", want: Key{Code: 'é'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			key, err := ParseKey(tt.name)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, key)
+		})
+	}
+}
+
+func TestParseKeyErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "", wantErr: `invalid key name: "": unknown key ""`},
+		{name: "ctrl+", wantErr: `invalid key name: "ctrl+": unknown key ""`},
+		{name: "hyper+a", wantErr: `invalid key name: "hyper+a": unknown modifier "hyper"`},
+		{name: "ctrl+ctrl+a", wantErr: `invalid key name: "ctrl+ctrl+a": ctrl is given twice`},
+		{name: "ctrl+foo", wantErr: `invalid key name: "ctrl+foo": unknown key "foo"`},
+		{name: "f13", wantErr: `invalid key name: "f13": unknown key "f13"`},
+		{name: "shift+1", wantErr: `invalid key name: "shift+1": name the character shift types instead`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseKey(tt.name)
+
+			require.ErrorIs(t, err, ErrInvalidKeyName)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestKeyString(t *testing.T) {
+	t.Parallel()
+
+	keys := []Key{
+		{Code: 'w', Mod: ModCtrl},
+		{Code: KeyLeft, Mod: ModCtrl | ModAlt},
+		{Code: KeyTab, Mod: ModShift},
+		{Code: 'A'},
+		{Code: 'A', Mod: ModCtrl | ModShift},
+		{Code: KeyF7},
+		{Code: '+', Mod: ModAlt},
+		{Code: KeySpace, Mod: ModCtrl},
+	}
+	for _, key := range keys {
+		t.Run(key.String(), func(t *testing.T) {
+			t.Parallel()
+
+			parsed, err := ParseKey(key.String())
+
+			require.NoError(t, err)
+			assert.Equal(t, key, parsed, "the name reads back as the same key")
+		})
+	}
+	assert.Equal(t, "ctrl+alt+left", Key{Code: KeyLeft, Mod: ModCtrl | ModAlt}.String())
+	assert.Equal(t, "shift+a", Key{Code: 'A'}.String())
+}
+
+func TestKeyActionNames(t *testing.T) {
+	t.Parallel()
+
+	for action := ActionNone; action <= ActionSuspend; action++ {
+		name := action.String()
+		assert.NotContains(t, name, "KeyAction(", "every action has a name")
+		parsed, err := ParseKeyAction(name)
+		require.NoError(t, err)
+		assert.Equal(t, action, parsed)
+	}
+	assert.Equal(t, "delete-word-back", ActionDeleteWordBack.String())
+	assert.Equal(t, "func", actionFunc.String())
+	assert.Equal(t, "KeyAction(-2)", KeyAction(-2).String())
+
+	action, err := ParseKeyAction(" Move-Home ")
+	require.NoError(t, err)
+	assert.Equal(t, ActionMoveHome, action)
+
+	_, err = ParseKeyAction("fly")
+	require.ErrorIs(t, err, ErrInvalidActionName)
+}
+
+func TestBindName(t *testing.T) {
+	t.Parallel()
+
+	t.Run("binds by name", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		require.NoError(t, keyMap.BindName("ctrl+w", ActionMoveHome))
+		require.NoError(t, keyMap.BindName("alt+left", ActionMoveEnd))
+		p, err := New("> ", WithTerminal(newMockTerminal("abc\x17!\x1b[1;3D?\r")), WithOutput(&bytes.Buffer{}), WithKeyMap(keyMap))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "!abc?", result)
+	})
+
+	t.Run("an invalid name binds nothing", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		before := keyMap.DumpBindings()
+
+		err := keyMap.BindName("ctrl+nope", ActionMoveHome)
+
+		require.ErrorIs(t, err, ErrInvalidKeyName)
+		assert.Equal(t, before, keyMap.DumpBindings())
+	})
+
+	t.Run("a context rune binding beats a Key binding for every context", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		require.NoError(t, keyMap.BindName("ctrl+e", ActionMoveHome))
+		keyMap.BindInContext(KeyContextMenu, '\x05', ActionMenuLast)
+
+		assert.Equal(t, ActionMenuLast, keyMap.runeAction(KeyContextMenu, '\x05'))
+		assert.Equal(t, ActionMoveHome, keyMap.runeAction(KeyContextEditing, '\x05'))
+	})
+}
+
+func TestDumpBindings(t *testing.T) {
+	t.Parallel()
+
+	keyMap := NewDefaultKeyMap()
+	require.NoError(t, keyMap.BindName("ctrl+w", ActionMoveHome))
+	keyMap.BindSequence("[99~", ActionYank)
+	keyMap.Bind('\x0f', ActionNone)
+	keyMap.BindSequencePattern("[25;*~", ActionComplete)
+	keyMap.BindSequencePattern("[24;*~", ActionComplete)
+	keyMap.BindSequencePattern("[23;*~", ActionNone)
+
+	bindings := keyMap.DumpBindings()
+
+	find := func(context KeyContext, name string) []KeyBinding {
+		var found []KeyBinding
+		for _, b := range bindings {
+			if b.Context == context && b.Name == name {
+				found = append(found, b)
+			}
+		}
+		return found
+	}
+	assert.Equal(t, []KeyBinding{{Name: "ctrl+w", Action: ActionMoveHome}}, find(KeyContextEditing, "ctrl+w"), "the Key binding hides the rune binding")
+	if enter := find(KeyContextEditing, "enter"); assert.Len(t, enter, 1, "Enter is listed once") {
+		assert.Equal(t, ActionSubmit, enter[0].Action)
+	}
+	assert.Equal(t, []KeyBinding{{Seq: "[A", Name: "up", Action: ActionMoveUp}}, find(KeyContextEditing, "up"))
+	if home := find(KeyContextMenu, "home"); assert.Len(t, home, 1, "Home is listed once for all of its sequences") {
+		assert.Equal(t, ActionMenuFirst, home[0].Action)
+	}
+	assert.Contains(t, find(KeyContextEditing, ""), KeyBinding{Seq: "[99~", Action: ActionYank}, "unknown sequences are listed without a name")
+	assert.Empty(t, find(KeyContextEditing, "ctrl+o"), "ActionNone is left out")
+	var patterns []string
+	for _, b := range find(KeyContextEditing, "") {
+		if strings.Contains(b.Seq, "*") {
+			patterns = append(patterns, b.Seq)
+		}
+	}
+	assert.Equal(t, []string{"[24;*~", "[25;*~"}, patterns, "patterns are listed once each, sorted, without ActionNone")
+	assert.True(t, slices.IsSortedFunc(bindings, func(a, b KeyBinding) int {
+		return cmp.Or(cmp.Compare(a.Context, b.Context), cmp.Compare(a.Name, b.Name), cmp.Compare(a.Seq, b.Seq))
+	}), "sorted by context, name and sequence")
+
+	var nilMap *KeyMap
+	assert.Nil(t, nilMap.DumpBindings())
+}
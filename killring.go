@@ -0,0 +1,93 @@
+package prompt
+
+import "slices"
+
+// maxKillRingSize is the number of killed texts the kill ring keeps.
+const maxKillRingSize = 60
+
+// yankState remembers where the last yank put its text, so that a following
+// yank-pop can replace it with an older kill.
+type yankState struct {
+	start int // Buffer position of the yanked text
+	end   int // Buffer position just past the yanked text
+	index int // Kill ring entry that was yanked
+}
+
+// KillRing returns the texts deleted with the kill commands (Ctrl+K, Ctrl+U and
+// Ctrl+W), most recent first. Ctrl+Y yanks the first entry and Alt+Y replaces
+// it with the next one.
+func (p *Prompt) KillRing() []string {
+	return slices.Clone(p.killRing)
+}
+
+// SetKillRing replaces the kill ring, most recent first, for example to carry
+// it over from another prompt or to pre-seed it with text the user is likely
+// to paste. Empty entries are dropped and only the first 60 entries are kept.
+func (p *Prompt) SetKillRing(entries []string) {
+	p.killRing = nil
+	for _, entry := range entries {
+		if entry != "" && len(p.killRing) < maxKillRingSize {
+			p.killRing = append(p.killRing, entry)
+		}
+	}
+}
+
+// kill deletes buffer[start:end] and saves the text on the kill ring. When the
+// previous command was also a kill, the text joins the most recent entry
+// instead, in front of it for backward kills, so that several Ctrl+W presses
+// in a row yank back as one piece like in Emacs and readline.
+func (p *Prompt) kill(start, end int, appendToLast bool) {
+	if start >= end {
+		return
+	}
+	text := string(p.buffer[start:end])
+	p.buffer = append(p.buffer[:start], p.buffer[end:]...)
+	backward := end <= p.cursor
+	p.cursor = start
+
+	if appendToLast && len(p.killRing) > 0 {
+		if backward {
+			p.killRing[0] = text + p.killRing[0]
+		} else {
+			p.killRing[0] += text
+		}
+		return
+	}
+	p.killRing = slices.Insert(p.killRing, 0, text)
+	if len(p.killRing) > maxKillRingSize {
+		p.killRing = p.killRing[:maxKillRingSize]
+	}
+}
+
+// yank inserts the most recent kill at the cursor.
+func (p *Prompt) yank() {
+	if len(p.killRing) == 0 {
+		return
+	}
+	start := p.cursor
+	p.insertText(p.killRing[0])
+	p.lastYank = yankState{start: start, end: p.cursor, index: 0}
+}
+
+// yankPop replaces the text inserted by the previous yank or yank-pop with the
+// next older kill, wrapping around at the end of the ring. It does nothing
+// unless the previous command was a yank.
+func (p *Prompt) yankPop() {
+	if len(p.killRing) == 0 || p.lastYank.end > len(p.buffer) {
+		return
+	}
+	index := (p.lastYank.index + 1) % len(p.killRing)
+	text := []rune(p.killRing[index])
+	p.buffer = slices.Replace(p.buffer, p.lastYank.start, p.lastYank.end, text...)
+	p.cursor = p.lastYank.start + len(text)
+	p.lastYank = yankState{start: p.lastYank.start, end: p.cursor, index: index}
+}
+
+// isKillAction reports whether action saves deleted text on the kill ring.
+func isKillAction(action KeyAction) bool {
+	switch action {
+	case ActionDeleteLine, ActionDeleteToEnd, ActionDeleteWordBack, ActionDeleteWordForward:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKillRing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		want     string
+		wantRing []string
+	}{
+		{name: "ctrl+k kills to the end and ctrl+y yanks it back", input: "hello world\x01\x0b\x19\r", want: "hello world", wantRing: []string{"hello world"}},
+		{name: "ctrl+w kills a word that can be yanked elsewhere", input: "foo bar\x17\x01\x19\r", want: "barfoo ", wantRing: []string{"bar"}},
+		{name: "consecutive ctrl+w kills join into one entry", input: "one two three\x17\x17\x19\r", want: "one two three", wantRing: []string{"two three"}},
+		{name: "ctrl+u kills the whole line", input: "hello\x15\x19\x19\r", want: "hellohello", wantRing: []string{"hello"}},
+		{name: "separate kills are separate entries", input: "aaa\x15bbb\x15\r", want: "", wantRing: []string{"bbb", "aaa"}},
+		{name: "alt+y replaces the yank with an older kill", input: "aaa\x15bbb\x15\x19\x1by\r", want: "aaa", wantRing: []string{"bbb", "aaa"}},
+		{name: "alt+y wraps around the ring", input: "aaa\x15bbb\x15\x19\x1by\x1by\r", want: "bbb", wantRing: []string{"bbb", "aaa"}},
+		{name: "alt+y without a yank before it does nothing", input: "aaa\x15x\x1by\r", want: "x", wantRing: []string{"aaa"}},
+		{name: "ctrl+y with an empty ring does nothing", input: "abc\x19\r", want: "abc"},
+		{name: "alt+d kills the word after the cursor", input: "foo bar\x01\x1bd\x05\x19\r", want: " barfoo", wantRing: []string{"foo"}},
+		{name: "consecutive alt+d kills join into one entry", input: "one two three\x01\x1bd\x1bd\r", want: " three", wantRing: []string{"one two"}},
+		{name: "alt+backspace kills the word before the cursor", input: "foo bar\x1b\x7f\r", want: "foo ", wantRing: []string{"bar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{Prefix: "$ "}, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+			assert.Equal(t, tt.wantRing, p.KillRing())
+		})
+	}
+
+	t.Run("SetKillRing pre-seeds the ring for yanking", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "$ "}, "\x19\x1by\r")
+		p.renderer.output = &bytes.Buffer{}
+		p.SetKillRing([]string{"newest", "", "oldest"})
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "oldest", result)
+		assert.Equal(t, []string{"newest", "oldest"}, p.KillRing())
+	})
+
+	t.Run("KillRing returns a copy", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{}, "")
+		p.SetKillRing([]string{"a"})
+		p.KillRing()[0] = "changed"
+
+		assert.Equal(t, []string{"a"}, p.KillRing())
+	})
+
+	t.Run("the ring keeps at most 60 entries", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{}, "")
+		for range maxKillRingSize + 5 {
+			p.setBuffer("x")
+			p.kill(0, 1, false)
+		}
+
+		assert.Len(t, p.KillRing(), maxKillRingSize)
+	})
+}
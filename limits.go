@@ -0,0 +1,60 @@
+package prompt
+
+// Defaults of the Limits fields, used for the fields left at zero.
+const (
+	// DefaultMenuRows is how many suggestions the menu shows at once.
+	DefaultMenuRows = 10
+	// DefaultEscapeSequenceLen is how many keys of an escape sequence are kept.
+	// The rest of a longer sequence is read and dropped.
+	DefaultEscapeSequenceLen = 16
+	// DefaultSearchResults is how many matches reverse history search lists.
+	DefaultSearchResults = 5
+	// DefaultHistoryEntries is how many history entries are kept in memory when
+	// HistoryConfig.MaxEntries is not set.
+	DefaultHistoryEntries = 1000
+)
+
+// Limits collects the sizes the prompt works with. Fields left at zero use
+// the Default constants above, so a Limits only needs the fields it changes.
+type Limits struct {
+	MenuRows          int // Suggestions shown in the menu at once, fewer on a short terminal
+	EscapeSequenceLen int // Keys of an escape sequence kept; the rest of a longer one is read and dropped
+	SearchResults     int // Matches listed below the input by reverse history search
+	HistoryEntries    int // History entries kept in memory when HistoryConfig.MaxEntries is 0
+}
+
+// WithLimits overrides the built-in limits. The suggestion menu and the key
+// handling read the same values, so paging moves by the rows that are drawn.
+// WithMaxSuggestions sets Limits.MenuRows alone.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithLimits(prompt.Limits{MenuRows: 5, SearchResults: 10}))
+func WithLimits(limits Limits) Option {
+	return func(c *Config) {
+		c.Limits = limits
+	}
+}
+
+// withDefaults returns l with every field that is not set replaced by its
+// default.
+func (l Limits) withDefaults() Limits {
+	if l.MenuRows <= 0 {
+		l.MenuRows = DefaultMenuRows
+	}
+	if l.EscapeSequenceLen <= 0 {
+		l.EscapeSequenceLen = DefaultEscapeSequenceLen
+	}
+	if l.SearchResults <= 0 {
+		l.SearchResults = DefaultSearchResults
+	}
+	if l.HistoryEntries <= 0 {
+		l.HistoryEntries = DefaultHistoryEntries
+	}
+	return l
+}
+
+// limits returns the limits of the prompt with the defaults filled in.
+func (p *Prompt) limits() Limits {
+	return p.config.Limits.withDefaults()
+}
@@ -0,0 +1,69 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+
+	t.Run("fields left at zero use the defaults", func(t *testing.T) {
+		t.Parallel()
+
+		got := Limits{SearchResults: 8}.withDefaults()
+
+		assert.Equal(t, Limits{
+			MenuRows:          DefaultMenuRows,
+			EscapeSequenceLen: DefaultEscapeSequenceLen,
+			SearchResults:     8,
+			HistoryEntries:    DefaultHistoryEntries,
+		}, got)
+	})
+
+	t.Run("the menu draws and pages by MenuRows", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{}
+		WithLimits(Limits{MenuRows: 4})(&config)
+		p := newForTestingWithConfig(t, config, "")
+
+		assert.Equal(t, 4, p.renderer.menuRows())
+	})
+
+	t.Run("HistoryEntries bounds a history without MaxEntries", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := New("$ ",
+			WithTerminal(newMockTerminal("")),
+			WithOutput(&bytes.Buffer{}),
+			WithLimits(Limits{HistoryEntries: 2}),
+			WithMemoryHistory(0),
+		)
+		require.NoError(t, err)
+		defer p.Close()
+
+		for _, entry := range []string{"ls", "pwd", "date"} {
+			p.AddHistory(entry)
+		}
+
+		assert.Equal(t, []string{"pwd", "date"}, p.GetHistory())
+	})
+
+	t.Run("reverse search lists SearchResults matches", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Limits: Limits{SearchResults: 2}}
+		p := newForTestingWithConfig(t, config, "")
+		var output bytes.Buffer
+		p.renderer.output = &output
+
+		require.NoError(t, p.renderHistorySearch("g", []string{"git push", "git pull", "go test"}, 0))
+
+		assert.Contains(t, output.String(), "it pull")
+		assert.NotContains(t, output.String(), "o test")
+	})
+}
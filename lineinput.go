@@ -0,0 +1,126 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// lineTerminal is the Terminal of a prompt reading non-interactive input: the
+// reader given with WithInput, or standard input when no terminal can be
+// opened. It has no raw mode and no size of its own, and it reads the input
+// one byte at a time, so nothing after the line Run returns is consumed and
+// the application can read the rest.
+type lineTerminal struct {
+	input   io.Reader
+	pending chan lineRead // Line read left in flight by a cancelled Run, nil when none
+}
+
+// lineInput returns the line terminal reading config.Input, or nil when Input
+// is not set and the prompt should read keys from a terminal.
+func lineInput(config Config) Terminal {
+	if config.Input != nil {
+		return &lineTerminal{input: config.Input}
+	}
+	return nil
+}
+
+// controllingOrLines returns the controlling terminal opened by open. When
+// there is none to open, as in a CI job, under cron or in a container started
+// without a TTY, it falls back to reading the lines of standard input. Piped
+// standard input alone does not make the prompt read lines, since open uses
+// /dev/tty.
+func controllingOrLines(open func() (Terminal, error)) Terminal {
+	terminal, err := open()
+	if err != nil {
+		return &lineTerminal{input: os.Stdin}
+	}
+	return terminal
+}
+
+func (t *lineTerminal) SetRaw() error  { return nil }
+func (t *lineTerminal) Restore() error { return nil }
+
+// Size reports the usual default, since there is no window to measure.
+func (t *lineTerminal) Size() (width, height int, err error) {
+	return 80, 24, nil
+}
+
+// ReadRune reads one UTF-8 encoded character, for widgets such as the palette
+// that read keys. Bytes that do not form one are returned as utf8.RuneError.
+func (t *lineTerminal) ReadRune() (rune, int, error) {
+	var buf [utf8.UTFMax]byte
+	n := 0
+	for n < len(buf) && !utf8.FullRune(buf[:n]) {
+		if _, err := io.ReadFull(t.input, buf[n:n+1]); err != nil {
+			if n > 0 {
+				break // Input ended within a character
+			}
+			return 0, 0, err
+		}
+		n++
+	}
+	r, size := utf8.DecodeRune(buf[:n])
+	return r, size, nil
+}
+
+// Close leaves the input open: it belongs to the application, or is standard
+// input.
+func (t *lineTerminal) Close() error { return nil }
+
+// readLine reads the next line with readLineFrom, as ReadLine does. The read
+// runs in its own goroutine so ctx can end the wait; a read left in flight is
+// kept, and its line is returned by the next call, from any prompt sharing the
+// terminal.
+func (t *lineTerminal) readLine(ctx context.Context) (string, error) {
+	if t.pending == nil {
+		t.pending = startLineRead(t.input)
+	}
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case read := <-t.pending:
+		t.pending = nil
+		return read.line, read.err
+	}
+}
+
+// lines returns the line terminal the prompt reads, on its own or through a
+// session, or nil when it reads keys from a terminal.
+func (p *Prompt) lines() *lineTerminal {
+	terminal := p.terminal
+	if shared, ok := terminal.(sessionTerminal); ok {
+		terminal = shared.Terminal
+	}
+	lines, _ := terminal.(*lineTerminal)
+	return lines
+}
+
+// readsLines reports whether the prompt reads non-interactive input.
+func (p *Prompt) readsLines() bool {
+	return p.lines() != nil
+}
+
+// runLines implements RunWithContext for non-interactive input: it reads the
+// next line and returns it as Enter would submit it, without drawing anything.
+func (p *Prompt) runLines(ctx context.Context) (string, error) {
+	line, err := p.lines().readLine(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	p.buffer = []rune(line)
+	p.cursor = len(p.buffer)
+	result, exit := p.submission()
+	if exit {
+		return result, ErrExit
+	}
+	if p.config.Validator != nil {
+		if err := p.config.Validator(result); err != nil {
+			return "", fmt.Errorf("invalid input %q: %w", result, err)
+		}
+	}
+	return result, nil
+}
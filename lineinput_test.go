@@ -0,0 +1,188 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineInput(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reads one line per call without drawing", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		p, err := New("$ ", WithInput(strings.NewReader("ls -l\r\n\ngit status")), WithOutput(&out), WithCompleter(func(Document) []Suggestion {
+			return []Suggestion{{Text: "ls"}}
+		}))
+		require.NoError(t, err)
+
+		for _, want := range []string{"ls -l", "", "git status"} {
+			line, err := p.Run()
+			require.NoError(t, err)
+			assert.Equal(t, want, line)
+		}
+		_, err = p.Run()
+		require.ErrorIs(t, err, ErrEOF)
+
+		p.Busy("running")()
+		require.NoError(t, p.Close())
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("leaves the rest of the input unread", func(t *testing.T) {
+		t.Parallel()
+
+		input := strings.NewReader("first\nsecond\n")
+		p, err := New("$ ", WithInput(input), WithOutput(io.Discard))
+		require.NoError(t, err)
+		defer p.Close()
+
+		line, err := p.Run()
+		require.NoError(t, err)
+		assert.Equal(t, "first", line)
+
+		rest, err := io.ReadAll(input)
+		require.NoError(t, err)
+		assert.Equal(t, "second\n", string(rest))
+	})
+
+	t.Run("submits the line as enter does", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := New("$ ",
+			WithInput(strings.NewReader("  Select 1  \nbad\nexit\n")),
+			WithOutput(io.Discard),
+			WithMemoryHistory(10),
+			WithTrimSpace(true),
+			WithInputSanitizer(strings.ToLower),
+			WithExitChecker(func(input string, breakline bool) bool { return breakline && input == "exit" }),
+			WithValidator(func(input string) error {
+				if input == "bad" {
+					return errors.New("not allowed")
+				}
+				return nil
+			}),
+		)
+		require.NoError(t, err)
+		defer p.Close()
+
+		line, err := p.Run()
+		require.NoError(t, err)
+		assert.Equal(t, "select 1", line)
+
+		_, err = p.Run()
+		require.EqualError(t, err, `invalid input "bad": not allowed`)
+
+		line, err = p.Run()
+		require.ErrorIs(t, err, ErrExit)
+		assert.Equal(t, "exit", line)
+
+		assert.Empty(t, p.GetHistory(), "lines read from a script are not recorded")
+	})
+
+	t.Run("a cancelled read is picked up by the next run", func(t *testing.T) {
+		t.Parallel()
+
+		r, w := io.Pipe()
+		p, err := New("$ ", WithInput(r), WithOutput(io.Discard))
+		require.NoError(t, err)
+		defer p.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = p.RunWithContext(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+
+		go func() {
+			_, _ = io.WriteString(w, "after\n")
+		}()
+		line, err := p.Run()
+		require.NoError(t, err)
+		assert.Equal(t, "after", line)
+	})
+
+	t.Run("prompts of a session share the input", func(t *testing.T) {
+		t.Parallel()
+
+		session, err := NewSession(WithInput(strings.NewReader("one\ntwo\n")), WithOutput(io.Discard))
+		require.NoError(t, err)
+		defer session.Close()
+		first, err := session.Prompt(Config{Prefix: "a> "})
+		require.NoError(t, err)
+		second, err := session.Prompt(Config{Prefix: "b> "})
+		require.NoError(t, err)
+
+		line, err := first.Run()
+		require.NoError(t, err)
+		assert.Equal(t, "one", line)
+		line, err = second.Run()
+		require.NoError(t, err)
+		assert.Equal(t, "two", line)
+	})
+
+	t.Run("a read cancelled by one prompt of a session is picked up by the next", func(t *testing.T) {
+		t.Parallel()
+
+		r, w := io.Pipe()
+		session, err := NewSession(WithInput(r), WithOutput(io.Discard))
+		require.NoError(t, err)
+		defer session.Close()
+		first, err := session.Prompt(Config{Prefix: "a> "})
+		require.NoError(t, err)
+		second, err := session.Prompt(Config{Prefix: "b> "})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = first.RunWithContext(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+
+		go func() {
+			_, _ = io.WriteString(w, "after\n")
+		}()
+		line, err := second.Run()
+		require.NoError(t, err)
+		assert.Equal(t, "after", line)
+	})
+
+	t.Run("falls back to standard input only when no terminal can be opened", func(t *testing.T) {
+		t.Parallel()
+
+		terminal := newMockTerminal("")
+		opened := controllingOrLines(func() (Terminal, error) { return terminal, nil })
+		assert.Same(t, terminal, opened, "a terminal that opens is used even when standard input is piped")
+
+		fallback := controllingOrLines(func() (Terminal, error) { return nil, errors.New("no such device") })
+		if lines, ok := fallback.(*lineTerminal); assert.True(t, ok) {
+			assert.Equal(t, os.Stdin, lines.input)
+		}
+	})
+
+	t.Run("decodes utf-8 a byte at a time", func(t *testing.T) {
+		t.Parallel()
+
+		terminal := &lineTerminal{input: strings.NewReader("日\xff")}
+
+		r, size, err := terminal.ReadRune()
+		require.NoError(t, err)
+		assert.Equal(t, '日', r)
+		assert.Equal(t, 3, size)
+
+		r, _, err = terminal.ReadRune()
+		require.NoError(t, err)
+		assert.Equal(t, utf8.RuneError, r)
+
+		_, _, err = terminal.ReadRune()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+}
@@ -0,0 +1,34 @@
+package prompt
+
+// menuPosition is the selection and scroll offset of the menu for one word.
+type menuPosition struct {
+	suggestions []Suggestion // Menu the position was taken in
+	selected    int
+	offset      int
+}
+
+// menuMemory remembers where the menu was scrolled to for each word before
+// the cursor, so that typing a character and deleting it again brings back
+// the position instead of resetting the menu to the top. It only lasts while
+// the menu stays open.
+type menuMemory map[string]menuPosition
+
+// save records the position of the open menu for word.
+func (m menuMemory) save(word string, suggestions []Suggestion, selected, offset int) {
+	m[word] = menuPosition{suggestions: suggestions, selected: selected, offset: offset}
+}
+
+// restore returns the position saved for word if the menu saved with it had
+// the same suggestions. ok is false when there is nothing to restore.
+func (m menuMemory) restore(word string, suggestions []Suggestion) (selected, offset int, ok bool) {
+	pos, found := m[word]
+	if !found || len(pos.suggestions) != len(suggestions) {
+		return 0, 0, false
+	}
+	for i, suggestion := range suggestions {
+		if pos.suggestions[i].Text != suggestion.Text {
+			return 0, 0, false
+		}
+	}
+	return pos.selected, pos.offset, true
+}
@@ -0,0 +1,59 @@
+package prompt
+
+// WithMaxSuggestions sets how many suggestions the menu shows at once. The
+// default is 10. On a terminal too short for that many rows the menu is
+// shortened to fit below the input. When there are more suggestions than
+// rows, the menu scrolls as the selection moves, PageUp and PageDown move a
+// page at a time, and a dimmed "12/43" row below the menu tells where the
+// selection is (see MsgMenuPosition). It sets Limits.MenuRows.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithMaxSuggestions(5))
+func WithMaxSuggestions(n int) Option {
+	return func(c *Config) {
+		c.Limits.MenuRows = n
+	}
+}
+
+// menuRows returns how many suggestions one page of the menu holds: maxRows,
+// or DefaultMenuRows when it is not set, reduced so that the input row, the
+// page, the position row and the footer fit in the terminal and in the
+// reserved rows. It is at least 1.
+func (r *renderer) menuRows() int {
+	rows := r.maxRows
+	if rows <= 0 {
+		rows = DefaultMenuRows
+	}
+	if r.terminal != nil {
+		if _, height, err := r.terminal.Size(); err == nil && height > 0 {
+			rows = min(rows, height-2-len(r.footer))
+		}
+	}
+	if r.reservedRows > 0 {
+		rows = min(rows, r.reservedRows-2-len(r.footer))
+	}
+	return max(rows, 1)
+}
+
+// pageMenu moves the selection by delta suggestions and scrolls the menu by
+// the same amount, as PageUp and PageDown do, and returns the new selection
+// and offset. Both stay within the n suggestions of the menu.
+func pageMenu(selected, offset, delta, n, rows int) (int, int) {
+	selected = max(0, min(selected+delta, n-1))
+	offset = max(0, min(offset+delta, n-rows))
+	return selected, scrollMenu(selected, offset, rows)
+}
+
+// scrollMenu returns the offset of the page of rows suggestions that shows
+// selected, scrolling as little as possible from offset.
+func scrollMenu(selected, offset, rows int) int {
+	switch {
+	case selected < offset:
+		return selected
+	case selected >= offset+rows:
+		return selected - rows + 1
+	default:
+		return offset
+	}
+}
@@ -0,0 +1,138 @@
+package prompt
+
+// MessageID identifies a user-visible string drawn by the library, so that it
+// can be replaced with WithMessages or WithPaletteMessages.
+//
+// Some messages are format strings for fmt.Sprintf; their documentation lists
+// the arguments, and a translation must use the same verbs in the same order.
+type MessageID int
+
+const (
+	// MsgHistorySearch is the label in front of the query during reverse
+	// history search (Ctrl+R). Default: "reverse-i-search: ".
+	MsgHistorySearch MessageID = iota
+	// MsgHistorySearchMatch introduces the selected match after the query
+	// during reverse history search. Default: " -> ".
+	MsgHistorySearchMatch
+	// MsgHistoryPosition is the indicator shown by WithHistoryIndicator, a
+	// format string receiving the position and the number of entries.
+	// Default: "[%d/%d]".
+	MsgHistoryPosition
+	// MsgViInsertMode is shown in front of the prefix in vi insert mode.
+	// Default: "(ins) ".
+	MsgViInsertMode
+	// MsgViNormalMode is shown in front of the prefix in vi normal mode.
+	// Default: "(cmd) ".
+	MsgViNormalMode
+	// MsgInterrupted is echoed when Ctrl+C cancels the input. Default: "^C".
+	MsgInterrupted
+	// MsgPaletteCount is the match counter of the command palette, a format
+	// string receiving the number of matches and the number of items.
+	// Default: "%d/%d".
+	MsgPaletteCount
+	// MsgPaletteNoMatches is shown in the command palette when nothing matches
+	// the query. Default: "no matches".
+	MsgPaletteNoMatches
+	// MsgCompletionLoading is the menu row shown while an async completer is
+	// running. Default: "loading…".
+	MsgCompletionLoading
+	// MsgCompletionMinChars is the menu row shown when Tab is pressed before
+	// the word is as long as WithMinCompletionChars requires, a format string
+	// receiving the minimum. Default: "type %d+ chars to search".
+	MsgCompletionMinChars
+	// MsgHistorySearchCount is the match counter of reverse history search, a
+	// format string receiving the position of the selected match and the
+	// number of matches. Default: "[%d/%d]".
+	MsgHistorySearchCount
+	// MsgMenuPosition is drawn below the suggestion menu when it has more
+	// suggestions than rows, a format string receiving the position of the
+	// selected suggestion and the number of suggestions. Default: "%d/%d".
+	MsgMenuPosition
+	// MsgPreviewStatus is the status line of the Alt+Enter input preview, a
+	// format string receiving the first and last line shown and the number of
+	// lines. Default: "lines %d-%d of %d (q to return)".
+	MsgPreviewStatus
+	// MsgUnclosed is drawn below the input when WithBalanceCheck keeps it from
+	// being submitted because a quote or bracket is not closed, a format
+	// string receiving the opening character and the one missing to close it.
+	// Default: "unclosed %c: missing %c".
+	MsgUnclosed
+	// MsgUnmatchedClose is drawn below the input when WithBalanceCheck keeps
+	// it from being submitted because of a closing bracket without a match, a
+	// format string receiving the bracket. Default: "unmatched %c".
+	MsgUnmatchedClose
+	// MsgNumericArgument is drawn below the input while a numeric argument is
+	// typed with Alt+digits, a format string receiving the argument.
+	// Default: "(arg: %d)".
+	MsgNumericArgument
+	// MsgInvalidInput is drawn below the input when the Validator rejects it
+	// with an error whose message is empty. Default: "invalid input".
+	MsgInvalidInput
+)
+
+// defaultMessage returns the built-in English text for id.
+func defaultMessage(id MessageID) string {
+	switch id {
+	case MsgHistorySearch:
+		return "reverse-i-search: "
+	case MsgHistorySearchMatch:
+		return " -> "
+	case MsgHistoryPosition:
+		return "[%d/%d]"
+	case MsgViInsertMode:
+		return "(ins) "
+	case MsgViNormalMode:
+		return "(cmd) "
+	case MsgInterrupted:
+		return "^C"
+	case MsgPaletteCount:
+		return "%d/%d"
+	case MsgPaletteNoMatches:
+		return "no matches"
+	case MsgCompletionLoading:
+		return "loading…"
+	case MsgCompletionMinChars:
+		return "type %d+ chars to search"
+	case MsgHistorySearchCount:
+		return "[%d/%d]"
+	case MsgMenuPosition:
+		return "%d/%d"
+	case MsgPreviewStatus:
+		return "lines %d-%d of %d (q to return)"
+	case MsgUnclosed:
+		return "unclosed %c: missing %c"
+	case MsgUnmatchedClose:
+		return "unmatched %c"
+	case MsgNumericArgument:
+		return "(arg: %d)"
+	case MsgInvalidInput:
+		return "invalid input"
+	default:
+		return ""
+	}
+}
+
+// WithMessages replaces built-in user-visible strings, for example to
+// localize them. Messages missing from the map keep their default English
+// text. See MessageID for the available messages and their defaults.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithMessages(map[prompt.MessageID]string{
+//		prompt.MsgHistorySearch: "historique : ",
+//		prompt.MsgInterrupted:   "^C (annulé)",
+//	}))
+func WithMessages(messages map[MessageID]string) Option {
+	return func(c *Config) {
+		c.Messages = messages
+	}
+}
+
+// message returns the text for id from the configured messages, falling back
+// to the built-in default.
+func (p *Prompt) message(id MessageID) string {
+	if text, ok := p.config.Messages[id]; ok {
+		return text
+	}
+	return defaultMessage(id)
+}
@@ -0,0 +1,99 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessages(t *testing.T) {
+	t.Parallel()
+
+	t.Run("every message has a default", func(t *testing.T) {
+		t.Parallel()
+
+		for id := MsgHistorySearch; id <= MsgInvalidInput; id++ {
+			assert.NotEmpty(t, defaultMessage(id), "message %d", id)
+		}
+	})
+
+	t.Run("configured messages replace the defaults and missing ones fall back", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{}
+		WithMessages(map[MessageID]string{MsgHistorySearch: "historique : "})(&config)
+		p := newForTestingWithConfig(t, config, "")
+
+		assert.Equal(t, "historique : ", p.message(MsgHistorySearch))
+		assert.Equal(t, " -> ", p.message(MsgHistorySearchMatch))
+	})
+
+	t.Run("history search uses the configured labels", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Messages: map[MessageID]string{MsgHistorySearch: "historique : ", MsgHistorySearchMatch: " => "}}
+		p := newForTestingWithConfig(t, config, "")
+		var output bytes.Buffer
+		p.renderer.output = &output
+
+		require.NoError(t, p.renderHistorySearch("gi", []string{"git status"}, 0))
+
+		assert.Contains(t, removeANSICodes(output.String()), "historique : gi => git status")
+		assert.NotContains(t, output.String(), "reverse-i-search")
+	})
+
+	t.Run("interrupt echo uses the configured text", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Messages: map[MessageID]string{MsgInterrupted: "^C (annulé)"}}
+		p := newForTestingWithConfig(t, config, "ls\x03")
+		var output bytes.Buffer
+		p.output = &output
+		p.renderer.output = &bytes.Buffer{}
+
+		_, err := p.Run()
+
+		require.ErrorIs(t, err, ErrInterrupted)
+		assert.Contains(t, output.String(), "^C (annulé)\r\n")
+	})
+
+	t.Run("vi mode indicators and the history position use the configured text", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{
+			Prefix:           "$ ",
+			EditMode:         EditModeVi,
+			HistoryIndicator: true,
+			Messages: map[MessageID]string{
+				MsgViInsertMode:    "[E] ",
+				MsgViNormalMode:    "[N] ",
+				MsgHistoryPosition: "(%d de %d)",
+			},
+		}
+		p := newForTestingWithConfig(t, config, "")
+		p.history = []string{"ls"}
+		p.setBuffer("ls")
+
+		assert.Equal(t, "[E] $ ", p.prefix())
+		p.viNormal = true
+		assert.Equal(t, "[N] $ ", p.prefix())
+		assert.Equal(t, "(1 de 1)", p.historyIndicator())
+	})
+
+	t.Run("palette counter and empty result use the configured text", func(t *testing.T) {
+		t.Parallel()
+
+		items := []Suggestion{{Text: "git status"}}
+		messages := map[MessageID]string{MsgPaletteCount: "%d sur %d", MsgPaletteNoMatches: "aucun résultat"}
+		var out bytes.Buffer
+
+		_, err := runPalette(newMockTerminal("zzz\x03"), &out, items, WithPaletteMessages(messages))
+
+		require.ErrorIs(t, err, ErrInterrupted)
+		assert.Contains(t, out.String(), "1 sur 1")
+		assert.Contains(t, out.String(), "0 sur 1  aucun résultat")
+		assert.NotContains(t, out.String(), "no matches")
+	})
+}
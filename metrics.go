@@ -0,0 +1,185 @@
+package prompt
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// metricsWindow is the number of recent frames RenderMetrics computes
+// percentiles over.
+const metricsWindow = 1024
+
+// FrameStats describes one frame drawn by the prompt.
+type FrameStats struct {
+	RenderDuration time.Duration // Time spent drawing the frame
+	BytesWritten   int           // Bytes written to the output for the frame (0 when it was the same as the last one)
+	// InputLatency is the time from reading the key that caused the frame to
+	// the end of drawing it. It is 0 for frames not caused by a key, such as
+	// redraws after a resize or when async suggestions arrive.
+	InputLatency time.Duration
+}
+
+// WithFrameStats calls observe after every frame the prompt draws, for
+// tracking its responsiveness. observe runs on the event loop and must return
+// quickly; RenderMetrics.Observe aggregates the frames into percentiles.
+//
+// Example:
+//
+//	metrics := prompt.NewRenderMetrics()
+//	expvar.Publish("prompt", metrics)
+//	p, err := prompt.New("$ ", prompt.WithFrameStats(metrics.Observe))
+func WithFrameStats(observe func(FrameStats)) Option {
+	return func(c *Config) {
+		c.FrameStats = observe
+	}
+}
+
+// RenderMetrics aggregates FrameStats into totals and latency percentiles over
+// the most recent 1024 frames. It is safe for concurrent use, so a metrics
+// endpoint can read it while a prompt is running. RenderMetrics implements
+// expvar.Var: String returns the current snapshot as JSON.
+type RenderMetrics struct {
+	mu      sync.Mutex
+	frames  int64
+	bytes   int64
+	render  []time.Duration // Render durations of recent frames, oldest overwritten first
+	latency []time.Duration // Input latencies of recent key-driven frames, oldest overwritten first
+	next    int             // Next slot of render to overwrite once it is full
+	nextKey int             // Next slot of latency to overwrite once it is full
+}
+
+// RenderMetricsSnapshot is a point-in-time copy of RenderMetrics. Durations
+// are in nanoseconds when encoded as JSON.
+type RenderMetricsSnapshot struct {
+	Frames           int64         `json:"frames"`             // Frames drawn in total
+	BytesWritten     int64         `json:"bytes_written"`      // Bytes written in total
+	RenderP50        time.Duration `json:"render_p50"`         // Median render duration
+	RenderP90        time.Duration `json:"render_p90"`         // 90th percentile render duration
+	RenderP99        time.Duration `json:"render_p99"`         // 99th percentile render duration
+	InputLatencyP50  time.Duration `json:"input_latency_p50"`  // Median key-to-frame latency
+	InputLatencyP90  time.Duration `json:"input_latency_p90"`  // 90th percentile key-to-frame latency
+	InputLatencyP99  time.Duration `json:"input_latency_p99"`  // 99th percentile key-to-frame latency
+	InputLatencyMax  time.Duration `json:"input_latency_max"`  // Slowest key-to-frame latency among recent frames
+	RecentFrameCount int           `json:"recent_frame_count"` // Frames the percentiles are computed over
+}
+
+// NewRenderMetrics returns an empty RenderMetrics.
+func NewRenderMetrics() *RenderMetrics {
+	return &RenderMetrics{}
+}
+
+// Observe records one frame. Pass it to WithFrameStats.
+func (m *RenderMetrics) Observe(stats FrameStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.frames++
+	m.bytes += int64(stats.BytesWritten)
+	m.render, m.next = appendWindow(m.render, m.next, stats.RenderDuration)
+	if stats.InputLatency > 0 {
+		m.latency, m.nextKey = appendWindow(m.latency, m.nextKey, stats.InputLatency)
+	}
+}
+
+// Snapshot returns the current totals and percentiles.
+func (m *RenderMetrics) Snapshot() RenderMetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	render := slices.Clone(m.render)
+	latency := slices.Clone(m.latency)
+	slices.Sort(render)
+	slices.Sort(latency)
+	snapshot := RenderMetricsSnapshot{
+		Frames:           m.frames,
+		BytesWritten:     m.bytes,
+		RenderP50:        percentile(render, 50),
+		RenderP90:        percentile(render, 90),
+		RenderP99:        percentile(render, 99),
+		InputLatencyP50:  percentile(latency, 50),
+		InputLatencyP90:  percentile(latency, 90),
+		InputLatencyP99:  percentile(latency, 99),
+		RecentFrameCount: len(render),
+	}
+	if len(latency) > 0 {
+		snapshot.InputLatencyMax = latency[len(latency)-1]
+	}
+	return snapshot
+}
+
+// String returns the snapshot as JSON, which makes RenderMetrics an expvar.Var.
+func (m *RenderMetrics) String() string {
+	data, err := json.Marshal(m.Snapshot())
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// appendWindow adds d to a window of at most metricsWindow samples, replacing
+// the oldest one once the window is full, and returns the window and the slot
+// to replace next.
+func appendWindow(window []time.Duration, next int, d time.Duration) ([]time.Duration, int) {
+	if len(window) < metricsWindow {
+		return append(window, d), next
+	}
+	window[next] = d
+	return window, (next + 1) % metricsWindow
+}
+
+// percentile returns the p-th percentile of sorted samples (nearest rank), or
+// 0 when there are none.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	return sorted[max(rank, 1)-1]
+}
+
+// countingWriter counts the bytes written through it, for FrameStats.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// frameStart returns the time and output byte count at the start of a frame,
+// to be passed to observeFrame when it is done.
+func (p *Prompt) frameStart() (time.Time, int64) {
+	if p.config.FrameStats == nil {
+		return time.Time{}, 0
+	}
+	var written int64
+	if counter, ok := p.output.(*countingWriter); ok {
+		written = counter.n
+	}
+	return time.Now(), written
+}
+
+// observeFrame reports the frame started at start to the FrameStats callback.
+// The latency is measured from the last key read, which is then cleared so
+// later frames without a key report none.
+func (p *Prompt) observeFrame(start time.Time, written int64) {
+	if p.config.FrameStats == nil {
+		return
+	}
+	now := time.Now()
+	stats := FrameStats{RenderDuration: now.Sub(start)}
+	if counter, ok := p.output.(*countingWriter); ok {
+		stats.BytesWritten = int(counter.n - written)
+	}
+	if !p.keyReadAt.IsZero() {
+		stats.InputLatency = now.Sub(p.keyReadAt)
+		p.keyReadAt = time.Time{}
+	}
+	p.config.FrameStats(stats)
+}
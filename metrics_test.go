@@ -0,0 +1,98 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMetrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty metrics report zeros", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, RenderMetricsSnapshot{}, NewRenderMetrics().Snapshot())
+	})
+
+	t.Run("totals and nearest-rank percentiles", func(t *testing.T) {
+		t.Parallel()
+
+		m := NewRenderMetrics()
+		for i := 1; i <= 100; i++ {
+			m.Observe(FrameStats{RenderDuration: time.Duration(i) * time.Millisecond, BytesWritten: 10, InputLatency: time.Duration(i) * time.Microsecond})
+		}
+		m.Observe(FrameStats{RenderDuration: 0, BytesWritten: 5}) // A redraw not caused by a key
+
+		snapshot := m.Snapshot()
+
+		assert.Equal(t, int64(101), snapshot.Frames)
+		assert.Equal(t, int64(1005), snapshot.BytesWritten)
+		assert.Equal(t, 50*time.Millisecond, snapshot.RenderP50)
+		assert.Equal(t, 90*time.Millisecond, snapshot.RenderP90)
+		assert.Equal(t, 99*time.Millisecond, snapshot.RenderP99)
+		assert.Equal(t, 50*time.Microsecond, snapshot.InputLatencyP50, "frames without a key do not count")
+		assert.Equal(t, 100*time.Microsecond, snapshot.InputLatencyMax)
+		assert.Equal(t, 101, snapshot.RecentFrameCount)
+	})
+
+	t.Run("percentiles cover only the most recent frames", func(t *testing.T) {
+		t.Parallel()
+
+		m := NewRenderMetrics()
+		for range metricsWindow {
+			m.Observe(FrameStats{RenderDuration: time.Second})
+		}
+		for range metricsWindow {
+			m.Observe(FrameStats{RenderDuration: time.Millisecond})
+		}
+
+		snapshot := m.Snapshot()
+
+		assert.Equal(t, int64(2*metricsWindow), snapshot.Frames)
+		assert.Equal(t, time.Millisecond, snapshot.RenderP99)
+		assert.Equal(t, metricsWindow, snapshot.RecentFrameCount)
+	})
+
+	t.Run("String is the JSON snapshot for expvar", func(t *testing.T) {
+		t.Parallel()
+
+		m := NewRenderMetrics()
+		m.Observe(FrameStats{RenderDuration: time.Millisecond, BytesWritten: 42})
+
+		var decoded RenderMetricsSnapshot
+		require.NoError(t, json.Unmarshal([]byte(m.String()), &decoded))
+
+		assert.Equal(t, m.Snapshot(), decoded)
+	})
+}
+
+func TestWithFrameStats(t *testing.T) {
+	t.Parallel()
+
+	var frames []FrameStats
+	var out bytes.Buffer
+	p, err := New("$ ", WithTerminal(newMockTerminal("ab\r")), WithOutput(&out), WithMemoryHistory(10),
+		WithFrameStats(func(stats FrameStats) { frames = append(frames, stats) }))
+	require.NoError(t, err)
+
+	result, err := p.Run()
+
+	require.NoError(t, err)
+	assert.Equal(t, "ab", result)
+	require.Len(t, frames, 3, "the initial frame and one per typed key")
+	assert.Zero(t, frames[0].InputLatency, "the initial frame is not caused by a key")
+	total := 0
+	for i, frame := range frames {
+		assert.Positive(t, frame.BytesWritten, "frame %d", i)
+		total += frame.BytesWritten
+		if i > 0 {
+			assert.GreaterOrEqual(t, frame.InputLatency, frame.RenderDuration, "frame %d", i)
+		}
+	}
+	assert.LessOrEqual(t, total, out.Len())
+}
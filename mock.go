@@ -1,8 +1,11 @@
 package prompt
 
-import "io"
+import (
+	"io"
+	"sync"
+)
 
-// mockTerminal implements terminalInterface for testing and development.
+// mockTerminal implements Terminal for testing and development.
 //
 // This implementation provides predictable, deterministic behavior for unit tests
 // and development scenarios. It simulates terminal behavior without requiring
@@ -20,7 +23,10 @@ type mockTerminal struct {
 	input        []rune // Pre-configured input sequence for testing
 	inputPos     int    // Current position in the input sequence
 	rawMode      bool   // Track raw mode state for test verification
-	terminalSize [2]int // Fixed terminal dimensions [width, height]
+	terminalSize [2]int // Terminal dimensions [width, height], changed by resize
+	sizeMu       sync.Mutex
+	resizeCh     chan struct{} // Resize notifications sent by resize
+	closed       bool          // Track Close for test verification
 }
 
 func newMockTerminal(input string) *mockTerminal {
@@ -29,6 +35,7 @@ func newMockTerminal(input string) *mockTerminal {
 		inputPos:     0,
 		rawMode:      false,
 		terminalSize: [2]int{80, 24},
+		resizeCh:     make(chan struct{}, 1),
 	}
 }
 
@@ -43,9 +50,28 @@ func (m *mockTerminal) Restore() error {
 }
 
 func (m *mockTerminal) Size() (width, height int, err error) {
+	m.sizeMu.Lock()
+	defer m.sizeMu.Unlock()
 	return m.terminalSize[0], m.terminalSize[1], nil
 }
 
+// ResizeEvents implements resizeNotifier so tests can exercise resize handling.
+func (m *mockTerminal) ResizeEvents() <-chan struct{} {
+	return m.resizeCh
+}
+
+// resize changes the reported size and notifies the event loop, like a
+// SIGWINCH from a real terminal. It is safe to call from another goroutine.
+func (m *mockTerminal) resize(width, height int) {
+	m.sizeMu.Lock()
+	m.terminalSize = [2]int{width, height}
+	m.sizeMu.Unlock()
+	select {
+	case m.resizeCh <- struct{}{}:
+	default:
+	}
+}
+
 func (m *mockTerminal) ReadRune() (rune, int, error) {
 	if m.inputPos >= len(m.input) {
 		return 0, 0, io.EOF
@@ -56,5 +82,6 @@ func (m *mockTerminal) ReadRune() (rune, int, error) {
 }
 
 func (m *mockTerminal) Close() error {
+	m.closed = true
 	return nil
 }
@@ -0,0 +1,96 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrUnknownMode is returned by SetMode for a name no mode was registered
+// under with WithModes.
+var ErrUnknownMode = errors.New("unknown mode")
+
+// Mode bundles the settings that change together when an application switches
+// between kinds of input, such as the SQL, shell and Python modes of a REPL.
+// A mode describes all of them: a field left at its zero value switches to
+// the default, not to what the previous mode had, so no setting leaks from one
+// mode into the next.
+type Mode struct {
+	Name           string                      // Name SetMode switches to the mode by
+	Prefix         string                      // Prompt prefix, when PrefixSegments is empty
+	PrefixSegments []PrefixSegment             // Colored parts of the prefix, replacing Prefix when set
+	Completer      func(Document) []Suggestion // Completion function (nil for no completion)
+	KeyMap         *KeyMap                     // Key bindings (nil for the default ones)
+	Theme          *ColorScheme                // Color scheme (nil for ThemeDefault)
+}
+
+// WithModes registers modes SetMode can switch to. The prompt starts with the
+// settings given to New; call SetMode before Run to start in one of the modes.
+//
+// Example:
+//
+//	p, err := prompt.New("> ", prompt.WithModes(
+//		prompt.Mode{Name: "sql", Prefix: "sql> ", Completer: sqlCompleter},
+//		prompt.Mode{Name: "shell", Prefix: "$ ", Completer: shellCompleter, Theme: prompt.ThemeDracula},
+//	))
+//	...
+//	if input == `\sql` {
+//		err = p.SetMode("sql")
+//	}
+func WithModes(modes ...Mode) Option {
+	return func(c *Config) {
+		c.Modes = append(c.Modes, modes...)
+	}
+}
+
+// SetMode switches the prefix, completer, key map and theme to those of the
+// mode registered under name, all at once, so no frame is drawn with some
+// settings of the old mode and some of the new one. It returns an error
+// wrapping ErrUnknownMode, and changes nothing, when there is no such mode.
+// Like SetPrefix and SetCompleter it must not be called concurrently with Run,
+// except from callbacks such as a Completer or through a PromptController; the
+// new settings are drawn with the next frame. A completer set with
+// WithAsyncCompleter still takes precedence over the mode's Completer.
+func (p *Prompt) SetMode(name string) error {
+	i := slices.IndexFunc(p.config.Modes, func(mode Mode) bool { return mode.Name == name })
+	if i < 0 {
+		return fmt.Errorf("%w: %q", ErrUnknownMode, name)
+	}
+	mode := p.config.Modes[i]
+
+	if len(mode.PrefixSegments) > 0 {
+		p.SetPrefixSegments(mode.PrefixSegments...)
+	} else {
+		p.SetPrefix(mode.Prefix)
+	}
+	p.SetCompleter(mode.Completer)
+
+	keyMap := mode.KeyMap
+	if keyMap == nil {
+		keyMap = NewDefaultKeyMap()
+	}
+	p.keyMapMu.Lock()
+	p.keyMap = keyMap
+	p.config.KeyMap = keyMap
+	p.pendingKeyMap = nil // An earlier SetKeyMap must not undo the switch
+	p.keyMapMu.Unlock()
+
+	theme := mode.Theme
+	if theme == nil {
+		theme = ThemeDefault
+	}
+	// The renderer is kept, unlike with SetTheme, so a switch from a callback
+	// during Run still clears the frame drawn before it
+	p.config.ColorScheme = theme
+	p.config.Theme = theme
+	p.renderer.colorScheme = theme
+
+	p.mode = name
+	return nil
+}
+
+// Mode returns the name of the mode last switched to with SetMode, or "" when
+// SetMode has not been called.
+func (p *Prompt) Mode() string {
+	return p.mode
+}
@@ -0,0 +1,129 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetMode(t *testing.T) {
+	t.Parallel()
+
+	submitOnX := NewDefaultKeyMap()
+	submitOnX.Bind('x', ActionSubmit)
+	sqlCompleter := func(Document) []Suggestion { return []Suggestion{{Text: "SELECT"}} }
+	modes := []Mode{
+		{Name: "sql", Prefix: "sql> ", Completer: sqlCompleter, KeyMap: submitOnX, Theme: ThemeDracula},
+		{Name: "shell", PrefixSegments: []PrefixSegment{{Text: "sh"}, {Text: " $ "}}},
+	}
+
+	t.Run("switches every setting of the mode", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", Modes: modes}, "S\tx")
+
+		require.NoError(t, p.SetMode("sql"))
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT", result, "Tab completes from the mode's completer and x submits")
+		assert.Equal(t, "sql", p.Mode())
+		assert.Equal(t, "sql> ", p.config.Prefix)
+		assert.Same(t, ThemeDracula, p.renderer.colorScheme)
+	})
+
+	t.Run("unset fields go back to the defaults", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", Modes: modes}, "ax\r")
+		require.NoError(t, p.SetMode("sql"))
+
+		require.NoError(t, p.SetMode("shell"))
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ax", result, "the default key map types x")
+		assert.Equal(t, "sh $ ", p.config.Prefix)
+		assert.Len(t, p.config.PrefixSegments, 2)
+		assert.Nil(t, p.config.Completer)
+		assert.Same(t, ThemeDefault, p.renderer.colorScheme)
+	})
+
+	t.Run("a pending SetKeyMap does not undo the switch", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", Modes: modes}, "ax")
+		p.SetKeyMap(NewDefaultKeyMap())
+
+		require.NoError(t, p.SetMode("sql"))
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "a", result)
+	})
+
+	t.Run("an unknown mode changes nothing", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", Modes: modes}, "")
+		require.NoError(t, p.SetMode("sql"))
+
+		err := p.SetMode("python")
+
+		require.ErrorIs(t, err, ErrUnknownMode)
+		assert.Equal(t, "sql", p.Mode())
+		assert.Equal(t, "sql> ", p.config.Prefix)
+	})
+
+	t.Run("WithModes registers the modes", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := New("> ", WithTerminal(newMockTerminal("")), WithOutput(&bytes.Buffer{}), WithModes(modes...))
+		require.NoError(t, err)
+		defer p.Close()
+
+		assert.Empty(t, p.Mode())
+		require.NoError(t, p.SetMode("shell"))
+		assert.Equal(t, "shell", p.Mode())
+	})
+
+	t.Run("a controller switches the mode from a callback", func(t *testing.T) {
+		t.Parallel()
+
+		fired := make(chan struct{}, 8)
+		config := Config{
+			Prefix:       "> ",
+			Modes:        modes,
+			IdleInterval: 10 * time.Millisecond,
+			OnIdle: func(c *PromptController) {
+				if c.p.Mode() == "" {
+					assert.NoError(t, c.SetMode("sql"))
+					fired <- struct{}{}
+				}
+			},
+		}
+		p := newForTestingWithConfig(t, config, "")
+		terminal := newChanTerminal()
+		p.terminal = terminal
+		var out syncBuffer
+		p.renderer = newRenderer(&out, ThemeDefault, terminal)
+
+		terminal.keys <- 'a'
+		go func() {
+			<-fired
+			terminal.keys <- 'x'
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		result, err := p.RunWithContext(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, "a", result, "x submits with the key map of the mode")
+		assert.Contains(t, out.String(), "sql> ")
+	})
+}
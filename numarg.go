@@ -0,0 +1,69 @@
+package prompt
+
+// maxNumericArgument caps the numeric argument typed with Alt+digits, so a
+// stray run of digits cannot stall the prompt repeating an edit.
+const maxNumericArgument = 1000
+
+// deleteChar deletes the character before the cursor for Backspace (r is
+// 0x7f or Ctrl+H) and the one at the cursor for any other key, such as Delete
+// and Ctrl+D. It reports whether a character was deleted.
+func (p *Prompt) deleteChar(r rune) bool {
+	if r == '\x7f' || r == '\b' {
+		if p.cursor == 0 {
+			return false
+		}
+		p.buffer = append(p.buffer[:p.cursor-1], p.buffer[p.cursor:]...)
+		p.cursor--
+		return true
+	}
+	if p.cursor >= len(p.buffer) {
+		return false
+	}
+	p.buffer = append(p.buffer[:p.cursor], p.buffer[p.cursor+1:]...)
+	return true
+}
+
+// repeatAction runs action, read as the key r, n times for a numeric
+// argument, ahead of the run the event loop makes itself. Only movements,
+// deletions, the word and transpose commands and typed characters repeat;
+// other actions run once. The first of the repeated kills joins the previous
+// kill when join is set, and the others join it, so the whole deletion
+// yanks back as one piece. It reports whether the text changed.
+func (p *Prompt) repeatAction(action KeyAction, r rune, n int, join bool) bool {
+	before := len(p.buffer)
+	changed := false
+	for i := range n {
+		switch action {
+		case ActionMoveLeft:
+			p.cursor = max(0, p.cursor-1)
+		case ActionMoveRight:
+			p.cursor = min(len(p.buffer), p.cursor+1)
+		case ActionMoveWordLeft:
+			p.cursor = p.findWordBoundary(-1)
+		case ActionMoveWordRight:
+			p.cursor = p.findWordBoundary(1)
+		case ActionDeleteChar:
+			p.deleteChar(r)
+		case ActionDeleteWordBack:
+			p.kill(p.findWordBoundary(-1), p.cursor, join || i > 0)
+		case ActionDeleteWordForward:
+			p.kill(p.cursor, p.findWordBoundary(1), join || i > 0)
+		case ActionUpcaseWord, ActionDowncaseWord, ActionCapitalizeWord:
+			changed = p.changeWordCase(action) || changed
+		case ActionTransposeChars:
+			changed = p.transposeChars() || changed
+		case ActionTransposeWords:
+			changed = p.transposeWords() || changed
+		case ActionNone:
+			switch {
+			case r == '\x04': // Ctrl+D
+				p.deleteChar(r)
+			case r >= 32 && r != 127:
+				p.insertRune(r)
+			}
+		default:
+			return false
+		}
+	}
+	return changed || len(p.buffer) != before
+}
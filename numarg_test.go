@@ -0,0 +1,64 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumericArgument(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		want     string
+		wantRing []string
+	}{
+		{name: "alt+5 ctrl+d deletes five characters", input: "abcdefgh\x01\x1b5\x04\r", want: "fgh"},
+		{name: "alt+3 backspace deletes three characters back", input: "abcdefgh\x1b3\x7f\r", want: "abcde"},
+		{name: "alt+3 left moves three characters", input: "abcdef\x1b3\x1b[D!\r", want: "abc!def"},
+		{name: "alt+2 alt+b moves two words", input: "one two three\x1b2\x1bb!\r", want: "one !two three"},
+		{name: "alt+digits and plain digits make one argument", input: "\x1b1\x1b2x\x1b1" + "2y\r", want: strings.Repeat("x", 12) + strings.Repeat("y", 12)},
+		{name: "a repeated kill yanks back as one piece", input: "one two three\x1b2\x17\r", want: "one ", wantRing: []string{"two three"}},
+		{name: "a repeated forward kill is one entry", input: "one two three\x01\x0b\x19\x01\x1b2\x1bd\r", want: " three", wantRing: []string{"one two"}},
+		{name: "a repeated deletion is undone in one step", input: "abcdef\x1b3\x7f\x1f\r", want: "abcdef"},
+		{name: "other actions run once", input: "abc\x1b5\x01!\r", want: "!abc"},
+		{name: "alt+1 runs once", input: "abc\x1b1\x7f\r", want: "ab"},
+		{name: "ctrl+d deletes the character at the cursor", input: "abc\x01\x04\r", want: "bc"},
+		{name: "the argument is capped", input: "\x1b9" + "999999x\r", want: strings.Repeat("x", maxNumericArgument)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{Prefix: "$ "}, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+			if tt.wantRing != nil {
+				assert.Equal(t, tt.wantRing, p.KillRing()[:1])
+			}
+		})
+	}
+
+	t.Run("the argument is shown while it is typed", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		p, err := New("$ ", WithTerminal(newMockTerminal("\x1b1"+"2x\r")), WithOutput(&out))
+		require.NoError(t, err)
+		defer p.Close()
+
+		_, err = p.Run()
+
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), "(arg: 12)")
+	})
+}
@@ -0,0 +1,403 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-colorable"
+)
+
+const (
+	altScreenEnableSequence  = "\x1b[?1049h"
+	altScreenDisableSequence = "\x1b[?1049l"
+
+	// escapeTimeout is how long the palette and vi mode wait after ESC for the
+	// rest of an escape sequence before treating it as a lone Escape key press.
+	escapeTimeout = 50 * time.Millisecond
+)
+
+// PaletteOption configures a command palette opened with Palette.
+type PaletteOption func(*paletteConfig)
+
+// paletteConfig holds the settings of a command palette.
+type paletteConfig struct {
+	prompt      string               // Text shown before the search query
+	height      int                  // Rows to use below the cursor; 0 takes the whole screen
+	colorScheme *ColorScheme         // Colors for the search box and the result list
+	profile     ColorProfile         // Colors the terminal can display
+	messages    map[MessageID]string // Replacements for the built-in strings
+}
+
+// WithPalettePrompt sets the text shown in front of the search query.
+// The default is "> ".
+func WithPalettePrompt(prompt string) PaletteOption {
+	return func(c *paletteConfig) {
+		c.prompt = prompt
+	}
+}
+
+// WithPaletteHeight limits the palette to the given number of rows below the
+// cursor instead of taking over the whole screen. The search box and the match
+// counter use two of the rows, so heights below 3 are raised to 3.
+func WithPaletteHeight(rows int) PaletteOption {
+	return func(c *paletteConfig) {
+		c.height = max(rows, 3)
+	}
+}
+
+// WithPaletteTheme sets the color scheme used to draw the palette.
+func WithPaletteTheme(theme *ColorScheme) PaletteOption {
+	return func(c *paletteConfig) {
+		c.colorScheme = theme
+	}
+}
+
+// WithPaletteColorProfile sets the colors the terminal can display, like
+// WithColorProfile does for a prompt. Palette detects them by default.
+func WithPaletteColorProfile(profile ColorProfile) PaletteOption {
+	return func(c *paletteConfig) {
+		c.profile = profile
+	}
+}
+
+// WithPaletteMessages replaces the built-in strings of the palette, such as
+// MsgPaletteCount and MsgPaletteNoMatches, for example to localize them.
+func WithPaletteMessages(messages map[MessageID]string) PaletteOption {
+	return func(c *paletteConfig) {
+		c.messages = messages
+	}
+}
+
+// Palette opens a full-screen fuzzy finder over items and returns the one the
+// user selects.
+//
+// The palette shows a search box on the first row, a match counter below it
+// and a scrollable list of the items that match the query, best matches first.
+// Matching uses the same fuzzy scorer as NewFuzzyCompleter. The user narrows
+// the list by typing, moves the selection with Up/Down, Ctrl+P/Ctrl+N or Tab,
+// and confirms with Enter. Escape or Ctrl+C closes the palette and returns
+// ErrInterrupted.
+//
+// By default the palette runs on the terminal's alternate screen, so the
+// previous screen contents are restored when it closes. Use WithPaletteHeight
+// to draw it inline below the cursor instead.
+//
+// Example:
+//
+//	items := []prompt.Suggestion{
+//		{Text: "git status", Description: "Show the working tree status"},
+//		{Text: "git log", Description: "Show commit logs"},
+//		{Text: "docker ps", Description: "List containers"},
+//	}
+//	selected, err := prompt.Palette(items, prompt.WithPalettePrompt("run> "))
+//	if errors.Is(err, prompt.ErrInterrupted) {
+//		return
+//	}
+//	fmt.Println(selected.Text)
+func Palette(items []Suggestion, opts ...PaletteOption) (Suggestion, error) {
+	terminal, err := newControllingTerminal()
+	if err != nil {
+		return Suggestion{}, fmt.Errorf("failed to create terminal: %w", err)
+	}
+	defer terminal.Close()
+
+	var output io.Writer = os.Stdout
+	if runtime.GOOS == windowsOS {
+		output = colorable.NewColorableStdout()
+	}
+	opts = append([]PaletteOption{WithPaletteColorProfile(DetectColorProfile())}, opts...)
+	return runPalette(terminal, output, items, opts...)
+}
+
+// runPalette runs the palette on the given terminal and output.
+func runPalette(terminal Terminal, output io.Writer, items []Suggestion, opts ...PaletteOption) (Suggestion, error) {
+	config := paletteConfig{
+		prompt:      "> ",
+		colorScheme: ThemeDefault,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.colorScheme == nil {
+		config.colorScheme = ThemeDefault
+	}
+
+	// The palette reuses the prompt's on-demand key reader
+	pal := &palette{
+		p:      &Prompt{terminal: terminal, output: output, config: Config{Messages: config.messages}},
+		config: config,
+		items:  items,
+	}
+	pal.filter()
+
+	if err := terminal.SetRaw(); err != nil {
+		return Suggestion{}, fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	if config.height == 0 {
+		fmt.Fprint(output, altScreenEnableSequence)
+	}
+	defer func() {
+		if config.height == 0 {
+			fmt.Fprint(output, altScreenDisableSequence)
+		} else {
+			fmt.Fprint(output, "\r\x1b[0J")
+		}
+		if err := terminal.Restore(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to exit raw mode: %v\n", err)
+		}
+	}()
+
+	return pal.run()
+}
+
+// palette is the state of a running command palette.
+type palette struct {
+	p        *Prompt
+	config   paletteConfig
+	items    []Suggestion
+	query    []rune
+	matches  []Suggestion // Items matching the query, best first
+	selected int          // Index into matches
+	offset   int          // First match shown in the list
+}
+
+// run reads keys until the user selects an item or cancels.
+func (pal *palette) run() (Suggestion, error) {
+	var resize <-chan struct{}
+	if notifier, ok := pal.p.terminal.(resizeNotifier); ok {
+		resize = notifier.ResizeEvents()
+	}
+
+	for {
+		if err := pal.render(); err != nil {
+			return Suggestion{}, fmt.Errorf("failed to render: %w", err)
+		}
+
+		var ev keyEvent
+		select {
+		case <-resize:
+			continue
+		case ev = <-pal.p.nextKey():
+			pal.p.keyCh = nil
+		}
+		if ev.err != nil {
+			if errors.Is(ev.err, io.EOF) {
+				return Suggestion{}, ErrEOF
+			}
+			return Suggestion{}, fmt.Errorf("failed to read input: %w", ev.err)
+		}
+
+		switch r := ev.r; r {
+		case '\r', '\n':
+			if len(pal.matches) > 0 {
+				return pal.matches[pal.selected], nil
+			}
+		case '\x03':
+			return Suggestion{}, ErrInterrupted
+		case '\x1b':
+			seq, ok := pal.readEscapeSequence()
+			switch {
+			case !ok:
+				return Suggestion{}, ErrInterrupted
+			case seq == "[A" || seq == "OA" || seq == "[Z":
+				pal.move(-1)
+			case seq == "[B" || seq == "OB":
+				pal.move(1)
+			case seq == "[5~":
+				pal.move(-pal.listRows())
+			case seq == "[6~":
+				pal.move(pal.listRows())
+			}
+		case '\x10': // Ctrl+P
+			pal.move(-1)
+		case '\x0e', '\t': // Ctrl+N, Tab
+			pal.move(1)
+		case '\x7f', '\b':
+			if len(pal.query) > 0 {
+				pal.query = pal.query[:len(pal.query)-1]
+				pal.filter()
+			}
+		case '\x15': // Ctrl+U
+			pal.query = pal.query[:0]
+			pal.filter()
+		default:
+			if r >= 32 && r != 127 {
+				pal.query = append(pal.query, r)
+				pal.filter()
+			}
+		}
+	}
+}
+
+// readEscapeSequence reads the rest of an escape sequence after ESC. It reports
+// false when nothing follows within escapeTimeout, which means the user
+// pressed Escape on its own.
+func (pal *palette) readEscapeSequence() (string, bool) {
+	timer := time.NewTimer(escapeTimeout)
+	defer timer.Stop()
+
+	var ev keyEvent
+	select {
+	case <-timer.C:
+		return "", false
+	case ev = <-pal.p.nextKey():
+		pal.p.keyCh = nil
+	}
+	if ev.err != nil || (ev.r != '[' && ev.r != 'O') {
+		return "", false
+	}
+
+	// Read parameter bytes up to the final byte of the sequence
+	seq := []rune{ev.r}
+	for range pal.p.limits().EscapeSequenceLen {
+		r, err := pal.p.readRune()
+		if err != nil {
+			break
+		}
+		seq = append(seq, r)
+		if r >= 0x40 && r <= 0x7e {
+			break
+		}
+	}
+	return string(seq), true
+}
+
+// filter recomputes the matches for the current query and resets the selection.
+func (pal *palette) filter() {
+	pal.selected = 0
+	pal.offset = 0
+	if len(pal.query) == 0 {
+		pal.matches = pal.items
+		return
+	}
+
+	query := string(pal.query)
+	type scored struct {
+		item  Suggestion
+		score int
+	}
+	var results []scored
+	for _, item := range pal.items {
+		if score, _ := FuzzyScore(query, item.Text); score > 0 {
+			results = append(results, scored{item: item, score: score})
+		}
+	}
+	slices.SortStableFunc(results, func(a, b scored) int {
+		return b.score - a.score
+	})
+
+	pal.matches = make([]Suggestion, len(results))
+	for i, result := range results {
+		pal.matches[i] = result.item
+	}
+}
+
+// move moves the selection by delta, clamped to the matches, and scrolls the
+// list so the selection stays visible.
+func (pal *palette) move(delta int) {
+	if len(pal.matches) == 0 {
+		return
+	}
+	pal.selected = max(0, min(pal.selected+delta, len(pal.matches)-1))
+
+	rows := pal.listRows()
+	if pal.selected < pal.offset {
+		pal.offset = pal.selected
+	} else if pal.selected >= pal.offset+rows {
+		pal.offset = pal.selected - rows + 1
+	}
+}
+
+// ansi returns the escape sequence drawing text in color on the terminal.
+func (pal *palette) ansi(color Color) string {
+	return color.ToANSIWithProfile(pal.config.profile)
+}
+
+// size returns the width and the total number of rows the palette draws.
+func (pal *palette) size() (width, height int) {
+	width, height, err := pal.p.terminal.Size()
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+	if pal.config.height > 0 {
+		height = min(height, pal.config.height)
+	}
+	return width, max(height, 3)
+}
+
+// listRows returns how many matches fit below the search box and the counter.
+func (pal *palette) listRows() int {
+	_, height := pal.size()
+	return height - 2
+}
+
+// render draws the palette and leaves the cursor after the query.
+func (pal *palette) render() error {
+	width, _ := pal.size()
+	colors := pal.config.colorScheme
+	var b strings.Builder
+
+	if pal.config.height == 0 {
+		b.WriteString("\x1b[H")
+	} else {
+		b.WriteString("\r")
+	}
+	b.WriteString("\x1b[0J")
+
+	// Search box
+	b.WriteString(pal.ansi(colors.Prefix))
+	b.WriteString(truncateWidth(pal.config.prompt, width))
+	b.WriteString(Reset())
+	b.WriteString(pal.ansi(colors.Input))
+	query := truncateWidth(sanitizeText(string(pal.query)), width-stringWidth(pal.config.prompt))
+	b.WriteString(query)
+	b.WriteString(Reset())
+
+	// Match counter
+	b.WriteString("\r\n")
+	b.WriteString(pal.ansi(colors.Suggestion.Description))
+	counter := "  " + fmt.Sprintf(pal.p.message(MsgPaletteCount), len(pal.matches), len(pal.items))
+	if len(pal.matches) == 0 && len(pal.items) > 0 {
+		counter += "  " + pal.p.message(MsgPaletteNoMatches)
+	}
+	b.WriteString(truncateWidth(counter, width))
+	b.WriteString(Reset())
+
+	// Result list
+	rows := pal.listRows()
+	end := min(pal.offset+rows, len(pal.matches))
+	for i := pal.offset; i < end; i++ {
+		item := pal.matches[i]
+		line := sanitizeText(item.display())
+		if item.Description != "" {
+			line += " - " + sanitizeText(item.Description)
+		}
+		b.WriteString("\r\n")
+		if i == pal.selected {
+			b.WriteString(pal.ansi(colors.Selected))
+			b.WriteString(truncateWidth("▶ "+line, width))
+		} else {
+			b.WriteString(pal.ansi(colors.Suggestion.Text))
+			b.WriteString(truncateWidth("  "+line, width))
+		}
+		b.WriteString(Reset())
+	}
+
+	// Back to the search box
+	if drawn := 1 + end - pal.offset; drawn > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", drawn)
+	}
+	b.WriteString("\r")
+	if col := min(stringWidth(pal.config.prompt)+stringWidth(query), width-1); col > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", col)
+	}
+
+	_, err := io.WriteString(pal.p.output, b.String())
+	return err
+}
@@ -0,0 +1,115 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPalette(t *testing.T) {
+	t.Parallel()
+
+	items := []Suggestion{
+		{Text: "git status", Description: "Show the working tree status"},
+		{Text: "git log", Description: "Show commit logs"},
+		{Text: "docker ps", Description: "List containers"},
+		{Text: "docker logs", Description: "Fetch container logs"},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "enter selects the first item without a query", input: "\r", want: "git status"},
+		{name: "typing narrows the list to the best match", input: "dps\r", want: "docker ps"},
+		{name: "down arrow moves the selection", input: "\x1b[B\r", want: "git log"},
+		{name: "ctrl+n and ctrl+p move the selection", input: "\x0e\x0e\x10\r", want: "git log"},
+		{name: "selection stops at the last match", input: "\t\t\t\t\t\t\r", want: "docker logs"},
+		{name: "backspace widens the list again", input: "dps\x7f\x7f\x7f\r", want: "git status"},
+		{name: "ctrl+u clears the query", input: "docker\x15\r", want: "git status"},
+		{name: "matching ignores case", input: "LOGS\r", want: "docker logs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			got, err := runPalette(newMockTerminal(tt.input), &out, items)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Text)
+		})
+	}
+
+	t.Run("escape cancels", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		_, err := runPalette(newMockTerminal("git\x1b"), &out, items)
+
+		assert.ErrorIs(t, err, ErrInterrupted)
+	})
+
+	t.Run("ctrl+c cancels", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		_, err := runPalette(newMockTerminal("\x03"), &out, items)
+
+		assert.ErrorIs(t, err, ErrInterrupted)
+	})
+
+	t.Run("enter does nothing when nothing matches", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		_, err := runPalette(newMockTerminal("zzz\r"), &out, items)
+
+		assert.ErrorIs(t, err, ErrEOF)
+	})
+
+	t.Run("full screen mode uses the alternate screen", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		terminal := newMockTerminal("\r")
+		_, err := runPalette(terminal, &out, items, WithPalettePrompt("run> "))
+
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), altScreenEnableSequence)
+		assert.Contains(t, out.String(), altScreenDisableSequence)
+		assert.Contains(t, out.String(), "run> ")
+		assert.Contains(t, out.String(), "4/4")
+		assert.False(t, terminal.rawMode)
+	})
+
+	t.Run("fixed height draws inline and scrolls", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		// Three rows leave room for a single match
+		_, err := runPalette(newMockTerminal("\x0e\x0e\r"), &out, items, WithPaletteHeight(3))
+
+		require.NoError(t, err)
+		assert.NotContains(t, out.String(), altScreenEnableSequence)
+		assert.Contains(t, out.String(), "▶ docker ps")
+		assert.True(t, bytes.HasSuffix(out.Bytes(), []byte("\r\x1b[0J")))
+	})
+
+	t.Run("long rows are truncated to the terminal width", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		terminal := newMockTerminal("\r")
+		terminal.terminalSize = [2]int{12, 24}
+		_, err := runPalette(terminal, &out, items)
+
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), "▶ git statu")
+		assert.NotContains(t, out.String(), "working tree")
+	})
+}
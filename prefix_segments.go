@@ -0,0 +1,81 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PrefixSegment is a part of the prompt prefix drawn in its own color, such as
+// the application name, a mode or the current directory. Segments keep colors
+// out of the prefix text, so its width is measured correctly; escape sequences
+// embedded in a plain prefix are counted as text.
+type PrefixSegment struct {
+	Text  string // Text of the segment, without escape sequences
+	Color *Color // Color of the segment (nil draws it in the theme's Prefix color)
+}
+
+// WithPrefixSegments sets the prefix as a sequence of colored segments instead
+// of the single prefix string given to New, which it replaces.
+//
+// Example:
+//
+//	prompt.New("", prompt.WithPrefixSegments(
+//		prompt.PrefixSegment{Text: "myapp", Color: &prompt.Color{R: 255, G: 121, B: 198, Bold: true}},
+//		prompt.PrefixSegment{Text: " ~/src", Color: &prompt.Color{R: 98, G: 114, B: 164}},
+//		prompt.PrefixSegment{Text: " $ "},
+//	))
+func WithPrefixSegments(segments ...PrefixSegment) Option {
+	return func(c *Config) {
+		c.Prefix = joinPrefixSegments(segments)
+		c.PrefixSegments = segments
+	}
+}
+
+// SetPrefixSegments changes the prefix to a sequence of colored segments. See
+// WithPrefixSegments.
+func (p *Prompt) SetPrefixSegments(segments ...PrefixSegment) {
+	p.config.Prefix = joinPrefixSegments(segments)
+	p.config.PrefixSegments = segments
+}
+
+// joinPrefixSegments returns the text of the prefix made of segments.
+func joinPrefixSegments(segments []PrefixSegment) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		b.WriteString(segment.Text)
+	}
+	return b.String()
+}
+
+// prefixSegments returns the segments of the prefix drawn before the input,
+// with the vi mode indicator as a first segment in the Prefix color, or nil
+// when the prefix has no segments.
+func (p *Prompt) prefixSegments() []PrefixSegment {
+	if len(p.config.PrefixSegments) == 0 {
+		return nil
+	}
+	mode, _ := strings.CutSuffix(p.prefix(), p.config.Prefix)
+	if mode == "" {
+		return p.config.PrefixSegments
+	}
+	return append([]PrefixSegment{{Text: mode}}, p.config.PrefixSegments...)
+}
+
+// writePrefix draws prefix at the cursor, each segment in its own color when
+// the segments spell out prefix, or else all of it in the theme's Prefix color.
+func (r *renderer) writePrefix(prefix string) error {
+	var b strings.Builder
+	if len(r.segments) > 0 && joinPrefixSegments(r.segments) == prefix {
+		for _, segment := range r.segments {
+			color := r.colorScheme.Prefix
+			if segment.Color != nil {
+				color = *segment.Color
+			}
+			b.WriteString(r.ansi(color) + segment.Text + Reset())
+		}
+	} else {
+		b.WriteString(r.ansi(r.colorScheme.Prefix) + prefix + Reset())
+	}
+	_, err := fmt.Fprint(r.output, b.String())
+	return err
+}
@@ -0,0 +1,72 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixSegments(t *testing.T) {
+	t.Parallel()
+
+	app := Color{R: 255, G: 121, B: 198, Bold: true}
+	path := Color{R: 98, G: 114, B: 164}
+	segments := []PrefixSegment{{Text: "app", Color: &app}, {Text: " ~/src", Color: &path}, {Text: " $ "}}
+
+	t.Run("each segment is drawn in its own color", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := New("ignored", WithPrefixSegments(segments...), WithTerminal(newMockTerminal("ls\r")))
+		require.NoError(t, err)
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ls", result)
+		assert.Equal(t, "app ~/src $ ", p.config.Prefix)
+		assert.Contains(t, out.String(),
+			app.ToANSI()+"app"+Reset()+path.ToANSI()+" ~/src"+Reset()+ThemeDefault.Prefix.ToANSI()+" $ "+Reset())
+	})
+
+	t.Run("the vi mode indicator comes first in the prefix color", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := newFromConfig(Config{PrefixSegments: segments, EditMode: EditModeVi, Terminal: newMockTerminal("")})
+		require.NoError(t, err)
+
+		got := p.prefixSegments()
+
+		require.Len(t, got, 4)
+		assert.Equal(t, PrefixSegment{Text: p.message(MsgViInsertMode)}, got[0])
+		assert.Equal(t, "app ~/src $ ", p.config.Prefix, "the prefix text is taken from the segments")
+	})
+
+	t.Run("SetPrefix replaces the segments", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{}, "")
+		p.SetPrefixSegments(segments...)
+		assert.Equal(t, "app ~/src $ ", p.prefix())
+
+		p.SetPrefix("> ")
+
+		assert.Equal(t, "> ", p.prefix())
+		assert.Nil(t, p.prefixSegments())
+	})
+
+	t.Run("segments that do not spell out the prefix are not used", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		r := newRenderer(&out, ThemeDefault, newMockTerminal(""))
+		r.segments = segments
+
+		require.NoError(t, r.writePrefix("> "))
+
+		assert.Equal(t, ThemeDefault.Prefix.ToANSI()+"> "+Reset(), out.String())
+	})
+}
@@ -0,0 +1,147 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// previewBuffer shows the whole input in a read-only view on the alternate
+// screen, so a long multi-line input can be reviewed before it is submitted.
+// Each line is numbered and wrapped to the terminal width. The view scrolls
+// with j/k or Up/Down by a row, Space/b or PageDown/PageUp by a page and
+// g/G or Home/End to either end, and closes on q, Escape or Ctrl+C, leaving
+// the input as it was.
+func (p *Prompt) previewBuffer() error {
+	text, _ := p.displayText()
+	if _, err := fmt.Fprint(p.renderer.output, altScreenEnableSequence); err != nil {
+		return err
+	}
+	if err := p.renderer.hideCursor(); err != nil {
+		return err
+	}
+	defer func() {
+		fmt.Fprint(p.renderer.output, altScreenDisableSequence)
+		if err := p.renderer.showCursor(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to show the cursor: %v\n", err)
+		}
+	}()
+
+	offset := 0
+	for {
+		rows := p.previewRows(text)
+		pageRows := p.previewHeight() - 1 // The last row is the status line
+		offset = max(0, min(offset, len(rows)-pageRows))
+		if err := p.renderPreview(rows, offset, pageRows); err != nil {
+			return err
+		}
+
+		r, err := p.readRune()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return ErrEOF
+			}
+			return err
+		}
+		switch r {
+		case 'q', '\x03', '\x07': // q, Ctrl+C, Ctrl+G
+			return nil
+		case 'j', '\r', '\n', '\x0e': // j, Enter, Ctrl+N
+			offset++
+		case 'k', '\x10': // k, Ctrl+P
+			offset--
+		case ' ', 'f', '\x06': // Space, f, Ctrl+F
+			offset += pageRows
+		case 'b', '\x02': // b, Ctrl+B
+			offset -= pageRows
+		case 'g':
+			offset = 0
+		case 'G':
+			offset = len(rows)
+		case '\x1b':
+			if p.loneEscape() {
+				return nil
+			}
+			seq, err := p.readEscapeSequence()
+			if err != nil {
+				return err
+			}
+			switch seq {
+			case "[A", "OA": // Up
+				offset--
+			case "[B", "OB": // Down
+				offset++
+			case "[5~": // PageUp
+				offset -= pageRows
+			case "[6~": // PageDown
+				offset += pageRows
+			case "[H", "OH", "[1~": // Home
+				offset = 0
+			case "[F", "OF", "[4~": // End
+				offset = len(rows)
+			}
+		}
+		offset = max(0, offset) // Scrolling up past the top stops there
+	}
+}
+
+// previewHeight returns the number of terminal rows, at least 2.
+func (p *Prompt) previewHeight() int {
+	_, height, err := p.terminal.Size()
+	if err != nil || height <= 0 {
+		height = 24
+	}
+	return max(height, 2)
+}
+
+// previewRows splits text into the rows of the preview: each line is prefixed
+// with its number and wrapped to the terminal width in columns, with the number column
+// left blank on the continuation rows.
+func (p *Prompt) previewRows(text string) []string {
+	lines := p.renderer.splitIntoLines(text)
+	digits := len(fmt.Sprint(len(lines)))
+	width := max(p.renderer.width()-1-digits-1, 1) // Never fill the last column
+	numberColor := p.renderer.ansi(p.renderer.colorScheme.Suggestion.Description)
+	inputColor := p.renderer.ansi(p.renderer.colorScheme.Input)
+
+	var rows []string
+	for i, line := range lines {
+		runes := []rune(sanitizeText(line))
+		number := fmt.Sprintf("%*d ", digits, i+1)
+		for {
+			// At least one character per row, even if it is wider than the row
+			chunk := []rune(truncateWidth(string(runes), width))
+			if len(chunk) == 0 && len(runes) > 0 {
+				chunk = runes[:graphemes(runes)[0].end]
+			}
+			runes = runes[len(chunk):]
+			rows = append(rows, numberColor+number+Reset()+inputColor+string(chunk)+Reset())
+			if len(runes) == 0 {
+				break
+			}
+			number = strings.Repeat(" ", digits+1)
+		}
+	}
+	return rows
+}
+
+// renderPreview draws pageRows rows of the preview starting at offset, and a
+// status line with the visible range on the last row.
+func (p *Prompt) renderPreview(rows []string, offset, pageRows int) error {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[0J")
+	last := min(offset+pageRows, len(rows))
+	for i := offset; i < last; i++ {
+		b.WriteString(rows[i])
+		b.WriteString("\r\n")
+	}
+	fmt.Fprintf(&b, "\x1b[%d;1H", pageRows+1)
+	status := fmt.Sprintf(p.message(MsgPreviewStatus), offset+1, last, len(rows))
+	b.WriteString(p.renderer.ansi(p.renderer.colorScheme.Suggestion.Description))
+	b.WriteString(truncateWidth(status, p.renderer.width()-1))
+	b.WriteString(Reset())
+	_, err := fmt.Fprint(p.renderer.output, b.String())
+	return err
+}
@@ -0,0 +1,71 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreview(t *testing.T) {
+	t.Parallel()
+
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("echo %d", i+1)
+	}
+	script := strings.Join(lines, "\n")
+	paste := "\x1b[200~" + script + "\x1b[201~"
+
+	tests := []struct {
+		name       string
+		keys       string
+		wantStatus []string
+	}{
+		{name: "Alt+Enter shows the first page", keys: "\x1b\rq", wantStatus: []string{"lines 1-23 of 30"}},
+		{name: "PageDown stops at the last page", keys: "\x1b\r\x1b[6~\x1b[6~q", wantStatus: []string{"lines 1-23 of 30", "lines 8-30 of 30"}},
+		{name: "j and k scroll by a row", keys: "\x1b\rjjkq", wantStatus: []string{"lines 3-25 of 30", "lines 2-24 of 30"}},
+		{name: "G and g jump to either end", keys: "\x1b\rGgq", wantStatus: []string{"lines 8-30 of 30", "lines 1-23 of 30"}},
+		{name: "Escape returns to editing", keys: "\x1b\r\x1b", wantStatus: []string{"lines 1-23 of 30"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{}, paste+tt.keys+"\r")
+			var output bytes.Buffer
+			p.renderer.output = &output
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, script, result, "the preview must not change the input")
+			got := output.String()
+			assert.Contains(t, got, altScreenEnableSequence)
+			assert.Greater(t, strings.LastIndex(got, altScreenDisableSequence), strings.LastIndex(got, altScreenEnableSequence))
+			for _, status := range tt.wantStatus {
+				assert.Contains(t, got, status)
+			}
+		})
+	}
+
+	t.Run("long lines wrap under their line number", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{}, "")
+		p.terminal.(*mockTerminal).terminalSize = [2]int{10, 24}
+
+		rows := p.previewRows("abcdefghijk\nx")
+
+		require.Len(t, rows, 3)
+		assert.Contains(t, rows[0], "1 ")
+		assert.Contains(t, rows[0], "abcdefg")
+		assert.Contains(t, rows[1], "  ")
+		assert.Contains(t, rows[1], "hijk")
+		assert.Contains(t, rows[2], "2 ")
+	})
+}
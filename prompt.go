@@ -12,8 +12,12 @@ import (
 	"runtime"
 	"slices"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"github.com/mattn/go-colorable"
+	"golang.org/x/text/language"
 )
 
 // Windows OS name constant
@@ -21,10 +25,14 @@ const windowsOS = "windows"
 
 // Common errors
 var (
-	// ErrEOF is returned when the user presses Ctrl+D or EOF is encountered
-	ErrEOF = errors.New("EOF")
+	// ErrEOF is returned when the user presses Ctrl+D or EOF is encountered.
+	// It wraps io.EOF.
+	ErrEOF = fmt.Errorf("%w", io.EOF)
 	// ErrInterrupted is returned when the user presses Ctrl+C
 	ErrInterrupted = errors.New("interrupted")
+	// ErrExit is returned with the input when the ExitChecker ends the session.
+	// It wraps ErrEOF.
+	ErrExit = fmt.Errorf("exit requested: %w", ErrEOF)
 )
 
 // Prompt represents an interactive terminal prompt.
@@ -33,18 +41,54 @@ type Prompt struct {
 	output         io.Writer
 	history        []string
 	historyManager *HistoryManager
+	historyExclude historyExclusion // Entries never recorded, compiled once by New
 	buffer         []rune
 	cursor         int
 	renderer       *renderer
-	terminal       terminalInterface
+	terminal       Terminal
 	keyMap         *KeyMap
-}
-
-// KeyBinding represents a keyboard shortcut mapping
+	keyCh          chan keyEvent // Pending key read, nil when no read is in flight
+	viNormal       bool          // Vi normal mode is active (EditModeVi only)
+	killRing       []string      // Killed texts, most recent first
+	lastYank       yankState     // Where the last yank inserted its text
+	undoStack      []editState   // Buffer states before each undoable edit, oldest first
+	redoStack      []editState   // Buffer states reverted by undo, most recently undone last
+	undoGrouping   bool          // The last edit was typed text that the next typed character joins
+	historyIndex   int           // History entry being browsed, len(history) while editing a new line
+	historyLine    string        // Line being typed when history browsing started, restored past the newest entry
+	descToggled    bool          // Alt+/ flipped HideDescriptions for the open menu
+	session        *Session      // Session sharing its terminal with this prompt, nil for prompts from New
+	keyReadAt      time.Time     // When the last key was read, for FrameStats latency (zero once reported)
+	invalid        string        // Message of the validation error shown below the input (empty when none)
+	menuHint       string        // Dimmed row shown in place of an empty menu until the next key (empty when none)
+	blockEdit      bool          // Enter inserts a newline instead of submitting (see SetMultiline)
+	keyMapMu       sync.Mutex    // Guards pendingKeyMap, which SetKeyMap may set from any goroutine
+	pendingKeyMap  *KeyMap       // Key map set by SetKeyMap, taken over before the next key is handled
+	mode           string        // Name of the mode set by SetMode (empty before the first call)
+	draft          *Draft        // Draft set by RestoreDraft for the next Run (nil when none)
+
+	reportedSequences map[string]bool      // Unknown escape sequences already passed to OnUnknownSequence
+	historyEdits      map[int]string       // Edited text of recalled history entries by index, kept until Run returns
+	unbalanced        *imbalance           // Quote or bracket the balance check rejected, marked until it is fixed (nil when none)
+	busyStop          func()               // Stops the line drawn by Busy, called by Run (nil when none was drawn)
+	lastSuggestion    *autoSuggestionCache // Auto-suggestion of the last frame, reused while the input is unchanged (nil when none)
+}
+
+// keyEvent carries the result of a single terminal read from the reader
+// goroutine to the event loop.
+type keyEvent struct {
+	r   rune
+	err error
+}
+
+// KeyBinding represents a keyboard shortcut mapping, as listed by
+// KeyMap.DumpBindings
 type KeyBinding struct {
-	Key    rune   // Key character (for simple keys)
-	Seq    string // Escape sequence (for special keys like arrows)
-	Action KeyAction
+	Key     rune       // Key character (for simple keys)
+	Seq     string     // Escape sequence (for special keys like arrows)
+	Name    string     // Name of the key in the form BindName takes (empty for a sequence of an unknown key)
+	Context KeyContext // Context the binding applies in
+	Action  KeyAction
 }
 
 // KeyAction represents the action to perform when a key is pressed
@@ -77,6 +121,94 @@ const (
 	// ActionClearScreen clears the terminal screen and redraws the prompt with
 	// the current input preserved, like Ctrl+L in a typical shell.
 	ActionClearScreen
+	// ActionYank inserts the most recently killed text at the cursor.
+	ActionYank
+	// ActionYankPop replaces the text just yanked with the next older kill.
+	ActionYankPop
+	// ActionUndo reverts the last edit. Consecutive typed characters are
+	// undone together.
+	ActionUndo
+	// ActionRedo reapplies the last edit reverted with ActionUndo.
+	ActionRedo
+	// ActionMenuFirst selects the first suggestion of the open menu.
+	ActionMenuFirst
+	// ActionMenuLast selects the last suggestion of the open menu.
+	ActionMenuLast
+	// ActionToggleMultiline switches block editing on or off, like SetMultiline.
+	// It has no default key; bind one such as Alt+M with BindSequence("m", ...).
+	ActionToggleMultiline
+	// ActionHistoryPrefixUp moves to the previous history entry that starts
+	// with the text before the cursor, keeping the cursor in place. It has no
+	// default key; WithHistoryPrefixSearch makes Up behave this way instead.
+	ActionHistoryPrefixUp
+	// ActionHistoryPrefixDown moves to the next history entry that starts with
+	// the text before the cursor, or back to the line being typed.
+	ActionHistoryPrefixDown
+	// ActionToggleDescriptions shows or hides the suggestion descriptions of
+	// the open menu. It is bound to Alt+/ while the menu is open.
+	ActionToggleDescriptions
+	// ActionMenuPageUp moves the selection of the open menu up by a page. It
+	// is bound to PageUp while the menu is open.
+	ActionMenuPageUp
+	// ActionMenuPageDown moves the selection of the open menu down by a page.
+	// It is bound to PageDown while the menu is open.
+	ActionMenuPageDown
+	// ActionPreview shows the whole input in a read-only view that scrolls
+	// with j/k, Up/Down and PageUp/PageDown, to review a long multi-line input
+	// before submitting it. q or Escape returns to editing. It is bound to
+	// Alt+Enter.
+	ActionPreview
+	// ActionDeleteWordForward deletes from the cursor to the end of the word,
+	// saving the text for ActionYank. It is bound to Alt+D.
+	ActionDeleteWordForward
+	// ActionUpcaseWord uppercases from the cursor to the end of the word and
+	// moves the cursor there. It is bound to Alt+U.
+	ActionUpcaseWord
+	// ActionDowncaseWord lowercases from the cursor to the end of the word and
+	// moves the cursor there. It is bound to Alt+L.
+	ActionDowncaseWord
+	// ActionCapitalizeWord uppercases the first letter of the word at or after
+	// the cursor, lowercases the rest of it and moves the cursor to its end.
+	// It is bound to Alt+C.
+	ActionCapitalizeWord
+	// ActionTransposeChars swaps the character before the cursor with the one
+	// at the cursor and moves the cursor forward, or swaps the last two
+	// characters at the end of the input. It is bound to Ctrl+T.
+	ActionTransposeChars
+	// ActionTransposeWords swaps the word before the cursor with the word
+	// after it and moves the cursor past both, or swaps the last two words at
+	// the end of the input. It is bound to Alt+T.
+	ActionTransposeWords
+	// ActionDigitArgument adds the digit of its key to the numeric argument,
+	// which repeats the next movement, deletion or typed character that many
+	// times, as in readline. Once an argument is started, plain digits extend
+	// it too. It is bound to Alt+0 to Alt+9.
+	ActionDigitArgument
+	// ActionAbort closes the suggestion menu and drops a numeric argument
+	// being typed, without submitting or clearing the input, and rings the
+	// bell, as readline's Ctrl+G does. It also ends the reverse history
+	// search. It is bound to Ctrl+G and Escape Escape.
+	ActionAbort
+	// ActionSuspend stops the program as Ctrl+Z does in a shell: the terminal
+	// is handed back in its original mode and the job is stopped until the
+	// shell continues it, when the prompt is drawn again. It only works on Unix
+	// when the program runs as a job of a shell with job control, and does
+	// nothing otherwise. It is bound to Ctrl+Z.
+	ActionSuspend
+)
+
+// KeyContext is the state of the prompt a key binding applies in. Bindings
+// made for a context take precedence over the KeyContextEditing bindings while
+// the prompt is in that context; keys without one fall back to them.
+type KeyContext int
+
+const (
+	// KeyContextEditing is the default context, used while no suggestion menu
+	// is shown. Bind and BindSequence bind keys in this context.
+	KeyContextEditing KeyContext = iota
+	// KeyContextMenu applies while the suggestion menu is open. By default
+	// Home and End select the first and last suggestion in it.
+	KeyContextMenu
 )
 
 const (
@@ -86,8 +218,15 @@ const (
 
 // KeyMap holds the key binding configuration
 type KeyMap struct {
-	bindings  map[rune]KeyAction
-	sequences map[string]KeyAction
+	bindings         map[rune]KeyAction
+	sequences        map[string]KeyAction
+	contextBindings  map[KeyContext]map[rune]KeyAction   // Bindings that override bindings in a context
+	contextSequences map[KeyContext]map[string]KeyAction // Sequences that override sequences in a context
+	keys             map[Key]KeyAction                   // Decoded keys, looked up before runes and sequences
+	contextKeys      map[KeyContext]map[Key]KeyAction    // Decoded keys that override keys in a context
+
+	funcs    map[Key]func(*PromptController) error // Handlers of the keys bound to actionFunc
+	patterns []sequencePattern                     // Sequence patterns, tried in order after exact sequences
 }
 
 // NewDefaultKeyMap creates the default key bindings for the prompt.
@@ -104,6 +243,8 @@ type KeyMap struct {
 //   - Ctrl+K: Delete from cursor to end of line
 //   - Ctrl+U: Delete entire line
 //   - Ctrl+W: Delete word backwards
+//   - Ctrl+Y: Yank the last deleted text, Alt+Y: cycle through older ones
+//   - Ctrl+_: Undo, Alt+_: Redo
 //   - Ctrl+R: Reverse history search
 //   - Ctrl+L: Clear the screen
 //   - Tab: Auto-completion
@@ -111,7 +252,15 @@ type KeyMap struct {
 //   - Arrow keys: Navigate history and move cursor
 //   - Home/End: Move to line beginning/end
 //   - Delete: Delete character forwards
-//   - Ctrl+Left/Right: Move by word
+//   - Ctrl+Left/Right, Alt+B/F: Move by word
+//   - Alt+D, Alt+Backspace: Delete word forwards, backwards
+//   - Alt+U, Alt+L, Alt+C: Uppercase, lowercase, capitalize word
+//   - Ctrl+T, Alt+T: Transpose characters, words
+//   - Alt+0 to Alt+9: Numeric argument repeating the next key
+//
+// While the suggestion menu is open (KeyContextMenu), Home and End select the
+// first and last suggestion instead of moving the cursor, and Alt+/ shows or
+// hides the suggestion descriptions.
 //
 // Example:
 //
@@ -140,6 +289,11 @@ func NewDefaultKeyMap() *KeyMap {
 	km.bindings['\x17'] = ActionDeleteWordBack // Ctrl+W
 	km.bindings['\x12'] = ActionHistorySearch  // Ctrl+R
 	km.bindings['\x0C'] = ActionClearScreen    // Ctrl+L
+	km.bindings['\x19'] = ActionYank           // Ctrl+Y
+	km.bindings['\x1f'] = ActionUndo           // Ctrl+_
+	km.bindings['\x14'] = ActionTransposeChars // Ctrl+T
+	km.bindings['\x07'] = ActionAbort          // Ctrl+G
+	km.bindings['\x1a'] = ActionSuspend        // Ctrl+Z
 	km.bindings['\t'] = ActionComplete
 	km.bindings['\x7f'] = ActionDeleteChar // Backspace
 	km.bindings['\b'] = ActionDeleteChar   // Backspace
@@ -149,17 +303,62 @@ func NewDefaultKeyMap() *KeyMap {
 	km.sequences["[B"] = ActionMoveDown
 	km.sequences["[C"] = ActionMoveRight
 	km.sequences["[D"] = ActionMoveLeft
-	km.sequences["[H"] = ActionMoveHome
-	km.sequences["[F"] = ActionMoveEnd
+	for _, seq := range homeSequences() {
+		km.sequences[seq] = ActionMoveHome
+	}
+	for _, seq := range endSequences() {
+		km.sequences[seq] = ActionMoveEnd
+	}
 	km.sequences["[1;5C"] = ActionMoveWordRight // Ctrl+Right
 	km.sequences["[1;5D"] = ActionMoveWordLeft  // Ctrl+Left
 	km.sequences["[3~"] = ActionDeleteChar      // Delete
 	km.sequences["[200~"] = ActionPasteStart
 	km.sequences["[201~"] = ActionPasteEnd
+	km.sequences["y"] = ActionYankPop  // Alt+Y
+	km.sequences["_"] = ActionRedo     // Alt+_
+	km.sequences["\r"] = ActionPreview // Alt+Enter
+	km.sequences["\x1b"] = ActionAbort // Escape Escape
+
+	// Readline's Alt word commands
+	km.sequences["b"] = ActionMoveWordLeft      // Alt+B
+	km.sequences["f"] = ActionMoveWordRight     // Alt+F
+	km.sequences["d"] = ActionDeleteWordForward // Alt+D
+	km.sequences["\x7f"] = ActionDeleteWordBack // Alt+Backspace
+	km.sequences["\b"] = ActionDeleteWordBack   // Alt+Backspace
+	km.sequences["u"] = ActionUpcaseWord        // Alt+U
+	km.sequences["l"] = ActionDowncaseWord      // Alt+L
+	km.sequences["c"] = ActionCapitalizeWord    // Alt+C
+	km.sequences["t"] = ActionTransposeWords    // Alt+T
+	for digit := '0'; digit <= '9'; digit++ {
+		km.sequences[string(digit)] = ActionDigitArgument // Alt+0 to Alt+9
+	}
+
+	// Suggestion menu
+	for _, seq := range homeSequences() {
+		km.BindSequenceInContext(KeyContextMenu, seq, ActionMenuFirst)
+	}
+	for _, seq := range endSequences() {
+		km.BindSequenceInContext(KeyContextMenu, seq, ActionMenuLast)
+	}
+	km.BindSequenceInContext(KeyContextMenu, "/", ActionToggleDescriptions) // Alt+/
+	km.BindSequenceInContext(KeyContextMenu, "[5~", ActionMenuPageUp)       // PageUp
+	km.BindSequenceInContext(KeyContextMenu, "[6~", ActionMenuPageDown)     // PageDown
 
 	return km
 }
 
+// homeSequences returns what terminals send for Home: xterm in normal and
+// application cursor mode, and the VT220-style keys of rxvt, screen and the
+// Linux console.
+func homeSequences() []string {
+	return []string{"[H", "OH", "[1~", "[7~"}
+}
+
+// endSequences returns what terminals send for End, like homeSequences.
+func endSequences() []string {
+	return []string{"[F", "OF", "[4~", "[8~"}
+}
+
 // Bind adds or updates a key binding for a single character.
 //
 // Use this method to bind actions to control characters, printable characters,
@@ -195,6 +394,79 @@ func (km *KeyMap) BindSequence(seq string, action KeyAction) {
 	km.sequences[seq] = action
 }
 
+// BindInContext adds or updates a key binding that applies only in the given
+// context, overriding the binding of the key made with Bind there.
+//
+// Example:
+//
+//	keyMap := prompt.NewDefaultKeyMap()
+//	// Ctrl+A and Ctrl+E jump to the first and last suggestion while the menu is open
+//	keyMap.BindInContext(prompt.KeyContextMenu, '\x01', prompt.ActionMenuFirst)
+//	keyMap.BindInContext(prompt.KeyContextMenu, '\x05', prompt.ActionMenuLast)
+func (km *KeyMap) BindInContext(context KeyContext, key rune, action KeyAction) {
+	if context == KeyContextEditing {
+		km.Bind(key, action)
+		return
+	}
+	if km.contextBindings == nil {
+		km.contextBindings = make(map[KeyContext]map[rune]KeyAction)
+	}
+	if km.contextBindings[context] == nil {
+		km.contextBindings[context] = make(map[rune]KeyAction)
+	}
+	km.contextBindings[context][key] = action
+}
+
+// BindSequenceInContext adds or updates an escape sequence binding that applies
+// only in the given context, overriding the binding made with BindSequence
+// there.
+//
+// Example:
+//
+//	keyMap := prompt.NewDefaultKeyMap()
+//	// Keep Home and End moving the cursor while the menu is open, for the
+//	// sequences xterm sends (BindKeyInContext covers every encoding)
+//	keyMap.BindSequenceInContext(prompt.KeyContextMenu, "[H", prompt.ActionMoveHome)
+//	keyMap.BindSequenceInContext(prompt.KeyContextMenu, "[F", prompt.ActionMoveEnd)
+func (km *KeyMap) BindSequenceInContext(context KeyContext, seq string, action KeyAction) {
+	if context == KeyContextEditing {
+		km.BindSequence(seq, action)
+		return
+	}
+	if km.contextSequences == nil {
+		km.contextSequences = make(map[KeyContext]map[string]KeyAction)
+	}
+	if km.contextSequences[context] == nil {
+		km.contextSequences[context] = make(map[string]KeyAction)
+	}
+	km.contextSequences[context][seq] = action
+}
+
+// GetActionInContext returns the action for a key in the given context: the
+// binding made for the context, or else the one made with Bind.
+func (km *KeyMap) GetActionInContext(context KeyContext, key rune) KeyAction {
+	if km == nil {
+		return ActionNone
+	}
+	if action, exists := km.contextBindings[context][key]; exists {
+		return action
+	}
+	return km.GetAction(key)
+}
+
+// GetSequenceActionInContext returns the action for an escape sequence in the
+// given context: the binding made for the context, or else the one made with
+// BindSequence.
+func (km *KeyMap) GetSequenceActionInContext(context KeyContext, seq string) KeyAction {
+	if km == nil {
+		return ActionNone
+	}
+	if action, exists := km.contextSequences[context][seq]; exists {
+		return action
+	}
+	return km.GetSequenceAction(seq)
+}
+
 // GetAction returns the action for a key, or ActionNone if not bound
 func (km *KeyMap) GetAction(key rune) KeyAction {
 	if km == nil || km.bindings == nil {
@@ -230,26 +502,95 @@ func (km *KeyMap) GetSequenceAction(seq string) KeyAction {
 // - Relative path: "./app_history" (converted to absolute)
 // - XDG compliant: Use GetDefaultHistoryFile() for "~/.config/prompt/history"
 //
+// An entry is not added when it matches the previous one after Normalize,
+// which by default ignores surrounding whitespace so "ls" and "ls " are stored
+// once. Entries are stored as typed. To compare entries exactly, set Normalize
+// to a function that returns its argument unchanged.
+//
 // The implementation follows XDG Base Directory Specification when possible.
 type HistoryConfig struct {
-	Enabled     bool   // Enable/disable history functionality
-	MaxEntries  int    // Maximum number of entries to keep in memory (default: 1000)
-	File        string // File path for history persistence (empty = memory only)
-	MaxFileSize int64  // Maximum file size in bytes before rotation (default: 1MB)
-	MaxBackups  int    // Maximum number of backup files to keep (default: 3)
+	Enabled             bool                    // Enable/disable history functionality
+	MaxEntries          int                     // Maximum number of entries to keep in memory (default: 1000)
+	File                string                  // File path for history persistence (empty = memory only)
+	MaxFileSize         int64                   // Maximum file size in bytes before rotation (default: 1MB)
+	MaxBackups          int                     // Maximum number of backup files to keep (default: 3)
+	FileMode            os.FileMode             // Permissions of a newly created history file (default: 0600)
+	DirMode             os.FileMode             // Permissions of created parent directories (default: 0700)
+	DisableDirCreation  bool                    // Fail to save instead of creating missing parent directories
+	InsecurePermissions HistoryPermissionPolicy // What LoadHistory does with a group or world readable file (default: warn)
+	Normalize           func(string) string     // Form in which consecutive entries are compared to skip duplicates (nil = surrounding whitespace ignored)
+	RecordDir           bool                    // Store the working directory with each entry, for the "cwd:" filter of Ctrl+R
+	Context             func() string           // Returns a custom context string stored with each entry (nil stores none)
+	EraseDups           bool                    // Remove older entries equal to a new one anywhere in the history, like bash's erasedups
+	AppendOnSubmit      bool                    // Append each new entry to File as soon as it is submitted, not only on Close
+	IgnoreSpace         bool                    // Do not record entries that start with a space, like bash's ignorespace
+	ExcludePatterns     []string                // Regular expressions; entries matching any of them anywhere are never recorded
+	ExcludeFunc         func(string) bool       // Entries for which it returns true are never recorded, e.g. ones holding secrets
 }
 
 // Config holds the configuration for a prompt.
 type Config struct {
-	Prefix        string                      // Prompt prefix (e.g., "$ ")
-	Completer     func(Document) []Suggestion // Completion function (accepts Document for context)
-	HistoryConfig *HistoryConfig              // History configuration (nil for default)
-	ColorScheme   *ColorScheme                // Color scheme (nil for default)
-	KeyMap        *KeyMap                     // Key bindings (nil for default)
-	Theme         *ColorScheme                // Alias for ColorScheme for compatibility
-	Multiline     bool                        // Enable multiline input mode
-	IsComplete    func(input string) bool     // Decides whether Enter submits in multiline mode (nil = always submit)
-	WordEscape    bool                        // Treat backslash-escaped whitespace as part of a word during completion
+	Prefix             string                      // Prompt prefix (e.g., "$ ")
+	Completer          func(Document) []Suggestion // Completion function (accepts Document for context)
+	HistoryConfig      *HistoryConfig              // History configuration (nil for default)
+	ColorScheme        *ColorScheme                // Color scheme (nil for default)
+	KeyMap             *KeyMap                     // Key bindings (nil for default)
+	Theme              *ColorScheme                // Alias for ColorScheme for compatibility
+	Multiline          bool                        // Enable multiline input mode
+	IsComplete         func(input string) bool     // Decides whether Enter submits in multiline mode (nil = always submit)
+	InputComplete      func(text string) bool      // Decides whether Enter submits in any mode, inserting a newline while it is false (nil = always submit)
+	WordEscape         bool                        // Treat backslash-escaped whitespace as part of a word during completion
+	IdleInterval       time.Duration               // Time without key presses before OnIdle runs (0 disables the hook)
+	OnIdle             func(*PromptController)     // Called on the event loop after IdleInterval without input
+	Collation          *language.Tag               // Sort suggestions with this language's collation (nil keeps completer order)
+	DisplayTransform   func(text string) string    // Rewrites the buffer for display only (nil shows it as is)
+	Lexer              Lexer                       // Splits the input into colored tokens for syntax highlighting (nil disables it)
+	Terminal           Terminal                    // Terminal to read keys from (nil opens the controlling terminal)
+	Output             io.Writer                   // Destination of the rendered prompt (nil for stdout)
+	Placeholder        string                      // Dimmed hint shown while the input is empty
+	TTYPath            string                      // Terminal device to run on (empty = the controlling terminal)
+	AutoSuggest        bool                        // Show the best history or completer match after the cursor (fish-style)
+	EditMode           EditMode                    // Emacs-style (default) or vi-style modal editing
+	Messages           map[MessageID]string        // Replacements for built-in user-visible strings (missing IDs keep the default)
+	HistoryIndicator   bool                        // Show the position of the history entry being browsed at the right edge
+	AsyncCompleter     AsyncCompleteFunc           // Completer run off the event loop (takes precedence over Completer)
+	CompletionDebounce time.Duration               // Typing pause before an open async menu is refreshed (default: 100ms)
+	EchoTransform      func(input string) string   // Rewrites the submitted input when it is echoed (nil echoes it as typed)
+	CompleteAsYouType  bool                        // Open the suggestion menu as the user types instead of only on Tab
+	AsYouTypeMinChars  int                         // Word length before the menu opens while typing (0 means 1; MinCompletionChars also holds back Tab)
+	CompletionTriggers string                      // Characters that open the menu while typing regardless of the word length
+	CompletionMode     CompletionMode              // What Tab does when several suggestions match (default: MenuComplete)
+	FrameStats         func(FrameStats)            // Called after every frame with its render time, size and input latency
+	Validator          func(input string) error    // Rejects Enter while it returns an error, which is shown below the input
+	ValidateAsYouType  bool                        // Run the Validator after every edit, not only on Enter
+	InputSanitizer     func(input string) string   // Rewrites the submitted input before validation, history and Run (nil keeps it)
+	LiveInputSanitizer func(input string) string   // Rewrites the buffer after every edit (nil keeps it as typed)
+	MinCompletionChars int                         // Word length before the completer is queried at all, also on Tab, which shows a hint (0 disables; see AsYouTypeMinChars)
+	RightPrompt        func() string               // Returns dimmed text drawn flush right on the first input row, like zsh's RPROMPT
+	InlineCycleRows    int                         // Terminal height below which Tab cycles suggestions in the input line (0 means 5, negative disables)
+	ExitChecker        func(string, bool) bool     // Ends the session with ErrExit when it returns true for the input (breakline is true on Enter)
+	OnUnknownSequence  func(seq string)            // Told once about each escape sequence that has no key binding
+	TrimSpace          bool                        // Remove surrounding whitespace from the submitted input, before the InputSanitizer
+	InitialSuggestions []Suggestion                // Suggestions shown in the menu as soon as Run starts, before anything is typed
+	HistoryPrefix      bool                        // Up/Down only visit history entries starting with the text before the cursor
+	HideDescriptions   bool                        // Leave suggestion descriptions out of the menu until Alt+/ shows them
+	KeepCursorVisible  bool                        // Never hide the cursor, not even while the suggestion menu is drawn
+	Limits             Limits                      // Menu rows, search results and other sizes (zero fields use the defaults)
+	ColorProfile       ColorProfile                // Colors the terminal can display (ColorProfileAuto detects them)
+	BalanceCheck       BalanceCheck                // What Enter does with unclosed quotes and brackets (BalanceOff submits)
+	HorizontalScroll   bool                        // Scroll long single-line input sideways instead of wrapping it
+	PrefixSegments     []PrefixSegment             // Colored parts of the prefix, replacing Prefix when set
+	HistoryArrows      HistoryArrowPolicy          // When Up/Down may replace typed input with history (HistoryArrowAlways by default)
+	ExtendedKeys       bool                        // Ask the terminal to report every modifier of a key (kitty protocol or modifyOtherKeys)
+	Modes              []Mode                      // Named bundles of prefix, completer, key map and theme that SetMode switches between
+	ReservedRows       int                         // Rows the prompt always takes, padded with blank rows while the menu is closed (0 takes only those drawn)
+	SubmitKey          SubmitKey                   // Whether Enter or Alt+Enter submits while the other inserts a newline (SubmitKeyDefault leaves it to the key map)
+	RightAlwaysAccepts bool                        // Right accepts the selected suggestion wherever the cursor is, not only at the end of the input
+	Dedupe             DedupeMode                  // What happens to duplicate suggestions (DedupeOff shows them all)
+	DedupeKey          func(Suggestion) string     // Identity of a suggestion for Dedupe (nil compares the Text)
+	Footer             func() []string             // Returns dimmed rows drawn below the input and the menu, such as key hints
+	Input              io.Reader                   // Lines to read without raw mode, completion or rendering (nil = stdin when no terminal can be opened)
+	HostHandlesSignals bool                        // The program catches termination signals itself, so Run does not raise them again
 }
 
 // Option represents a configuration option for prompt
@@ -279,15 +620,14 @@ func WithHistory(historyConfig *HistoryConfig) Option {
 }
 
 // WithMemoryHistory is a convenience function for memory-only history setup.
+// A maxEntries of 0 or less keeps Limits.HistoryEntries entries (1000 by
+// default).
 //
 // Example:
 //
 //	prompt.New("$ ", prompt.WithMemoryHistory(100))
 func WithMemoryHistory(maxEntries int) Option {
 	return func(c *Config) {
-		if maxEntries <= 0 {
-			maxEntries = 1000 // Default
-		}
 		c.HistoryConfig = &HistoryConfig{
 			Enabled:    true,
 			MaxEntries: maxEntries,
@@ -297,15 +637,14 @@ func WithMemoryHistory(maxEntries int) Option {
 }
 
 // WithFileHistory is a convenience function for history with file persistence.
+// A maxEntries of 0 or less keeps Limits.HistoryEntries entries (1000 by
+// default).
 //
 // Example:
 //
 //	prompt.New("$ ", prompt.WithFileHistory("~/.myapp_history", 100))
 func WithFileHistory(file string, maxEntries int) Option {
 	return func(c *Config) {
-		if maxEntries <= 0 {
-			maxEntries = 1000 // Default
-		}
 		c.HistoryConfig = &HistoryConfig{
 			Enabled:     true,
 			MaxEntries:  maxEntries,
@@ -357,6 +696,35 @@ func WithIsComplete(isComplete func(input string) bool) Option {
 	}
 }
 
+// WithInputCompleteFunc sets a predicate consulted whenever Enter would submit
+// the input, in single-line and multiline mode alike. While it returns false
+// for the whole buffer, Enter inserts a newline instead, so a REPL can keep
+// reading a statement whose braces, quotes or blocks are still open.
+// BalancedInput is a ready-made predicate for shell-like input. A suggestion
+// selected in the menu is still accepted by Enter.
+//
+// Example:
+//
+//	prompt.New(">>> ", prompt.WithInputCompleteFunc(prompt.BalancedInput))
+func WithInputCompleteFunc(complete func(text string) bool) Option {
+	return func(c *Config) {
+		c.InputComplete = complete
+	}
+}
+
+// BalancedInput reports whether text has no unclosed quote or bracket and
+// does not end in a backslash, reading it like a shell line as
+// WithBalanceCheck does. Pass it to WithInputCompleteFunc to continue such
+// input on a new line.
+func BalancedInput(text string) bool {
+	trimmed := strings.TrimRight(text, " \t")
+	if backslashes := len(trimmed) - len(strings.TrimRight(trimmed, "\\")); backslashes%2 == 1 {
+		return false // Continued on the next line; an even run is escaped backslashes
+	}
+	_, ok := checkBalance([]rune(text))
+	return ok
+}
+
 // WithWordEscape makes completion treat backslash-escaped whitespace as part of
 // the word before the cursor. A shell-style path like "my\ data.csv" is then
 // completed and accepted as one word instead of breaking at the escaped space.
@@ -369,10 +737,388 @@ func WithWordEscape() Option {
 	}
 }
 
+// WithOnIdle registers a hook that runs when no key has been pressed for d.
+// The hook runs on the event loop goroutine and receives a PromptController, so
+// it can refresh dynamic prompt decorations such as a clock or the git status in
+// the prefix without waiting for the next keystroke. The prompt is redrawn once
+// after the hook returns. While the user stays idle the hook keeps running every
+// d; any key press restarts the countdown. A non-positive d or a nil hook
+// disables it.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithOnIdle(time.Second, func(c *prompt.PromptController) {
+//		c.SetPrefix(time.Now().Format("15:04:05") + " $ ")
+//	}))
+func WithOnIdle(d time.Duration, hook func(*PromptController)) Option {
+	return func(c *Config) {
+		c.IdleInterval = d
+		c.OnIdle = hook
+	}
+}
+
+// WithCollation sorts the suggestion menu alphabetically using the collation
+// rules of the given language instead of keeping the order the completer
+// returned. Byte order puts accented and non-Latin letters in surprising places
+// ("Zebra" before "apple", "Äpfel" after "Zucker"); collation orders localized
+// command or file names the way a native speaker expects. Suggestions that
+// compare equal keep their completer order.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithCollation(language.German))
+func WithCollation(tag language.Tag) Option {
+	return func(c *Config) {
+		c.Collation = &tag
+	}
+}
+
+// WithDisplayTransform sets a function that rewrites the input for display only.
+// Editing, completion, history and the value returned by Run all keep working on
+// the real text; the transform only changes what is drawn. Use it to mask
+// sensitive parts of the input, for example showing "**** **** **** 1234" while
+// a card number is typed.
+//
+// The transform is called with the whole buffer and with the text before the
+// cursor; the cursor is drawn right after the transformed text before the cursor.
+// Length-preserving transforms such as masking therefore keep the cursor exactly
+// where it is in the real text. The transformed text must not add or remove
+// newlines.
+//
+// Example:
+//
+//	prompt.New("card: ", prompt.WithDisplayTransform(func(text string) string {
+//		runes := []rune(text)
+//		for i := 0; i < len(runes)-4; i++ {
+//			if runes[i] != ' ' {
+//				runes[i] = '*'
+//			}
+//		}
+//		return string(runes)
+//	}))
+func WithDisplayTransform(transform func(text string) string) Option {
+	return func(c *Config) {
+		c.DisplayTransform = transform
+	}
+}
+
+// WithEchoTransform rewrites the submitted input on screen when Enter is
+// pressed, so the scrollback shows the canonical form of the command, for
+// example with aliases expanded or surrounding whitespace trimmed, rather than
+// exactly what was typed. The prompt line is redrawn with the transformed text
+// before the prompt moves to the next line. Only the echo changes: Run still
+// returns, and history still records, the input itself. A DisplayTransform
+// is applied to the echoed text as well.
+//
+// Example:
+//
+//	aliases := map[string]string{"ll": "ls -l", "la": "ls -a"}
+//	prompt.New("$ ", prompt.WithEchoTransform(func(input string) string {
+//		input = strings.TrimSpace(input)
+//		if expanded, ok := aliases[input]; ok {
+//			return expanded
+//		}
+//		return input
+//	}))
+func WithEchoTransform(transform func(input string) string) Option {
+	return func(c *Config) {
+		c.EchoTransform = transform
+	}
+}
+
+// WithInputSanitizer rewrites the input when Enter is pressed, before it is
+// validated, added to history and returned by Run, for example to trim
+// whitespace, normalize Unicode or expand aliases. The prompt line keeps
+// showing what was typed; combine it with WithEchoTransform to echo the
+// sanitized form.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithInputSanitizer(func(input string) string {
+//		return norm.NFC.String(strings.TrimSpace(input))
+//	}))
+func WithInputSanitizer(sanitize func(input string) string) Option {
+	return func(c *Config) {
+		c.InputSanitizer = sanitize
+	}
+}
+
+// WithSubmitTransform is another name for WithInputSanitizer: transform
+// normalizes the submitted input before it is validated, added to history and
+// returned by Run.
+func WithSubmitTransform(transform func(input string) string) Option {
+	return WithInputSanitizer(transform)
+}
+
+// WithHideCursorDuringRender sets whether the cursor is hidden while the
+// suggestion menu is drawn, which keeps it from flickering across the menu
+// rows. It is on by default. Turn it off for terminals or screen readers that
+// lose track of a hidden cursor; the cursor then stays visible, after the last
+// menu row while the menu is open. A hidden cursor is shown again whenever
+// Run returns, even through a panic, and by Close.
+func WithHideCursorDuringRender(enabled bool) Option {
+	return func(c *Config) {
+		c.KeepCursorVisible = !enabled
+	}
+}
+
+// WithInitialSuggestions opens the suggestion menu with suggestions as soon as
+// Run starts, before anything is typed, for menu-driven flows such as choosing
+// a subcommand to begin with. The first suggestion is selected, so Enter or Tab
+// takes it; typing or Escape closes the menu as usual.
+//
+// Example:
+//
+//	prompt.New("> ", prompt.WithInitialSuggestions([]prompt.Suggestion{
+//		{Text: "new", Description: "Create a project"},
+//		{Text: "open", Description: "Open a project"},
+//	}))
+func WithInitialSuggestions(suggestions []Suggestion) Option {
+	return func(c *Config) {
+		c.InitialSuggestions = suggestions
+	}
+}
+
+// WithTrimSpace removes leading and trailing whitespace from the submitted
+// input, so Run returns "ls" for "  ls " and history stores it once. It is
+// applied before any InputSanitizer. A line of only spaces becomes empty.
+func WithTrimSpace(enabled bool) Option {
+	return func(c *Config) {
+		c.TrimSpace = enabled
+	}
+}
+
+// WithLiveInputSanitizer rewrites the buffer after every edit, so the user
+// sees the transformed text while typing, such as upper-cased identifiers.
+// The cursor keeps its distance from the end of the buffer, which leaves it in
+// place for transforms that only change text before it or keep the length.
+// It is not applied while a bracketed paste is in progress, only once the
+// paste is complete.
+func WithLiveInputSanitizer(sanitize func(input string) string) Option {
+	return func(c *Config) {
+		c.LiveInputSanitizer = sanitize
+	}
+}
+
+// WithCompleteWhileTyping opens the suggestion menu automatically as the user
+// types, instead of only when Tab is pressed. The completer is queried again on
+// every edit; an async completer (see WithAsyncCompleter) is queried once
+// typing pauses for the debounce interval. Nothing is queried while text is
+// being pasted.
+//
+// By default the menu opens as soon as the word before the cursor has one
+// character; use WithCompleteWhileTypingTrigger to require more characters or
+// to open it after characters such as ".". In a menu opened while typing
+// nothing is selected, so Enter still submits the input as typed; Down or Tab
+// selects the first suggestion.
+//
+// Example:
+//
+//	prompt.New("> ",
+//		prompt.WithCompleter(completer),
+//		prompt.WithCompleteWhileTyping(true),
+//	)
+func WithCompleteWhileTyping(enabled bool) Option {
+	return func(c *Config) {
+		c.CompleteAsYouType = enabled
+	}
+}
+
+// WithCompleteWhileTypingTrigger sets when the menu opens with
+// WithCompleteWhileTyping: once the word before the cursor has at least
+// minChars characters, or right after typing any of the characters in
+// triggers. Tab still completes shorter words; use WithMinCompletionChars to
+// keep the completer from running for them at all.
+//
+// Example:
+//
+//	// Complete member names after "." and other words from 3 characters on
+//	prompt.New(">>> ",
+//		prompt.WithCompleter(completer),
+//		prompt.WithCompleteWhileTyping(true),
+//		prompt.WithCompleteWhileTypingTrigger(3, "."),
+//	)
+func WithCompleteWhileTypingTrigger(minChars int, triggers string) Option {
+	return func(c *Config) {
+		c.AsYouTypeMinChars = minChars
+		c.CompletionTriggers = triggers
+	}
+}
+
+// WithLexer enables syntax highlighting of the input. The lexer is called with
+// the text being drawn on every render and returns it split into tokens, each
+// with its own color, so a REPL can highlight keywords, strings and numbers as
+// the user types. The token texts must add up to the whole input; if they do
+// not, the input is drawn without highlighting. When a DisplayTransform is set,
+// the lexer sees the transformed text.
+//
+// Example:
+//
+//	keyword := prompt.Color{R: 255, G: 121, B: 198, Bold: true}
+//	prompt.New("sql> ", prompt.WithLexer(func(text string) []prompt.Token {
+//		var tokens []prompt.Token
+//		for _, word := range strings.SplitAfter(text, " ") {
+//			token := prompt.Token{Text: word}
+//			if strings.EqualFold(strings.TrimSpace(word), "select") {
+//				token.Color = &keyword
+//			}
+//			tokens = append(tokens, token)
+//		}
+//		return tokens
+//	}))
+func WithLexer(lexer Lexer) Option {
+	return func(c *Config) {
+		c.Lexer = lexer
+	}
+}
+
+// WithTerminal makes the prompt read keys and the terminal size from the given
+// Terminal instead of opening the controlling terminal. It is mainly meant for
+// tests; see the prompttest package for a scriptable implementation.
+func WithTerminal(terminal Terminal) Option {
+	return func(c *Config) {
+		c.Terminal = terminal
+	}
+}
+
+// WithTTYPath runs the prompt on the terminal device at path, for example
+// "/dev/pts/3", instead of the controlling terminal. Keys are read from the
+// device, raw mode is applied to it and the prompt is drawn to it (unless
+// WithOutput is also given). This lets a daemon without a controlling terminal
+// offer an admin console on a dedicated PTY. Device paths are only supported on
+// Unix-like systems.
+func WithTTYPath(path string) Option {
+	return func(c *Config) {
+		c.TTYPath = path
+	}
+}
+
+// WithTTYFd runs the prompt on the terminal open as file descriptor fd, for
+// example a PTY accepted from a client connection. The descriptor is reopened
+// through /dev/fd, so the caller keeps ownership of fd; see WithTTYPath.
+func WithTTYFd(fd uintptr) Option {
+	return func(c *Config) {
+		c.TTYPath = fmt.Sprintf("/dev/fd/%d", fd)
+	}
+}
+
+// WithInput makes the prompt read whole lines from r instead of keys from a
+// terminal, as it does from standard input on its own when no terminal can be
+// opened. Run then returns one line of r per call; see "Non-interactive input"
+// at RunWithContext.
+func WithInput(r io.Reader) Option {
+	return func(c *Config) {
+		c.Input = r
+	}
+}
+
+// WithOutput makes the prompt draw to w instead of stdout.
+func WithOutput(w io.Writer) Option {
+	return func(c *Config) {
+		c.Output = w
+	}
+}
+
+// WithPlaceholder sets a dimmed hint, such as "type a command…", that is shown
+// after the prefix while the input is empty. It disappears as soon as the user
+// types and is never part of the returned input.
+func WithPlaceholder(text string) Option {
+	return func(c *Config) {
+		c.Placeholder = text
+	}
+}
+
+// WithAutoSuggest enables fish-style auto-suggestions. While the cursor is at
+// the end of the input, the rest of the most recent matching history entry is
+// shown dimmed after it; when no history entry matches, the first completer
+// suggestion that extends the current word is used instead. Right arrow or End
+// accepts the whole suggestion and Ctrl+Right accepts its next word. The
+// suggestion is only drawn, never part of the returned input until accepted.
+func WithAutoSuggest() Option {
+	return func(c *Config) {
+		c.AutoSuggest = true
+	}
+}
+
+// WithRightAlwaysAccepts makes Right accept the selected suggestion wherever
+// the cursor is, as it did before. By default Right only accepts it while the
+// cursor is at the end of the input and otherwise moves the cursor, as in fish.
+func WithRightAlwaysAccepts(enabled bool) Option {
+	return func(c *Config) {
+		c.RightAlwaysAccepts = enabled
+	}
+}
+
+// WithEditMode selects the editing style. EditModeVi enables vi-style modal
+// editing: Escape switches to normal mode, where keys such as h, l, w, dw, cw
+// and dd move and edit, and i, a or A return to insert mode. The active mode
+// is shown in front of the prefix. See EditModeVi for the supported commands.
+//
+// Example:
+//
+//	p, err := prompt.New("$ ", prompt.WithEditMode(prompt.EditModeVi))
+func WithEditMode(mode EditMode) Option {
+	return func(c *Config) {
+		c.EditMode = mode
+	}
+}
+
+// WithHistoryIndicator shows a dimmed "[position/total]" indicator at the
+// right edge of the prompt while a history entry recalled with Up or Down is
+// on screen, so users can tell how far back they are. It disappears as soon as
+// the entry is edited. The indicator is left out when the input does not leave
+// room for it on the first row.
+//
+// Example:
+//
+//	p, err := prompt.New("$ ", prompt.WithMemoryHistory(500), prompt.WithHistoryIndicator())
+func WithHistoryIndicator() Option {
+	return func(c *Config) {
+		c.HistoryIndicator = true
+	}
+}
+
+// WithRightPrompt shows the text returned by rightPrompt flush right on the
+// first row of the input, like zsh's RPROMPT, for information such as the time
+// or the git branch. It is called on every redraw. The text is hidden while
+// the input would run into it, and erased when the line is submitted, like
+// zsh's TRANSIENT_RPROMPT. While a history entry is browsed, the
+// WithHistoryIndicator position is shown in front of it.
+//
+// Example:
+//
+//	p, err := prompt.New("$ ", prompt.WithRightPrompt(func() string {
+//		return time.Now().Format("15:04")
+//	}))
+func WithRightPrompt(rightPrompt func() string) Option {
+	return func(c *Config) {
+		c.RightPrompt = rightPrompt
+	}
+}
+
+// Token is a span of input text and the color it is drawn in.
+type Token struct {
+	Text  string // Text of the token
+	Color *Color // Color of the token (nil for the theme's input color)
+}
+
+// Lexer splits input text into tokens for syntax highlighting.
+// The texts of the returned tokens must concatenate to the input.
+type Lexer func(text string) []Token
+
 // Suggestion represents a completion suggestion.
+//
+// Only Text and Description are required. The other fields change how the
+// suggestion is shown in the completion menu, which lays them out in aligned
+// columns: icon, text, category and description.
 type Suggestion struct {
-	Text        string // The text to complete
-	Description string // Description of the suggestion
+	Text             string // The text to complete
+	Description      string // Description of the suggestion
+	DisplayText      string // Text shown in the menu instead of Text (empty shows Text)
+	Category         string // Short group label shown in its own column, e.g. "file" or "command"
+	Icon             string // Symbol shown in front of the text, e.g. "📁"
+	Color            *Color // Color of the text when not selected (nil for the theme's suggestion color)
+	DescriptionColor *Color // Color of the category and description (nil for the theme's description color)
 }
 
 // Suggest is an alias for Suggestion for compatibility
@@ -521,13 +1267,17 @@ func New(prefix string, options ...Option) (*Prompt, error) {
 }
 
 func newFromConfig(config Config) (*Prompt, error) {
+	if len(config.PrefixSegments) > 0 {
+		config.Prefix = joinPrefixSegments(config.PrefixSegments)
+	}
 	// Set defaults for history config
 	if config.HistoryConfig == nil {
 		config.HistoryConfig = DefaultHistoryConfig()
+		config.HistoryConfig.MaxEntries = config.Limits.withDefaults().HistoryEntries
 	} else {
 		// Set defaults for incomplete history config
 		if config.HistoryConfig.MaxEntries <= 0 {
-			config.HistoryConfig.MaxEntries = 1000
+			config.HistoryConfig.MaxEntries = config.Limits.withDefaults().HistoryEntries
 		}
 		if config.HistoryConfig.MaxFileSize <= 0 {
 			config.HistoryConfig.MaxFileSize = 1024 * 1024 // 1MB
@@ -547,17 +1297,22 @@ func newFromConfig(config Config) (*Prompt, error) {
 		config.KeyMap = NewDefaultKeyMap()
 	}
 
-	// Setup output writer with color support
-	var output io.Writer = os.Stdout
-	if runtime.GOOS == windowsOS {
-		// Use colorable for Windows ANSI color support
-		output = colorable.NewColorableStdout()
+	if config.ColorProfile == ColorProfileAuto && config.Terminal == nil {
+		config.ColorProfile = DetectColorProfile()
 	}
 
-	// Create terminal interface using external libraries
-	terminal, err := newRealTerminal()
+	// Reject invalid patterns before the terminal is opened
+	historyExclude, err := newHistoryExclusion(config.HistoryConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create terminal: %w", err)
+		return nil, err
+	}
+
+	terminal, output, err := openTerminal(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.FrameStats != nil {
+		output = &countingWriter{w: output}
 	}
 
 	// Initialize history manager
@@ -565,6 +1320,7 @@ func newFromConfig(config Config) (*Prompt, error) {
 
 	// Load history from file if configured
 	if err := historyManager.LoadHistory(); err != nil {
+		_ = terminal.Close()
 		return nil, fmt.Errorf("failed to load history: %w", err)
 	}
 
@@ -576,16 +1332,54 @@ func newFromConfig(config Config) (*Prompt, error) {
 		output:         output,
 		history:        historyManager.GetHistory(),
 		historyManager: historyManager,
+		historyExclude: historyExclude,
 		terminal:       terminal,
 		keyMap:         config.KeyMap,
 	}
 
 	// Initialize renderer
 	p.renderer = newRenderer(output, config.ColorScheme, p.terminal)
+	p.configureRenderer()
 
 	return p, nil
 }
 
+// openTerminal returns the terminal and output writer for config: the ones it
+// names, line input from Input or from a standard input that is not a
+// terminal, a terminal device opened from TTYPath, or the controlling terminal
+// and standard output.
+func openTerminal(config Config) (Terminal, io.Writer, error) {
+	// Create terminal interface using external libraries unless one was given
+	terminal := config.Terminal
+	var deviceOutput io.Writer
+	if terminal == nil {
+		terminal = lineInput(config)
+	}
+	if terminal == nil && config.TTYPath != "" {
+		deviceTerminal, err := newDeviceTerminal(config.TTYPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open terminal %s: %w", config.TTYPath, err)
+		}
+		terminal = deviceTerminal
+		deviceOutput = deviceTerminal.output
+	} else if terminal == nil {
+		terminal = controllingOrLines(newControllingTerminal)
+	}
+
+	// Setup output writer with color support
+	output := config.Output
+	if output == nil && deviceOutput != nil {
+		output = deviceOutput
+	} else if output == nil {
+		output = os.Stdout
+		if runtime.GOOS == windowsOS {
+			// Use colorable for Windows ANSI color support
+			output = colorable.NewColorableStdout()
+		}
+	}
+	return terminal, output, nil
+}
+
 // Run starts the interactive prompt and returns the user input.
 //
 // This is a convenience method that calls RunWithContext with a background context.
@@ -614,16 +1408,34 @@ func (p *Prompt) Run() (string, error) {
 // Supported key bindings include:
 //   - Enter: Submit input (or add newline in multi-line mode)
 //   - Ctrl+C: Cancel and return ErrInterrupted
-//   - Ctrl+D: EOF when buffer is empty
+//   - Ctrl+D: Return ErrEOF when buffer is empty, else delete the character at the cursor
 //   - Arrow keys: Navigate history or move cursor
 //   - Ctrl+A/Home: Move to beginning of line
 //   - Ctrl+E/End: Move to end of line
 //   - Ctrl+K: Delete from cursor to end of line
 //   - Ctrl+U: Delete entire line
-//   - Ctrl+W: Delete word backwards
+//   - Ctrl+W, Alt+Backspace: Delete word backwards, Alt+D: forwards
+//   - Alt+B/Alt+F: Move by word
+//   - Alt+U/Alt+L/Alt+C: Uppercase, lowercase, capitalize word
+//   - Ctrl+T/Alt+T: Transpose characters, words
+//   - Alt+digits: Repeat the next movement, deletion or character
+//   - Ctrl+Y: Yank the last deleted text, Alt+Y: cycle through older ones
+//   - Ctrl+_: Undo, Alt+_: Redo
 //   - Ctrl+R: Reverse history search
 //   - Tab: Auto-completion
 //
+// # Non-interactive input
+//
+// When no terminal can be opened, as in a CI job or under cron, or input was
+// given with WithInput, there are no keys to handle: each call reads the next
+// line, of standard input or of the given reader, without raw mode and returns
+// it without drawing the prompt, completion or anything else. TrimSpace, the
+// InputSanitizer and the ExitChecker apply as on Enter, the Validator turns a
+// rejected line into an error, and the line is not recorded in history. At the
+// end of the input, RunWithContext returns ErrEOF; a last line without a line
+// ending is returned first. Standard input that is merely piped does not count:
+// the prompt still reads keys from /dev/tty when it can open it.
+//
 // Example with timeout:
 //
 //	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -642,12 +1454,24 @@ func (p *Prompt) Run() (string, error) {
 //	}
 //	fmt.Printf("Input: %s\n", input)
 func (p *Prompt) RunWithContext(ctx context.Context) (string, error) {
+	if p.busyStop != nil {
+		p.busyStop()
+		p.busyStop = nil
+	}
+	if p.readsLines() {
+		return p.runLines(ctx)
+	}
 	if err := p.enterRawMode(); err != nil {
 		return "", fmt.Errorf("failed to enter raw mode: %w", err)
 	}
 
 	restored := false
 	defer func() {
+		// Deferred calls also run when the event loop panics, so neither the
+		// cursor nor raw mode outlive Run whichever way it ends
+		if err := p.renderer.showCursor(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to show the cursor: %v\n", err)
+		}
 		// Only restore if not already restored (prevents double restoration)
 		if !restored {
 			if err := p.exitRawMode(); err != nil {
@@ -657,19 +1481,73 @@ func (p *Prompt) RunWithContext(ctx context.Context) (string, error) {
 		}
 	}()
 
+	if p.session != nil {
+		// Pick up a key the previous prompt of the session left in flight, and
+		// pass on the one this prompt leaves.
+		p.keyCh = p.session.takeKeys()
+		defer func() {
+			p.session.keepKeys(p.keyCh)
+			p.keyCh = nil
+		}()
+	}
+
 	// Initialize buffer and display
 	p.buffer = []rune{}
 	p.cursor = 0
+	p.viNormal = false
+	p.historyIndex = len(p.history)
+	p.historyEdits = nil
+	p.unbalanced = nil
+	p.lastSuggestion = nil // The history may have changed since the last Run
+	p.resetUndo()
+	p.applyDraft()
+	p.renderer.lastFrame = "" // The app may have written below the last prompt
 	if err := p.render(); err != nil {
 		return "", fmt.Errorf("failed to render prompt: %w", err)
 	}
 
-	historyIndex := len(p.history)
 	inPaste := false
+	lastAction := ActionNone // Previous command, for joining kills and yank-pop
+	argument := -1           // Numeric argument typed with Alt+digits, -1 when none
 	var suggestions []Suggestion
 	selectedSuggestion := 0
 	suggestionOffset := 0 // Track the offset for scrolling through suggestions
 
+	var cycle *inlineCycle // Tab cycling suggestions in the input line on a short terminal
+	positions := menuMemory{}
+	if len(p.config.InitialSuggestions) > 0 && !p.cyclesInline() {
+		// Open the menu before anything is typed, as if Tab had been pressed
+		suggestions = p.matchSuggestions(Document{Text: string(p.buffer), CursorPosition: p.cursor}, p.config.InitialSuggestions)
+		if err := p.renderWithSuggestionsOffset(suggestions, selectedSuggestion, suggestionOffset); err != nil {
+			return "", fmt.Errorf("failed to render prompt: %w", err)
+		}
+	}
+
+	idle := p.newIdleTimer()
+	if idle != nil {
+		defer idle.Stop()
+	}
+	async := p.newAsyncCompleter(ctx)
+	defer async.stop()
+	var resize <-chan struct{}
+	if notifier, ok := p.terminal.(resizeNotifier); ok {
+		resize = notifier.ResizeEvents()
+	}
+	watcher, _ := p.terminal.(terminationWatcher)
+	var terminate <-chan os.Signal // Signals that end the program, caught to restore the terminal first
+	releaseTermination := func() {}
+	if watcher != nil {
+		terminate, releaseTermination = watcher.watchTermination()
+		defer releaseTermination()
+	}
+	jobs, _ := p.terminal.(jobController)
+	var cont <-chan os.Signal // SIGCONT while the program is a job the shell can stop
+	if jobs != nil {
+		var release func()
+		cont, release = jobs.watchContinue()
+		defer release()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -677,33 +1555,226 @@ func (p *Prompt) RunWithContext(ctx context.Context) (string, error) {
 		default:
 		}
 
-		// Read key input
-		r, err := p.readRune()
+		// Wait for the next key, redrawing when the terminal is resized and
+		// running the idle hook whenever the user stays quiet for the configured
+		// interval. A nil channel never fires, which disables unused events.
+		var idleC <-chan time.Time
+		if idle != nil {
+			idleC = idle.C
+		}
+		var ev keyEvent
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-resize:
+			if width, _, err := p.terminal.Size(); err == nil {
+				p.renderer.reflow(width)
+			}
+			if err := p.renderMenu(async, suggestions, selectedSuggestion, suggestionOffset); err != nil {
+				return "", fmt.Errorf("failed to render: %w", err)
+			}
+			continue
+		case sig := <-terminate:
+			restored = true
+			releaseTermination()
+			return "", p.terminate(watcher, sig)
+		case <-cont:
+			if err := p.resume(); err != nil {
+				return "", err
+			}
+			if err := p.renderMenu(async, suggestions, selectedSuggestion, suggestionOffset); err != nil {
+				return "", fmt.Errorf("failed to render: %w", err)
+			}
+			continue
+		case <-idleC:
+			p.config.OnIdle(newPromptController(p))
+			idle.Reset(p.config.IdleInterval)
+			if err := p.renderMenu(async, suggestions, selectedSuggestion, suggestionOffset); err != nil {
+				return "", fmt.Errorf("failed to render: %w", err)
+			}
+			continue
+		case <-async.timerC():
+			async.fire()
+			if err := p.renderMenu(async, suggestions, selectedSuggestion, suggestionOffset); err != nil {
+				return "", fmt.Errorf("failed to render: %w", err)
+			}
+			continue
+		case result := <-async.resultC():
+			async.received()
+			suggestions = nil
+			if result.err == nil {
+				suggestions = p.matchSuggestions(result.doc, result.suggestions)
+			}
+			selectedSuggestion = 0
+			if !result.explicit && p.config.CompleteAsYouType {
+				selectedSuggestion = -1 // Opened while typing: Enter still submits
+			}
+			suggestionOffset = 0
+			if !result.explicit {
+				// Refreshed while typing: back to where the user was for this word
+				if selected, offset, ok := positions.restore(p.completionWord(result.doc), suggestions); ok {
+					selectedSuggestion, suggestionOffset = selected, offset
+				}
+			}
+			if result.explicit && len(suggestions) == 1 {
+				// Like synchronous completion, Tab completes a single match
+				p.acceptSuggestion(suggestions[0])
+				suggestions = nil
+			} else if result.explicit && p.config.CompletionMode == PrefixComplete && p.insertCommonPrefix(result.doc, suggestions) {
+				suggestions = nil
+			} else if len(suggestions) > 0 && p.cyclesInline() {
+				// No room for the menu: Tab cycles in the input line instead
+				if result.explicit {
+					cycle = p.startCycle(suggestions)
+				}
+				suggestions = nil
+			}
+			if err := p.renderMenu(async, suggestions, selectedSuggestion, suggestionOffset); err != nil {
+				return "", fmt.Errorf("failed to render: %w", err)
+			}
+			continue
+		case ev = <-p.nextKey():
+			p.keyCh = nil
+			p.keyReadAt = time.Now()
+			if idle != nil {
+				resetTimer(idle, p.config.IdleInterval)
+			}
+		}
+
+		r, err := ev.r, ev.err
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				return "", ErrEOF
 			}
 			return "", fmt.Errorf("failed to read input: %w", err)
 		}
+		p.menuHint = "" // A hint only stays up until the next key
+		p.applyPendingKeyMap()
 
 		var action KeyAction
+		before := p.snapshot()                              // Buffer before this key, for undo
+		inserted := false                                   // The key typed text, which joins the current undo step
+		menuOpen := len(suggestions) > 0 || async.loading() // Typing refreshes an open async menu
+		edited := false                                     // The key typed or deleted text
+		keyContext := KeyContextEditing
+		accepting := len(suggestions) > 0 && selectedSuggestion >= 0 // Enter accepts the selected suggestion
+		if len(suggestions) > 0 {
+			keyContext = KeyContextMenu
+			positions.save(p.completionWord(Document{Text: string(p.buffer), CursorPosition: p.cursor}), suggestions, selectedSuggestion, suggestionOffset)
+		} else {
+			clear(positions) // A menu opened again starts at the top
+			p.descToggled = false
+		}
 
+		key := runeKey(r) // The key pressed, decoded from its escape sequence below
 		// Handle escape sequences
 		if r == '\x1b' {
+			if p.config.EditMode == EditModeVi && p.loneEscape() {
+				if !p.viNormal {
+					p.enterViNormal()
+				}
+				p.recordEdit(before, false)
+				suggestions = nil
+				async.stop()
+				if err := p.render(); err != nil {
+					return "", fmt.Errorf("failed to render prompt: %w", err)
+				}
+				continue
+			}
+			if len(suggestions) > 0 && p.escapeTimedOut() {
+				// Escape on its own closes the menu
+				suggestions = nil
+				async.stop()
+				if err := p.render(); err != nil {
+					return "", fmt.Errorf("failed to render prompt: %w", err)
+				}
+				continue
+			}
 			seq, err := p.readEscapeSequence()
 			if err != nil {
 				continue
 			}
-			action = p.keyMap.GetSequenceAction(seq)
+			action = p.keyMap.sequenceAction(keyContext, seq)
+			if enter, isKey := decodeSequence(seq); isKey {
+				if submit, ok := p.submitKeyAction(keyContext, enter, accepting); ok {
+					action = submit
+				}
+			}
+			if action == ActionNone && isExtendedSequence(seq) {
+				// Without a Key binding, the key is handled as what a terminal
+				// without the extended encodings sends for it
+				key, _ := decodeSequence(seq)
+				classic, classicSeq, ok := classicInput(key)
+				if ok && classicSeq == "" {
+					p.unreadKey(classic)
+					continue
+				}
+				if ok {
+					seq = classicSeq
+					action = p.keyMap.sequenceAction(keyContext, seq)
+				}
+			}
+			key, _ = decodeSequence(seq)
+			if action == ActionNone {
+				p.reportUnknownSequence(seq)
+			}
+		} else if p.viNormal && (!unicode.IsControl(r) || r == '\x7f' || r == '\b') {
+			// Printable keys and Backspace are commands in vi normal mode
+			action, err = p.viNormalKey(r)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return "", ErrEOF
+				}
+				return "", fmt.Errorf("failed to read input: %w", err)
+			}
+			if action == ActionNone {
+				p.recordEdit(before, false)
+				suggestions = nil
+				async.stop()
+				if err := p.render(); err != nil {
+					return "", fmt.Errorf("failed to render prompt: %w", err)
+				}
+				continue
+			}
 		} else {
-			action = p.keyMap.GetAction(r)
+			action = p.keyMap.runeAction(keyContext, r)
+			if submit, ok := p.submitKeyAction(keyContext, key, accepting); ok {
+				action = submit
+			}
+		}
+
+		if digit := rune(key.Code) - '0'; !p.viNormal && (action == ActionDigitArgument ||
+			(argument >= 0 && action == ActionNone && r >= '0' && r <= '9')) && digit >= 0 && digit <= 9 {
+			// Plain digits extend an argument once Alt+digit started it
+			argument = min(max(argument, 0)*10+int(digit), maxNumericArgument)
+			p.menuHint = fmt.Sprintf(p.message(MsgNumericArgument), argument)
+			if err := p.renderMenu(async, suggestions, selectedSuggestion, suggestionOffset); err != nil {
+				return "", fmt.Errorf("failed to render: %w", err)
+			}
+			continue
+		}
+		if argument >= 0 {
+			if p.repeatAction(action, r, argument-1, isKillAction(lastAction)) {
+				suggestions = nil
+				edited = true
+			}
+			if isKillAction(action) && argument > 1 {
+				lastAction = action // The last run joins the kills of the repeated ones
+			}
+			argument = -1
+		}
+
+		previousAction := lastAction
+		lastAction = action
+		if action != ActionComplete {
+			cycle = nil // Any other key keeps the suggestion in the buffer
 		}
 
 		// Execute action
 		switch action {
 		case ActionSubmit:
-			// If suggestions are displayed, accept the selected one and continue editing
-			if len(suggestions) > 0 {
+			// If a suggestion is selected, accept it and continue editing
+			if len(suggestions) > 0 && selectedSuggestion >= 0 {
 				p.acceptSuggestion(suggestions[selectedSuggestion])
 				suggestions = nil
 				// Clear suggestions and continue editing without submitting
@@ -712,22 +1783,46 @@ func (p *Prompt) RunWithContext(ctx context.Context) (string, error) {
 				// content is inserted into the buffer instead of being submitted early.
 				if inPaste {
 					p.insertRune('\n')
+					inserted = true
 					suggestions = nil
 				} else if p.isShiftEnter() {
 					p.insertRune('\n')
 					suggestions = nil
-				} else if p.config.Multiline && p.config.IsComplete != nil && !p.config.IsComplete(string(p.buffer)) {
+				} else if p.blockEdit {
+					// Block editing: Enter only ends the line until it is switched off
+					p.insertRune('\n')
+					suggestions = nil
+				} else if !p.inputComplete() {
 					// The app reports the statement is incomplete, so keep editing on a
 					// new line instead of submitting (e.g. SQL buffered until ";").
 					p.insertRune('\n')
 					suggestions = nil
+				} else if p.rejectUnbalanced(true) {
+					// Stay on the line with the unclosed quote or bracket marked
+					suggestions = nil
+				} else if result, exit := p.submission(); !exit && p.rejectInput(result) {
+					// Stay on the line with the error shown below it
+					suggestions = nil
 				} else {
-					result := string(p.buffer)
-					if result != "" && (len(p.history) == 0 || p.history[len(p.history)-1] != result) {
+					if p.renderer.suggestionsActive || p.renderer.errorMessage != "" {
+						// Erase the menu opened while typing, a hint row or the
+						// fixed validation error before leaving the line
+						if err := p.render(); err != nil {
+							return "", fmt.Errorf("failed to render prompt: %w", err)
+						}
+					}
+					if !p.hiddenFromHistory() {
 						p.addToHistory(result)
 					}
+					if err := p.echoSubmitted(result); err != nil {
+						return "", fmt.Errorf("failed to render: %w", err)
+					}
+					p.clearGhost()
 					fmt.Fprint(p.output, "\r\n")
 					// Terminal will be restored by defer, no need to mark as restored here
+					if exit {
+						return result, ErrExit
+					}
 					return result, nil
 				}
 			}
@@ -739,7 +1834,8 @@ func (p *Prompt) RunWithContext(ctx context.Context) (string, error) {
 				fmt.Fprintf(os.Stderr, "Warning: failed to restore terminal state: %v\n", err)
 			}
 			restored = true // Mark as restored to prevent double restoration in defer
-			fmt.Fprint(p.output, "^C\r\n")
+			p.clearGhost()
+			fmt.Fprint(p.output, p.message(MsgInterrupted)+"\r\n")
 			return "", ErrInterrupted
 
 		case ActionMoveLeft:
@@ -748,12 +1844,14 @@ func (p *Prompt) RunWithContext(ctx context.Context) (string, error) {
 			}
 
 		case ActionMoveRight:
-			if len(suggestions) > 0 {
+			if len(suggestions) > 0 && selectedSuggestion >= 0 && (p.cursor == len(p.buffer) || p.config.RightAlwaysAccepts) {
 				// Accept current suggestion and continue editing
 				p.acceptSuggestion(suggestions[selectedSuggestion])
 				suggestions = nil
 			} else if p.cursor < len(p.buffer) {
 				p.cursor++
+			} else {
+				p.acceptAutoSuggestion(false)
 			}
 
 		case ActionMoveUp:
@@ -766,161 +1864,181 @@ func (p *Prompt) RunWithContext(ctx context.Context) (string, error) {
 						suggestionOffset = selectedSuggestion
 					}
 				}
-			} else if p.isMultiLine() {
-				// Navigate up within multi-line input
-				p.cursor = p.findCursorUp()
-			} else {
-				// Navigate history
-				if historyIndex > 0 {
-					historyIndex--
-					p.setBuffer(p.history[historyIndex])
-					suggestions = nil
-				}
+			} else if p.moveDisplayRow(-1) {
+				// Moved up a row within long or multi-line input
+			} else if p.keepTypedLine(-1) {
+				// The HistoryArrows policy leaves the typed input in place
+			} else if p.historyUp(p.prefixBrowsing()) {
+				suggestions = nil
 			}
 
 		case ActionMoveDown:
 			if len(suggestions) > 0 {
 				// Navigate suggestions with scrolling
-				maxDisplayed := 10 // Maximum suggestions to display at once
 				if selectedSuggestion < len(suggestions)-1 {
 					selectedSuggestion++
-					// Scroll down if needed
-					if selectedSuggestion >= suggestionOffset+maxDisplayed {
-						suggestionOffset = selectedSuggestion - maxDisplayed + 1
-					}
-				}
-			} else if p.isMultiLine() {
-				// Navigate down within multi-line input
-				p.cursor = p.findCursorDown()
-			} else {
-				// Navigate history
-				if historyIndex < len(p.history) {
-					historyIndex++
-					if historyIndex == len(p.history) {
-						p.setBuffer("")
-					} else {
-						p.setBuffer(p.history[historyIndex])
-					}
-					suggestions = nil
+					suggestionOffset = scrollMenu(selectedSuggestion, suggestionOffset, p.renderer.menuRows())
 				}
+			} else if p.moveDisplayRow(1) {
+				// Moved down a row within long or multi-line input
+			} else if p.keepTypedLine(1) {
+				// The HistoryArrows policy leaves the typed input in place
+			} else if p.historyDown(p.prefixBrowsing()) {
+				suggestions = nil
 			}
 
-		case ActionMoveHome:
-			if p.isMultiLine() {
-				p.cursor = p.findLineStart()
-			} else {
-				p.cursor = 0
+		case ActionHistoryUp, ActionHistoryPrefixUp:
+			if p.historyUp(action == ActionHistoryPrefixUp) {
+				suggestions = nil
 			}
 
+		case ActionHistoryDown, ActionHistoryPrefixDown:
+			if p.historyDown(action == ActionHistoryPrefixDown) {
+				suggestions = nil
+			}
+
+		case ActionMoveHome:
+			p.cursor = p.displayRowStart()
+
 		case ActionMoveEnd:
-			if p.isMultiLine() {
-				p.cursor = p.findLineEnd()
-			} else {
-				p.cursor = len(p.buffer)
+			if p.acceptAutoSuggestion(false) {
+				break
 			}
+			p.cursor = p.displayRowEnd()
 
 		case ActionMoveWordLeft:
 			p.cursor = p.findWordBoundary(-1)
 
 		case ActionMoveWordRight:
-			p.cursor = p.findWordBoundary(1)
+			if !p.acceptAutoSuggestion(true) {
+				p.cursor = p.findWordBoundary(1)
+			}
 
 		case ActionDeleteChar:
-			if r == '\x7f' || r == '\b' {
-				// Backspace
-				if p.cursor > 0 {
-					p.buffer = append(p.buffer[:p.cursor-1], p.buffer[p.cursor:]...)
-					p.cursor--
-					suggestions = nil
-				}
-			} else {
-				// Delete key
-				if p.cursor < len(p.buffer) {
-					p.buffer = append(p.buffer[:p.cursor], p.buffer[p.cursor+1:]...)
-					suggestions = nil
-				}
+			if p.deleteChar(r) {
+				suggestions = nil
+				edited = true
 			}
 
 		case ActionDeleteLine:
-			p.buffer = []rune{}
-			p.cursor = 0
+			p.kill(0, len(p.buffer), isKillAction(previousAction))
 
 		case ActionDeleteToEnd:
-			if p.isMultiLine() {
-				lineEnd := p.findLineEnd()
-				p.buffer = append(p.buffer[:p.cursor], p.buffer[lineEnd:]...)
-			} else {
-				p.buffer = p.buffer[:p.cursor]
-			}
+			p.kill(p.cursor, p.findLineEnd(), isKillAction(previousAction))
 
 		case ActionDeleteWordBack:
 			if p.cursor > 0 {
-				newPos := p.findWordBoundary(-1)
-				p.buffer = append(p.buffer[:newPos], p.buffer[p.cursor:]...)
-				p.cursor = newPos
+				p.kill(p.findWordBoundary(-1), p.cursor, isKillAction(previousAction))
+				suggestions = nil
+			}
+
+		case ActionDeleteWordForward:
+			if p.cursor < len(p.buffer) {
+				p.kill(p.cursor, p.findWordBoundary(1), isKillAction(previousAction))
+				suggestions = nil
+			}
+
+		case ActionUpcaseWord, ActionDowncaseWord, ActionCapitalizeWord:
+			if p.changeWordCase(action) {
+				suggestions = nil
+			}
+
+		case ActionTransposeChars:
+			if p.transposeChars() {
+				suggestions = nil
+			}
+
+		case ActionTransposeWords:
+			if p.transposeWords() {
 				suggestions = nil
 			}
 
+		case ActionYank:
+			p.yank()
+			suggestions = nil
+
+		case ActionYankPop:
+			if previousAction == ActionYank || previousAction == ActionYankPop {
+				p.yankPop()
+				suggestions = nil
+			} else {
+				lastAction = ActionNone
+			}
+
 		case ActionComplete:
-			if p.config.Completer != nil {
+			if cycle != nil {
+				cycle.advance(p)
+			} else if len(suggestions) == 0 && !async.loading() && p.belowMinCompletionChars() {
+				// Too short to search a large candidate set; say so instead
+				p.menuHint = fmt.Sprintf(p.message(MsgCompletionMinChars), p.config.MinCompletionChars)
+			} else if async != nil {
+				if len(suggestions) > 0 {
+					if selectedSuggestion, suggestions = p.completeFromMenu(suggestions, selectedSuggestion); suggestions == nil {
+						async.stop()
+					}
+				} else {
+					async.start(Document{Text: string(p.buffer), CursorPosition: p.cursor}, true)
+				}
+			} else if p.config.Completer != nil {
 				if len(suggestions) > 0 {
 					// TAB accepts the currently selected suggestion
-					p.acceptSuggestion(suggestions[selectedSuggestion])
-					suggestions = nil
+					selectedSuggestion, suggestions = p.completeFromMenu(suggestions, selectedSuggestion)
 				} else {
 					// Generate new suggestions
 					doc := Document{
 						Text:           string(p.buffer),
 						CursorPosition: p.cursor,
 					}
-					suggestions = p.config.Completer(doc)
+					suggestions = p.matchSuggestions(doc, p.config.Completer(doc))
 					selectedSuggestion = 0
 					suggestionOffset = 0 // Reset scroll position
-
-					// Smart matching: filter suggestions based on current input
-					currentWord := p.completionWord(doc)
-					if currentWord != "" {
-						// Filter suggestions to only show those that match the current input
-						filteredSuggestions := make([]Suggestion, 0)
-						for _, suggestion := range suggestions {
-							if strings.HasPrefix(suggestion.Text, currentWord) {
-								filteredSuggestions = append(filteredSuggestions, suggestion)
-							}
-						}
-						suggestions = filteredSuggestions
-
-						// If no suggestions match, don't show anything
-						if len(suggestions) == 0 {
-							suggestions = nil
-						} else if len(suggestions) == 1 {
-							// If only one suggestion matches, auto-complete
-							p.acceptSuggestion(suggestions[0])
-							suggestions = nil
-						}
-						// Multiple filtered suggestions: show them for user selection
-					} else {
-						// No current word (at space or beginning)
-						// Show all suggestions for user selection
-						if len(suggestions) == 1 {
-							// Single suggestion: auto-complete
-							p.acceptSuggestion(suggestions[0])
-							suggestions = nil
-						}
-						// Multiple suggestions: show them for user selection
+					if len(suggestions) == 1 {
+						// If only one suggestion matches, auto-complete
+						p.acceptSuggestion(suggestions[0])
+						suggestions = nil
+					} else if p.config.CompletionMode == PrefixComplete && p.insertCommonPrefix(doc, suggestions) {
+						suggestions = nil // The menu is shown on the next Tab
+					} else if len(suggestions) > 1 && p.cyclesInline() {
+						cycle, suggestions = p.startCycle(suggestions), nil
 					}
+					// Multiple suggestions: show them for user selection
 				}
 			}
 
 		case ActionHistorySearch:
 			if result, err := p.searchHistory(); err == nil && result != "" {
 				p.setBuffer(result)
-				historyIndex = len(p.history)
+				p.historyIndex = len(p.history)
 			}
 			// Re-render after search
 			if err := p.render(); err != nil {
 				return "", fmt.Errorf("failed to render prompt: %w", err)
 			}
 
+		case ActionAbort:
+			// Close the menu, keeping the input as it is. A numeric argument
+			// was already dropped above, since abort does not repeat.
+			suggestions = nil
+			async.stop()
+			fmt.Fprint(p.output, "\a")
+
+		case ActionSuspend:
+			if cont == nil {
+				break // Not a job the shell can stop, so Ctrl+Z is ignored
+			}
+			suggestions = nil
+			async.stop()
+			if err := p.suspend(jobs); err != nil {
+				return "", err
+			}
+			continue // Drawn again once continued
+
+		case ActionPreview:
+			if err := p.previewBuffer(); err != nil {
+				return "", fmt.Errorf("failed to preview input: %w", err)
+			}
+			suggestions = nil
+
 		case ActionNewLine:
 			p.insertRune('\n')
 			suggestions = nil
@@ -938,6 +2056,48 @@ func (p *Prompt) RunWithContext(ctx context.Context) (string, error) {
 			p.renderer.clearScreen()
 			suggestions = nil
 
+		case ActionMenuFirst:
+			selectedSuggestion = 0
+			suggestionOffset = 0
+
+		case ActionMenuLast:
+			if len(suggestions) > 0 {
+				selectedSuggestion = len(suggestions) - 1
+				suggestionOffset = max(0, len(suggestions)-p.renderer.menuRows()) // Show the last page
+			}
+
+		case ActionMenuPageUp, ActionMenuPageDown:
+			if len(suggestions) > 0 {
+				rows := p.renderer.menuRows()
+				delta := rows
+				if action == ActionMenuPageUp {
+					delta = -rows
+				}
+				selectedSuggestion, suggestionOffset = pageMenu(selectedSuggestion, suggestionOffset, delta, len(suggestions), rows)
+			}
+
+		case ActionToggleMultiline:
+			p.blockEdit = !p.blockEdit
+
+		case ActionToggleDescriptions:
+			if len(suggestions) > 0 {
+				p.descToggled = !p.descToggled
+			}
+
+		case actionFunc:
+			if err := p.keyMap.funcs[key](newPromptController(p)); err != nil {
+				return "", err
+			}
+			suggestions = nil // The handler may have changed the input the menu was for
+
+		case ActionUndo:
+			p.undo()
+			suggestions = nil
+
+		case ActionRedo:
+			p.redo()
+			suggestions = nil
+
 		default:
 			// Handle regular character input
 			if r >= 32 && r < 127 || r > 127 { // Printable characters
@@ -947,17 +2107,55 @@ func (p *Prompt) RunWithContext(ctx context.Context) (string, error) {
 					continue
 				}
 				p.insertRune(r)
-				suggestions = nil             // Clear suggestions on new input
-				historyIndex = len(p.history) // Reset history position
+				inserted = true
+				suggestions = nil // Clear suggestions on new input
+				edited = true
 			} else if r == '\x04' { // Ctrl+D (EOF)
 				if len(p.buffer) == 0 {
-					return "", io.EOF
+					p.clearGhost()
+					return "", ErrEOF
+				}
+				if p.deleteChar(r) { // Deletes the character at the cursor otherwise
+					suggestions = nil
+					edited = true
+				}
+			}
+		}
+
+		if action != ActionUndo && action != ActionRedo {
+			p.recordEdit(before, inserted)
+		}
+		if p.viNormal {
+			p.clampViCursor()
+		}
+		if !inPaste && (action == ActionPasteEnd || !slices.Equal(before.buffer, p.buffer)) {
+			p.applyLiveSanitizer()
+		}
+		if !slices.Equal(before.buffer, p.buffer) {
+			p.revalidate()
+			if !inPaste && p.exitsWhileTyping() {
+				return p.exitLine()
+			}
+		}
+		if edited && !inPaste && ((menuOpen && async != nil && !p.belowMinCompletionChars()) || p.completesWhileTyping(r)) {
+			doc := Document{Text: string(p.buffer), CursorPosition: p.cursor}
+			if async != nil {
+				async.schedule(doc)
+			} else {
+				// Nothing is selected, so Enter still submits what was typed
+				suggestions = p.matchSuggestions(doc, p.config.Completer(doc))
+				selectedSuggestion = -1
+				suggestionOffset = 0
+				if selected, offset, ok := positions.restore(p.completionWord(doc), suggestions); ok {
+					selectedSuggestion, suggestionOffset = selected, offset
 				}
 			}
+		} else if action != ActionComplete && len(suggestions) == 0 {
+			async.stop() // The menu was closed
 		}
 
 		// Re-render with suggestions if any
-		if err := p.renderWithSuggestionsOffset(suggestions, selectedSuggestion, suggestionOffset); err != nil {
+		if err := p.renderMenu(async, suggestions, selectedSuggestion, suggestionOffset); err != nil {
 			return "", fmt.Errorf("failed to render: %w", err)
 		}
 	}
@@ -980,8 +2178,13 @@ func (p *Prompt) RunWithContext(ctx context.Context) (string, error) {
 //	// Use the prompt...
 //	result, err := p.Run()
 func (p *Prompt) Close() error {
+	if p.busyStop != nil {
+		p.busyStop()
+		p.busyStop = nil
+	}
+
 	// Restore cursor visibility before closing
-	if p.output != nil {
+	if p.output != nil && !p.readsLines() {
 		fmt.Fprint(p.output, "\x1b[?25h") // Show cursor
 		fmt.Fprint(p.output, "\n")        // Move to new line
 	}
@@ -1133,16 +2336,110 @@ func (p *Prompt) SetTheme(theme *ColorScheme) {
 	p.config.ColorScheme = theme
 	p.config.Theme = theme
 	p.renderer = newRenderer(p.output, theme, p.terminal)
+	p.configureRenderer()
+}
+
+// configureRenderer passes the rendering options from the config to the renderer.
+func (p *Prompt) configureRenderer() {
+	p.renderer.lexer = p.config.Lexer
+	p.renderer.placeholder = p.config.Placeholder
+	p.renderer.keepCursor = p.config.KeepCursorVisible
+	p.renderer.maxRows = p.config.Limits.MenuRows
+	p.renderer.profile = p.config.ColorProfile
+	p.renderer.hscroll = p.config.HorizontalScroll
+	p.renderer.reservedRows = p.config.ReservedRows
 }
 
 // SetPrefix changes the prompt prefix
 func (p *Prompt) SetPrefix(prefix string) {
 	p.config.Prefix = prefix
+	p.config.PrefixSegments = nil
+}
+
+// SetKeyMap replaces the key bindings without recreating the prompt, for
+// example to switch to an application-specific key map from a toggle key or a
+// command. It is safe to call from any goroutine, including while Run is
+// waiting for input: the new key map is used from the next key on. A nil key
+// map restores the default bindings.
+func (p *Prompt) SetKeyMap(keyMap *KeyMap) {
+	if keyMap == nil {
+		keyMap = NewDefaultKeyMap()
+	}
+	p.keyMapMu.Lock()
+	defer p.keyMapMu.Unlock()
+	p.pendingKeyMap = keyMap
+}
+
+// applyPendingKeyMap switches to the key map set by SetKeyMap, if any.
+func (p *Prompt) applyPendingKeyMap() {
+	p.keyMapMu.Lock()
+	defer p.keyMapMu.Unlock()
+	if p.pendingKeyMap != nil {
+		p.keyMap = p.pendingKeyMap
+		p.config.KeyMap = p.pendingKeyMap
+		p.pendingKeyMap = nil
+	}
+}
+
+// SetMultiline switches block editing on or off, so a REPL can take a
+// multi-line block on demand. While it is on, Enter inserts a newline instead
+// of submitting, regardless of IsComplete; once it is switched off again, Enter
+// submits as before. The state lasts across calls to Run. Like the other
+// setters it must not be called concurrently with Run, except from callbacks
+// such as a Completer or OnIdle hook. ActionToggleMultiline switches it from a
+// key.
+func (p *Prompt) SetMultiline(enabled bool) {
+	p.blockEdit = enabled
+}
+
+// completeFromMenu handles Tab while the suggestion menu is open: the selected
+// suggestion is accepted, closing the menu. In a menu opened while typing,
+// where nothing is selected yet, a single suggestion is accepted and otherwise
+// the first one is selected. It returns the new selection and suggestions.
+func (p *Prompt) completeFromMenu(suggestions []Suggestion, selected int) (int, []Suggestion) {
+	if selected < 0 && len(suggestions) > 1 {
+		return 0, suggestions
+	}
+	p.acceptSuggestion(suggestions[max(selected, 0)])
+	return 0, nil
+}
+
+// completesWhileTyping reports whether the suggestion menu should open after
+// the key r edited the input: complete-while-typing is enabled and r is one
+// of the trigger characters, or the word before the cursor is long enough.
+func (p *Prompt) completesWhileTyping(r rune) bool {
+	if !p.config.CompleteAsYouType || (p.config.Completer == nil && p.config.AsyncCompleter == nil) || p.cyclesInline() {
+		return false
+	}
+	if strings.ContainsRune(p.config.CompletionTriggers, r) {
+		return true
+	}
+	word := p.completionWord(Document{Text: string(p.buffer), CursorPosition: p.cursor})
+	return len([]rune(word)) >= max(1, p.config.AsYouTypeMinChars, p.config.MinCompletionChars)
+}
+
+// matchSuggestions drops duplicate suggestions of a completer, sorts the rest
+// and keeps the ones that start with the word before the cursor in doc. With
+// no word before the cursor all of them are kept.
+func (p *Prompt) matchSuggestions(doc Document, suggestions []Suggestion) []Suggestion {
+	suggestions = p.sortSuggestions(p.dedupeSuggestions(suggestions))
+	currentWord := p.completionWord(doc)
+	if currentWord == "" {
+		return suggestions
+	}
+	matches := make([]Suggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		if strings.HasPrefix(suggestion.Text, currentWord) {
+			matches = append(matches, suggestion)
+		}
+	}
+	return matches
 }
 
 // SetCompleter changes the completion function
 func (p *Prompt) SetCompleter(completer func(Document) []Suggestion) {
 	p.config.Completer = completer
+	p.lastSuggestion = nil
 }
 
 // fuzzyMatcher provides reusable fuzzy matching logic for completions and history search
@@ -1223,10 +2520,8 @@ func (f *fuzzyMatcher) fuzzySearch(query string) []fuzzyMatch {
 	}
 
 	var matches []fuzzyMatch
-	queryLower := strings.ToLower(query)
-
 	for _, item := range f.items {
-		if score := calculateFuzzyScore(queryLower, strings.ToLower(item), false); score > 0 {
+		if score, _ := FuzzyScore(query, item); score > 0 {
 			matches = append(matches, fuzzyMatch{
 				text:  item,
 				score: score,
@@ -1234,14 +2529,10 @@ func (f *fuzzyMatcher) fuzzySearch(query string) []fuzzyMatch {
 		}
 	}
 
-	// Sort by score (descending)
-	for i := range len(matches) - 1 {
-		for j := i + 1; j < len(matches); j++ {
-			if matches[i].score < matches[j].score {
-				matches[i], matches[j] = matches[j], matches[i]
-			}
-		}
-	}
+	// Sort by score (descending), keeping the item order among equal scores
+	slices.SortStableFunc(matches, func(a, b fuzzyMatch) int {
+		return b.score - a.score
+	})
 
 	return matches
 }
@@ -1266,9 +2557,13 @@ func (f *fuzzyMatcher) fuzzySearch(query string) []fuzzyMatch {
 //
 // Used for implementing Ctrl+Left/Right navigation and Ctrl+W word deletion.
 func (p *Prompt) findWordBoundary(direction int) int {
+	return p.wordBoundaryFrom(p.cursor, direction)
+}
+
+// wordBoundaryFrom is findWordBoundary from pos instead of the cursor.
+func (p *Prompt) wordBoundaryFrom(pos, direction int) int {
 	if direction > 0 {
 		// Find next word start (Ctrl+Right)
-		pos := p.cursor
 		for pos < len(p.buffer) && !isWordChar(p.buffer[pos]) {
 			pos++ // Skip non-word characters
 		}
@@ -1278,7 +2573,6 @@ func (p *Prompt) findWordBoundary(direction int) int {
 		return pos
 	}
 	// Find previous word start (Ctrl+Left)
-	pos := p.cursor
 	if pos > 0 {
 		pos-- // Move back one position
 	}
@@ -1353,16 +2647,24 @@ func (f *fuzzyMatcher) searchFunc(query string) []string {
 	return results
 }
 
-// searchHistory implements reverse history search (like Ctrl+R in bash)
+// searchHistory implements reverse history search (like Ctrl+R in bash). The
+// matches are listed below the input, pinned entries first and most recent
+// first among equally good ones; Ctrl+R, Tab and Down move to the next match
+// and Ctrl+S and Up to the previous one, and Ctrl+T pins or unpins the
+// selected match. Enter returns the selected match, or the query when nothing
+// matches. Escape, Ctrl+G, Ctrl+C and a key bound to ActionAbort end the
+// search and return an empty string, so the input is left as it was.
 func (p *Prompt) searchHistory() (string, error) {
-	search := NewHistorySearcher(p.history)
+	search := p.historySearcher()
 	searchBuffer := []rune{}
 	searchResults := search("")
 	selectedIndex := 0
 
 	for {
 		// Render search interface
-		p.renderHistorySearch(string(searchBuffer), searchResults, selectedIndex)
+		if err := p.renderHistorySearch(string(searchBuffer), searchResults, selectedIndex); err != nil {
+			return "", fmt.Errorf("failed to render history search: %w", err)
+		}
 
 		// Read key input
 		r, err := p.readRune()
@@ -1377,9 +2679,24 @@ func (p *Prompt) searchHistory() (string, error) {
 			}
 			return string(searchBuffer), nil
 
-		case '\x03', '\x1b': // Ctrl+C or Escape - cancel search
+		case '\x03', '\x07': // Ctrl+C or Ctrl+G - abort search
 			return "", nil
 
+		case '\x1b':
+			if p.loneEscape() { // Escape - abort search
+				return "", nil
+			}
+			seq, err := p.readEscapeSequence()
+			if err != nil {
+				return "", err
+			}
+			switch seq {
+			case "[A", "OA": // Up - previous result
+				selectedIndex = cycleIndex(selectedIndex, -1, len(searchResults))
+			case "[B", "OB": // Down - next result
+				selectedIndex = cycleIndex(selectedIndex, 1, len(searchResults))
+			}
+
 		case '\x7f', '\b': // Backspace
 			if len(searchBuffer) > 0 {
 				searchBuffer = searchBuffer[:len(searchBuffer)-1]
@@ -1387,12 +2704,24 @@ func (p *Prompt) searchHistory() (string, error) {
 				selectedIndex = 0
 			}
 
-		case '\t': // Tab - next result
-			if len(searchResults) > 0 {
-				selectedIndex = (selectedIndex + 1) % len(searchResults)
+		case '\x12', '\t': // Ctrl+R or Tab - next result
+			selectedIndex = cycleIndex(selectedIndex, 1, len(searchResults))
+
+		case '\x13': // Ctrl+S - previous result
+			selectedIndex = cycleIndex(selectedIndex, -1, len(searchResults))
+
+		case '\x14': // Ctrl+T - pin or unpin the selected result, which stays selected
+			if selectedIndex < len(searchResults) {
+				entry := searchResults[selectedIndex]
+				p.togglePinned(entry)
+				searchResults = search(string(searchBuffer))
+				selectedIndex = max(0, slices.Index(searchResults, entry))
 			}
 
 		default:
+			if p.keyMap.runeAction(KeyContextEditing, r) == ActionAbort {
+				return "", nil
+			}
 			if r >= 32 && r < 127 || r > 127 { // Printable characters
 				searchBuffer = append(searchBuffer, r)
 				searchResults = search(string(searchBuffer))
@@ -1402,46 +2731,165 @@ func (p *Prompt) searchHistory() (string, error) {
 	}
 }
 
-// renderHistorySearch renders the history search interface
-func (p *Prompt) renderHistorySearch(query string, results []string, selected int) {
-	// Clear screen
-	fmt.Fprint(p.output, "\r\x1b[K")
-
-	// Show search prompt
-	fmt.Fprintf(p.output, "reverse-i-search: %s", query)
+// cycleIndex moves index by delta within n items, wrapping around at both ends.
+func cycleIndex(index, delta, n int) int {
+	if n == 0 {
+		return 0
+	}
+	return ((index+delta)%n + n) % n
+}
 
-	// Show selected result if any
-	if selected < len(results) && len(results) > 0 {
-		fmt.Fprintf(p.output, " -> %s", results[selected])
+// historySearcher returns the search function of Ctrl+R. A query starting with
+// "cwd:" only searches the entries added in a directory, which needs
+// HistoryConfig.RecordDir: "cwd:" alone means the current directory and
+// "cwd:PATH" the given one. The rest of the query after a space is matched as
+// usual. Entries are searched newest first, so the most recent of equally
+// good matches comes first, and pinned entries are moved before the others.
+func (p *Prompt) historySearcher() func(string) []string {
+	newest := slices.Clone(p.history)
+	slices.Reverse(newest)
+	searchAll := NewHistorySearcher(newest)
+	search := func(query string) []string {
+		filter, rest, _ := strings.Cut(query, " ")
+		dir, ok := strings.CutPrefix(filter, historyDirFilter)
+		if !ok {
+			return searchAll(query)
+		}
+		dir, err := historyFilterDir(dir)
+		if err != nil {
+			return nil
+		}
+		var items []string
+		for _, entry := range slices.Backward(p.historyEntries()) {
+			if entry.Dir == dir {
+				items = append(items, entry.Text)
+			}
+		}
+		return NewHistorySearcher(items)(rest)
 	}
+	return func(query string) []string {
+		return p.pinnedFirst(search(query))
+	}
+}
 
-	fmt.Fprint(p.output, "\r\n")
+// historyDirFilter starts a Ctrl+R query that only searches the entries added
+// in a directory.
+const historyDirFilter = "cwd:"
 
-	// Show top 5 results
-	maxResults := 5
-	if len(results) > maxResults {
-		results = results[:maxResults]
+// historyFilterDir returns the absolute directory named by a "cwd:" filter,
+// or the current directory when it names none.
+func historyFilterDir(dir string) (string, error) {
+	if dir == "" {
+		return os.Getwd()
 	}
+	return expandHistoryPath(dir)
+}
 
-	for i, result := range results {
+// historyEntries returns the history with the context of each entry. Entries
+// kept without a history manager have no context.
+func (p *Prompt) historyEntries() []HistoryEntry {
+	if p.historyManager != nil && p.historyManager.IsEnabled() {
+		return p.historyManager.GetEntries()
+	}
+	entries := make([]HistoryEntry, len(p.history))
+	for i, text := range p.history {
+		entries[i] = HistoryEntry{Text: text}
+	}
+	return entries
+}
+
+// renderHistorySearch draws the reverse history search below the input: a
+// search line with the query, the selected match and a match counter, then a
+// page of the matches with the characters the query matched highlighted and
+// underlined and pinned matches marked with a star. The selected match is drawn in the Selected color, which the
+// built-in themes show in reverse video. The cursor is left after the query.
+func (p *Prompt) renderHistorySearch(query string, results []string, selected int) error {
+	colors := p.renderer.colorScheme
+	width := p.renderer.width() - 1 // Never fill the last column, which would wrap
+
+	// Matches, highlighted with the part of the query after a "cwd:" filter
+	matchQuery := query
+	if filter, rest, _ := strings.Cut(query, " "); strings.HasPrefix(filter, historyDirFilter) {
+		matchQuery = rest
+	}
+
+	// Search line. The query is drawn in the match color, and so are the
+	// characters of the selected match it matched, to make plain what Enter
+	// recalls.
+	col := min(stringWidth(p.message(MsgHistorySearch)+sanitizeText(query)), width)
+	position := 0
+	if len(results) > 0 {
+		position = selected + 1
+	}
+	counter := "  " + fmt.Sprintf(p.message(MsgHistorySearchCount), position, len(results))
+	match := colors.Suggestion.Match.onRow(colors.Input)
+	var line strings.Builder
+	used := 0
+	write := func(text string, positions []int, color Color) {
+		n := max(0, width-stringWidth(counter)-used)
+		line.WriteString(p.renderer.ansi(color) + p.renderer.highlightRunes(text, positions, color, match, n) + Reset())
+		used += min(stringWidth(sanitizeText(text)), n)
+	}
+	write(p.message(MsgHistorySearch), nil, colors.Input)
+	write(query, nil, match)
+	if selected < len(results) {
+		_, positions := FuzzyScore(matchQuery, results[selected])
+		write(p.message(MsgHistorySearchMatch), nil, colors.Input)
+		write(results[selected], positions, colors.Input)
+	}
+	rows := []string{line.String() +
+		p.renderer.ansi(colors.Suggestion.Description) + truncateWidth(counter, width-used) + Reset()}
+
+	// The list scrolls to keep the selected match in view
+	pageRows := p.limits().SearchResults
+	offset := max(0, selected-pageRows+1)
+	pinned := p.pinnedHistory()
+	for i := offset; i < min(offset+pageRows, len(results)); i++ {
+		marker, color := "  ", colors.Suggestion.Text
 		if i == selected {
-			fmt.Fprintf(p.output, "  > %s\r\n", result)
-		} else {
-			fmt.Fprintf(p.output, "    %s\r\n", result)
+			marker, color = "▶ ", colors.Selected
+		}
+		if pinned[results[i]] {
+			marker += "★ "
 		}
+		// Matched characters are underlined too, so they stand out on the
+		// reverse video of the selected match
+		match := colors.Suggestion.Match.onRow(color)
+		match.Underline = true
+		_, positions := FuzzyScore(matchQuery, results[i])
+		rows = append(rows, p.renderer.ansi(color)+marker+
+			p.renderer.highlightRunes(results[i], positions, color, match, width-stringWidth(marker))+Reset())
+	}
+
+	return p.renderer.renderOverlay(p.prefix(), string(p.buffer), rows, col)
+}
+
+// highlightRunes returns as much of text, sanitized, as fits in n columns,
+// drawn in color with the runes at positions drawn in match instead.
+func (r *renderer) highlightRunes(text string, positions []int, color, match Color, n int) string {
+	var b strings.Builder
+	used := 0
+	for i, ch := range []rune(text) {
+		cell := sanitizeText(string(ch))
+		if used += stringWidth(cell); used > n {
+			break
+		}
+		if len(positions) > 0 && positions[0] == i {
+			positions = positions[1:]
+			b.WriteString(r.ansi(match) + cell + Reset() + r.ansi(color))
+			continue
+		}
+		b.WriteString(cell)
 	}
+	return b.String()
 }
 
 // syncHistoryAfterAdd synchronizes in-memory history with history manager after adding an entry.
 func (p *Prompt) syncHistoryAfterAdd() {
 	if p.historyManager != nil && p.historyManager.IsEnabled() {
+		// Trim history if it exceeds max size, keeping the pinned entries
+		p.historyManager.trim(p.getMaxHistoryEntries())
 		p.history = p.historyManager.GetHistory()
-		// Trim in-memory history if it exceeds max size
-		maxEntries := p.getMaxHistoryEntries()
-		if len(p.history) > maxEntries {
-			p.history = p.history[len(p.history)-maxEntries:]
-			p.historyManager.SetHistory(p.history)
-		}
 	}
 }
 
@@ -1450,10 +2898,17 @@ func (p *Prompt) getMaxHistoryEntries() int {
 	if p.config.HistoryConfig != nil && p.config.HistoryConfig.MaxEntries > 0 {
 		return p.config.HistoryConfig.MaxEntries
 	}
-	return 1000 // Default max entries
+	return p.limits().HistoryEntries
 }
 
 // addToHistory adds text to history, handling both historyManager and in-memory fallback
+// hiddenFromHistory reports whether the buffer submitted with Enter starts
+// with a space while HistoryConfig.IgnoreSpace is set. The buffer is checked
+// as typed, since TrimSpace or the InputSanitizer may have removed the space.
+func (p *Prompt) hiddenFromHistory() bool {
+	return p.config.HistoryConfig != nil && p.config.HistoryConfig.IgnoreSpace && strings.HasPrefix(string(p.buffer), " ")
+}
+
 func (p *Prompt) addToHistory(text string) {
 	if text == "" {
 		return
@@ -1463,14 +2918,28 @@ func (p *Prompt) addToHistory(text string) {
 		if p.historyManager.IsEnabled() {
 			p.historyManager.AddEntry(text)
 			p.syncHistoryAfterAdd()
+			if p.config.HistoryConfig != nil && p.config.HistoryConfig.AppendOnSubmit {
+				if err := p.historyManager.appendLatest(); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to save history: %v\n", err)
+				}
+			}
 		}
 		// Do nothing when history manager exists but is disabled
 		return
 	}
 
 	// Fallback to in-memory only (when no history manager)
-	if len(p.history) > 0 && p.history[len(p.history)-1] == text {
-		return // Avoid duplicate consecutive entries
+	if isDuplicateHistoryEntry(p.config.HistoryConfig, p.history, text) {
+		return
+	}
+	if p.historyExclude.excludes(text) {
+		return
+	}
+	if p.config.HistoryConfig != nil && p.config.HistoryConfig.EraseDups {
+		normalized := normalizeHistoryEntry(p.config.HistoryConfig, text)
+		p.history = slices.DeleteFunc(p.history, func(h string) bool {
+			return normalizeHistoryEntry(p.config.HistoryConfig, h) == normalized
+		})
 	}
 	p.history = append(p.history, text)
 
@@ -1481,6 +2950,17 @@ func (p *Prompt) addToHistory(text string) {
 	}
 }
 
+// inputComplete reports whether Enter may submit the buffer: the IsComplete
+// predicate in multiline mode and the InputComplete predicate in any mode must
+// both accept it.
+func (p *Prompt) inputComplete() bool {
+	text := string(p.buffer)
+	if p.config.Multiline && p.config.IsComplete != nil && !p.config.IsComplete(text) {
+		return false
+	}
+	return p.config.InputComplete == nil || p.config.InputComplete(text)
+}
+
 // isShiftEnter detects if we should add a newline instead of submitting
 func (p *Prompt) isShiftEnter() bool {
 	currentLine := p.getCurrentLineText()
@@ -1626,7 +3106,11 @@ func (p *Prompt) enterRawMode() error {
 		return err
 	}
 	if p.output != nil {
-		if _, err := fmt.Fprint(p.output, bracketedPasteEnableSequence); err != nil {
+		enable := bracketedPasteEnableSequence
+		if p.extendedKeys() {
+			enable += extendedKeysEnableSequence
+		}
+		if _, err := fmt.Fprint(p.output, enable); err != nil {
 			return errors.Join(err, p.terminal.Restore())
 		}
 	}
@@ -1636,7 +3120,11 @@ func (p *Prompt) enterRawMode() error {
 func (p *Prompt) exitRawMode() error {
 	var errs []error
 	if p.output != nil {
-		if _, err := fmt.Fprint(p.output, bracketedPasteDisableSequence); err != nil {
+		disable := bracketedPasteDisableSequence
+		if p.extendedKeys() {
+			disable = extendedKeysDisableSequence + disable
+		}
+		if _, err := fmt.Fprint(p.output, disable); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -1650,38 +3138,343 @@ func (p *Prompt) exitRawMode() error {
 }
 
 func (p *Prompt) render() error {
-	return p.renderer.render(p.config.Prefix, string(p.buffer), p.cursor)
+	defer p.observeFrame(p.frameStart())
+	text, cursor := p.displayText()
+	p.renderer.autoSuggestion = p.autoSuggestion()
+	p.renderer.rightSegment = p.rightSegment()
+	p.renderer.errorMessage = p.invalid
+	p.renderer.marks = p.balanceMarks()
+	p.renderer.segments = p.prefixSegments()
+	p.renderer.footer = p.footer()
+	return p.renderer.render(p.prefix(), text, cursor)
 }
 
 func (p *Prompt) renderWithSuggestionsOffset(suggestions []Suggestion, selected int, offset int) error {
-	return p.renderer.renderWithSuggestionsOffset(p.config.Prefix, string(p.buffer), p.cursor, suggestions, selected, offset)
+	defer p.observeFrame(p.frameStart())
+	text, cursor := p.displayText()
+	p.renderer.autoSuggestion = ""
+	if len(suggestions) == 0 {
+		p.renderer.autoSuggestion = p.autoSuggestion()
+	}
+	p.renderer.rightSegment = p.rightSegment()
+	p.renderer.errorMessage = p.invalid
+	p.renderer.marks = p.balanceMarks()
+	p.renderer.segments = p.prefixSegments()
+	p.renderer.footer = p.footer()
+	if p.config.HideDescriptions != p.descToggled {
+		suggestions = withoutDescriptions(suggestions)
+	}
+	p.renderer.menuCounter = ""
+	if selected >= 0 && len(suggestions) > p.renderer.menuRows() {
+		p.renderer.menuCounter = fmt.Sprintf(p.message(MsgMenuPosition), selected+1, len(suggestions))
+	}
+	return p.renderer.renderWithSuggestionsOffset(p.prefix(), text, cursor, suggestions, selected, offset)
+}
+
+// rightSegment returns the text drawn at the right edge of the first row: the
+// history indicator and the RightPrompt, separated by a space when both are
+// shown.
+func (p *Prompt) rightSegment() string {
+	segment := p.historyIndicator()
+	if p.config.RightPrompt == nil {
+		return segment
+	}
+	rightPrompt := sanitizeText(p.config.RightPrompt())
+	if segment == "" || rightPrompt == "" {
+		return segment + rightPrompt
+	}
+	return segment + " " + rightPrompt
+}
+
+// historyIndicator returns the "[position/total]" text shown while a recalled
+// history entry is in the buffer unchanged, or "" when the indicator is
+// disabled, no entry is being browsed or the entry has been edited.
+func (p *Prompt) historyIndicator() string {
+	if !p.config.HistoryIndicator || p.historyIndex < 0 || p.historyIndex >= len(p.history) {
+		return ""
+	}
+	if string(p.buffer) != p.history[p.historyIndex] {
+		return ""
+	}
+	return fmt.Sprintf(p.message(MsgHistoryPosition), p.historyIndex+1, len(p.history))
+}
+
+// submittedInput returns the buffer as it is submitted: without surrounding
+// whitespace with TrimSpace, then rewritten by the InputSanitizer when one is
+// set.
+func (p *Prompt) submittedInput() string {
+	input := string(p.buffer)
+	if p.config.TrimSpace {
+		input = strings.TrimSpace(input)
+	}
+	if p.config.InputSanitizer != nil {
+		input = p.config.InputSanitizer(input)
+	}
+	return input
+}
+
+// applyLiveSanitizer rewrites the buffer with the LiveInputSanitizer, keeping
+// the cursor at the same distance from the end of the buffer.
+func (p *Prompt) applyLiveSanitizer() {
+	if p.config.LiveInputSanitizer == nil {
+		return
+	}
+	sanitized := []rune(p.config.LiveInputSanitizer(string(p.buffer)))
+	if slices.Equal(sanitized, p.buffer) {
+		return
+	}
+	fromEnd := len(p.buffer) - p.cursor
+	p.buffer = sanitized
+	p.cursor = max(0, len(p.buffer)-fromEnd)
+}
+
+// clearGhost erases a visible placeholder, auto-suggestion or right-aligned
+// segment before the prompt line is left behind, so it does not stay on screen
+// as if it had been entered. The cursor sits at the start of the ghost text, so
+// erasing to the end of the line is enough for it; the right-aligned segment is
+// on the cursor's row too and is erased from its first column. A validation
+// error and the footer below the input, and the mark of an unbalanced quote or
+// bracket, are erased by drawing the prompt again without them.
+func (p *Prompt) clearGhost() {
+	if p.renderer.errorMessage != "" || len(p.renderer.footer) > 0 {
+		p.invalid = ""
+		p.unbalanced = nil
+		footer := p.config.Footer
+		p.config.Footer = nil
+		_ = p.render()
+		p.config.Footer = footer
+	}
+	if p.renderer.rightEnd > 0 {
+		start := p.renderer.rightEnd - stringWidth(p.renderer.rightSegment)
+		fmt.Fprintf(p.output, "\x1b[%dG\x1b[K\x1b[%dG", start+1, p.renderer.frameCursorCol+1)
+		p.renderer.rightEnd = 0
+		p.renderer.lastFrame = ""
+	}
+	if p.renderer.ghostWidth > 0 {
+		fmt.Fprint(p.output, "\x1b[K")
+		p.renderer.ghostWidth = 0
+		p.renderer.lastFrame = ""
+	}
+}
+
+// echoSubmitted redraws the prompt line with the submitted input rewritten by
+// the EchoTransform, without suggestions, placeholder or other dimmed extras.
+// It does nothing when no EchoTransform is set.
+func (p *Prompt) echoSubmitted(input string) error {
+	if p.config.EchoTransform == nil {
+		return nil
+	}
+	text := p.config.EchoTransform(input)
+	if p.config.DisplayTransform != nil {
+		text = p.config.DisplayTransform(text)
+	}
+	placeholder := p.renderer.placeholder
+	p.renderer.placeholder = ""
+	p.renderer.autoSuggestion = ""
+	p.renderer.rightSegment = ""
+	err := p.renderer.render(p.prefix(), text, len([]rune(text)))
+	p.renderer.placeholder = placeholder
+	return err
+}
+
+// autoSuggestion returns the fish-style suggestion for the rest of the input:
+// the remainder of the most recent history entry that starts with the buffer,
+// or else of the first completer suggestion that extends the word before the
+// cursor. It is empty unless auto-suggestions are enabled and the cursor is at
+// the end of a non-empty buffer. Multi-line candidates are skipped, and so is
+// everything while a DisplayTransform is set, so masked input is never
+// revealed through history.
+//
+// The suggestion is kept until the buffer or the cursor changes, so redraws
+// after a resize or an idle tick do not run the completer again.
+func (p *Prompt) autoSuggestion() string {
+	if !p.config.AutoSuggest || p.config.DisplayTransform != nil || len(p.buffer) == 0 || p.cursor != len(p.buffer) {
+		return ""
+	}
+	text := string(p.buffer)
+	if cached := p.lastSuggestion; cached != nil && cached.text == text && cached.cursor == p.cursor {
+		return cached.suggestion
+	}
+	suggestion := p.findAutoSuggestion(text)
+	p.lastSuggestion = &autoSuggestionCache{text: text, cursor: p.cursor, suggestion: suggestion}
+	return suggestion
+}
+
+// autoSuggestionCache is the auto-suggestion last found, with the input it was
+// found for.
+type autoSuggestionCache struct {
+	text       string
+	cursor     int
+	suggestion string
+}
+
+// findAutoSuggestion looks up the auto-suggestion for text, which ends at the
+// cursor.
+func (p *Prompt) findAutoSuggestion(text string) string {
+	for i := len(p.history) - 1; i >= 0; i-- {
+		entry := p.history[i]
+		if len(entry) > len(text) && strings.HasPrefix(entry, text) && !strings.ContainsAny(entry[len(text):], "\n\r") {
+			return entry[len(text):]
+		}
+	}
+
+	if p.config.Completer == nil {
+		return ""
+	}
+	doc := Document{Text: text, CursorPosition: p.cursor}
+	word := p.completionWord(doc)
+	if word == "" {
+		return ""
+	}
+	for _, suggestion := range p.config.Completer(doc) {
+		if len(suggestion.Text) > len(word) && strings.HasPrefix(suggestion.Text, word) && !strings.ContainsAny(suggestion.Text, "\n\r") {
+			return suggestion.Text[len(word):]
+		}
+	}
+	return ""
+}
+
+// acceptAutoSuggestion inserts the auto-suggestion, or only its next word when
+// wordOnly is set, and reports whether there was one to accept.
+func (p *Prompt) acceptAutoSuggestion(wordOnly bool) bool {
+	ghost := []rune(p.autoSuggestion())
+	if len(ghost) == 0 {
+		return false
+	}
+	if wordOnly {
+		end := 0
+		for end < len(ghost) && !isWordChar(ghost[end]) {
+			end++
+		}
+		for end < len(ghost) && isWordChar(ghost[end]) {
+			end++
+		}
+		ghost = ghost[:end]
+	}
+	p.insertText(string(ghost))
+	return true
+}
+
+// displayText returns the buffer as it should be drawn and the cursor position
+// within that drawn text. With a DisplayTransform the cursor is mapped by
+// transforming the text before the cursor and measuring the result, which keeps
+// it in place for length-preserving transforms and clamps it for others.
+func (p *Prompt) displayText() (string, int) {
+	if p.config.DisplayTransform == nil {
+		return string(p.buffer), p.cursor
+	}
+	text := p.config.DisplayTransform(string(p.buffer))
+	before := p.config.DisplayTransform(string(p.buffer[:p.cursor]))
+	cursor := min(len([]rune(before)), len([]rune(text)))
+	return text, cursor
 }
 
 func (p *Prompt) readRune() (rune, error) {
-	r, _, err := p.terminal.ReadRune()
-	return r, err
+	ev := <-p.nextKey()
+	p.keyCh = nil
+	return ev.r, ev.err
+}
+
+// nextKey returns a channel that delivers the next key from the terminal. A
+// read is only started on demand, one rune at a time, so the prompt never reads
+// ahead and steals input meant for the host application after Run returns. If a
+// read is already in flight (for example because the event loop woke up for the
+// idle hook instead), the same channel is returned. Callers must set p.keyCh to
+// nil after receiving from it.
+func (p *Prompt) nextKey() <-chan keyEvent {
+	if p.keyCh == nil {
+		ch := make(chan keyEvent, 1) // Buffered so the reader never blocks on send
+		terminal := p.terminal
+		go func() {
+			r, _, err := terminal.ReadRune()
+			ch <- keyEvent{r: r, err: err}
+		}()
+		p.keyCh = ch
+	}
+	return p.keyCh
+}
+
+// unreadKey puts r back as the next key, for the event loop to read as if the
+// terminal had sent it. There must be no read in flight.
+func (p *Prompt) unreadKey(r rune) {
+	ch := make(chan keyEvent, 1)
+	ch <- keyEvent{r: r}
+	p.keyCh = ch
+}
+
+// newIdleTimer returns a timer for the idle hook, or nil when no hook is set.
+func (p *Prompt) newIdleTimer() *time.Timer {
+	if p.config.OnIdle == nil || p.config.IdleInterval <= 0 {
+		return nil
+	}
+	return time.NewTimer(p.config.IdleInterval)
+}
+
+// resetTimer restarts t so it fires d from now, draining a pending expiry so the
+// next receive does not see a stale tick.
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
 }
 
+// readEscapeSequence reads the rest of an escape sequence after ESC. A CSI
+// sequence ("[" followed by parameter and intermediate bytes) ends at its
+// final byte: only the first EscapeSequenceLen keys of it are kept and the
+// rest is read and dropped, so no part of an unknown sequence is typed as
+// text. Reading stops after four times EscapeSequenceLen keys, so input that
+// never ends the sequence is not swallowed. A key that cannot be part of a
+// CSI sequence ends it early and is put back to be handled on its own.
 func (p *Prompt) readEscapeSequence() (string, error) {
-	seq := make([]rune, 0, 10) // Pre-allocate with capacity
-	for range 10 {             // Limit to prevent infinite loop
+	maxLen := p.limits().EscapeSequenceLen
+	maxRead := 4 * maxLen          // Keys read before a sequence without end is given up
+	seq := make([]rune, 0, maxLen) // Pre-allocate with capacity
+	dropped := 0
+	for {
 		r, err := p.readRune()
 		if err != nil {
 			return "", err
 		}
-		seq = append(seq, r)
 
-		// Check for complete sequences
-		s := string(seq)
-		if s == "[A" || s == "[B" || s == "[C" || s == "[D" || s == "[H" || s == "[F" {
-			return s, nil
-		}
-		if strings.HasSuffix(s, "~") && len(s) >= 3 {
-			return s, nil
-		}
-		if len(seq) >= 3 && (seq[len(seq)-1] < '0' || seq[len(seq)-1] > '9') {
-			return s, nil
+		switch {
+		case len(seq) == 0:
+			seq = append(seq, r)
+			// Alt+key arrives as ESC followed by the key itself
+			if r != '[' && r != 'O' {
+				return string(seq), nil
+			}
+		case seq[0] == 'O':
+			// SS3 sequences such as "OP" for F1 end with the character after "O"
+			return string(append(seq, r)), nil
+		case r < 0x20 || r > 0x7e:
+			// Not a byte of a CSI sequence, so the sequence was cut short
+			p.unreadKey(r)
+			return string(seq), nil
+		case len(seq) >= maxLen:
+			// Too long for a key: drop the rest up to the final byte, which is
+			// left out so the truncated sequence matches no shorter one
+			if r >= 0x40 {
+				return string(seq), nil
+			}
+			// No terminal sends a key this long: stop draining and leave the
+			// key, and the rest after it, to be read as input
+			if dropped++; len(seq)+dropped >= maxRead {
+				p.unreadKey(r)
+				return string(seq), nil
+			}
+		default:
+			seq = append(seq, r)
+			// Parameters (e.g. "1;5" in "[1;5C" for Ctrl+Right, with
+			// sub-parameters after ':' in the kitty keyboard protocol) and
+			// intermediates are below 0x40, and the final byte ends the
+			// sequence. The Linux console sends "[[A" to "[[E" for F1 to F5.
+			if r >= 0x40 && string(seq) != "[[" {
+				return string(seq), nil
+			}
 		}
 	}
-	return string(seq), nil
 }
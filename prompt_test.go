@@ -143,6 +143,20 @@ func TestPromptWithMockTerminal(t *testing.T) {
 				IsComplete: func(in string) bool { return strings.HasSuffix(strings.TrimSpace(in), ";") },
 			},
 		},
+		{
+			// InputComplete applies without multiline mode, so a REPL keeps
+			// reading until the brace is closed.
+			name:     "InputComplete continues an open block",
+			input:    "if x {\n  y()\n}\n",
+			expected: "if x {\n  y()\n}",
+			config:   Config{Prefix: "$ ", InputComplete: BalancedInput},
+		},
+		{
+			name:     "InputComplete continues an open quote",
+			input:    "echo 'a\nb'\n",
+			expected: "echo 'a\nb'",
+			config:   Config{Prefix: "$ ", InputComplete: BalancedInput},
+		},
 	}
 
 	for _, tt := range tests {
@@ -2155,7 +2169,7 @@ func TestFuzzyScore(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			score := calculateFuzzyScore(tt.input, tt.candidate, false)
+			score, _ := FuzzyScore(tt.input, tt.candidate, WithScoreCaseSensitive())
 			if tt.minScore == 0 {
 				if score != 0 {
 					t.Errorf("Expected no match (score 0), got %d", score)
@@ -2169,6 +2183,51 @@ func TestFuzzyScore(t *testing.T) {
 	}
 }
 
+func TestFuzzyScorePositions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		query     string
+		candidate string
+		opts      []ScoreOption
+		score     int
+		positions []int
+	}{
+		{name: "exact match covers the candidate", query: "Git", candidate: "git", score: 1000, positions: []int{0, 1, 2}},
+		{name: "prefix match", query: "gi", candidate: "git status", score: 820, positions: []int{0, 1}},
+		{name: "substring match is found by rune index", query: "ст", candidate: "git статус", score: 510, positions: []int{4, 5}},
+		{name: "characters in order", query: "gst", candidate: "git status", score: 30, positions: []int{0, 4, 5}},
+		{name: "case sensitive scoring rejects other case", query: "GIT", candidate: "git", opts: []ScoreOption{WithScoreCaseSensitive()}},
+		{name: "empty query matches everything", query: "", candidate: "anything", score: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			score, positions := FuzzyScore(tt.query, tt.candidate, tt.opts...)
+
+			assert.Equal(t, tt.score, score)
+			assert.Equal(t, tt.positions, positions)
+		})
+	}
+
+	t.Run("the fuzzy completer ranks with the same scores", func(t *testing.T) {
+		t.Parallel()
+
+		candidates := []string{"git status", "gist", "grep"}
+		suggestions := NewFuzzyCompleter(candidates)(Document{Text: "gis", CursorPosition: 3})
+
+		require.NotEmpty(t, suggestions)
+		for i := 1; i < len(suggestions); i++ {
+			prev, _ := FuzzyScore("gis", suggestions[i-1].Text)
+			score, _ := FuzzyScore("gis", suggestions[i].Text)
+			assert.GreaterOrEqual(t, prev, score)
+		}
+	})
+}
+
 func TestHistorySearcher(t *testing.T) {
 	t.Parallel()
 
@@ -2332,6 +2391,93 @@ func TestPromptConfigurationMethods(t *testing.T) {
 	}
 }
 
+func TestSetMultiline(t *testing.T) {
+	t.Parallel()
+
+	keyMap := NewDefaultKeyMap()
+	keyMap.BindSequence("m", ActionToggleMultiline) // Alt+M
+
+	t.Run("Enter inserts newlines until block editing is toggled off", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", KeyMap: keyMap}, "a\x1bm\rb\x1bm\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "a\nb", result)
+	})
+
+	t.Run("block editing set before Run overrides IsComplete", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Prefix: "> ", KeyMap: keyMap, Multiline: true, IsComplete: func(string) bool { return true }}
+		p := newForTestingWithConfig(t, config, "a\rb\x1bm\r")
+		p.SetMultiline(true)
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "a\nb", result)
+		assert.False(t, p.blockEdit, "the toggle switched it off")
+	})
+}
+
+func TestSetKeyMap(t *testing.T) {
+	t.Parallel()
+
+	submitOnX := NewDefaultKeyMap()
+	submitOnX.Bind('x', ActionSubmit)
+
+	t.Run("the key map takes effect from the next key", func(t *testing.T) {
+		t.Parallel()
+
+		var p *Prompt
+		config := Config{Prefix: "> ", Completer: func(Document) []Suggestion {
+			// Switch key maps from Tab, like an application toggle key
+			p.SetKeyMap(submitOnX)
+			return nil
+		}}
+		p = newForTestingWithConfig(t, config, "x\tabx")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "xab", result, "x is typed before the switch and submits after it")
+	})
+
+	t.Run("nil restores the default bindings", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", KeyMap: submitOnX}, "ax\r")
+		p.SetKeyMap(nil)
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ax", result)
+	})
+
+	t.Run("it is safe to call from another goroutine", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "abc\r")
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range 100 {
+				p.SetKeyMap(NewDefaultKeyMap())
+			}
+		}()
+
+		result, err := p.Run()
+		<-done
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc", result)
+	})
+}
+
 func TestWordBoundaryFunctions(t *testing.T) {
 	t.Parallel()
 
@@ -2495,6 +2641,52 @@ func TestKeyMapGetActionAdvanced(t *testing.T) {
 	}
 }
 
+func TestKeyMapContexts(t *testing.T) {
+	t.Parallel()
+
+	t.Run("menu bindings override editing bindings only in the menu", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+
+		assert.Equal(t, ActionMoveHome, keyMap.GetSequenceActionInContext(KeyContextEditing, "[H"))
+		assert.Equal(t, ActionMenuFirst, keyMap.GetSequenceActionInContext(KeyContextMenu, "[H"))
+		assert.Equal(t, ActionMenuLast, keyMap.GetSequenceActionInContext(KeyContextMenu, "[F"))
+		assert.Equal(t, ActionMoveUp, keyMap.GetSequenceActionInContext(KeyContextMenu, "[A"), "unbound keys fall back to editing")
+	})
+
+	t.Run("keys can be bound for the menu only", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		keyMap.BindInContext(KeyContextMenu, '\x01', ActionMenuFirst)
+
+		assert.Equal(t, ActionMenuFirst, keyMap.GetActionInContext(KeyContextMenu, '\x01'))
+		assert.Equal(t, ActionMoveHome, keyMap.GetActionInContext(KeyContextEditing, '\x01'))
+		assert.Equal(t, ActionMoveHome, keyMap.GetAction('\x01'))
+	})
+
+	t.Run("binding in the editing context is the same as Bind", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := &KeyMap{bindings: make(map[rune]KeyAction), sequences: make(map[string]KeyAction)}
+		keyMap.BindInContext(KeyContextEditing, 'x', ActionCancel)
+		keyMap.BindSequenceInContext(KeyContextEditing, "[Z", ActionComplete)
+
+		assert.Equal(t, ActionCancel, keyMap.GetAction('x'))
+		assert.Equal(t, ActionComplete, keyMap.GetSequenceAction("[Z"))
+	})
+
+	t.Run("nil key map has no bindings", func(t *testing.T) {
+		t.Parallel()
+
+		var keyMap *KeyMap
+
+		assert.Equal(t, ActionNone, keyMap.GetActionInContext(KeyContextMenu, 'a'))
+		assert.Equal(t, ActionNone, keyMap.GetSequenceActionInContext(KeyContextMenu, "[H"))
+	})
+}
+
 func TestPromptInsertRuneAdvanced(t *testing.T) {
 	t.Parallel()
 
@@ -3464,15 +3656,18 @@ func TestRunWithContextAdditionalCoverage(t *testing.T) {
 		}
 
 		// Type some content, then Ctrl+D
-		input := "hello\x04"
+		input := "hello\x04\r"
 		p := newForTestingWithConfig(t, config, input)
 		defer p.Close()
 
-		_, err := p.RunWithContext(context.Background())
+		result, err := p.RunWithContext(context.Background())
 		// Should not return EOF when buffer has content
 		if errors.Is(err, io.EOF) {
 			t.Error("Should not return EOF when buffer has content")
 		}
+		if result != "hello" {
+			t.Errorf("Expected 'hello', got %q", result)
+		}
 	})
 
 	t.Run("CtrlCInterrupt", func(t *testing.T) {
@@ -4120,6 +4315,39 @@ func TestOptionFunctions(t *testing.T) {
 	}
 }
 
+func TestRightSegment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		indicator   bool
+		rightPrompt func() string
+		want        string
+	}{
+		{name: "nothing configured", want: ""},
+		{name: "history indicator only", indicator: true, want: "[1/1]"},
+		{name: "right prompt only", rightPrompt: func() string { return "main" }, want: "main"},
+		{name: "both are joined", indicator: true, rightPrompt: func() string { return "main" }, want: "[1/1] main"},
+		{name: "an empty right prompt adds nothing", indicator: true, rightPrompt: func() string { return "" }, want: "[1/1]"},
+		{name: "control characters are not drawn raw", rightPrompt: func() string { return "\x1b[31mmain" }, want: "^[[31mmain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Prompt{
+				config:       Config{HistoryIndicator: tt.indicator, RightPrompt: tt.rightPrompt},
+				history:      []string{"ls"},
+				buffer:       []rune("ls"),
+				historyIndex: 0,
+			}
+
+			assert.Equal(t, tt.want, p.rightSegment())
+		})
+	}
+}
+
 // newForTestingWithConfig creates a new prompt with a mock terminal for testing.
 // This function is mainly for testing and migration purposes.
 func newForTestingWithConfig(t *testing.T, config Config, mockInput string) *Prompt {
@@ -4168,6 +4396,7 @@ func newForTestingWithConfig(t *testing.T, config Config, mockInput string) *Pro
 
 	// Initialize renderer
 	p.renderer = newRenderer(output, config.ColorScheme, p.terminal)
+	p.configureRenderer()
 
 	return p
 }
@@ -0,0 +1,52 @@
+package prompttest
+
+import "strings"
+
+// StripANSI returns s without escape sequences and carriage returns: the
+// text of raw terminal output, such as Session.Output, with colors, cursor
+// movement and mode changes removed. Rows the prompt drew over each other
+// all stay in the text, one after the other; use Screen to get what a user
+// would see.
+func StripANSI(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\x1b':
+			i += escapeLength(s[i:]) - 1
+		case c == '\r':
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// escapeLength returns the number of bytes of the escape sequence at the
+// start of s: a CSI sequence, an OSC string or ESC with one character.
+func escapeLength(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[':
+		// Parameters and intermediates, then a final byte from @ to ~
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']':
+		// Ended by BEL or by ESC \
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	}
+	return 2
+}
@@ -0,0 +1,39 @@
+// Package prompttest provides helpers for testing code built on the prompt
+// package without a real terminal.
+//
+// Terminal is a scriptable stand-in for the terminal a prompt reads keys from,
+// and Screen is a small terminal emulator that interprets the ANSI escape
+// sequences the prompt writes, so tests can look at what the user would see
+// instead of grepping raw escape codes. ExpectFrames ties them together: it
+// replays scripted key input step by step and compares the screen after each
+// step with the expected rows. Start returns a Session for tests that need
+// more control: it sends keys by name, resizes the terminal, waits for
+// redraws and checks the result of Run, and StripANSI turns the output it
+// keeps into plain text.
+//
+// VerifyNoLeaks catches prompts that outlive their test: when the test ends,
+// it checks that their goroutines have exited and that the terminals it was
+// given are closed and out of raw mode.
+//
+// Example:
+//
+//	func TestCompletion(t *testing.T) {
+//		result, err := prompttest.ExpectFrames(t, "$ ",
+//			[]prompt.Option{prompt.WithCompleter(completer)},
+//			prompttest.Step{
+//				Keys:  "gi\t",
+//				Frame: []string{"$ gi", "▶ git", "  gist"},
+//			},
+//			prompttest.Step{
+//				Keys:  "\r",
+//				Frame: []string{"$ git"},
+//			},
+//			prompttest.Step{
+//				Keys:  "\r",
+//				Frame: []string{"$ git"},
+//			},
+//		)
+//		require.NoError(t, err)
+//		assert.Equal(t, "git", result)
+//	}
+package prompttest
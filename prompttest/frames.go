@@ -0,0 +1,85 @@
+package prompttest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nao1215/prompt"
+)
+
+const (
+	// defaultWidth and defaultHeight are the size of the terminal used by
+	// ExpectFrames.
+	defaultWidth  = 80
+	defaultHeight = 24
+
+	// stepTimeout bounds how long ExpectFrames waits for the prompt to handle
+	// the keys of one step.
+	stepTimeout = 5 * time.Second
+)
+
+// Step is one round of scripted input and the screen expected after it.
+type Step struct {
+	Keys  string   // Keys to type; escape sequences are written out, e.g. "\x1b[A"
+	Frame []string // Expected screen rows once the prompt has handled Keys, as returned by Screen.Lines
+}
+
+// ExpectFrames runs a prompt created with prefix and options on an 80x24
+// scripted terminal and replays steps in order. After each step it waits until
+// the prompt has handled the keys and compares the screen with the step's
+// Frame, reporting every mismatch as a test error. The frame is what a user
+// would see after the ANSI output is interpreted, so stale rows left behind by
+// a redraw show up as extra lines.
+//
+// If the prompt is still running after the last step, its input is closed.
+// ExpectFrames returns the result of Run.
+func ExpectFrames(t testing.TB, prefix string, options []prompt.Option, steps ...Step) (string, error) {
+	t.Helper()
+
+	s := Start(t, prefix, options...)
+	defer s.prompt.Close()
+
+	for i, step := range steps {
+		if err := s.send(func() error { s.terminal.SendKeys(step.Keys); return nil }); err != nil {
+			if s.finished != nil {
+				t.Errorf("prompttest: step %d (%q): %v", i+1, step.Keys, err)
+				break
+			}
+			t.Fatalf("prompttest: step %d (%q): %v", i+1, step.Keys, err)
+		}
+
+		if got := s.screen.Lines(); !slices.Equal(got, step.Frame) {
+			t.Errorf("prompttest: step %d (%q): unexpected frame\nwant:\n%s\ngot:\n%s",
+				i+1, step.Keys, formatFrame(step.Frame), formatFrame(got))
+		}
+	}
+
+	return s.Result()
+}
+
+// waitIdle returns a channel that is closed once terminal is idle.
+func waitIdle(terminal *Terminal) <-chan struct{} {
+	ch := make(chan struct{})
+	go func() {
+		if terminal.WaitIdle(stepTimeout) {
+			close(ch)
+		}
+	}()
+	return ch
+}
+
+// formatFrame renders frame rows for a failure message, one numbered row per
+// line, so trailing rows and blank lines are easy to spot.
+func formatFrame(frame []string) string {
+	if len(frame) == 0 {
+		return "  (empty)"
+	}
+	var b strings.Builder
+	for i, line := range frame {
+		fmt.Fprintf(&b, "  %2d|%s\n", i, line)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
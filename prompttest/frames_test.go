@@ -0,0 +1,553 @@
+package prompttest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectFrames(t *testing.T) {
+	t.Parallel()
+
+	completer := func(d prompt.Document) []prompt.Suggestion {
+		var suggestions []prompt.Suggestion
+		for _, s := range []prompt.Suggestion{{Text: "git"}, {Text: "gist"}, {Text: "grep"}} {
+			if strings.HasPrefix(s.Text, d.GetWordBeforeCursor()) {
+				suggestions = append(suggestions, s)
+			}
+		}
+		return suggestions
+	}
+
+	t.Run("typing and submitting", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := ExpectFrames(t, "$ ", nil,
+			Step{Keys: "hello", Frame: []string{"$ hello"}},
+			Step{Keys: "\x7f\x7fp", Frame: []string{"$ help"}},
+			Step{Keys: "\r", Frame: []string{"$ help"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "help", result)
+	})
+
+	t.Run("wide characters wrap by their width", func(t *testing.T) {
+		t.Parallel()
+
+		// 2 columns of prefix and 39 wide characters fill the 80 columns
+		row := "$ " + strings.Repeat("語", 39)
+		result, err := ExpectFrames(t, "$ ", nil,
+			Step{Keys: strings.Repeat("語", 40), Frame: []string{row, "語"}},
+			Step{Keys: "\x7f", Frame: []string{row}},
+			// The last character no longer fits in the last column of the row
+			Step{Keys: "\x1b[D\x1b[Dx", Frame: []string{"$ " + strings.Repeat("語", 37) + "x語", "語"}},
+			Step{Keys: "\r", Frame: []string{"$ " + strings.Repeat("語", 37) + "x語", "語"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat("語", 37)+"x語語", result)
+	})
+
+	t.Run("Up, Down and Home move by the rows of a wrapped line", func(t *testing.T) {
+		t.Parallel()
+
+		text := strings.Repeat("0123456789", 10)
+		edited := text[:20] + "X" + text[20:]
+		result, err := ExpectFrames(t, "$ ", nil,
+			Step{Keys: text, Frame: []string{"$ " + text[:78], text[78:]}},
+			// Up keeps the column, 22, which is 20 characters after the prefix
+			Step{Keys: "\x1b[AX", Frame: []string{"$ " + edited[:78], edited[78:]}},
+			Step{Keys: "\x1b[BY", Frame: []string{"$ " + edited[:78], edited[78:] + "Y"}},
+			Step{Keys: "\x1b[HZ", Frame: []string{"$ " + edited[:78], "Z" + edited[78:] + "Y"}},
+			Step{Keys: "\r", Frame: []string{"$ " + edited[:78], "Z" + edited[78:] + "Y"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, edited[:78]+"Z"+edited[78:]+"Y", result)
+	})
+
+	t.Run("horizontal scrolling keeps long input on one row", func(t *testing.T) {
+		t.Parallel()
+
+		text := strings.Repeat("0123456789", 10)
+		options := []prompt.Option{prompt.WithHorizontalScroll(true)}
+		result, err := ExpectFrames(t, "$ ", options,
+			// 80 columns minus the prefix, "<" and the last column leave 76
+			Step{Keys: text, Frame: []string{"$ <" + text[24:]}},
+			Step{Keys: "\x1b[H", Frame: []string{"$ " + text[:76] + ">"}},
+			Step{Keys: "\x1b[F", Frame: []string{"$ <" + text[24:]}},
+			Step{Keys: "\r", Frame: []string{"$ <" + text[24:]}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, text, result)
+	})
+
+	t.Run("completion menu opens and is cleared after accepting", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := ExpectFrames(t, "$ ", []prompt.Option{prompt.WithCompleter(completer)},
+			Step{Keys: "gi\t", Frame: []string{"$ gi", "▶ git", "  gist"}},
+			Step{Keys: "\x1b[B", Frame: []string{"$ gi", "  git", "▶ gist"}},
+			Step{Keys: "\r", Frame: []string{"$ gist"}},
+			Step{Keys: "\r", Frame: []string{"$ gist"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "gist", result)
+	})
+
+	t.Run("Home and End jump to the first and last suggestion in the menu", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := prompt.NewDefaultKeyMap()
+		keyMap.BindInContext(prompt.KeyContextMenu, '\x01', prompt.ActionMenuFirst)
+		options := []prompt.Option{prompt.WithCompleter(completer), prompt.WithKeyMap(keyMap)}
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "g\t", Frame: []string{"$ g", "▶ git", "  gist", "  grep"}},
+			Step{Keys: "\x1b[F", Frame: []string{"$ g", "  git", "  gist", "▶ grep"}},
+			Step{Keys: "\x1b[H", Frame: []string{"$ g", "▶ git", "  gist", "  grep"}},
+			Step{Keys: "\x1b[F\x01", Frame: []string{"$ g", "▶ git", "  gist", "  grep"}},
+			Step{Keys: "\x1b[B\r", Frame: []string{"$ gist"}},
+			Step{Keys: "\x1b[H\r", Frame: []string{"$ gist"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "gist", result)
+	})
+
+	t.Run("every encoding of Home and End works in the menu and in the input", func(t *testing.T) {
+		t.Parallel()
+
+		for _, keys := range [][2]string{{"\x1bOH", "\x1bOF"}, {"\x1b[1~", "\x1b[4~"}, {"\x1b[7~", "\x1b[8~"}} {
+			home, end := keys[0], keys[1]
+			result, err := ExpectFrames(t, "$ ", []prompt.Option{prompt.WithCompleter(completer)},
+				Step{Keys: "g\t", Frame: []string{"$ g", "▶ git", "  gist", "  grep"}},
+				Step{Keys: end, Frame: []string{"$ g", "  git", "  gist", "▶ grep"}},
+				Step{Keys: home, Frame: []string{"$ g", "▶ git", "  gist", "  grep"}},
+				Step{Keys: "\r" + home + "x" + end + "y\r", Frame: []string{"$ xgity"}},
+			)
+
+			require.NoError(t, err, "Home %q, End %q", home, end)
+			assert.Equal(t, "xgity", result)
+		}
+	})
+
+	t.Run("prefix completion inserts the shared prefix before showing the menu", func(t *testing.T) {
+		t.Parallel()
+
+		branches := func(d prompt.Document) []prompt.Suggestion {
+			return []prompt.Suggestion{{Text: "feature/login"}, {Text: "feature/logout"}, {Text: "main"}}
+		}
+		options := []prompt.Option{prompt.WithCompleter(branches), prompt.WithCompletionMode(prompt.PrefixComplete)}
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "f\t", Frame: []string{"$ feature/log"}},
+			Step{Keys: "\t", Frame: []string{"$ feature/log", "▶ feature/login", "  feature/logout"}},
+			Step{Keys: "\x1b[B\t", Frame: []string{"$ feature/logout"}},
+			Step{Keys: "\r", Frame: []string{"$ feature/logout"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "feature/logout", result)
+	})
+
+	t.Run("placeholder disappears on the first keystroke", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := ExpectFrames(t, "$ ", []prompt.Option{prompt.WithPlaceholder("type a command")},
+			Step{Keys: "", Frame: []string{"$ type a command"}},
+			Step{Keys: "l", Frame: []string{"$ l"}},
+			Step{Keys: "\x7f", Frame: []string{"$ type a command"}},
+			Step{Keys: "\r", Frame: []string{"$"}},
+		)
+
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("a masked card number keeps the cursor in the middle of the input", func(t *testing.T) {
+		t.Parallel()
+
+		// The example of WithDisplayTransform: every digit but the last four is masked
+		mask := func(text string) string {
+			runes := []rune(text)
+			for i := 0; i < len(runes)-4; i++ {
+				if runes[i] != ' ' {
+					runes[i] = '*'
+				}
+			}
+			return string(runes)
+		}
+		s := Start(t, "card: ", prompt.WithDisplayTransform(mask))
+		s.SendKeys("4111 1111 1111 1234")
+		s.ExpectFrame("card: **** **** **** 1234")
+
+		// Ten characters back puts the cursor after "4111 1111"
+		s.SendKeys(strings.Repeat("\x1b[D", 10))
+		s.ExpectFrame("card: **** **** **** 1234")
+		row, col := s.Screen().Cursor()
+		assert.Equal(t, []int{0, 15}, []int{row, col})
+
+		s.SendKeys("5")
+		s.ExpectFrame("card: **** ***** **** 1234")
+		row, col = s.Screen().Cursor()
+		assert.Equal(t, []int{0, 16}, []int{row, col})
+
+		s.SendKeys("\r")
+		s.ExpectResult("4111 11115 1111 1234")
+	})
+
+	t.Run("menu opens while typing without selecting anything", func(t *testing.T) {
+		t.Parallel()
+
+		options := []prompt.Option{prompt.WithCompleter(completer), prompt.WithCompleteWhileTyping(true)}
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "g", Frame: []string{"$ g", "  git", "  gist", "  grep"}},
+			Step{Keys: "i", Frame: []string{"$ gi", "  git", "  gist"}},
+			Step{Keys: "\x7f\x7f", Frame: []string{"$"}},
+			Step{Keys: "gi\t", Frame: []string{"$ gi", "▶ git", "  gist"}},
+			Step{Keys: "\t", Frame: []string{"$ git"}},
+			Step{Keys: "\x7f\r", Frame: []string{"$ gi"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "gi", result, "Enter submits the input while nothing is selected")
+	})
+
+	t.Run("trigger characters open the menu before the minimum length", func(t *testing.T) {
+		t.Parallel()
+
+		members := func(d prompt.Document) []prompt.Suggestion {
+			if strings.HasSuffix(d.TextBeforeCursor(), "os.") {
+				return []prompt.Suggestion{{Text: "os.Args"}, {Text: "os.Exit"}}
+			}
+			return completer(d)
+		}
+		options := []prompt.Option{
+			prompt.WithCompleter(members),
+			prompt.WithCompleteWhileTyping(true),
+			prompt.WithCompleteWhileTypingTrigger(3, "."),
+		}
+		result, err := ExpectFrames(t, "> ", options,
+			Step{Keys: "gi", Frame: []string{"> gi"}},
+			Step{Keys: "s", Frame: []string{"> gis", "  gist"}},
+			Step{Keys: "\x7f\x7f\x7fos", Frame: []string{"> os"}},
+			Step{Keys: ".", Frame: []string{"> os.", "  os.Args", "  os.Exit"}},
+			Step{Keys: "\x1b[B\x1b[B\r", Frame: []string{"> os.Exit"}},
+			Step{Keys: "\r", Frame: []string{"> os.Exit"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "os.Exit", result)
+	})
+
+	t.Run("echo transform rewrites the submitted line but not the result", func(t *testing.T) {
+		t.Parallel()
+
+		expand := func(input string) string {
+			if strings.TrimSpace(input) == "ll" {
+				return "ls -l"
+			}
+			return input
+		}
+
+		result, err := ExpectFrames(t, "$ ", []prompt.Option{prompt.WithEchoTransform(expand), prompt.WithPlaceholder("command")},
+			Step{Keys: " ll ", Frame: []string{"$  ll"}},
+			Step{Keys: "\r", Frame: []string{"$ ls -l"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, " ll ", result)
+	})
+
+	t.Run("Tab on a word shorter than the minimum shows a hint", func(t *testing.T) {
+		t.Parallel()
+
+		options := []prompt.Option{prompt.WithCompleter(completer), prompt.WithMinCompletionChars(2)}
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "g\t", Frame: []string{"$ g", "  type 2+ chars to search"}},
+			Step{Keys: "i", Frame: []string{"$ gi"}},
+			Step{Keys: "\t", Frame: []string{"$ gi", "▶ git", "  gist"}},
+			Step{Keys: "\x1b[B\r", Frame: []string{"$ gist"}},
+			Step{Keys: "\x7f\x7f\x7f\t", Frame: []string{"$ g", "  type 2+ chars to search"}},
+			Step{Keys: "\r", Frame: []string{"$ g"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "g", result)
+	})
+
+	t.Run("validation errors block Enter until the input is fixed", func(t *testing.T) {
+		t.Parallel()
+
+		port := func(input string) error {
+			if _, err := strconv.Atoi(input); err != nil {
+				return errors.New("not a number")
+			}
+			return nil
+		}
+
+		result, err := ExpectFrames(t, "port: ", []prompt.Option{prompt.WithValidator(port)},
+			Step{Keys: "80a", Frame: []string{"port: 80a"}},
+			Step{Keys: "\r", Frame: []string{"port: 80a", "not a number"}},
+			Step{Keys: "b", Frame: []string{"port: 80ab", "not a number"}},
+			Step{Keys: "\x7f\x7f", Frame: []string{"port: 80"}},
+			Step{Keys: "\r", Frame: []string{"port: 80"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "80", result)
+	})
+
+	t.Run("validating while typing shows the error before Enter", func(t *testing.T) {
+		t.Parallel()
+
+		options := []prompt.Option{
+			prompt.WithValidateWhileTyping(true),
+			prompt.WithValidator(func(input string) error {
+				if len(input) < 3 {
+					return fmt.Errorf("%d more characters", 3-len(input))
+				}
+				return nil
+			}),
+		}
+
+		result, err := ExpectFrames(t, "name: ", options,
+			Step{Keys: "a", Frame: []string{"name: a", "2 more characters"}},
+			Step{Keys: "bc", Frame: []string{"name: abc"}},
+			Step{Keys: "\r", Frame: []string{"name: abc"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc", result)
+	})
+
+	t.Run("right prompt is drawn flush right until the input reaches it", func(t *testing.T) {
+		t.Parallel()
+
+		options := []prompt.Option{prompt.WithRightPrompt(func() string { return "main" })}
+		long := strings.Repeat("x", 72)
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "ls", Frame: []string{"$ ls" + strings.Repeat(" ", 71) + "main"}},
+			Step{Keys: "\x7f\x7f" + long, Frame: []string{"$ " + long + " main"}},
+			Step{Keys: "x", Frame: []string{"$ " + long + "x"}},
+			Step{Keys: "\r", Frame: []string{"$ " + long + "x"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, long+"x", result)
+	})
+
+	t.Run("footer stays below the menu and is erased on submit", func(t *testing.T) {
+		t.Parallel()
+
+		options := []prompt.Option{
+			prompt.WithCompleter(completer),
+			prompt.WithFooter(func() []string { return []string{"Tab: complete"} }),
+		}
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "g", Frame: []string{"$ g", "Tab: complete"}},
+			Step{Keys: "\t", Frame: []string{"$ g", "▶ git", "  gist", "  grep", "Tab: complete"}},
+			Step{Keys: "\x07", Frame: []string{"$ g", "Tab: complete"}},
+			Step{Keys: "\r", Frame: []string{"$ g"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "g", result)
+	})
+
+	t.Run("Tab cycles suggestions in the input line on a short terminal", func(t *testing.T) {
+		t.Parallel()
+
+		completer := func(prompt.Document) []prompt.Suggestion {
+			return []prompt.Suggestion{{Text: "git"}, {Text: "go"}, {Text: "grep"}}
+		}
+		// The 24-row test terminal counts as short below 30 rows
+		options := []prompt.Option{prompt.WithCompleter(completer), prompt.WithInlineCycling(30)}
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "g\t", Frame: []string{"$ git"}},
+			Step{Keys: "\t", Frame: []string{"$ go"}},
+			Step{Keys: "\t", Frame: []string{"$ grep"}},
+			Step{Keys: "\t", Frame: []string{"$ git"}},
+			Step{Keys: " ", Frame: []string{"$ git"}},
+			Step{Keys: "\r", Frame: []string{"$ git"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "git ", result)
+	})
+
+	t.Run("Alt+/ toggles hidden descriptions for the open menu", func(t *testing.T) {
+		t.Parallel()
+
+		described := func(prompt.Document) []prompt.Suggestion {
+			return []prompt.Suggestion{{Text: "git", Description: "Version control"}, {Text: "go", Description: "Go tool"}}
+		}
+		options := []prompt.Option{prompt.WithCompleter(described), prompt.WithSuggestionDescriptionHidden(true)}
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "g\t", Frame: []string{"$ g", "▶ git", "  go"}},
+			Step{Keys: "\x1b/", Frame: []string{"$ g", "▶ git - Version control", "  go  - Go tool"}},
+			Step{Keys: "\x1b/", Frame: []string{"$ g", "▶ git", "  go"}},
+			Step{Keys: "\x1b/\x1b[B", Frame: []string{"$ g", "  git - Version control", "▶ go  - Go tool"}},
+			Step{Keys: "\r", Frame: []string{"$ go"}},
+			Step{Keys: "\x7f\t", Frame: []string{"$ g", "▶ git", "  go"}},
+			Step{Keys: "\r\r", Frame: []string{"$ git"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "git", result)
+	})
+
+	t.Run("PageDown and PageUp scroll a menu longer than WithMaxSuggestions", func(t *testing.T) {
+		t.Parallel()
+
+		completer := func(prompt.Document) []prompt.Suggestion {
+			var suggestions []prompt.Suggestion
+			for i := 1; i <= 7; i++ {
+				suggestions = append(suggestions, prompt.Suggestion{Text: fmt.Sprintf("c%d", i)})
+			}
+			return suggestions
+		}
+		options := []prompt.Option{prompt.WithCompleter(completer), prompt.WithMaxSuggestions(3)}
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "c\t", Frame: []string{"$ c", "▶ c1", "  c2", "  c3", "  1/7"}},
+			Step{Keys: "\x1b[6~", Frame: []string{"$ c", "▶ c4", "  c5", "  c6", "  4/7"}},
+			Step{Keys: "\x1b[6~", Frame: []string{"$ c", "  c5", "  c6", "▶ c7", "  7/7"}},
+			Step{Keys: "\x1b[5~", Frame: []string{"$ c", "  c2", "  c3", "▶ c4", "  4/7"}},
+			Step{Keys: "\r", Frame: []string{"$ c4"}},
+			Step{Keys: "\r", Frame: []string{"$ c4"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "c4", result)
+	})
+
+	t.Run("initial suggestions are shown before anything is typed", func(t *testing.T) {
+		t.Parallel()
+
+		initial := []prompt.Suggestion{{Text: "new"}, {Text: "open"}, {Text: "quit"}}
+		options := []prompt.Option{prompt.WithInitialSuggestions(initial)}
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "", Frame: []string{"$", "▶ new", "  open", "  quit"}},
+			Step{Keys: "\x1b[B", Frame: []string{"$", "  new", "▶ open", "  quit"}},
+			Step{Keys: "x", Frame: []string{"$ x"}},
+			Step{Keys: "\r", Frame: []string{"$ x"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "x", result)
+	})
+
+	t.Run("history position is shown while browsing and hidden while edited", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(historyFile, []byte("ls\npwd\n"), 0o600))
+		options := []prompt.Option{prompt.WithFileHistory(historyFile, 100), prompt.WithHistoryIndicator()}
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "\x1b[A", Frame: []string{"$ pwd" + strings.Repeat(" ", 69) + "[2/2]"}},
+			Step{Keys: "\x1b[A", Frame: []string{"$ ls" + strings.Repeat(" ", 70) + "[1/2]"}},
+			Step{Keys: "x", Frame: []string{"$ lsx"}},
+			Step{Keys: "\x7f", Frame: []string{"$ ls" + strings.Repeat(" ", 70) + "[1/2]"}},
+			Step{Keys: "\r", Frame: []string{"$ ls"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "ls", result)
+	})
+
+	t.Run("a line typed with a leading space is not recorded when it is trimmed", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		options := []prompt.Option{
+			prompt.WithHistory(&prompt.HistoryConfig{Enabled: true, File: historyFile, IgnoreSpace: true, AppendOnSubmit: true}),
+			prompt.WithTrimSpace(true),
+		}
+
+		result, err := ExpectFrames(t, "$ ", options, Step{Keys: "ls\r", Frame: []string{"$ ls"}})
+		require.NoError(t, err)
+		assert.Equal(t, "ls", result)
+
+		result, err = ExpectFrames(t, "$ ", options, Step{Keys: " secret\r", Frame: []string{"$  secret"}})
+		require.NoError(t, err)
+		assert.Equal(t, "secret", result)
+
+		_, err = ExpectFrames(t, "$ ", options, Step{Keys: "\x1b[A", Frame: []string{"$ ls"}})
+		require.ErrorIs(t, err, prompt.ErrEOF)
+	})
+
+	t.Run("Ctrl+R lists matches below the input and Ctrl+G restores it", func(t *testing.T) {
+		t.Parallel()
+
+		historyFile := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, os.WriteFile(historyFile, []byte("git status\nls\ngit push\n"), 0o600))
+		options := []prompt.Option{prompt.WithFileHistory(historyFile, 100)}
+
+		result, err := ExpectFrames(t, "$ ", options,
+			Step{Keys: "ab", Frame: []string{"$ ab"}},
+			Step{Keys: "\x12", Frame: []string{"$ ab", "reverse-i-search:  -> git push  [1/3]", "▶ git push", "  ls", "  git status"}},
+			Step{Keys: "gi", Frame: []string{"$ ab", "reverse-i-search: gi -> git push  [1/2]", "▶ git push", "  git status"}},
+			Step{Keys: "\x12", Frame: []string{"$ ab", "reverse-i-search: gi -> git status  [2/2]", "  git push", "▶ git status"}},
+			Step{Keys: "\x13", Frame: []string{"$ ab", "reverse-i-search: gi -> git push  [1/2]", "▶ git push", "  git status"}},
+			Step{Keys: "\x07", Frame: []string{"$ ab"}},
+			Step{Keys: "\x12st\r", Frame: []string{"$ git status"}},
+			Step{Keys: "\r", Frame: []string{"$ git status"}},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "git status", result)
+	})
+
+	t.Run("the prompt is ended with EOF when steps run out", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ExpectFrames(t, "> ", nil,
+			Step{Keys: "abc", Frame: []string{"> abc"}},
+		)
+
+		assert.True(t, errors.Is(err, prompt.ErrEOF))
+	})
+
+	t.Run("mismatched frames are reported", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &recorder{TB: t}
+		_, _ = ExpectFrames(rec, "> ", nil,
+			Step{Keys: "abc", Frame: []string{"> abd"}},
+		)
+
+		require.Len(t, rec.errors, 1)
+		assert.Contains(t, rec.errors[0], "step 1 (\"abc\")")
+		assert.Contains(t, rec.errors[0], " 0|> abc")
+	})
+}
+
+// recorder captures test errors instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
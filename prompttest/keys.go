@@ -0,0 +1,86 @@
+package prompttest
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/nao1215/prompt"
+)
+
+// keyInput returns what an xterm-compatible terminal sends for key. Keys the
+// classic encoding cannot express, such as Ctrl+Enter or Ctrl+Shift+A, are
+// encoded as in the kitty keyboard protocol ("CSI-u"), which the prompt reads
+// as well.
+func keyInput(key prompt.Key) string {
+	// The xterm modifier parameter is 1 plus 1 for Shift, 2 for Alt and 4 for
+	// Ctrl, which are the bits of prompt.Modifiers
+	modifier := 1 + int(key.Mod)
+	if key.Code >= prompt.KeyUp {
+		return specialKeyInput(key.Code, modifier)
+	}
+
+	code := rune(key.Code)
+	alt := ""
+	if key.Mod&prompt.ModAlt != 0 {
+		alt = "\x1b"
+	}
+	switch key.Mod &^ prompt.ModAlt {
+	case 0:
+		return alt + string(code)
+	case prompt.ModShift:
+		if key.Code == prompt.KeyTab && alt == "" {
+			return "\x1b[Z"
+		}
+	case prompt.ModCtrl:
+		switch {
+		case code == ' ' || code == '@':
+			return alt + "\x00"
+		case code >= 'a' && code <= 'z', code >= '@' && code <= '_':
+			return alt + string(code&0x1f) // Ctrl+A is 0x01, and Ctrl+_ is 0x1f
+		case code == '?':
+			return alt + "\x7f"
+		}
+	}
+	if key.Mod&prompt.ModShift != 0 {
+		code = unicode.ToLower(code) // CSI-u sends the unshifted key with Shift
+	}
+	return fmt.Sprintf("\x1b[%d;%du", code, modifier)
+}
+
+// specialKeyInput returns the escape sequence of a key that types no
+// character, with the xterm modifier parameter when it is not 1.
+func specialKeyInput(code prompt.KeyCode, modifier int) string {
+	switch {
+	case code <= prompt.KeyEnd:
+		final := "ABCDHF"[code-prompt.KeyUp] // Up, Down, Right, Left, Home, End
+		if modifier == 1 {
+			return "\x1b[" + string(final)
+		}
+		return fmt.Sprintf("\x1b[1;%d%c", modifier, final)
+	case code >= prompt.KeyF1 && code <= prompt.KeyF4:
+		final := 'P' + rune(code-prompt.KeyF1)
+		if modifier == 1 {
+			return "\x1bO" + string(final)
+		}
+		return fmt.Sprintf("\x1b[1;%d%c", modifier, final)
+	}
+
+	var n int
+	switch code {
+	case prompt.KeyInsert:
+		n = 2
+	case prompt.KeyDelete:
+		n = 3
+	case prompt.KeyPageUp:
+		n = 5
+	case prompt.KeyPageDown:
+		n = 6
+	default:
+		// F5 to F12, with the gaps xterm leaves at 16 and 22
+		n = []int{15, 17, 18, 19, 20, 21, 23, 24}[code-prompt.KeyF5]
+	}
+	if modifier == 1 {
+		return fmt.Sprintf("\x1b[%d~", n)
+	}
+	return fmt.Sprintf("\x1b[%d;%d~", n, modifier)
+}
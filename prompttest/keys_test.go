@@ -0,0 +1,70 @@
+package prompttest
+
+import (
+	"testing"
+
+	"github.com/nao1215/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendSequence(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "a", want: "a"},
+		{name: "shift+a", want: "A"},
+		{name: "enter", want: "\r"},
+		{name: "esc", want: "\x1b"},
+		{name: "ctrl+r", want: "\x12"},
+		{name: "ctrl+space", want: "\x00"},
+		{name: "ctrl+_", want: "\x1f"},
+		{name: "alt+b", want: "\x1bb"},
+		{name: "ctrl+alt+h", want: "\x1b\x08"},
+		{name: "shift+tab", want: "\x1b[Z"},
+		{name: "up", want: "\x1b[A"},
+		{name: "ctrl+left", want: "\x1b[1;5D"},
+		{name: "shift+end", want: "\x1b[1;2F"},
+		{name: "f1", want: "\x1bOP"},
+		{name: "alt+f4", want: "\x1b[1;3S"},
+		{name: "delete", want: "\x1b[3~"},
+		{name: "ctrl+pagedown", want: "\x1b[6;5~"},
+		{name: "f12", want: "\x1b[24~"},
+		{name: "ctrl+enter", want: "\x1b[13;5u"},
+		{name: "ctrl+shift+a", want: "\x1b[97;6u"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			terminal := NewTerminal(80, 24)
+			require.NoError(t, terminal.SendSequence(tt.name))
+			terminal.CloseInput()
+
+			var got []rune
+			for {
+				r, _, err := terminal.ReadRune()
+				if err != nil {
+					break
+				}
+				got = append(got, r)
+			}
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+
+	t.Run("nothing is queued for an invalid name", func(t *testing.T) {
+		t.Parallel()
+
+		terminal := NewTerminal(80, 24)
+		err := terminal.SendSequence("a", "hyper+x")
+		terminal.CloseInput()
+
+		require.ErrorIs(t, err, prompt.ErrInvalidKeyName)
+		_, _, err = terminal.ReadRune()
+		assert.Error(t, err, "the input is empty")
+	})
+}
@@ -0,0 +1,178 @@
+package prompttest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// leakTimeout bounds how long VerifyNoLeaks waits for goroutines of a
+	// closed prompt to exit, since they notice the close asynchronously.
+	leakTimeout = time.Second
+	// promptFrame is how functions of the prompt package appear in stacks.
+	promptFrame = "github.com/nao1215/prompt."
+)
+
+// VerifyNoLeaks checks, when t ends, that the prompts used during the test
+// cleaned up after themselves:
+//
+//   - every goroutine running prompt code that was started during the test
+//     has exited
+//   - no terminal device opened during the test is still open (Linux only,
+//     where open file descriptors are listed in /proc)
+//   - standard input, when it is a terminal, is back in the mode it was in
+//   - each of terminals is closed and out of raw mode
+//
+// Call it at the start of a test, before creating prompts, and Close every
+// prompt before the test ends. Goroutines of other tests cannot be told apart
+// from the test's own, so do not use it in tests that run in parallel with
+// other prompt tests.
+//
+// Example:
+//
+//	func TestShell(t *testing.T) {
+//		terminal := prompttest.NewTerminal(80, 24)
+//		prompttest.VerifyNoLeaks(t, terminal)
+//		p, err := prompt.New("$ ", prompt.WithTerminal(terminal))
+//		require.NoError(t, err)
+//		defer p.Close()
+//		...
+//	}
+func VerifyNoLeaks(t testing.TB, terminals ...*Terminal) {
+	t.Helper()
+
+	snapshot := takeLeakSnapshot()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(leakTimeout)
+		leaks := snapshot.leaks(terminals)
+		for len(leaks) > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			leaks = snapshot.leaks(terminals)
+		}
+		for _, leak := range leaks {
+			t.Errorf("prompttest: %s", leak)
+		}
+	})
+}
+
+// leakSnapshot is the state VerifyNoLeaks compares against when the test ends.
+type leakSnapshot struct {
+	goroutines map[string]bool // IDs of the goroutines that were running
+	fds        map[string]bool // Open terminal devices, as "fd -> path"
+	stdin      *term.State     // Mode of standard input, nil if it is not a terminal
+}
+
+// takeLeakSnapshot records the current goroutines, terminal descriptors and
+// standard input mode.
+func takeLeakSnapshot() leakSnapshot {
+	s := leakSnapshot{goroutines: map[string]bool{}, fds: map[string]bool{}}
+	for _, g := range goroutineStacks() {
+		s.goroutines[g.id] = true
+	}
+	for _, fd := range terminalFDs() {
+		s.fds[fd] = true
+	}
+	s.stdin = stdinState()
+	return s
+}
+
+// leaks describes everything left behind since the snapshot was taken.
+func (s leakSnapshot) leaks(terminals []*Terminal) []string {
+	var leaks []string
+	for _, g := range goroutineStacks() {
+		if !s.goroutines[g.id] && strings.Contains(g.stack, promptFrame) {
+			leaks = append(leaks, fmt.Sprintf("leaked goroutine %s:\n%s", g.id, g.stack))
+		}
+	}
+	for _, fd := range terminalFDs() {
+		if !s.fds[fd] {
+			leaks = append(leaks, fmt.Sprintf("terminal file descriptor left open: %s", fd))
+		}
+	}
+	if state := stdinState(); state != nil && s.stdin != nil && !reflect.DeepEqual(state, s.stdin) {
+		leaks = append(leaks, "standard input was not restored to its original mode")
+	}
+	for i, terminal := range terminals {
+		if terminal.IsRaw() {
+			leaks = append(leaks, fmt.Sprintf("terminal %d is still in raw mode", i))
+		}
+		if !terminal.IsClosed() {
+			leaks = append(leaks, fmt.Sprintf("terminal %d was not closed", i))
+		}
+	}
+	return leaks
+}
+
+// goroutine is one goroutine from a full stack dump.
+type goroutine struct {
+	id    string
+	stack string
+}
+
+// goroutineStacks returns the stacks of all goroutines.
+func goroutineStacks() []goroutine {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var goroutines []goroutine
+	for _, block := range bytes.Split(buf, []byte("\n\n")) {
+		// Each block starts with a header like "goroutine 7 [chan receive]:"
+		header, _, _ := strings.Cut(string(block), "\n")
+		fields := strings.Fields(header)
+		if len(fields) < 2 || fields[0] != "goroutine" {
+			continue
+		}
+		goroutines = append(goroutines, goroutine{id: fields[1], stack: string(block)})
+	}
+	return goroutines
+}
+
+// terminalFDs lists the open file descriptors that refer to a terminal
+// device, as "fd -> path". It returns nothing where /proc is not available.
+func terminalFDs() []string {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return nil
+	}
+	var fds []string
+	for _, entry := range entries {
+		target, err := os.Readlink(filepath.Join("/proc/self/fd", entry.Name()))
+		if err != nil {
+			continue // Closed while listing, such as the directory itself
+		}
+		if target == "/dev/tty" || target == "/dev/ptmx" || strings.HasPrefix(target, "/dev/pts/") {
+			fds = append(fds, entry.Name()+" -> "+target)
+		}
+	}
+	return fds
+}
+
+// stdinState returns the mode of standard input, or nil if it is not a
+// terminal.
+func stdinState() *term.State {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil
+	}
+	state, err := term.GetState(fd)
+	if err != nil {
+		return nil
+	}
+	return state
+}
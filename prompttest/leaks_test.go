@@ -0,0 +1,50 @@
+package prompttest
+
+import (
+	"testing"
+
+	"github.com/nao1215/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The tests in this file do not run in parallel: VerifyNoLeaks cannot tell the
+// goroutines of other prompt tests from the ones it is checking.
+
+func TestVerifyNoLeaks(t *testing.T) {
+	t.Run("a closed prompt leaves nothing behind", func(t *testing.T) {
+		terminal := NewTerminal(80, 24)
+		VerifyNoLeaks(t, terminal)
+		p, err := prompt.New("$ ", prompt.WithTerminal(terminal), prompt.WithOutput(NewScreen(80, 24)))
+		require.NoError(t, err)
+
+		terminal.SendKeys("ls\r")
+		result, err := p.Run()
+		require.NoError(t, err)
+		require.NoError(t, p.Close())
+
+		assert.Equal(t, "ls", result)
+	})
+
+	t.Run("a running prompt is reported", func(t *testing.T) {
+		snapshot := takeLeakSnapshot()
+		terminal := NewTerminal(80, 24)
+		p, err := prompt.New("$ ", prompt.WithTerminal(terminal), prompt.WithOutput(NewScreen(80, 24)))
+		require.NoError(t, err)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = p.Run()
+		}()
+		require.True(t, terminal.WaitIdle(stepTimeout))
+
+		leaks := snapshot.leaks([]*Terminal{terminal})
+
+		require.NoError(t, p.Close())
+		<-done
+		require.NotEmpty(t, leaks)
+		assert.Contains(t, leaks[0], "leaked goroutine")
+		assert.Contains(t, leaks, "terminal 0 is still in raw mode")
+		assert.Contains(t, leaks, "terminal 0 was not closed")
+	})
+}
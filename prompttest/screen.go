@@ -0,0 +1,332 @@
+package prompttest
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// wideTail fills the cell covered by the right half of a wide character.
+const wideTail = rune(0)
+
+// Screen is a minimal terminal emulator that keeps the text a terminal would
+// show after receiving everything written to it.
+//
+// It understands the control characters and escape sequences the prompt uses:
+// carriage return, line feed, backspace, cursor movement (CUU, CUD, CUF, CUB,
+// CNL, CPL, CHA, CUP), erasing (ED, EL) and the alternate screen. Colors and
+// other modes are accepted and ignored. East Asian wide characters and emoji
+// take two columns, combining marks and other zero-width runes are dropped,
+// every other rune takes one column, and text wraps at the right edge like
+// xterm does. Screen is safe for concurrent use.
+type Screen struct {
+	mu      sync.Mutex
+	width   int
+	height  int
+	cells   [][]rune
+	main    [][]rune // Main screen contents while the alternate screen is active
+	row     int
+	col     int
+	wrap    bool   // The cursor is past the last column; the next rune wraps
+	pending []byte // Incomplete UTF-8 or escape sequence from the previous Write
+}
+
+// NewScreen returns an empty screen of the given size with the cursor in the
+// top left corner.
+func NewScreen(width, height int) *Screen {
+	s := &Screen{
+		width:  max(width, 1),
+		height: max(height, 1),
+	}
+	s.cells = s.blank()
+	return s
+}
+
+// Write interprets p as terminal output. It never fails.
+func (s *Screen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := append(s.pending, p...)
+	s.pending = nil
+	for len(data) > 0 {
+		n := s.consume(data)
+		if n == 0 {
+			// Wait for the rest of the sequence
+			s.pending = append([]byte(nil), data...)
+			break
+		}
+		data = data[n:]
+	}
+	return len(p), nil
+}
+
+// Lines returns the rows of the screen with trailing spaces removed and
+// trailing empty rows dropped.
+func (s *Screen) Lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make([]string, len(s.cells))
+	last := -1
+	for i, row := range s.cells {
+		lines[i] = strings.TrimRight(strings.ReplaceAll(string(row), string(wideTail), ""), " ")
+		if lines[i] != "" {
+			last = i
+		}
+	}
+	return lines[:last+1]
+}
+
+// String returns the screen contents as returned by Lines, joined by newlines.
+func (s *Screen) String() string {
+	return strings.Join(s.Lines(), "\n")
+}
+
+// Cursor returns the zero-based row and column of the cursor.
+func (s *Screen) Cursor() (row, col int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.row, min(s.col, s.width-1)
+}
+
+// Resize changes the size of the screen. Rows and columns that no longer fit
+// are cut off; the text is not reflowed.
+func (s *Screen) Resize(width, height int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.cells
+	s.width, s.height = max(width, 1), max(height, 1)
+	s.cells = s.blank()
+	for i := range min(len(old), s.height) {
+		copy(s.cells[i], old[i])
+	}
+	s.row = min(s.row, s.height-1)
+	s.col = min(s.col, s.width-1)
+	s.wrap = false
+}
+
+// blank returns empty screen contents for the current size.
+func (s *Screen) blank() [][]rune {
+	cells := make([][]rune, s.height)
+	for i := range cells {
+		cells[i] = []rune(strings.Repeat(" ", s.width))
+	}
+	return cells
+}
+
+// consume interprets the control character, escape sequence or rune at the
+// start of data and returns how many bytes it used, or 0 if data ends in the
+// middle of it.
+func (s *Screen) consume(data []byte) int {
+	switch c := data[0]; c {
+	case '\x1b':
+		return s.escape(data)
+	case '\r':
+		s.col, s.wrap = 0, false
+	case '\n':
+		s.lineFeed()
+	case '\b':
+		s.col, s.wrap = max(0, min(s.col, s.width-1)-1), false
+	case '\a':
+	default:
+		if c < 0x20 || c == 0x7f {
+			return 1 // Other control characters have no visible effect
+		}
+		if !utf8.FullRune(data) {
+			return 0
+		}
+		r, size := utf8.DecodeRune(data)
+		s.put(r)
+		return size
+	}
+	return 1
+}
+
+// put draws r at the cursor and advances it, wrapping at the right edge. A
+// wide character that does not fit in the last column wraps as a whole.
+func (s *Screen) put(r rune) {
+	w := runeWidth(r)
+	if w == 0 {
+		return
+	}
+	if w == 2 && s.col == s.width-1 && s.width > 1 && !s.wrap {
+		s.cells[s.row][s.col] = ' '
+		s.wrap = true
+	}
+	if s.wrap {
+		s.col, s.wrap = 0, false
+		s.lineFeed()
+	}
+	s.cells[s.row][s.col] = r
+	if w == 2 && s.col < s.width-1 {
+		s.col++
+		s.cells[s.row][s.col] = wideTail
+	}
+	if s.col == s.width-1 {
+		s.wrap = true
+	} else {
+		s.col++
+	}
+}
+
+// runeWidth returns the number of columns r takes: 0 for combining marks and
+// other zero-width runes, 2 for East Asian wide and fullwidth characters, which
+// include most emoji, and 1 otherwise.
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// lineFeed moves the cursor down one row, scrolling at the bottom.
+func (s *Screen) lineFeed() {
+	s.wrap = false
+	if s.row < s.height-1 {
+		s.row++
+		return
+	}
+	copy(s.cells, s.cells[1:])
+	s.cells[s.height-1] = []rune(strings.Repeat(" ", s.width))
+}
+
+// escape interprets the escape sequence at the start of data.
+func (s *Screen) escape(data []byte) int {
+	if len(data) < 2 {
+		return 0
+	}
+	switch data[1] {
+	case '[':
+		// CSI: parameter and intermediate bytes up to a final byte
+		for i := 2; i < len(data); i++ {
+			if data[i] >= 0x40 && data[i] <= 0x7e {
+				s.csi(string(data[2:i]), data[i])
+				return i + 1
+			}
+		}
+		return 0
+	case ']':
+		// OSC: ends with BEL or ST
+		for i := 2; i < len(data); i++ {
+			if data[i] == '\a' {
+				return i + 1
+			}
+			if data[i] == '\x1b' && i+1 < len(data) && data[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return 0
+	default:
+		return 2
+	}
+}
+
+// csi applies a control sequence with the given parameters and final byte.
+func (s *Screen) csi(params string, final byte) {
+	if strings.HasPrefix(params, "?") {
+		if params == "?1049" && (final == 'h' || final == 'l') {
+			s.alternateScreen(final == 'h')
+		}
+		return // Other private modes (cursor visibility, bracketed paste) are ignored
+	}
+
+	args := strings.Split(params, ";")
+	arg := func(i, def int) int {
+		if i >= len(args) || args[i] == "" {
+			return def
+		}
+		n, err := strconv.Atoi(args[i])
+		if err != nil {
+			return def
+		}
+		return n
+	}
+
+	col := min(s.col, s.width-1)
+	switch final {
+	case 'A':
+		s.moveTo(s.row-max(arg(0, 1), 1), col)
+	case 'B':
+		s.moveTo(s.row+max(arg(0, 1), 1), col)
+	case 'C':
+		s.moveTo(s.row, col+max(arg(0, 1), 1))
+	case 'D':
+		s.moveTo(s.row, col-max(arg(0, 1), 1))
+	case 'E':
+		s.moveTo(s.row+max(arg(0, 1), 1), 0)
+	case 'F':
+		s.moveTo(s.row-max(arg(0, 1), 1), 0)
+	case 'G':
+		s.moveTo(s.row, arg(0, 1)-1)
+	case 'H', 'f':
+		s.moveTo(arg(0, 1)-1, arg(1, 1)-1)
+	case 'J':
+		s.eraseDisplay(arg(0, 0))
+	case 'K':
+		s.eraseLine(s.row, arg(0, 0))
+	}
+}
+
+// moveTo moves the cursor, keeping it on the screen.
+func (s *Screen) moveTo(row, col int) {
+	s.row = max(0, min(row, s.height-1))
+	s.col = max(0, min(col, s.width-1))
+	s.wrap = false
+}
+
+// eraseDisplay implements ED: 0 erases from the cursor to the end of the
+// screen, 1 from the start of the screen to the cursor and 2 or 3 everything.
+func (s *Screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseLine(s.row, 0)
+		for i := s.row + 1; i < s.height; i++ {
+			s.eraseLine(i, 2)
+		}
+	case 1:
+		for i := range s.row {
+			s.eraseLine(i, 2)
+		}
+		s.eraseLine(s.row, 1)
+	case 2, 3:
+		s.cells = s.blank()
+	}
+}
+
+// eraseLine implements EL on the given row: 0 erases from the cursor to the end
+// of the row, 1 from the start of the row to the cursor and 2 the whole row.
+func (s *Screen) eraseLine(row, mode int) {
+	col := min(s.col, s.width-1)
+	from, to := 0, s.width
+	switch mode {
+	case 0:
+		from = col
+	case 1:
+		to = col + 1
+	}
+	for i := from; i < to; i++ {
+		s.cells[row][i] = ' '
+	}
+}
+
+// alternateScreen switches to the alternate screen and back.
+func (s *Screen) alternateScreen(enable bool) {
+	switch {
+	case enable && s.main == nil:
+		s.main = s.cells
+		s.cells = s.blank()
+	case !enable && s.main != nil:
+		s.cells = s.main
+		s.main = nil
+	}
+}
@@ -0,0 +1,128 @@
+package prompttest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScreen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		width     int
+		height    int
+		output    string
+		want      []string
+		wantRow   int
+		wantCol   int
+		skipCheck bool
+	}{
+		{
+			name: "plain text and line breaks", width: 10, height: 5,
+			output: "abc\r\ndef",
+			want:   []string{"abc", "def"}, wantRow: 1, wantCol: 3,
+		},
+		{
+			name: "colors are ignored", width: 10, height: 5,
+			output: "\x1b[1;38;2;0;255;0m$ \x1b[0mls",
+			want:   []string{"$ ls"}, wantRow: 0, wantCol: 4,
+		},
+		{
+			name: "carriage return and erase to end of line overwrite a row", width: 10, height: 5,
+			output: "hello\r\x1b[Kab",
+			want:   []string{"ab"}, wantRow: 0, wantCol: 2,
+		},
+		{
+			name: "wide characters take two columns", width: 10, height: 5,
+			output: "日本a\u0301",
+			want:   []string{"日本a"}, wantRow: 0, wantCol: 5,
+		},
+		{
+			name: "a wide character wraps whole at the right edge", width: 5, height: 5,
+			output: "ab日本",
+			want:   []string{"ab日", "本"}, wantRow: 1, wantCol: 2,
+		},
+		{
+			name: "text wraps at the right edge", width: 4, height: 5,
+			output: "abcdef",
+			want:   []string{"abcd", "ef"}, wantRow: 1, wantCol: 2,
+		},
+		{
+			name: "a full row does not wrap until the next rune", width: 4, height: 5,
+			output: "abcd\r\nx",
+			want:   []string{"abcd", "x"}, wantRow: 1, wantCol: 1,
+		},
+		{
+			name: "cursor up and erase below clears a menu", width: 10, height: 5,
+			output: "$ g\r\n  git\r\n  gist\x1b[2A\r\x1b[0J$ git",
+			want:   []string{"$ git"}, wantRow: 0, wantCol: 5,
+		},
+		{
+			name: "cursor movement by columns", width: 10, height: 5,
+			output: "abcdef\x1b[3Dx\x1b[1Cy\x1b[2Gz",
+			want:   []string{"azcxey"}, wantRow: 0, wantCol: 2,
+		},
+		{
+			name: "absolute positioning and erase display", width: 10, height: 5,
+			output: "one\r\ntwo\x1b[2J\x1b[2;3Hx",
+			want:   []string{"", "  x"}, wantRow: 1, wantCol: 3,
+		},
+		{
+			name: "line feed at the bottom scrolls", width: 10, height: 2,
+			output: "a\r\nb\r\nc",
+			want:   []string{"b", "c"}, wantRow: 1, wantCol: 1,
+		},
+		{
+			name: "alternate screen restores the main screen", width: 10, height: 5,
+			output: "main\x1b[?1049h\x1b[Halt\x1b[?1049l",
+			want:   []string{"main"}, skipCheck: true,
+		},
+		{
+			name: "private modes are ignored", width: 10, height: 5,
+			output: "\x1b[?25l\x1b[?2004hx\x1b[?25h",
+			want:   []string{"x"}, wantRow: 0, wantCol: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			screen := NewScreen(tt.width, tt.height)
+			_, err := screen.Write([]byte(tt.output))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, screen.Lines())
+			if !tt.skipCheck {
+				row, col := screen.Cursor()
+				assert.Equal(t, tt.wantRow, row, "cursor row")
+				assert.Equal(t, tt.wantCol, col, "cursor column")
+			}
+		})
+	}
+
+	t.Run("sequences split across writes", func(t *testing.T) {
+		t.Parallel()
+
+		screen := NewScreen(10, 5)
+		output := []byte("ab\x1b[1D▶c")
+		for i := range output {
+			_, _ = screen.Write(output[i : i+1])
+		}
+
+		assert.Equal(t, []string{"a▶c"}, screen.Lines())
+	})
+
+	t.Run("resize cuts off rows and columns", func(t *testing.T) {
+		t.Parallel()
+
+		screen := NewScreen(10, 5)
+		_, _ = screen.Write([]byte("abcdef\r\nghi"))
+		screen.Resize(3, 1)
+
+		assert.Equal(t, []string{"abc"}, screen.Lines())
+		assert.Equal(t, "abc", screen.String())
+	})
+}
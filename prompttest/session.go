@@ -0,0 +1,217 @@
+package prompttest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nao1215/prompt"
+)
+
+// Session is a prompt running on a scripted terminal during a test. Keys are
+// sent one batch at a time, each call returning once the prompt has handled
+// them, so the screen can be checked in between.
+//
+// Example:
+//
+//	func TestGitCompletion(t *testing.T) {
+//		s := prompttest.Start(t, "$ ", prompt.WithCompleter(completer))
+//		s.SendKeys("gi")
+//		s.SendSequence("tab")
+//		s.ExpectFrame("$ gi", "▶ git", "  gist")
+//		s.SendSequence("enter", "enter")
+//		s.ExpectResult("git")
+//	}
+type Session struct {
+	t        testing.TB
+	terminal *Terminal
+	screen   *Screen
+	output   syncBuffer
+	prompt   *prompt.Prompt
+	done     chan runResult
+	finished *runResult
+}
+
+// runResult is what Run returned.
+type runResult struct {
+	result string
+	err    error
+}
+
+// Start runs a prompt created with prefix and options on an 80x24 scripted
+// terminal. What the prompt writes goes to the session's Screen and is kept
+// for Output. If the prompt is still running when the test ends, its input is
+// closed; the prompt is closed either way.
+func Start(t testing.TB, prefix string, options ...prompt.Option) *Session {
+	t.Helper()
+
+	s := &Session{
+		t:        t,
+		terminal: NewTerminal(defaultWidth, defaultHeight),
+		screen:   NewScreen(defaultWidth, defaultHeight),
+		done:     make(chan runResult, 1),
+	}
+	output := io.MultiWriter(s.screen, &s.output)
+	opts := append([]prompt.Option{prompt.WithTerminal(s.terminal), prompt.WithOutput(output)}, options...)
+	p, err := prompt.New(prefix, opts...)
+	if err != nil {
+		t.Fatalf("prompttest: failed to create prompt: %v", err)
+	}
+	s.prompt = p
+	t.Cleanup(func() {
+		s.wait()
+		_ = p.Close()
+	})
+
+	go func() {
+		result, err := p.Run()
+		s.done <- runResult{result: result, err: err}
+	}()
+	return s
+}
+
+// Terminal returns the terminal the prompt runs on.
+func (s *Session) Terminal() *Terminal {
+	return s.terminal
+}
+
+// Screen returns the screen the prompt draws on.
+func (s *Session) Screen() *Screen {
+	return s.screen
+}
+
+// Output returns everything the prompt has written so far, escape sequences
+// included. StripANSI turns it into plain text.
+func (s *Session) Output() string {
+	return s.output.String()
+}
+
+// SendKeys types keys and waits until the prompt has handled them or
+// returned. Escape sequences are written out, for example "\x1b[A" for the up
+// arrow. It fails the test if the prompt takes longer than five seconds.
+func (s *Session) SendKeys(keys string) {
+	s.t.Helper()
+	if err := s.send(func() error { s.terminal.SendKeys(keys); return nil }); err != nil {
+		s.t.Fatalf("prompttest: keys %q: %v", keys, err)
+	}
+}
+
+// SendSequence types the keys given by name, as Terminal.SendSequence does,
+// and waits like SendKeys. It fails the test for a name prompt.ParseKey
+// cannot read.
+func (s *Session) SendSequence(names ...string) {
+	s.t.Helper()
+	if err := s.send(func() error { return s.terminal.SendSequence(names...) }); err != nil {
+		s.t.Fatalf("prompttest: keys %q: %v", names, err)
+	}
+}
+
+// send queues keys with queue and waits until the prompt is idle again or
+// has returned.
+func (s *Session) send(queue func() error) error {
+	if s.finished != nil {
+		return fmt.Errorf("prompt already returned %q, %v", s.finished.result, s.finished.err)
+	}
+	if err := queue(); err != nil {
+		return err
+	}
+	select {
+	case r := <-s.done:
+		s.finished = &r
+	case <-waitIdle(s.terminal):
+	case <-time.After(stepTimeout):
+		return fmt.Errorf("prompt did not finish handling the keys")
+	}
+	return nil
+}
+
+// Resize changes the size of the terminal and the screen, like a user
+// resizing the window. The prompt redraws on its own; ExpectFrame waits for
+// the new frame.
+func (s *Session) Resize(width, height int) {
+	s.screen.Resize(width, height)
+	s.terminal.Resize(width, height)
+}
+
+// ExpectFrame checks that the screen shows rows, as returned by Screen.Lines.
+// The prompt redraws on its own after a resize, a finished async completion
+// or a tick of the busy indicator, so ExpectFrame waits up to five seconds for
+// the rows to appear before it reports the mismatch as a test error.
+func (s *Session) ExpectFrame(rows ...string) bool {
+	s.t.Helper()
+	deadline := time.Now().Add(stepTimeout)
+	got := s.screen.Lines()
+	for !slices.Equal(got, rows) {
+		if time.Now().After(deadline) {
+			s.t.Errorf("prompttest: unexpected frame\nwant:\n%s\ngot:\n%s", formatFrame(rows), formatFrame(got))
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+		got = s.screen.Lines()
+	}
+	return true
+}
+
+// Result closes the input if the prompt is still running and returns what
+// Run returned.
+func (s *Session) Result() (string, error) {
+	s.t.Helper()
+	if !s.wait() {
+		s.t.Fatalf("prompttest: prompt did not return after the input was closed")
+	}
+	return s.finished.result, s.finished.err
+}
+
+// ExpectResult checks that Run returned want without an error, closing the
+// input first if the prompt is still running. Mismatches are reported as
+// test errors.
+func (s *Session) ExpectResult(want string) bool {
+	s.t.Helper()
+	result, err := s.Result()
+	if err != nil {
+		s.t.Errorf("prompttest: Run returned an error: %v", err)
+		return false
+	}
+	if result != want {
+		s.t.Errorf("prompttest: Run returned %q, want %q", result, want)
+		return false
+	}
+	return true
+}
+
+// wait closes the input if the prompt is still running and waits for Run to
+// return. It reports false if Run did not return in time.
+func (s *Session) wait() bool {
+	if s.finished == nil {
+		s.terminal.CloseInput()
+		select {
+		case r := <-s.done:
+			s.finished = &r
+		case <-time.After(stepTimeout):
+			return false
+		}
+	}
+	return true
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
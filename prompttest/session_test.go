@@ -0,0 +1,98 @@
+package prompttest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nao1215/prompt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	completer := func(d prompt.Document) []prompt.Suggestion {
+		var suggestions []prompt.Suggestion
+		for _, s := range []prompt.Suggestion{{Text: "git"}, {Text: "gist"}} {
+			if strings.HasPrefix(s.Text, d.GetWordBeforeCursor()) {
+				suggestions = append(suggestions, s)
+			}
+		}
+		return suggestions
+	}
+
+	t.Run("keys by name drive the menu", func(t *testing.T) {
+		t.Parallel()
+
+		s := Start(t, "$ ", prompt.WithCompleter(completer))
+		s.SendKeys("gi")
+		s.SendSequence("tab")
+		s.ExpectFrame("$ gi", "▶ git", "  gist")
+		s.SendSequence("down", "enter")
+		s.ExpectFrame("$ gist")
+		s.SendSequence("enter")
+
+		s.ExpectResult("gist")
+	})
+
+	t.Run("the prompt redraws for the new width after a resize", func(t *testing.T) {
+		t.Parallel()
+
+		s := Start(t, "$ ", prompt.WithRightPrompt(func() string { return "main" }))
+		s.SendKeys("ls")
+		s.ExpectFrame("$ ls" + strings.Repeat(" ", 71) + "main")
+
+		s.Resize(40, 10)
+
+		s.ExpectFrame("$ ls" + strings.Repeat(" ", 31) + "main")
+		s.SendKeys("\r")
+		s.ExpectResult("ls")
+	})
+
+	t.Run("output is kept with its escape sequences", func(t *testing.T) {
+		t.Parallel()
+
+		s := Start(t, "$ ")
+		s.SendKeys("hi\r")
+
+		s.ExpectResult("hi")
+		assert.Contains(t, s.Output(), "\x1b[")
+		assert.Contains(t, StripANSI(s.Output()), "$ hi")
+		assert.NotContains(t, StripANSI(s.Output()), "\x1b")
+	})
+
+	t.Run("the input is closed when the result is asked for", func(t *testing.T) {
+		t.Parallel()
+
+		s := Start(t, "$ ")
+		s.SendKeys("abc")
+
+		_, err := s.Result()
+
+		assert.ErrorIs(t, err, prompt.ErrEOF)
+	})
+}
+
+func TestStripANSI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain text is kept", input: "$ ls\n", want: "$ ls\n"},
+		{name: "colors and erasing", input: "\r\x1b[K\x1b[1;38;2;0;255;0m$ \x1b[0mls", want: "$ ls"},
+		{name: "private modes", input: "\x1b[?2004h\x1b[?25lok\x1b[?25h", want: "ok"},
+		{name: "OSC strings ended by BEL or ST", input: "\x1b]0;title\ab\x1b]8;;url\x1b\\c", want: "bc"},
+		{name: "two-character escapes", input: "\x1b7a\x1b8", want: "a"},
+		{name: "an unfinished sequence at the end", input: "a\x1b[1;3", want: "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, StripANSI(tt.input))
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package prompttest
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nao1215/prompt"
+)
+
+// Terminal is a scriptable prompt.Terminal for tests.
+//
+// Keys sent with SendKeys or SendSequence are queued and handed to the prompt
+// one rune at a time. When the queue is empty, ReadRune blocks until more keys
+// arrive or the input is closed, just like a user who has stopped typing.
+// WaitIdle reports when that happens, which is the moment the prompt has
+// handled every key sent so far and drawn the result. Resize changes the size
+// and tells the prompt, as a real terminal does when its window is resized.
+type Terminal struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []rune
+	eof     bool // CloseInput was called; ReadRune returns io.EOF once the queue is empty
+	waiting bool // ReadRune is blocked on an empty queue
+	idle    chan struct{}
+	resize  chan struct{}
+	width   int
+	height  int
+	raw     bool
+	closed  bool
+}
+
+var _ prompt.Terminal = (*Terminal)(nil)
+
+// NewTerminal returns a terminal of the given size with no pending input.
+func NewTerminal(width, height int) *Terminal {
+	t := &Terminal{
+		idle:   make(chan struct{}, 1),
+		resize: make(chan struct{}, 1),
+		width:  width,
+		height: height,
+	}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// SendKeys queues keys as typed input. Escape sequences are written out, for
+// example "\x1b[A" for the up arrow.
+func (t *Terminal) SendKeys(keys string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// An idle notification from before these keys is stale now
+	select {
+	case <-t.idle:
+	default:
+	}
+	t.queue = append(t.queue, []rune(keys)...)
+	t.cond.Broadcast()
+}
+
+// SendSequence queues keys given by name, as prompt.ParseKey reads them, such
+// as "ctrl+r", "up" or "alt+b", with the input a terminal sends for each:
+// "\x12", "\x1b[A" and "\x1bb" for these. Keys the classic encoding cannot
+// tell apart from others, such as "ctrl+enter", are sent in the kitty
+// keyboard protocol's encoding. Nothing is queued when a name is invalid; the
+// error wraps prompt.ErrInvalidKeyName.
+func (t *Terminal) SendSequence(names ...string) error {
+	var keys strings.Builder
+	for _, name := range names {
+		key, err := prompt.ParseKey(name)
+		if err != nil {
+			return err
+		}
+		keys.WriteString(keyInput(key))
+	}
+	t.SendKeys(keys.String())
+	return nil
+}
+
+// Resize changes the size Size reports and notifies the prompt through
+// ResizeEvents, which redraws for the new width.
+func (t *Terminal) Resize(width, height int) {
+	t.mu.Lock()
+	t.width, t.height = width, height
+	t.mu.Unlock()
+	select {
+	case t.resize <- struct{}{}:
+	default: // A notification is already pending
+	}
+}
+
+// ResizeEvents reports the calls of Resize. The prompt checks for this method
+// to redraw when the terminal is resized.
+func (t *Terminal) ResizeEvents() <-chan struct{} {
+	return t.resize
+}
+
+// CloseInput ends the input. Once the queued keys are used up, ReadRune
+// returns io.EOF.
+func (t *Terminal) CloseInput() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.eof = true
+	t.cond.Broadcast()
+}
+
+// WaitIdle waits until the prompt asks for a key while none is queued and
+// reports whether that happened within timeout.
+func (t *Terminal) WaitIdle(timeout time.Duration) bool {
+	t.mu.Lock()
+	if t.waiting && len(t.queue) == 0 {
+		t.mu.Unlock()
+		return true
+	}
+	t.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-t.idle:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// IsRaw reports whether the prompt has the terminal in raw mode.
+func (t *Terminal) IsRaw() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.raw
+}
+
+// IsClosed reports whether Close has been called.
+func (t *Terminal) IsClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+// SetRaw implements prompt.Terminal.
+func (t *Terminal) SetRaw() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.raw = true
+	return nil
+}
+
+// Restore implements prompt.Terminal.
+func (t *Terminal) Restore() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.raw = false
+	return nil
+}
+
+// Size implements prompt.Terminal.
+func (t *Terminal) Size() (width, height int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.width, t.height, nil
+}
+
+// ReadRune implements prompt.Terminal. It blocks while no key is queued.
+func (t *Terminal) ReadRune() (rune, int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for len(t.queue) == 0 {
+		if t.eof || t.closed {
+			return 0, 0, io.EOF
+		}
+		if !t.waiting {
+			t.waiting = true
+			select {
+			case t.idle <- struct{}{}:
+			default:
+			}
+		}
+		t.cond.Wait()
+	}
+	t.waiting = false
+
+	r := t.queue[0]
+	t.queue = t.queue[1:]
+	return r, 1, nil
+}
+
+// Close implements prompt.Terminal. A blocked ReadRune returns io.EOF.
+func (t *Terminal) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	t.cond.Broadcast()
+	return nil
+}
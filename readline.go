@@ -0,0 +1,94 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// ReadLine prints prefix and reads one line from standard input in the
+// terminal's normal (cooked) mode, returning it without the line ending. It is
+// a light alternative to New and Run for simple confirmations and for scripts
+// where standard input is not a terminal: there is no raw mode, no history,
+// no completion and no key handling beyond what the terminal itself does.
+//
+// The errors match those of Run: ErrEOF when input ends before anything was
+// typed, such as Ctrl+D on an empty line, and ErrInterrupted on Ctrl+C. After
+// an interrupt the line being typed is discarded, but the read of standard
+// input already in flight is not canceled and consumes the next line.
+//
+// Example:
+//
+//	answer, err := prompt.ReadLine("Continue? [y/N] ")
+//	if err != nil || !strings.EqualFold(answer, "y") {
+//		return
+//	}
+func ReadLine(prefix string) (string, error) {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	return readLine(os.Stdin, os.Stdout, prefix, interrupt)
+}
+
+// readLine implements ReadLine on r and w, returning ErrInterrupted when a
+// signal arrives on interrupt before the line is complete.
+func readLine(r io.Reader, w io.Writer, prefix string, interrupt <-chan os.Signal) (string, error) {
+	if _, err := io.WriteString(w, prefix); err != nil {
+		return "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	select {
+	case read := <-startLineRead(r):
+		return read.line, read.err
+	case <-interrupt:
+		fmt.Fprint(w, "\n") // The terminal echoed ^C but stays on the line
+		return "", ErrInterrupted
+	}
+}
+
+// lineRead is the result of a line read by startLineRead.
+type lineRead struct {
+	line string
+	err  error
+}
+
+// startLineRead reads the next line of r with readLineFrom in its own
+// goroutine, so the caller can stop waiting for it, and delivers the result on
+// the returned channel.
+func startLineRead(r io.Reader) chan lineRead {
+	ch := make(chan lineRead, 1) // Buffered so the reader never blocks on send
+	go func() {
+		line, err := readLineFrom(r)
+		ch <- lineRead{line: line, err: err}
+	}()
+	return ch
+}
+
+// readLineFrom reads up to and including the next newline one byte at a time,
+// so nothing after the line is consumed from r, and returns the line without
+// its "\n" or "\r\n" ending.
+func readLineFrom(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return strings.TrimSuffix(string(line), "\r"), nil
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return "", fmt.Errorf("failed to read input: %w", err)
+			}
+			if len(line) == 0 {
+				return "", ErrEOF
+			}
+			return strings.TrimSuffix(string(line), "\r"), nil // Last line without a newline
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package prompt
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		err   error
+	}{
+		{name: "the line ending is removed", input: "yes\nno\n", want: "yes"},
+		{name: "CRLF endings are removed", input: "yes\r\n", want: "yes"},
+		{name: "an empty line is returned as is", input: "\n", want: ""},
+		{name: "a last line without a newline", input: "yes", want: "yes"},
+		{name: "end of input returns ErrEOF", input: "", err: ErrEOF},
+		{name: "escape sequences are kept as typed", input: "a\x1b[Db\n", want: "a\x1b[Db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			line, err := readLine(strings.NewReader(tt.input), &out, "? ", nil)
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, line)
+			assert.Equal(t, "? ", out.String())
+		})
+	}
+
+	t.Run("the rest of the input is left unread", func(t *testing.T) {
+		t.Parallel()
+
+		input := strings.NewReader("first\nsecond\n")
+		_, err := readLine(input, io.Discard, "", nil)
+		require.NoError(t, err)
+
+		second, err := readLine(input, io.Discard, "", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "second", second)
+	})
+
+	t.Run("an interrupt returns ErrInterrupted", func(t *testing.T) {
+		t.Parallel()
+
+		r, w := io.Pipe()
+		defer w.Close()
+		interrupt := make(chan os.Signal, 1)
+		interrupt <- os.Interrupt
+		var out bytes.Buffer
+
+		line, err := readLine(r, &out, "? ", interrupt)
+
+		require.ErrorIs(t, err, ErrInterrupted)
+		assert.Empty(t, line)
+		assert.Equal(t, "? \n", out.String())
+	})
+}
@@ -25,15 +25,40 @@ import (
 // visual output and handles complex scenarios like suggestion menus and
 // multi-line editing with proper text wrapping.
 type renderer struct {
-	output            io.Writer         // Target output writer (typically stdout or colorable wrapper)
-	colorScheme       *ColorScheme      // Color configuration for themed rendering
-	lastLines         int               // Track number of lines rendered for efficient cleanup
-	suggestionsActive bool              // Track if suggestions are currently displayed
-	terminal          terminalInterface // Terminal interface for getting size information
+	output            io.Writer    // Target output writer (typically stdout or colorable wrapper)
+	colorScheme       *ColorScheme // Color configuration for themed rendering
+	lastLines         int          // Track number of lines rendered for efficient cleanup
+	cursorRow         int          // Row of the terminal cursor relative to the first rendered line
+	suggestionsActive bool         // Track if suggestions are currently displayed
+	terminal          Terminal     // Terminal interface for getting size information
+	frameRows         []int        // Width of each logical row of the last frame, kept to reflow it after a resize
+	frameCursorRow    int          // Logical row of the cursor in the last frame
+	frameCursorCol    int          // Column of the cursor within its logical row in the last frame
+	lexer             Lexer        // Optional syntax highlighter for the input (nil draws it in the input color)
+	placeholder       string       // Hint drawn after the prefix while the input is empty
+	autoSuggestion    string       // Completion drawn after the cursor when it is at the end of the input
+	ghostWidth        int          // Columns taken by the placeholder or auto-suggestion in the last frame
+	rightSegment      string       // Dimmed text drawn at the right edge of the first row, such as the history position
+	rightEnd          int          // Column just past the right-aligned text in the last frame (0 when it was not drawn)
+	errorMessage      string       // Validation error drawn on the row below the input (empty draws none)
+	keepCursor        bool         // Never hide the cursor, not even while the menu is drawn
+	cursorHidden      bool         // The cursor is hidden and showCursor has to bring it back
+	maxRows           int          // Most suggestions shown at once (0 means DefaultMenuRows)
+	profile           ColorProfile // Colors the terminal can display
+	menuCounter       string       // Dimmed position drawn on a row below the menu, such as "12/43" (empty draws none)
+	marks             []int        // Rune indexes of the input drawn in the error color, such as an unclosed quote
+	hscroll           bool         // Scroll long single-line input sideways instead of wrapping it
+	scrollStart       int          // Rune index of the first input character shown while scrolling sideways
+	window            []*Color     // Colors of the scrolled input window with its edge indicators (nil when not scrolled)
+	reservedRows      int          // Rows every frame takes, padded with blank rows (0 takes only those drawn)
+	lastFrame         string       // Output of the last frame, which an identical frame is not written over ("" when the screen may differ from it)
+
+	segments []PrefixSegment // Colored parts of the prefix (nil draws it in the prefix color)
+	footer   []string        // Dimmed rows drawn below the input and the menu (nil draws none)
 }
 
 // newRenderer creates a new renderer with the given output and color scheme.
-func newRenderer(output io.Writer, colorScheme *ColorScheme, terminal terminalInterface) *renderer {
+func newRenderer(output io.Writer, colorScheme *ColorScheme, terminal Terminal) *renderer {
 	return &renderer{
 		output:            output,
 		colorScheme:       colorScheme,
@@ -49,9 +74,47 @@ func (r *renderer) render(prefix, input string, cursor int) error {
 }
 
 // renderWithSuggestionsOffset displays the prompt with completion suggestions and scrolling support.
+//
+// The frame is put together first and written in one piece, and not at all
+// when it is the same as the last frame: keys that change nothing, such as
+// Left at the start of the input, then leave the screen alone instead of
+// erasing and drawing the prompt again, which makes the cursor flicker.
 func (r *renderer) renderWithSuggestionsOffset(prefix, input string, cursor int, suggestions []Suggestion, selected int, offset int) error {
+	output := r.output
+	var frame strings.Builder
+	r.output = &frame
+	err := r.drawFrame(prefix, input, cursor, suggestions, selected, offset)
+	r.output = output
+	if err != nil {
+		return err
+	}
+	if frame.String() == r.lastFrame {
+		return nil
+	}
+	r.lastFrame = ""
+	if _, err := io.WriteString(output, frame.String()); err != nil {
+		return err
+	}
+	r.lastFrame = frame.String()
+	return nil
+}
+
+// drawFrame draws the prompt with the suggestions below it over the last
+// frame.
+func (r *renderer) drawFrame(prefix, input string, cursor int, suggestions []Suggestion, selected int, offset int) error {
+	// Control characters from history or completers must not reach the
+	// terminal raw, where they could move the cursor or inject sequences
+	input = sanitizeInput(input)
+	suggestions = sanitizeSuggestions(suggestions)
+	r.window = nil
+	if r.hscroll {
+		input, cursor = r.scroll(prefix, input, cursor)
+	}
+
 	// Clear previous output using the CURRENT lastLines value
 	r.clearPreviousLines()
+	r.ghostWidth = 0
+	r.rightEnd = 0
 
 	// Calculate the actual number of lines that will be rendered
 	// This accounts for both explicit newlines and terminal wrapping
@@ -59,10 +122,13 @@ func (r *renderer) renderWithSuggestionsOffset(prefix, input string, cursor int,
 	if inputLines == 0 {
 		inputLines = 1
 	}
+	if r.errorMessage != "" {
+		inputLines++ // The error row is drawn right below the input
+	}
 
 	if len(suggestions) > 0 {
 		// Hide cursor during suggestion rendering
-		if _, err := fmt.Fprint(r.output, "\x1b[?25l"); err != nil {
+		if err := r.hideCursor(); err != nil {
 			return err
 		}
 
@@ -70,50 +136,218 @@ func (r *renderer) renderWithSuggestionsOffset(prefix, input string, cursor int,
 		if err := r.renderMainLineWithoutCursor(prefix, input); err != nil {
 			return err
 		}
+		if r.errorMessage != "" {
+			if err := r.renderError(); err != nil {
+				return err
+			}
+		}
 
 		// Render suggestions
 		if err := r.renderSuggestionsWithOffset(prefix, input, cursor, suggestions, selected, offset); err != nil {
 			return err
 		}
 
-		// Update state AFTER rendering
-		visibleCount := min(len(suggestions), 10)
-		r.lastLines = inputLines + visibleCount
+		// Update state AFTER rendering. The cursor is left on the last
+		// suggestion row, below every input line.
+		visibleCount := min(len(suggestions), r.menuRows())
+		if r.menuCounter != "" {
+			visibleCount++
+		}
+		padding, err := r.padRows(inputLines + visibleCount + len(r.footer))
+		if err != nil {
+			return err
+		}
+		footer, err := r.renderFooter()
+		if err != nil {
+			return err
+		}
+		r.lastLines = inputLines + visibleCount + padding + footer
+		r.cursorRow = r.lastLines - 1
 		r.suggestionsActive = true
+		r.recordFrame(prefix, input, suggestions, offset, -1, 0)
 	} else {
 		// No suggestions - render normally with cursor
 		if err := r.renderMainLine(prefix, input, cursor); err != nil {
 			return err
 		}
 
-		// Show cursor
-		if _, err := fmt.Fprint(r.output, "\x1b[?25h"); err != nil {
+		if err := r.showCursor(); err != nil {
 			return err
 		}
 
-		// Update lastLines to match the actual number of lines rendered
-		r.lastLines = inputLines
+		// Update lastLines to match the actual number of lines rendered,
+		// including the blank rows renderMainLine padded the frame with
+		r.lastLines = max(inputLines+len(r.footer), r.reservedRows)
 		r.suggestionsActive = false
+		cursorLine, cursorCol := r.findCursorPosition([]rune(input), cursor)
+		r.recordFrame(prefix, input, nil, 0, cursorLine, cursorCol)
 	}
 
 	return nil
 }
 
+// hideCursor hides the terminal cursor, unless keepCursor is set, and
+// remembers it so that showCursor can bring it back. The state is recorded
+// before writing, so a failed write still gets the cursor restored.
+func (r *renderer) hideCursor() error {
+	if r.keepCursor || r.cursorHidden {
+		return nil
+	}
+	r.cursorHidden = true
+	_, err := fmt.Fprint(r.output, "\x1b[?25l")
+	return err
+}
+
+// showCursor shows the cursor again if hideCursor hid it. Every path that ends
+// a prompt calls it, so the cursor is never left hidden.
+func (r *renderer) showCursor() error {
+	if !r.cursorHidden {
+		return nil
+	}
+	r.cursorHidden = false
+	_, err := fmt.Fprint(r.output, "\x1b[?25h")
+	return err
+}
+
 // renderMainLine renders the main prompt line with prefix and input.
 func (r *renderer) renderMainLine(prefix, input string, cursor int) error {
 	if err := r.renderLines(prefix, input); err != nil {
 		return err
 	}
+	reserved, err := r.renderRightSegment(prefix, input)
+	if err != nil {
+		return err
+	}
+	ghost := r.placeholder
+	if input != "" {
+		ghost = ""
+		if cursor == len([]rune(input)) {
+			ghost = r.autoSuggestion
+		}
+	}
+	if ghost != "" {
+		if err := r.renderGhost(prefix, input, ghost, reserved); err != nil {
+			return err
+		}
+	}
+	below := 0 // Rows drawn under the input
+	if r.errorMessage != "" {
+		if err := r.renderError(); err != nil {
+			return err
+		}
+		below++
+	}
+	padding, err := r.padRows(r.calculateRenderedLines(prefix, input) + below + len(r.footer))
+	if err != nil {
+		return err
+	}
+	footer, err := r.renderFooter()
+	if err != nil {
+		return err
+	}
+	if below += padding + footer; below > 0 {
+		// Go back to the end of the input, where positionCursor starts from
+		if _, err := fmt.Fprintf(r.output, "\x1b[%dA\x1b[%dG", below, r.inputEndColumn(prefix, input)+1); err != nil {
+			return err
+		}
+	}
 
-	// Position cursor correctly
-	lines := r.splitIntoLines(input)
-	inputRunes := []rune(input)
-	cursorLine, cursorCol := r.findCursorPosition(inputRunes, cursor)
-	r.positionCursor(lines, cursorLine, cursorCol, len([]rune(prefix)))
+	// Position cursor correctly. Remember where it was left so the next clear
+	// starts from the right row even when the cursor is not on the last one
+	// (e.g. after accepting a multi-line suggestion or moving up inside a
+	// wrapped line)
+	r.cursorRow = r.positionCursor(prefix, input, cursor)
 
 	return nil
 }
 
+// renderGhost draws dimmed text after the end of the input, such as the
+// placeholder or an auto-suggestion, and moves the cursor back to its start.
+// The text is cut to fit on the row the input ends on, minus the reserved
+// columns at the right edge, so moving back never has to cross a line.
+func (r *renderer) renderGhost(prefix, input, text string, reserved int) error {
+	termWidth := r.width()
+	col := r.lastRowEnd(prefix, input)
+	if col == termWidth {
+		return nil // The cursor waits at the right edge; there is no room on this row
+	}
+	text = truncateWidth(sanitizeText(text), termWidth-col-1-reserved)
+	width := stringWidth(text)
+	if width == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(r.output, "%s%s%s\x1b[%dD", r.ansi(r.colorScheme.Suggestion.Description), text, Reset(), width); err != nil {
+		return err
+	}
+	r.ghostWidth = width
+	return nil
+}
+
+// renderError draws the validation error on a new row below the input in the
+// theme's error color. It is cut to fit on that row so the number of rows the
+// frame takes stays known.
+func (r *renderer) renderError() error {
+	text := truncateWidth(sanitizeText(r.errorMessage), r.width()-1)
+	_, err := fmt.Fprintf(r.output, "\r\n\x1b[K%s%s%s", r.ansi(r.colorScheme.errorColor()), text, Reset())
+	return err
+}
+
+// inputEndColumn returns the 0-based terminal column just past the end of the
+// input, where the cursor is left after drawing it. Text that exactly fills a
+// row leaves the cursor waiting in the last column.
+func (r *renderer) inputEndColumn(prefix, input string) int {
+	return min(r.lastRowEnd(prefix, input), r.width()-1)
+}
+
+// lastRowEnd returns the column just past the end of the input on the last row
+// it takes, which is the terminal width when the text exactly fills the row.
+func (r *renderer) lastRowEnd(prefix, input string) int {
+	lines := r.splitIntoLines(input)
+	last := []rune(lines[len(lines)-1])
+	if len(lines) == 1 {
+		last = append([]rune(prefix), last...)
+	}
+	_, col := wrapText(last, r.width())
+	return col
+}
+
+// renderRightSegment draws the right-aligned segment at the right edge of the
+// row the input is on and moves the cursor back to the end of the input. It is
+// only drawn when the prompt fits on a single row with at least one blank
+// column between the input and the segment; otherwise it is left out rather
+// than overlapping the input. The last column stays empty because writing
+// there makes some terminals wrap. It returns the number of columns taken,
+// including the gap, so later text on the row can avoid them.
+func (r *renderer) renderRightSegment(prefix, input string) (int, error) {
+	if r.rightSegment == "" || r.calculateRenderedLines(prefix, input) != 1 {
+		return 0, nil
+	}
+	used := stringWidth(prefix) + stringWidth(input)
+	width := stringWidth(r.rightSegment)
+	start := r.width() - 1 - width
+	if start <= used {
+		return 0, nil
+	}
+
+	if _, err := fmt.Fprintf(r.output, "\x1b[%dG%s%s%s\x1b[%dG", start+1, r.ansi(r.colorScheme.Suggestion.Description), r.rightSegment, Reset(), used+1); err != nil {
+		return 0, err
+	}
+	r.rightEnd = start + width
+	return width + 1, nil
+}
+
+// width returns the terminal width, falling back to 80 columns when it is
+// unknown.
+func (r *renderer) width() int {
+	if r.terminal != nil {
+		if width, _, err := r.terminal.Size(); err == nil && width > 0 {
+			return width
+		}
+	}
+	return 80
+}
+
 // renderMainLineWithoutCursor renders the main prompt line without cursor positioning (for suggestions)
 func (r *renderer) renderMainLineWithoutCursor(prefix, input string) error {
 	return r.renderLines(prefix, input)
@@ -128,6 +362,11 @@ func (r *renderer) renderLines(prefix, input string) error {
 
 	// Split input into lines
 	lines := r.splitIntoLines(input)
+	colors := r.window
+	if colors == nil {
+		colors = r.highlight(input)
+	}
+	lineStart := 0 // Rune offset of the current line within input
 
 	// Render each line
 	for lineIndex, line := range lines {
@@ -140,27 +379,29 @@ func (r *renderer) renderLines(prefix, input string) error {
 
 		if lineIndex == 0 {
 			// First line: render prefix
-			if _, err := fmt.Fprint(r.output, r.colorScheme.Prefix.ToANSI()); err != nil {
+			if err := r.writePrefix(prefix); err != nil {
+				return err
+			}
+		}
+
+		// Render line content with color
+		lineLen := len([]rune(line))
+		if colors != nil {
+			if err := r.writeHighlighted(line, colors[lineStart:lineStart+lineLen]); err != nil {
 				return err
 			}
-			if _, err := fmt.Fprint(r.output, prefix); err != nil {
+		} else {
+			if _, err := fmt.Fprint(r.output, r.ansi(r.colorScheme.Input)); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprint(r.output, line); err != nil {
 				return err
 			}
 			if _, err := fmt.Fprint(r.output, Reset()); err != nil {
 				return err
 			}
 		}
-
-		// Render line content with color
-		if _, err := fmt.Fprint(r.output, r.colorScheme.Input.ToANSI()); err != nil {
-			return err
-		}
-		if _, err := fmt.Fprint(r.output, line); err != nil {
-			return err
-		}
-		if _, err := fmt.Fprint(r.output, Reset()); err != nil {
-			return err
-		}
+		lineStart += lineLen + 1 // Skip the newline
 
 		// Move to next line if not the last line
 		if lineIndex < len(lines)-1 {
@@ -173,6 +414,73 @@ func (r *renderer) renderLines(prefix, input string) error {
 	return nil
 }
 
+// highlight returns the color of every rune of input: the colors of the lexer,
+// with the marked runes in the theme's error color. It returns nil when there
+// is neither a lexer whose tokens add up to the input nor a mark. A nil entry
+// means the rune is drawn in the theme's input color.
+func (r *renderer) highlight(input string) []*Color {
+	colors := r.lexerColors(input)
+	if len(r.marks) == 0 {
+		return colors
+	}
+	n := len([]rune(input))
+	if colors == nil {
+		colors = make([]*Color, n)
+	}
+	errorColor := r.colorScheme.errorColor()
+	for _, i := range r.marks {
+		if i >= 0 && i < n {
+			colors[i] = &errorColor
+		}
+	}
+	return colors
+}
+
+// lexerColors runs the lexer over input and returns the color of every rune,
+// or nil when there is no lexer or its tokens do not add up to the input.
+func (r *renderer) lexerColors(input string) []*Color {
+	if r.lexer == nil || input == "" {
+		return nil
+	}
+	tokens := r.lexer(input)
+
+	colors := make([]*Color, 0, len(input))
+	var text strings.Builder
+	for _, token := range tokens {
+		text.WriteString(token.Text)
+		for range token.Text {
+			colors = append(colors, token.Color)
+		}
+	}
+	if text.String() != input {
+		return nil
+	}
+	return colors
+}
+
+// writeHighlighted writes line with one color per rune, switching colors only
+// where they change.
+func (r *renderer) writeHighlighted(line string, colors []*Color) error {
+	var b strings.Builder
+	var current *Color
+	for i, ch := range []rune(line) {
+		color := colors[i]
+		if color == nil {
+			color = &r.colorScheme.Input
+		}
+		if i == 0 || *color != *current {
+			// Reset first so bold from the previous token does not carry over
+			b.WriteString(Reset())
+			b.WriteString(r.ansi(*color))
+			current = color
+		}
+		b.WriteRune(ch)
+	}
+	b.WriteString(Reset())
+	_, err := fmt.Fprint(r.output, b.String())
+	return err
+}
+
 // renderSuggestionsWithOffset renders the completion suggestions with scrolling support.
 func (r *renderer) renderSuggestionsWithOffset(_, _ string, _ int, suggestions []Suggestion, selected int, offset int) error {
 	// Start rendering suggestions
@@ -180,7 +488,7 @@ func (r *renderer) renderSuggestionsWithOffset(_, _ string, _ int, suggestions [
 		return err
 	}
 
-	maxSuggestions := 10 // Limit number of displayed suggestions
+	maxSuggestions := r.menuRows() // Limit number of displayed suggestions
 
 	// Clamp offset to valid range for all suggestion counts
 	maxOffset := max(0, len(suggestions)-maxSuggestions)
@@ -198,58 +506,36 @@ func (r *renderer) renderSuggestionsWithOffset(_, _ string, _ int, suggestions [
 		visibleSelected = -1 // Selected item is not visible
 	}
 
+	columns := newSuggestionColumns(visibleSuggestions).fit(r.width() - 1)
 	for i, suggestion := range visibleSuggestions {
 		// Clear line and move to beginning
 		if _, err := fmt.Fprint(r.output, "\r\x1b[K"); err != nil {
 			return err
 		}
 
-		// Render selection indicator and suggestion
+		// Render selection indicator, icon and suggestion text
+		icon, text, category, description := columns.cells(suggestion)
+		marker, textColor := "  ", r.colorScheme.Suggestion.Text
 		if i == visibleSelected {
-			// Selected suggestion
-			if _, err := fmt.Fprint(r.output, r.colorScheme.Selected.ToANSI()); err != nil {
-				return err
-			}
-			if _, err := fmt.Fprint(r.output, "▶ "); err != nil {
-				return err
-			}
-			if _, err := fmt.Fprint(r.output, suggestion.Text); err != nil {
-				return err
-			}
-			if _, err := fmt.Fprint(r.output, Reset()); err != nil {
-				return err
-			}
-		} else {
-			// Normal suggestion
-			if _, err := fmt.Fprint(r.output, r.colorScheme.Suggestion.Text.ToANSI()); err != nil {
-				return err
-			}
-			if _, err := fmt.Fprint(r.output, "  "); err != nil {
-				return err
-			}
-			if _, err := fmt.Fprint(r.output, suggestion.Text); err != nil {
-				return err
-			}
-			if _, err := fmt.Fprint(r.output, Reset()); err != nil {
-				return err
-			}
+			marker, textColor = "▶ ", r.colorScheme.Selected
+		} else if suggestion.Color != nil {
+			textColor = *suggestion.Color
+		}
+		if _, err := fmt.Fprint(r.output, r.ansi(textColor), marker, icon, text, Reset()); err != nil {
+			return err
 		}
 
-		// Render description if available
-		if suggestion.Description != "" {
-			if _, err := fmt.Fprint(r.output, " "); err != nil {
-				return err
-			}
-			if _, err := fmt.Fprint(r.output, r.colorScheme.Suggestion.Description.ToANSI()); err != nil {
-				return err
-			}
-			if _, err := fmt.Fprint(r.output, "- "); err != nil {
-				return err
+		// Render category and description if available
+		if category != "" || description != "" {
+			detailColor := r.colorScheme.Suggestion.Description
+			if suggestion.DescriptionColor != nil {
+				detailColor = *suggestion.DescriptionColor
 			}
-			if _, err := fmt.Fprint(r.output, suggestion.Description); err != nil {
-				return err
+			if i == visibleSelected {
+				// Keep the reverse video or background of the selection across the row
+				detailColor = detailColor.onRow(textColor)
 			}
-			if _, err := fmt.Fprint(r.output, Reset()); err != nil {
+			if _, err := fmt.Fprint(r.output, r.ansi(detailColor), category, description, Reset()); err != nil {
 				return err
 			}
 		}
@@ -262,35 +548,183 @@ func (r *renderer) renderSuggestionsWithOffset(_, _ string, _ int, suggestions [
 		}
 	}
 
+	if r.menuCounter != "" {
+		if _, err := fmt.Fprint(r.output, "\r\n\x1b[K", r.ansi(r.colorScheme.Suggestion.Description), "  ", r.menuCounter, Reset()); err != nil {
+			return err
+		}
+	}
+
 	// Leave cursor at the end of suggestions
 	// Parent function will handle final cursor positioning
 	return nil
 }
 
+// renderOverlay draws the input line without a cursor and rows below it, such
+// as the reverse history search, and leaves the cursor on the first row at
+// column col. The rows are written as given, so they must already be colored,
+// sanitized and short enough not to wrap.
+func (r *renderer) renderOverlay(prefix, input string, rows []string, col int) error {
+	r.lastFrame = ""
+	input = sanitizeInput(input)
+	r.window = nil
+	if r.hscroll {
+		input, _ = r.scroll(prefix, input, r.scrollStart) // Keep the window of the last frame
+	}
+
+	r.clearPreviousLines()
+	r.ghostWidth = 0
+	r.rightEnd = 0
+	inputLines := max(r.calculateRenderedLines(prefix, input), 1)
+
+	if err := r.renderMainLineWithoutCursor(prefix, input); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprint(r.output, "\r\n\x1b[K", row); err != nil {
+			return err
+		}
+	}
+	padding, err := r.padRows(inputLines + len(rows))
+	if err != nil {
+		return err
+	}
+
+	// Back to the first row below the input
+	var b strings.Builder
+	if up := len(rows) + padding - 1; up > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", up)
+	}
+	b.WriteString("\r")
+	if col > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", col)
+	}
+	if _, err := io.WriteString(r.output, b.String()); err != nil {
+		return err
+	}
+	if err := r.showCursor(); err != nil {
+		return err
+	}
+
+	r.lastLines = inputLines + len(rows) + padding
+	r.cursorRow = min(inputLines, r.lastLines-1)
+	r.suggestionsActive = false
+	r.frameRows = nil // Not reflowed on resize; the next frame is drawn in full
+	return nil
+}
+
+// recordFrame remembers the logical rows of the frame just drawn and where the
+// cursor was left, so reflow can work out where they ended up if the terminal is
+// resized before the next render. A negative cursorLine means the cursor was
+// left at the end of the last row (after a suggestion menu); cursorCol is the
+// rune index of the cursor within its line.
+func (r *renderer) recordFrame(prefix, input string, suggestions []Suggestion, offset int, cursorLine, cursorCol int) {
+	prefixWidth := stringWidth(prefix)
+	lines := r.splitIntoLines(input)
+	rows := make([]int, 0, len(lines)+min(len(suggestions), r.menuRows())+1)
+	for i, line := range lines {
+		width := stringWidth(line)
+		if i == 0 {
+			width += prefixWidth
+		}
+		rows = append(rows, width)
+	}
+	if r.errorMessage != "" {
+		rows = append(rows, min(stringWidth(sanitizeText(r.errorMessage)), r.width()-1))
+	}
+	if len(suggestions) > 0 {
+		pageRows := r.menuRows()
+		start := max(0, min(offset, len(suggestions)-pageRows))
+		page := suggestions[start:min(start+pageRows, len(suggestions))]
+		columns := newSuggestionColumns(page).fit(r.width() - 1)
+		for _, suggestion := range page {
+			rows = append(rows, columns.width(suggestion))
+		}
+		if r.menuCounter != "" {
+			rows = append(rows, 2+stringWidth(r.menuCounter))
+		}
+	}
+	for len(rows)+len(r.footer) < r.reservedRows {
+		rows = append(rows, 0) // Blank padding rows
+	}
+	for _, row := range r.footer {
+		rows = append(rows, min(stringWidth(sanitizeText(row)), r.width()-1))
+	}
+	rows[len(lines)-1] += r.ghostWidth
+	rows[0] = max(rows[0], r.rightEnd)
+	r.frameRows = rows
+
+	switch {
+	case cursorLine < 0:
+		r.frameCursorRow = len(rows) - 1
+		r.frameCursorCol = rows[len(rows)-1]
+	default:
+		r.frameCursorRow = cursorLine
+		r.frameCursorCol = runesWidth([]rune(lines[cursorLine])[:cursorCol])
+		if cursorLine == 0 {
+			r.frameCursorCol += prefixWidth
+		}
+	}
+}
+
+// reflow updates the line tracking after the terminal was resized to width.
+// Terminals re-wrap existing output on resize, so the previous frame may now
+// take more (or fewer) rows than when it was drawn and the cursor may sit on a
+// different row. Recomputing both from the remembered logical rows lets the next
+// clearPreviousLines remove the whole old frame instead of leaving stale rows.
+func (r *renderer) reflow(width int) {
+	r.lastFrame = "" // The terminal re-wrapped the last frame
+	if width <= 0 || len(r.frameRows) == 0 || r.frameCursorRow >= len(r.frameRows) {
+		return
+	}
+	cursorRow, total := 0, 0
+	for i, rowWidth := range r.frameRows {
+		rows := max(1, (rowWidth+width-1)/width)
+		if i < r.frameCursorRow {
+			cursorRow += rows
+		}
+		total += rows
+	}
+	col := r.frameCursorCol
+	if col > 0 && col >= r.frameRows[r.frameCursorRow] {
+		// At the end of the text the cursor stays on the row it filled instead
+		// of moving to the next one until another character is written
+		cursorRow += (col - 1) / width
+	} else {
+		cursorRow += col / width
+	}
+	r.cursorRow = cursorRow
+	r.lastLines = total
+}
+
 // clearPreviousLines clears the previously rendered lines.
 // clearScreen clears the entire terminal screen and scrollback and homes the
 // cursor, then resets the line-tracking state so the next render draws the
 // prompt at the top. It implements the Ctrl+L clear-screen behavior.
 func (r *renderer) clearScreen() {
 	fmt.Fprint(r.output, "\x1b[H\x1b[2J\x1b[3J")
+	r.lastFrame = ""
 	r.lastLines = 1
+	r.cursorRow = 0
 	r.suggestionsActive = false
+	r.frameRows = nil
 }
 
 func (r *renderer) clearPreviousLines() {
+	// Move cursor up to the first line of the previously rendered content. The
+	// cursor is not necessarily on the last rendered line: multi-line input can
+	// leave it on an earlier line, so use the tracked row instead of lastLines.
+	if r.cursorRow > 0 {
+		fmt.Fprintf(r.output, "\x1b[%dA", r.cursorRow)
+	}
+
 	if r.lastLines <= 1 {
 		// Just clear the current line
 		fmt.Fprint(r.output, "\r\x1b[K")
 		return
 	}
 
-	// For multi-line content, we need to:
-	// 1. Move cursor up to the beginning of the rendered content
-	// 2. Clear from cursor position to end of screen
-	// This ensures all previously rendered lines are cleared properly
-
-	// Move cursor up to the first line of the previously rendered content
-	fmt.Fprintf(r.output, "\x1b[%dA", r.lastLines-1)
+	// For multi-line content, clear every previously rendered line, including
+	// an old suggestion menu below the input
 
 	// Move to beginning of line and clear from cursor to end of screen
 	// \x1b[0J clears from cursor position to end of screen
@@ -355,113 +789,52 @@ func (r *renderer) findCursorPosition(inputRunes []rune, cursor int) (line, col
 	return line, col
 }
 
-// positionCursor moves the terminal cursor to the correct position using ANSI escape sequences.
-//
-// Simplified approach for multiline:
-//   - Single-line input: Normal positioning with prefix
-//   - Multi-line input: Continuation lines always start from line beginning (column 0)
-//   - No complex calculations - just move to target line and position from start
-//
-// Uses standard ANSI escape codes:
-//   - \x1b[<n>A: Move cursor up n lines
-//   - \x1b[<n>C: Move cursor right n characters
-//   - \r: Move cursor to beginning of line
-func (r *renderer) positionCursor(lines []string, cursorLine, cursorCol, prefixLen int) {
-	totalLines := len(lines)
-	if totalLines <= 1 {
-		// Single line - move cursor back from end of line
-		lineLen := len([]rune(lines[0]))
-		if cursorCol < lineLen {
-			runesAfterCursor := lineLen - cursorCol
-			if runesAfterCursor > 0 {
-				fmt.Fprintf(r.output, "\x1b[%dD", runesAfterCursor)
-			}
-		}
-		return
-	}
-
-	// Multi-line positioning: simple approach
-	// 1. Move up to the target line (if needed)
-	// 2. Move to beginning of that line
-	// 3. Move right to cursor position (no prefix calculation for continuation lines)
-
-	// Calculate how many lines to move up from the last line (where cursor currently is)
-	// to the target line (cursorLine)
-	currentLine := totalLines - 1 // We're currently at the last line (0-indexed)
-	linesToMoveUp := currentLine - cursorLine
-	if linesToMoveUp > 0 {
-		fmt.Fprintf(r.output, "\x1b[%dA", linesToMoveUp)
+// positionCursor moves the terminal cursor from the end of the input, where
+// drawing it left the cursor, to the rune index cursor, and returns the row it
+// is on relative to the first row of the input. The rows and columns come from
+// layoutInput, so soft-wrapped lines and wide characters put the cursor where
+// the terminal drew the character. A cursor on the same row as the end of the
+// input moves left; otherwise it moves up to its row and right from the start
+// of it:
+//   - \x1b[<n>D: Move cursor left n columns
+//   - \x1b[<n>A: Move cursor up n rows
+//   - \r and \x1b[<n>C: Move cursor to the start of the row and right n columns
+func (r *renderer) positionCursor(prefix, input string, cursor int) int {
+	termWidth := r.width()
+	l := layoutInput(prefix, []rune(input), termWidth)
+	endRow, endCol := len(l.rows)-1, l.rows[len(l.rows)-1].width
+	row, col := l.locate(max(0, min(cursor, len(l.input))))
+	if row == endRow && col == endCol {
+		return row // Already there
 	}
 
-	// Move to beginning of current line
-	fmt.Fprint(r.output, "\r")
-
-	// Simple column positioning
-	if cursorLine == 0 {
-		// First line: add prefix length
-		totalCol := cursorCol + prefixLen
-		if totalCol > 0 {
-			fmt.Fprintf(r.output, "\x1b[%dC", totalCol)
-		}
+	var b strings.Builder
+	if row == endRow && endCol < termWidth {
+		fmt.Fprintf(&b, "\x1b[%dD", endCol-col)
 	} else {
-		// Continuation lines: just move to cursor column (from line start)
-		if cursorCol > 0 {
-			fmt.Fprintf(r.output, "\x1b[%dC", cursorCol)
+		// The end of a full row leaves the cursor waiting past the last
+		// column, from where relative left movement would be off by one
+		if up := endRow - row; up > 0 {
+			fmt.Fprintf(&b, "\x1b[%dA", up)
+		}
+		b.WriteString("\r")
+		if col > 0 {
+			fmt.Fprintf(&b, "\x1b[%dC", col)
 		}
 	}
+	fmt.Fprint(r.output, b.String())
+	return row
 }
 
 // calculateRenderedLines calculates the actual number of lines that will be rendered,
-// accounting for both explicit newlines and terminal wrapping.
+// accounting for both explicit newlines and terminal wrapping. Lines are
+// measured in terminal columns, so wide characters take two. Empty input still
+// takes the rows of the prefix.
 func (r *renderer) calculateRenderedLines(prefix, input string) int {
-	// Get terminal width
-	termWidth := 80 // Default fallback
-	if r.terminal != nil {
-		if width, _, err := r.terminal.Size(); err == nil && width > 0 {
-			termWidth = width
-		}
-	}
-
-	// If input is empty, we still have one line with just the prefix
-	if input == "" {
-		return 1
-	}
-
-	// Split by explicit newlines
-	lines := strings.Split(input, "\n")
-
-	totalLines := 0
-	prefixLen := len([]rune(prefix))
-
-	for i, line := range lines {
-		lineRunes := []rune(line)
-
-		// Calculate the actual length including prefix/indentation
-		var actualLength int
-		if i == 0 {
-			// First line includes the actual prefix
-			actualLength = prefixLen + len(lineRunes)
-		} else {
-			// Continuation lines have no prefix, just the line content
-			actualLength = len(lineRunes)
-		}
-
-		// Calculate how many terminal lines this will take
-		if actualLength == 0 || (i == 0 && actualLength == prefixLen) {
-			// Empty line or just prefix
-			totalLines++
-		} else if termWidth > 0 {
-			// Calculate wrapped lines based on terminal width
-			// Use ceiling division: (actualLength + termWidth - 1) / termWidth
-			wrappedLines := (actualLength + termWidth - 1) / termWidth
-			if wrappedLines == 0 {
-				wrappedLines = 1
-			}
-			totalLines += wrappedLines
-		} else {
-			totalLines++
-		}
-	}
+	return len(layoutInput(prefix, []rune(input), r.width()).rows)
+}
 
-	return totalLines
+// ansi returns the escape sequence drawing text in color on the terminal.
+func (r *renderer) ansi(color Color) string {
+	return color.ToANSIWithProfile(r.profile)
 }
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewRenderer(t *testing.T) {
@@ -211,19 +214,33 @@ func TestRendererFindCursorPosition(t *testing.T) {
 func TestRendererPositionCursor(t *testing.T) {
 	t.Parallel()
 
-	var output bytes.Buffer
-	renderer := newRenderer(&output, ThemeDefault, nil)
+	tests := []struct {
+		name   string
+		input  string
+		cursor int
+		want   string
+		row    int
+	}{
+		{name: "end of the input stays put", input: "hello", cursor: 5, want: "", row: 0},
+		{name: "same row moves left", input: "hello", cursor: 2, want: "\x1b[3D", row: 0},
+		{name: "earlier line moves up and right from the start of the row", input: "line1\nline2\nline3", cursor: 8, want: "\x1b[1A\r\x1b[2C", row: 1},
+		{name: "first line adds the prefix", input: "line1\nline2", cursor: 2, want: "\x1b[1A\r\x1b[4C", row: 0},
+		{name: "wrapped line moves up a row", input: strings.Repeat("a", 12), cursor: 3, want: "\x1b[1A\r\x1b[5C", row: 0},
+		{name: "cursor at a wrap point is on the next row", input: strings.Repeat("a", 12), cursor: 8, want: "\x1b[4D", row: 1},
+		{name: "full last row moves from the start of the row", input: strings.Repeat("a", 18), cursor: 17, want: "\r\x1b[9C", row: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
 
-	lines := []string{"line1", "line2", "line3"}
+			var output bytes.Buffer
+			renderer := newRenderer(&output, ThemeDefault, &mockTerminal{terminalSize: [2]int{10, 24}})
 
-	// This mainly tests that the method doesn't crash
-	renderer.positionCursor(lines, 1, 2, 2)
+			row := renderer.positionCursor("> ", tt.input, tt.cursor)
 
-	// Check that some output was written
-	result := output.String()
-	// positionCursor writes ANSI escape sequences
-	if len(result) == 0 {
-		t.Error("Expected some output from positionCursor")
+			assert.Equal(t, tt.want, output.String())
+			assert.Equal(t, tt.row, row)
+		})
 	}
 }
 
@@ -255,6 +272,58 @@ func TestRendererMultipleRenders(t *testing.T) {
 	}
 }
 
+func TestRendererSkipsUnchangedFrames(t *testing.T) {
+	t.Parallel()
+
+	t.Run("an identical frame is not written", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+		suggestions := []Suggestion{{Text: "git"}, {Text: "go"}}
+		require.NoError(t, renderer.renderWithSuggestionsOffset("$ ", "g", 1, suggestions, 0, 0))
+		require.NoError(t, renderer.renderWithSuggestionsOffset("$ ", "g", 1, suggestions, 0, 0))
+		first := output.String()
+
+		require.NoError(t, renderer.renderWithSuggestionsOffset("$ ", "g", 1, suggestions, 0, 0))
+
+		assert.Equal(t, first, output.String())
+		require.NoError(t, renderer.renderWithSuggestionsOffset("$ ", "g", 1, suggestions, 1, 0))
+		assert.NotEqual(t, first, output.String(), "a new selection is drawn")
+	})
+
+	t.Run("the frame is drawn again after the screen changed", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+		require.NoError(t, renderer.render("$ ", "hello", 5))
+		renderer.clearScreen()
+		output.Reset()
+
+		require.NoError(t, renderer.render("$ ", "hello", 5))
+
+		assert.Contains(t, output.String(), "hello")
+	})
+
+	t.Run("keys that change nothing write nothing", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		p, err := New("$ ", WithTerminal(newMockTerminal("\x1b[Dab\x1b[C\r")), WithOutput(&output))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ab", result)
+		// Drawn for the empty input, "a" and "ab", but not for Left at the
+		// start or Right at the end
+		assert.Equal(t, 3, strings.Count(output.String(), "$ "))
+	})
+}
+
 func TestRendererSuggestionScrolling(t *testing.T) {
 	t.Parallel()
 
@@ -938,3 +1007,594 @@ func TestRendererLongListScrolling(t *testing.T) {
 		}
 	}
 }
+
+func TestRendererClearsAfterMultiLineInsertion(t *testing.T) {
+	t.Parallel()
+
+	t.Run("accepting a multi-line suggestion clears the whole old menu", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+		suggestions := []Suggestion{{Text: "for {\n}"}, {Text: "func"}, {Text: "fmt"}}
+
+		if err := renderer.renderWithSuggestionsOffset("> ", "f", 1, suggestions, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+		output.Reset()
+
+		// Accept the snippet: the input now spans two lines and the menu closes
+		if err := renderer.render("> ", "for {\n}", 6); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(output.String(), "\x1b[3A\r\x1b[0J") {
+			t.Errorf("clear after accept = %q, want it to move up over the 3 menu rows", output.String())
+		}
+		if renderer.lastLines != 2 {
+			t.Errorf("lastLines = %d, want 2", renderer.lastLines)
+		}
+	})
+
+	t.Run("cursor on an earlier line is cleared from that line", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+
+		// Cursor after "{" on the first of three lines
+		if err := renderer.render("> ", "for {\n\tx\n}", 5); err != nil {
+			t.Fatal(err)
+		}
+		if renderer.cursorRow != 0 {
+			t.Fatalf("cursorRow = %d, want 0", renderer.cursorRow)
+		}
+		output.Reset()
+
+		if err := renderer.render("> ", "for {\n\tx\n}", 5); err != nil {
+			t.Fatal(err)
+		}
+		// The cursor already sits on the first line, so moving up would erase
+		// output above the prompt
+		if strings.HasPrefix(output.String(), "\x1b[2A") {
+			t.Errorf("clear = %q, should not move above the first rendered line", output.String())
+		}
+		if !strings.HasPrefix(output.String(), "\r\x1b[0J") {
+			t.Errorf("clear = %q, want it to clear from the first line to the end of screen", output.String())
+		}
+	})
+}
+
+func TestRendererReflow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("shrinking the terminal makes the old line wrap", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+		if err := renderer.render("$ ", "hello world", 11); err != nil {
+			t.Fatal(err)
+		}
+
+		// "$ hello world" is 13 columns: 3 rows at width 5, cursor at the end
+		renderer.reflow(5)
+
+		if renderer.lastLines != 3 {
+			t.Errorf("lastLines = %d, want 3", renderer.lastLines)
+		}
+		if renderer.cursorRow != 2 {
+			t.Errorf("cursorRow = %d, want 2", renderer.cursorRow)
+		}
+	})
+
+	t.Run("menu rows are counted after a resize", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+		suggestions := []Suggestion{
+			{Text: "status", Description: "Show the working tree status"},
+			{Text: "stash"},
+		}
+		if err := renderer.renderWithSuggestionsOffset("$ ", "st", 2, suggestions, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		// Rows: "$ st" (4), "▶ status - Show the working tree status" (39), "  stash" (7)
+		renderer.reflow(20)
+
+		if renderer.lastLines != 4 {
+			t.Errorf("lastLines = %d, want 4", renderer.lastLines)
+		}
+		if renderer.cursorRow != 3 {
+			t.Errorf("cursorRow = %d, want 3", renderer.cursorRow)
+		}
+	})
+
+	t.Run("cursor on an earlier line keeps its logical position", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+		if err := renderer.render("> ", "abcdefgh\nx", 3); err != nil {
+			t.Fatal(err)
+		}
+
+		// First line "> abcdefgh" is 10 columns: 2 rows at width 6; the cursor
+		// after "abc" is at column 5, still on the first row
+		renderer.reflow(6)
+
+		if renderer.cursorRow != 0 {
+			t.Errorf("cursorRow = %d, want 0", renderer.cursorRow)
+		}
+		if renderer.lastLines != 3 {
+			t.Errorf("lastLines = %d, want 3", renderer.lastLines)
+		}
+	})
+
+	t.Run("nothing to reflow before the first render", func(t *testing.T) {
+		t.Parallel()
+
+		renderer := newRenderer(&bytes.Buffer{}, ThemeDefault, nil)
+		renderer.reflow(10)
+
+		if renderer.lastLines != 1 || renderer.cursorRow != 0 {
+			t.Errorf("lastLines = %d, cursorRow = %d, want 1 and 0", renderer.lastLines, renderer.cursorRow)
+		}
+	})
+}
+
+func TestRendererLexer(t *testing.T) {
+	t.Parallel()
+
+	keyword := Color{R: 255, G: 0, B: 0, Bold: true}
+	number := Color{R: 0, G: 0, B: 255}
+	lexer := func(text string) []Token {
+		var tokens []Token
+		for _, word := range strings.SplitAfter(text, " ") {
+			token := Token{Text: word}
+			switch strings.TrimSpace(word) {
+			case "select":
+				token.Color = &keyword
+			case "1":
+				token.Color = &number
+			}
+			tokens = append(tokens, token)
+		}
+		return tokens
+	}
+
+	t.Run("tokens are drawn in their colors", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+		renderer.lexer = lexer
+
+		if err := renderer.render("> ", "select 1", 8); err != nil {
+			t.Fatal(err)
+		}
+
+		want := Reset() + keyword.ToANSI() + "select " +
+			Reset() + number.ToANSI() + "1" + Reset()
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("output %q does not contain the highlighted text", output.String())
+		}
+	})
+
+	t.Run("tokens without a color use the input color", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+		renderer.lexer = lexer
+
+		if err := renderer.render("> ", "from t", 6); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(output.String(), Reset()+ThemeDefault.Input.ToANSI()+"from t"+Reset()) {
+			t.Errorf("output %q does not contain the highlighted text", output.String())
+		}
+	})
+
+	t.Run("highlighting continues across lines", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+		renderer.lexer = func(text string) []Token {
+			return []Token{{Text: text, Color: &number}}
+		}
+
+		if err := renderer.render("> ", "ab\ncd", 5); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(output.String(), number.ToANSI()+"ab") {
+			t.Errorf("output %q does not contain the highlighted text", output.String())
+		}
+		if !strings.Contains(output.String(), number.ToANSI()+"cd") {
+			t.Errorf("output %q does not contain the highlighted text", output.String())
+		}
+	})
+
+	t.Run("tokens that do not match the input are ignored", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, nil)
+		renderer.lexer = func(string) []Token {
+			return []Token{{Text: "other", Color: &keyword}}
+		}
+
+		if err := renderer.render("> ", "select", 6); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(output.String(), ThemeDefault.Input.ToANSI()+"select"+Reset()) {
+			t.Errorf("output %q does not contain the highlighted text", output.String())
+		}
+		if strings.Contains(output.String(), keyword.ToANSI()) {
+			t.Errorf("output %q should not be highlighted", output.String())
+		}
+	})
+
+	t.Run("WithLexer sets the lexer used by the prompt", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{}
+		WithLexer(lexer)(&config)
+		if config.Lexer == nil {
+			t.Fatal("WithLexer did not set Config.Lexer")
+		}
+
+		p := newForTestingWithConfig(t, config, "select 1\r")
+		var output bytes.Buffer
+		p.renderer.output = &output
+
+		result, err := p.Run()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != "select 1" {
+			t.Errorf("result = %q, want %q", result, "select 1")
+		}
+		if !strings.Contains(output.String(), keyword.ToANSI()+"select ") {
+			t.Errorf("output %q does not contain the highlighted text", output.String())
+		}
+	})
+}
+
+func TestRendererPlaceholder(t *testing.T) {
+	t.Parallel()
+
+	dim := ThemeDefault.Suggestion.Description.ToANSI()
+
+	t.Run("shown dimmed with the cursor at its start while the input is empty", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, newMockTerminal(""))
+		renderer.placeholder = "type a command"
+
+		if err := renderer.render("$ ", "", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		want := dim + "type a command" + Reset() + "\x1b[14D"
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("output %q does not contain %q", output.String(), want)
+		}
+	})
+
+	t.Run("hidden once there is input", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, newMockTerminal(""))
+		renderer.placeholder = "type a command"
+
+		if err := renderer.render("$ ", "l", 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(output.String(), "type a command") {
+			t.Errorf("placeholder drawn with input: %q", output.String())
+		}
+		if renderer.ghostWidth != 0 {
+			t.Errorf("ghostWidth = %d, want 0", renderer.ghostWidth)
+		}
+	})
+
+	t.Run("cut to fit on the prefix row", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		terminal := newMockTerminal("")
+		terminal.terminalSize = [2]int{10, 24}
+		renderer := newRenderer(&output, ThemeDefault, terminal)
+		renderer.placeholder = "type a command"
+
+		if err := renderer.render("$ ", "", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		want := dim + "type a " + Reset() + "\x1b[7D"
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("output %q does not contain %q", output.String(), want)
+		}
+
+		// The placeholder row wraps when the terminal gets narrower
+		renderer.reflow(5)
+		if renderer.lastLines != 2 {
+			t.Errorf("lastLines after reflow = %d, want 2", renderer.lastLines)
+		}
+	})
+
+	t.Run("never part of the result", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Prefix: "$ "}
+		WithPlaceholder("type a command")(&config)
+		p := newForTestingWithConfig(t, config, "\r")
+		var output bytes.Buffer
+		p.renderer.output = &output
+
+		result, err := p.Run()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != "" {
+			t.Errorf("result = %q, want empty", result)
+		}
+		if !strings.Contains(output.String(), "type a command") {
+			t.Errorf("placeholder not drawn: %q", output.String())
+		}
+	})
+}
+
+func TestRendererRightSegment(t *testing.T) {
+	t.Parallel()
+
+	dim := ThemeDefault.Suggestion.Description.ToANSI()
+
+	t.Run("drawn at the right edge with the cursor back at the end of the input", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		terminal := newMockTerminal("")
+		terminal.terminalSize = [2]int{20, 24}
+		renderer := newRenderer(&output, ThemeDefault, terminal)
+		renderer.rightSegment = "[1/3]"
+
+		if err := renderer.render("$ ", "ls", 2); err != nil {
+			t.Fatal(err)
+		}
+
+		// Columns 15-19 (1-based) hold the segment, leaving the last column empty
+		want := "\x1b[15G" + dim + "[1/3]" + Reset() + "\x1b[5G"
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("output %q does not contain %q", output.String(), want)
+		}
+		if renderer.rightEnd != 19 {
+			t.Errorf("rightEnd = %d, want 19", renderer.rightEnd)
+		}
+		if renderer.frameRows[0] != 19 {
+			t.Errorf("frameRows[0] = %d, want 19", renderer.frameRows[0])
+		}
+	})
+
+	t.Run("left out when the input leaves no room", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		terminal := newMockTerminal("")
+		terminal.terminalSize = [2]int{20, 24}
+		renderer := newRenderer(&output, ThemeDefault, terminal)
+		renderer.rightSegment = "[1/3]"
+
+		if err := renderer.render("$ ", "echo hello w", 12); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(output.String(), "[1/3]") {
+			t.Errorf("segment drawn over the input: %q", output.String())
+		}
+		if renderer.rightEnd != 0 {
+			t.Errorf("rightEnd = %d, want 0", renderer.rightEnd)
+		}
+	})
+
+	t.Run("left out for multi-line input", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		renderer := newRenderer(&output, ThemeDefault, newMockTerminal(""))
+		renderer.rightSegment = "[1/3]"
+
+		if err := renderer.render("$ ", "a\nb", 3); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(output.String(), "[1/3]") {
+			t.Errorf("segment drawn for multi-line input: %q", output.String())
+		}
+	})
+
+	t.Run("auto-suggestion stops before the segment", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		terminal := newMockTerminal("")
+		terminal.terminalSize = [2]int{20, 24}
+		renderer := newRenderer(&output, ThemeDefault, terminal)
+		renderer.rightSegment = "[1/3]"
+		renderer.autoSuggestion = "s -la --color"
+
+		if err := renderer.render("$ ", "l", 1); err != nil {
+			t.Fatal(err)
+		}
+
+		// 20 columns - 3 used - 1 last column - 6 for the segment and its gap
+		want := dim + "s -la --co" + Reset() + "\x1b[10D"
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("output %q does not contain %q", output.String(), want)
+		}
+	})
+}
+
+func TestRendererSuggestionStyling(t *testing.T) {
+	t.Parallel()
+
+	red := &Color{R: 255}
+	blue := &Color{B: 255}
+	suggestions := []Suggestion{
+		{Text: "rm", Icon: "!", Category: "danger", Description: "remove files", Color: red, DescriptionColor: blue},
+		{Text: "ls", Description: "list files"},
+	}
+
+	var output bytes.Buffer
+	renderer := newRenderer(&output, ThemeDefault, newMockTerminal(""))
+	if err := renderer.renderWithSuggestionsOffset("$ ", "", 0, suggestions, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	got := output.String()
+	wantRows := []string{
+		red.ToANSI() + "  ! rm" + Reset() + blue.ToANSI() + "  danger - remove files" + Reset(),
+		ThemeDefault.Selected.ToANSI() + "▶   ls" + Reset() + ThemeDefault.Suggestion.Description.onRow(ThemeDefault.Selected).ToANSI() + "         - list files" + Reset(),
+	}
+	for _, want := range wantRows {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %q does not contain %q", got, want)
+		}
+	}
+	if renderer.frameRows[1] != 2+len("! rm  danger - remove files") {
+		t.Errorf("frameRows[1] = %d, want the width of the first menu row", renderer.frameRows[1])
+	}
+}
+
+func TestCursorVisibility(t *testing.T) {
+	t.Parallel()
+
+	const hide, show = "\x1b[?25l", "\x1b[?25h"
+	menu := func(d Document) []Suggestion {
+		if d.Text == "gi" {
+			panic("completer failed")
+		}
+		return []Suggestion{{Text: "git"}, {Text: "go"}}
+	}
+
+	t.Run("a panic with the menu open leaves the cursor shown and raw mode off", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Completer: menu, CompleteAsYouType: true}, "g\ti")
+		var output bytes.Buffer
+		p.renderer.output = &output
+
+		assert.Panics(t, func() { _, _ = p.Run() })
+
+		got := output.String()
+		require.Contains(t, got, hide)
+		assert.Greater(t, strings.LastIndex(got, show), strings.LastIndex(got, hide))
+		assert.False(t, p.terminal.(*mockTerminal).rawMode)
+	})
+
+	t.Run("WithHideCursorDuringRender(false) never hides it", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Completer: menu}
+		WithHideCursorDuringRender(false)(&config)
+		p := newForTestingWithConfig(t, config, "g\t\r\r")
+		var output bytes.Buffer
+		p.renderer.output = &output
+
+		_, err := p.Run()
+
+		require.NoError(t, err)
+		assert.NotContains(t, output.String(), hide)
+	})
+
+	t.Run("showing the cursor is only written once after hiding it", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		r := newRenderer(&output, ThemeDefault, nil)
+
+		require.NoError(t, r.showCursor())
+		require.NoError(t, r.hideCursor())
+		require.NoError(t, r.hideCursor())
+		require.NoError(t, r.showCursor())
+		require.NoError(t, r.showCursor())
+
+		assert.Equal(t, hide+show, output.String())
+	})
+}
+
+func TestMenuPaging(t *testing.T) {
+	t.Parallel()
+
+	t.Run("page size follows WithMaxSuggestions and the terminal height", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name    string
+			maxRows int
+			height  int
+			want    int
+		}{
+			{name: "default", maxRows: 0, height: 24, want: 10},
+			{name: "configured", maxRows: 5, height: 24, want: 5},
+			{name: "capped by a short terminal", maxRows: 30, height: 12, want: 10},
+			{name: "never below one row", maxRows: 5, height: 2, want: 1},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				terminal := newMockTerminal("")
+				terminal.terminalSize = [2]int{80, tt.height}
+				r := newRenderer(&bytes.Buffer{}, ThemeDefault, terminal)
+				r.maxRows = tt.maxRows
+
+				assert.Equal(t, tt.want, r.menuRows())
+			})
+		}
+	})
+
+	t.Run("PageUp and PageDown move the selection a page at a time", func(t *testing.T) {
+		t.Parallel()
+
+		selected, offset := pageMenu(0, 0, 3, 7, 3)
+		assert.Equal(t, []int{3, 3}, []int{selected, offset})
+		selected, offset = pageMenu(selected, offset, 3, 7, 3)
+		assert.Equal(t, []int{6, 4}, []int{selected, offset})
+		selected, offset = pageMenu(selected, offset, -3, 7, 3)
+		assert.Equal(t, []int{3, 1}, []int{selected, offset})
+		selected, offset = pageMenu(selected, offset, -3, 7, 3)
+		assert.Equal(t, []int{0, 0}, []int{selected, offset})
+	})
+
+	t.Run("the position row is drawn only when the menu scrolls", func(t *testing.T) {
+		t.Parallel()
+
+		menu := func(Document) []Suggestion {
+			return []Suggestion{{Text: "c1"}, {Text: "c2"}, {Text: "c3"}, {Text: "c4"}}
+		}
+		config := Config{Completer: menu}
+		WithMaxSuggestions(3)(&config)
+		p := newForTestingWithConfig(t, config, "c\t\x1b[6~\r\r")
+		var output bytes.Buffer
+		p.renderer.output = &output
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "c4", result)
+		assert.Contains(t, output.String(), "1/4")
+		assert.Contains(t, output.String(), "4/4")
+	})
+}
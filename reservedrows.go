@@ -0,0 +1,35 @@
+package prompt
+
+import (
+	"io"
+	"strings"
+)
+
+// WithReservedRows makes the prompt always take n rows of the terminal, the
+// input and the menu area together. While the menu is closed the rows below
+// the input are left blank, so the output above the prompt does not scroll up
+// and down as suggestions open and close. The menu shows at most n-2
+// suggestions, leaving room for the input row and the position row. Input
+// that wraps onto more rows than n still grows the prompt. 0, the default,
+// takes only the rows that are drawn.
+//
+// Example:
+//
+//	prompt.New("$ ", prompt.WithReservedRows(8)) // Input row and up to 6 suggestions
+func WithReservedRows(n int) Option {
+	return func(c *Config) {
+		c.ReservedRows = max(n, 0)
+	}
+}
+
+// padRows draws blank rows below a frame that takes rows rows until it takes
+// reservedRows, and returns how many it drew. The cursor is left on the last
+// of them.
+func (r *renderer) padRows(rows int) (int, error) {
+	n := r.reservedRows - rows
+	if n <= 0 {
+		return 0, nil
+	}
+	_, err := io.WriteString(r.output, strings.Repeat("\r\n\x1b[K", n))
+	return n, err
+}
@@ -0,0 +1,103 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservedRows(t *testing.T) {
+	t.Parallel()
+
+	newReserved := func(output *bytes.Buffer, rows int) *renderer {
+		terminal := newMockTerminal("")
+		terminal.terminalSize = [2]int{40, 24}
+		r := newRenderer(output, ThemeDefault, terminal)
+		r.reservedRows = rows
+		return r
+	}
+	suggestions := []Suggestion{{Text: "alpha"}, {Text: "beta"}}
+
+	t.Run("pads the closed menu with blank rows", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		r := newReserved(&output, 5)
+
+		require.NoError(t, r.render("$ ", "ls", 1))
+
+		assert.Equal(t, 5, r.lastLines)
+		assert.Equal(t, 0, r.cursorRow, "the cursor goes back to the input row")
+		assert.Contains(t, output.String(), strings.Repeat("\r\n\x1b[K", 4)+"\x1b[4A\x1b[5G\x1b[1D")
+		assert.Len(t, r.frameRows, 5)
+	})
+
+	t.Run("keeps the height while the menu opens and closes", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		r := newReserved(&output, 5)
+
+		require.NoError(t, r.renderWithSuggestionsOffset("$ ", "a", 1, suggestions, 0, 0))
+		assert.Equal(t, 5, r.lastLines)
+		assert.Equal(t, 4, r.cursorRow)
+		assert.True(t, strings.HasSuffix(output.String(), strings.Repeat("\r\n\x1b[K", 2)), "two blank rows below the menu")
+
+		output.Reset()
+		require.NoError(t, r.render("$ ", "a", 1))
+		assert.Equal(t, 5, r.lastLines)
+		assert.True(t, strings.HasPrefix(output.String(), "\x1b[4A\r\x1b[0J"), "the whole reserved area is cleared")
+	})
+
+	t.Run("counts the error row", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		r := newReserved(&output, 3)
+		r.errorMessage = "invalid"
+
+		require.NoError(t, r.render("$ ", "ls", 2))
+
+		assert.Equal(t, 3, r.lastLines)
+		assert.Contains(t, output.String(), Reset()+"\r\n\x1b[K\x1b[2A\x1b[5G")
+	})
+
+	t.Run("leaves room for the input and position rows in the menu", func(t *testing.T) {
+		t.Parallel()
+
+		r := newReserved(&bytes.Buffer{}, 5)
+		assert.Equal(t, 3, r.menuRows())
+		r.reservedRows = 1
+		assert.Equal(t, 1, r.menuRows())
+	})
+
+	t.Run("grows with input taller than the reserved rows", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		r := newReserved(&output, 2)
+
+		require.NoError(t, r.render("$ ", "a\nb\nc", 5))
+
+		assert.Equal(t, 3, r.lastLines)
+		assert.NotContains(t, output.String(), "\r\n\x1b[K")
+	})
+
+	t.Run("set by WithReservedRows", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		p, err := New("$ ", WithTerminal(newMockTerminal("\r")), WithOutput(&output), WithReservedRows(4))
+		require.NoError(t, err)
+		defer p.Close()
+
+		_, err = p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, p.renderer.reservedRows)
+		assert.Contains(t, output.String(), strings.Repeat("\r\n\x1b[K", 3))
+	})
+}
@@ -0,0 +1,92 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// ExitReason tells how a run of the prompt ended.
+type ExitReason int
+
+const (
+	// ExitSubmitted means the input was submitted with Enter.
+	ExitSubmitted ExitReason = iota
+	// ExitEOF means Ctrl+D was pressed on an empty buffer or the input ended
+	// (ErrEOF).
+	ExitEOF
+	// ExitInterrupted means Ctrl+C was pressed (ErrInterrupted).
+	ExitInterrupted
+	// ExitCommand means the ExitChecker ended the session (ErrExit).
+	ExitCommand
+	// ExitCanceled means the context was canceled or its deadline passed.
+	ExitCanceled
+	// ExitError means the run failed, for example because the terminal could
+	// not be read.
+	ExitError
+)
+
+// String returns the name of the reason, such as "eof".
+func (r ExitReason) String() string {
+	switch r {
+	case ExitSubmitted:
+		return "submitted"
+	case ExitEOF:
+		return "eof"
+	case ExitInterrupted:
+		return "interrupted"
+	case ExitCommand:
+		return "exit-command"
+	case ExitCanceled:
+		return "canceled"
+	case ExitError:
+		return "error"
+	}
+	return "ExitReason(" + strconv.Itoa(int(r)) + ")"
+}
+
+// Result is what RunResult returns: the input and how the run ended.
+type Result struct {
+	Input  string     // Submitted input, or the input an exit command ended with
+	Reason ExitReason // How the run ended
+}
+
+// RunResult runs the prompt like RunWithContext and tells how the run ended in
+// the Reason of the result, so a caller can switch on one value instead of
+// matching the error against each sentinel. The error is the one
+// RunWithContext returns, nil only for ExitSubmitted.
+//
+// Example:
+//
+//	result, err := p.RunResult(ctx)
+//	switch result.Reason {
+//	case prompt.ExitSubmitted:
+//		handle(result.Input)
+//	case prompt.ExitInterrupted:
+//		// Discard the line
+//	case prompt.ExitError:
+//		log.Fatal(err)
+//	default:
+//		return // Ctrl+D, exit command or canceled context
+//	}
+func (p *Prompt) RunResult(ctx context.Context) (Result, error) {
+	input, err := p.RunWithContext(ctx)
+	return Result{Input: input, Reason: exitReason(err)}, err
+}
+
+// exitReason returns the reason of a run that returned err.
+func exitReason(err error) ExitReason {
+	switch {
+	case err == nil:
+		return ExitSubmitted
+	case errors.Is(err, ErrExit): // Before ErrEOF, which it wraps
+		return ExitCommand
+	case errors.Is(err, ErrEOF):
+		return ExitEOF
+	case errors.Is(err, ErrInterrupted):
+		return ExitInterrupted
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ExitCanceled
+	}
+	return ExitError
+}
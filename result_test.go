@@ -0,0 +1,81 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtrlDReturnsErrEOF(t *testing.T) {
+	t.Parallel()
+
+	p := newForTestingWithConfig(t, Config{Prefix: "> "}, "\x04")
+
+	_, err := p.Run()
+
+	require.ErrorIs(t, err, ErrEOF)
+	assert.ErrorIs(t, err, io.EOF, "ErrEOF wraps io.EOF")
+	assert.EqualError(t, err, "EOF")
+}
+
+func TestRunResult(t *testing.T) {
+	t.Parallel()
+
+	exitChecker := func(input string, breakline bool) bool { return breakline && input == "exit" }
+	tests := []struct {
+		name      string
+		input     string
+		want      Result
+		wantError error
+	}{
+		{name: "submitted", input: "hello\r", want: Result{Input: "hello", Reason: ExitSubmitted}},
+		{name: "Ctrl+D", input: "\x04", want: Result{Reason: ExitEOF}, wantError: ErrEOF},
+		{name: "end of input", input: "hel", want: Result{Reason: ExitEOF}, wantError: ErrEOF},
+		{name: "Ctrl+C", input: "hello\x03", want: Result{Reason: ExitInterrupted}, wantError: ErrInterrupted},
+		{name: "exit command", input: "exit\r", want: Result{Input: "exit", Reason: ExitCommand}, wantError: ErrExit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{Prefix: "> ", ExitChecker: exitChecker}, tt.input)
+
+			result, err := p.RunResult(context.Background())
+
+			assert.Equal(t, tt.want, result)
+			if tt.wantError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantError)
+			}
+		})
+	}
+
+	t.Run("canceled context", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> "}, "")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := p.RunResult(ctx)
+
+		assert.Equal(t, ExitCanceled, result.Reason)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestExitReason(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ExitCommand, exitReason(fmt.Errorf("session: %w", ErrExit)), "ErrExit is not taken for ErrEOF")
+	assert.Equal(t, ExitCanceled, exitReason(context.DeadlineExceeded))
+	assert.Equal(t, ExitError, exitReason(errors.New("failed to read input")))
+	assert.Equal(t, "eof", ExitEOF.String())
+	assert.Equal(t, "ExitReason(42)", ExitReason(42).String())
+}
@@ -0,0 +1,77 @@
+package prompt
+
+import (
+	"strings"
+	"unicode"
+)
+
+// sanitizeText makes text from completers, history and other outside sources
+// safe to draw: control characters, which could corrupt the display or inject
+// escape sequences, are shown in caret notation (ESC as ^[, DEL as ^?), and C1
+// controls, which have none, as U+FFFD. Use it for text that is only displayed,
+// such as suggestions; the raw text is what gets inserted on accept.
+func sanitizeText(text string) string {
+	if !hasControl(text) {
+		return text
+	}
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r < 0x20:
+			b.WriteByte('^')
+			b.WriteRune(r + '@')
+		case r == 0x7f:
+			b.WriteString("^?")
+		case unicode.IsControl(r):
+			b.WriteRune(unicode.ReplacementChar)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// sanitizeInput makes the input buffer safe to draw. Unlike sanitizeText it
+// keeps line breaks, and it replaces every other control character with a
+// single U+FFFD so the text keeps one column per rune and the cursor position
+// stays valid. Such characters get into the buffer from recalled history or
+// accepted suggestions.
+func sanitizeInput(input string) string {
+	if !hasControl(input) {
+		return input
+	}
+	return strings.Map(func(r rune) rune {
+		if r != '\n' && unicode.IsControl(r) {
+			return unicode.ReplacementChar
+		}
+		return r
+	}, input)
+}
+
+// sanitizeSuggestions returns the suggestions with their displayed parts
+// sanitized, for drawing the menu. The slice is returned as is when nothing
+// needs to change.
+func sanitizeSuggestions(suggestions []Suggestion) []Suggestion {
+	var sanitized []Suggestion
+	for i, s := range suggestions {
+		if !hasControl(s.display()) && !hasControl(s.Description) && !hasControl(s.Category) && !hasControl(s.Icon) {
+			continue
+		}
+		if sanitized == nil {
+			sanitized = append([]Suggestion(nil), suggestions...)
+		}
+		sanitized[i].DisplayText = sanitizeText(s.display())
+		sanitized[i].Description = sanitizeText(s.Description)
+		sanitized[i].Category = sanitizeText(s.Category)
+		sanitized[i].Icon = sanitizeText(s.Icon)
+	}
+	if sanitized == nil {
+		return suggestions
+	}
+	return sanitized
+}
+
+// hasControl reports whether text contains a control character.
+func hasControl(text string) bool {
+	return strings.IndexFunc(text, unicode.IsControl) >= 0
+}
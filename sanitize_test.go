@@ -0,0 +1,112 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain text is unchanged", input: "report.txt", want: "report.txt"},
+		{name: "escape sequences are defused", input: "evil\x1b[2Jname", want: "evil^[[2Jname"},
+		{name: "NUL, tab and newline use caret notation", input: "a\x00b\tc\nd", want: "a^@b^Ic^Jd"},
+		{name: "DEL is ^?", input: "x\x7f", want: "x^?"},
+		{name: "C1 controls are replaced", input: "a\u009bb", want: "a�b"},
+		{name: "non-ASCII text is kept", input: "日本語", want: "日本語"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, sanitizeText(tt.input))
+		})
+	}
+}
+
+func TestSanitizeInput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain text is unchanged", input: "ls -la", want: "ls -la"},
+		{name: "line breaks are kept", input: "SELECT *\nFROM t", want: "SELECT *\nFROM t"},
+		{name: "other controls take one column each", input: "rm \x1b[2J\x00", want: "rm �[2J�"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := sanitizeInput(tt.input)
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, len([]rune(tt.input)), len([]rune(got)), "the cursor position must stay valid")
+		})
+	}
+}
+
+func TestSanitizeSuggestions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("clean suggestions are returned as is", func(t *testing.T) {
+		t.Parallel()
+
+		suggestions := []Suggestion{{Text: "a", Description: "b"}}
+
+		got := sanitizeSuggestions(suggestions)
+
+		assert.Same(t, &suggestions[0], &got[0])
+	})
+
+	t.Run("displayed parts are sanitized in a copy", func(t *testing.T) {
+		t.Parallel()
+
+		suggestions := []Suggestion{{Text: "ok"}, {Text: "bad\x1b]0;pwned\x07", Description: "line\nbreak", Category: "\x01", Icon: "\x02"}}
+
+		got := sanitizeSuggestions(suggestions)
+
+		assert.Equal(t, Suggestion{Text: "ok"}, got[0])
+		assert.Equal(t, Suggestion{
+			Text:        "bad\x1b]0;pwned\x07",
+			DisplayText: "bad^[]0;pwned^G",
+			Description: "line^Jbreak",
+			Category:    "^A",
+			Icon:        "^B",
+		}, got[1], "Text keeps the raw value for accepting")
+		assert.Empty(t, suggestions[1].DisplayText, "the caller's suggestions are not modified")
+	})
+}
+
+func TestControlCharactersAreNotWrittenRaw(t *testing.T) {
+	t.Parallel()
+
+	name := "notes\x1b[2J.txt"
+	config := Config{
+		Prefix:    "$ ",
+		Completer: func(Document) []Suggestion { return []Suggestion{{Text: name}, {Text: "notes.md"}} },
+	}
+	p := newForTestingWithConfig(t, config, "no\t\r\r")
+	var out bytes.Buffer
+	p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+	result, err := p.Run()
+
+	require.NoError(t, err)
+	assert.Equal(t, name, result, "the raw suggestion is accepted")
+	assert.NotContains(t, out.String(), "\x1b[2J")
+	assert.Contains(t, out.String(), "notes^[[2J.txt", "the menu shows caret notation")
+	assert.Contains(t, out.String(), "notes�[2J.txt", "the buffer shows a replacement character")
+}
@@ -0,0 +1,63 @@
+package prompt
+
+import "strings"
+
+// sequencePattern is an escape sequence pattern bound with
+// BindSequencePattern.
+type sequencePattern struct {
+	pattern string
+	action  KeyAction
+}
+
+// BindSequencePattern binds every escape sequence matching pattern to action.
+// A "*" in the pattern matches any run of characters, including none, and
+// every other character matches itself; as with BindSequence, the initial ESC
+// is left out. Patterns bind families of sequences that have no Key, such as
+// the F13 to F24 keys some terminals send with varying modifier parameters,
+// or terminal-specific sequences. They are tried after the bindings of keys
+// and exact sequences, in the order they were first bound; binding a pattern
+// again replaces its action.
+//
+// Example:
+//
+//	keyMap := prompt.NewDefaultKeyMap()
+//	// F13 with any modifiers, sent as "[25~", "[25;2~", "[25;5~" and so on
+//	keyMap.BindSequencePattern("[25~", prompt.ActionComplete)
+//	keyMap.BindSequencePattern("[25;*~", prompt.ActionComplete)
+func (km *KeyMap) BindSequencePattern(pattern string, action KeyAction) {
+	for i := range km.patterns {
+		if km.patterns[i].pattern == pattern {
+			km.patterns[i].action = action
+			return
+		}
+	}
+	km.patterns = append(km.patterns, sequencePattern{pattern: pattern, action: action})
+}
+
+// patternAction returns the action of the first pattern matching seq.
+func (km *KeyMap) patternAction(seq string) KeyAction {
+	for _, p := range km.patterns {
+		if matchSequencePattern(p.pattern, seq) {
+			return p.action
+		}
+	}
+	return ActionNone
+}
+
+// matchSequencePattern reports whether seq matches pattern, in which "*"
+// matches any run of characters.
+func matchSequencePattern(pattern, seq string) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star < 0 {
+		return pattern == seq
+	}
+	if !strings.HasPrefix(seq, pattern[:star]) {
+		return false
+	}
+	for i := star; i <= len(seq); i++ {
+		if matchSequencePattern(pattern[star+1:], seq[i:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchSequencePattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		seq     string
+		want    bool
+	}{
+		{pattern: "[25~", seq: "[25~", want: true},
+		{pattern: "[25~", seq: "[26~", want: false},
+		{pattern: "[25;*~", seq: "[25;5~", want: true},
+		{pattern: "[25;*~", seq: "[25;13~", want: true},
+		{pattern: "[25;*~", seq: "[25~", want: false},
+		{pattern: "[*~", seq: "[~", want: true},
+		{pattern: "[*;*R", seq: "[12;40R", want: true},
+		{pattern: "[*;*R", seq: "[12R", want: false},
+		{pattern: "*", seq: "anything", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.seq, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, matchSequencePattern(tt.pattern, tt.seq))
+		})
+	}
+}
+
+func TestBindSequencePattern(t *testing.T) {
+	t.Parallel()
+
+	keyMap := NewDefaultKeyMap()
+	keyMap.BindSequencePattern("[25;*~", ActionMoveEnd)
+	keyMap.BindSequencePattern("[25;*~", ActionMoveHome) // Replaces the action
+	keyMap.BindSequencePattern("[*~", ActionYank)
+
+	assert.Equal(t, ActionMoveHome, keyMap.sequenceAction(KeyContextEditing, "[25;5~"))
+	assert.Equal(t, ActionYank, keyMap.sequenceAction(KeyContextEditing, "[99~"), "the next pattern matches")
+	assert.Equal(t, ActionDeleteChar, keyMap.sequenceAction(KeyContextEditing, "[3~"), "exact sequences come first")
+	assert.Contains(t, keyMap.DumpBindings(), KeyBinding{Seq: "[25;*~", Action: ActionMoveHome})
+
+	p, err := New("> ", WithTerminal(newMockTerminal("abc\x1b[25;5~!\r")), WithOutput(&bytes.Buffer{}), WithKeyMap(keyMap))
+	require.NoError(t, err)
+	defer p.Close()
+
+	result, err := p.Run()
+
+	require.NoError(t, err)
+	assert.Equal(t, "!abc", result)
+}
+
+func TestUnknownSequencesAreNotTyped(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		input  string
+		limits Limits
+		want   string
+	}{
+		{name: "F13 to F24", input: "a\x1b[25~b\x1b[34;2~c\r", want: "abc"},
+		{name: "longer than the limit", input: "a\x1b[?1;2;3;4;5;6;7;8;9;10c\x1b[1;2;3;4;5;6;7;8;9;10;11;12Ab\r", want: "ab"},
+		{name: "longer than a custom limit", input: "a\x1b[1;2;3Db\r", limits: Limits{EscapeSequenceLen: 3}, want: "ab"},
+		{name: "private parameters", input: "a\x1b[<0;10;5Mb\r", want: "ab"},
+		{name: "a control key cuts the sequence short", input: "ab\x1b[1\x01x\r", want: "xab"},
+		{name: "a non-ASCII key cuts the sequence short", input: "a\x1b[é\r", want: "aé"},
+		{name: "Linux console function keys", input: "a\x1b[[Ab\r", want: "ab"},
+		{name: "a sequence without end is read up to four times the limit", input: "a\x1b[" + strings.Repeat("1", 30) + "b\r", limits: Limits{EscapeSequenceLen: 3}, want: "a" + strings.Repeat("1", 20) + "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{Prefix: "> ", Limits: tt.limits}, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package prompt
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// ErrSessionClosed is returned by Session.Prompt after the session was closed.
+var ErrSessionClosed = errors.New("session is closed")
+
+// Session owns one terminal and hands out prompts that share it. Each prompt
+// has its own configuration (prefix, completer, key map, history, theme), so a
+// multi-mode CLI such as a shell with an SQL mode keeps one prompt per mode and
+// switches between them instantly, without opening the terminal again.
+//
+// Only one prompt of a session may run at a time. A key that was already read
+// when a prompt returned, for example because its context was cancelled, is
+// handed to the next prompt that runs instead of being lost.
+//
+// Example:
+//
+//	session, err := prompt.NewSession()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer session.Close()
+//
+//	shell, _ := session.Prompt(prompt.Config{Prefix: "$ ", Completer: shellCompleter})
+//	sql, _ := session.Prompt(prompt.Config{Prefix: "sql> ", Completer: sqlCompleter})
+//
+//	current := shell
+//	for {
+//		line, err := current.Run()
+//		if err != nil {
+//			break
+//		}
+//		switch line {
+//		case `\sql`:
+//			current = sql
+//		case `\shell`:
+//			current = shell
+//		}
+//	}
+type Session struct {
+	terminal Terminal
+	output   io.Writer
+
+	mu     sync.Mutex
+	keyCh  chan keyEvent // Key read left in flight by the last prompt that ran
+	closed bool
+}
+
+// NewSession opens the terminal for a session. Only the terminal options are
+// used: WithTerminal, WithOutput, WithTTYPath, WithTTYFd, WithStream and
+// WithInput. Without them the session uses the controlling terminal and
+// standard output, or reads lines from standard input when it is not a
+// terminal.
+func NewSession(options ...Option) (*Session, error) {
+	var config Config
+	for _, option := range options {
+		option(&config)
+	}
+	terminal, output, err := openTerminal(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{terminal: terminal, output: output}, nil
+}
+
+// Prompt returns a new prompt drawing on the session's terminal with the given
+// configuration. Config.Terminal is ignored, and Config.Output defaults to the
+// session's output. Closing the prompt saves its history but leaves the
+// terminal open; close the session when done.
+func (s *Session) Prompt(config Config) (*Prompt, error) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return nil, ErrSessionClosed
+	}
+
+	config.Terminal = sessionTerminal{s.terminal}
+	config.TTYPath = ""
+	if config.Output == nil {
+		config.Output = s.output
+	}
+	p, err := newFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	p.session = s
+	return p, nil
+}
+
+// Close closes the terminal. Prompts of the session can no longer run.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.terminal.Close()
+}
+
+// takeKeys returns the key read left in flight by the previous prompt, if any,
+// and forgets it. The caller gives it back with keepKeys when it returns.
+func (s *Session) takeKeys() chan keyEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := s.keyCh
+	s.keyCh = nil
+	return ch
+}
+
+// keepKeys stores a key read that is still in flight for the next prompt.
+func (s *Session) keepKeys(ch chan keyEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keyCh = ch
+}
+
+// sessionTerminal is the terminal given to the prompts of a session. Closing a
+// prompt must not close the shared terminal, so Close does nothing here; the
+// session closes the terminal itself.
+type sessionTerminal struct {
+	Terminal
+}
+
+// Close leaves the shared terminal open.
+func (sessionTerminal) Close() error {
+	return nil
+}
+
+// ResizeEvents forwards the resize notifications of the shared terminal, if it
+// has any.
+func (t sessionTerminal) ResizeEvents() <-chan struct{} {
+	if notifier, ok := t.Terminal.(resizeNotifier); ok {
+		return notifier.ResizeEvents()
+	}
+	return nil
+}
+
+// watchContinue forwards the SIGCONT notifications of the shared terminal. The
+// channel is nil when it takes no part in job control, so Ctrl+Z is ignored.
+func (t sessionTerminal) watchContinue() (<-chan os.Signal, func()) {
+	if jobs, ok := t.Terminal.(jobController); ok {
+		return jobs.watchContinue()
+	}
+	return nil, func() {}
+}
+
+// stopJob stops the job through the shared terminal. It is only called after
+// watchContinue returned a channel.
+func (t sessionTerminal) stopJob() error {
+	if jobs, ok := t.Terminal.(jobController); ok {
+		return jobs.stopJob()
+	}
+	return nil
+}
+
+// watchTermination forwards the termination signals caught by the shared
+// terminal. The channel is nil when it catches none.
+func (t sessionTerminal) watchTermination() (<-chan os.Signal, func()) {
+	if watcher, ok := t.Terminal.(terminationWatcher); ok {
+		return watcher.watchTermination()
+	}
+	return nil, func() {}
+}
+
+// raise sends sig again through the shared terminal. It is only called for a
+// signal that watchTermination reported.
+func (t sessionTerminal) raise(sig os.Signal) error {
+	if watcher, ok := t.Terminal.(terminationWatcher); ok {
+		return watcher.raise(sig)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeCountingTerminal counts how often the terminal is closed.
+type closeCountingTerminal struct {
+	*mockTerminal
+	closed int
+}
+
+func (c *closeCountingTerminal) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	t.Run("prompts share the terminal but keep their own configuration", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+		session, err := NewSession(WithTerminal(newMockTerminal("ls\t\rselect\t\r")), WithOutput(&output))
+		require.NoError(t, err)
+		defer session.Close()
+
+		shell, err := session.Prompt(Config{Prefix: "$ ", Completer: func(Document) []Suggestion {
+			return []Suggestion{{Text: "ls -la"}}
+		}})
+		require.NoError(t, err)
+		sql, err := session.Prompt(Config{Prefix: "sql> ", Completer: func(Document) []Suggestion {
+			return []Suggestion{{Text: "select * from"}}
+		}})
+		require.NoError(t, err)
+
+		line, err := shell.Run()
+		require.NoError(t, err)
+		assert.Equal(t, "ls -la", line)
+
+		line, err = sql.Run()
+		require.NoError(t, err)
+		assert.Equal(t, "select * from", line)
+		assert.Contains(t, output.String(), "sql> ")
+	})
+
+	t.Run("closing a prompt leaves the terminal open", func(t *testing.T) {
+		t.Parallel()
+
+		terminal := &closeCountingTerminal{mockTerminal: newMockTerminal("")}
+		session, err := NewSession(WithTerminal(terminal), WithOutput(io.Discard))
+		require.NoError(t, err)
+
+		p, err := session.Prompt(Config{Prefix: "> "})
+		require.NoError(t, err)
+		require.NoError(t, p.Close())
+		assert.Equal(t, 0, terminal.closed)
+
+		require.NoError(t, session.Close())
+		require.NoError(t, session.Close())
+		assert.Equal(t, 1, terminal.closed, "the session closes the terminal once")
+
+		_, err = session.Prompt(Config{Prefix: "> "})
+		assert.ErrorIs(t, err, ErrSessionClosed)
+	})
+
+	t.Run("ctrl+z stops the job through the shared terminal", func(t *testing.T) {
+		t.Parallel()
+
+		terminal := newJobTerminal("ab\x1ac\r")
+		session, err := NewSession(WithTerminal(terminal), WithOutput(io.Discard))
+		require.NoError(t, err)
+		defer session.Close()
+		p, err := session.Prompt(Config{Prefix: "$ "})
+		require.NoError(t, err)
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc", result)
+		assert.Equal(t, 1, terminal.stops)
+		assert.False(t, terminal.rawStop, "the terminal is restored before the job stops")
+	})
+
+	t.Run("a termination signal restores the shared terminal and is raised again", func(t *testing.T) {
+		t.Parallel()
+
+		terminal := newSignalTerminal(t, "ab")
+		session, err := NewSession(WithTerminal(terminal), WithOutput(io.Discard))
+		require.NoError(t, err)
+		defer session.Close()
+		p, err := session.Prompt(Config{Prefix: "$ "})
+		require.NoError(t, err)
+		go func() {
+			<-terminal.keys
+			terminal.signals <- syscall.SIGTERM
+		}()
+
+		_, err = p.Run()
+
+		require.ErrorIs(t, err, ErrTerminated)
+		assert.Equal(t, []os.Signal{syscall.SIGTERM}, terminal.raised)
+		assert.False(t, terminal.rawRaise, "raw mode is off before the signal is raised")
+	})
+
+	t.Run("a key read by a cancelled prompt goes to the next prompt", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := io.Pipe()
+		rw := &session{Reader: reader}
+		terminal := NewStreamTerminal(rw, WindowSize{Width: 80, Height: 24}, nil)
+		s, err := NewSession(WithTerminal(terminal), WithOutput(terminal))
+		require.NoError(t, err)
+		defer s.Close()
+
+		first, err := s.Prompt(Config{Prefix: "a> "})
+		require.NoError(t, err)
+		second, err := s.Prompt(Config{Prefix: "b> "})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = first.RunWithContext(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		go func() {
+			_, _ = writer.Write([]byte("x\r"))
+		}()
+		line, err := second.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "x", line)
+	})
+}
@@ -0,0 +1,99 @@
+package prompt
+
+import "strings"
+
+// ShellEscape selects how NewFileCompleter escapes the file names it suggests,
+// so that an accepted name is read back as one literal word by the shell that
+// runs the command line.
+type ShellEscape int
+
+const (
+	// ShellEscapeNone inserts file names as they are (default).
+	ShellEscapeNone ShellEscape = iota
+	// ShellEscapePOSIX puts a backslash before characters that sh, bash and
+	// zsh treat specially, such as spaces, "$", "`" and "!".
+	ShellEscapePOSIX
+	// ShellEscapePowerShell puts a backtick before characters that PowerShell
+	// treats specially, such as spaces, "$" and "`".
+	ShellEscapePowerShell
+)
+
+// Characters escaped by each profile. A newline cannot be escaped with a
+// backslash in POSIX shells, where it continues the line, so it is left alone.
+const (
+	posixSpecialChars      = " \t\\'\"`$!&;|<>()[]{}*?#~"
+	powerShellSpecialChars = " \t`$'\"&;|<>(){}@#,"
+)
+
+// escapeChar returns the escape character of the profile and the characters
+// it escapes.
+func (e ShellEscape) escapeChar() (rune, string) {
+	switch e {
+	case ShellEscapePOSIX:
+		return '\\', posixSpecialChars
+	case ShellEscapePowerShell:
+		return '`', powerShellSpecialChars
+	default:
+		return 0, ""
+	}
+}
+
+// Escape returns text with every character that is special to the shell
+// escaped. ShellEscapeNone returns text unchanged.
+//
+// Example:
+//
+//	prompt.ShellEscapePOSIX.Escape("cost $5!.txt") // `cost\ \$5\!.txt`
+func (e ShellEscape) Escape(text string) string {
+	escape, special := e.escapeChar()
+	if special == "" || !strings.ContainsAny(text, special) {
+		return text
+	}
+	var b strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(special, r) {
+			b.WriteRune(escape)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Unescape reverses Escape: it removes every escape character of the profile
+// and keeps the character after it literally. A trailing escape character,
+// such as one just typed, is kept. ShellEscapeNone returns text unchanged.
+func (e ShellEscape) Unescape(text string) string {
+	escape, _ := e.escapeChar()
+	if escape == 0 || !strings.ContainsRune(text, escape) {
+		return text
+	}
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == escape && i+1 < len(runes) {
+			i++
+		}
+		b.WriteRune(runes[i])
+	}
+	return b.String()
+}
+
+// FileCompleterOption configures a completer created with NewFileCompleter.
+type FileCompleterOption func(*fileCompleterConfig)
+
+// fileCompleterConfig holds the settings of a file completer.
+type fileCompleterConfig struct {
+	escape ShellEscape
+}
+
+// WithFileEscape makes the file completer escape the names it suggests for
+// the given shell, so accepting a name like "report $(date).txt" inserts text
+// that the shell reads as that literal name. The path typed before the cursor
+// is unescaped with the same profile before it is looked up, and the menu
+// shows the names unescaped. With ShellEscapePOSIX, also set WithWordEscape so
+// an escaped space does not split the word being completed.
+func WithFileEscape(escape ShellEscape) FileCompleterOption {
+	return func(c *fileCompleterConfig) {
+		c.escape = escape
+	}
+}
@@ -0,0 +1,61 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellEscape(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		escape  ShellEscape
+		text    string
+		escaped string
+	}{
+		{name: "none keeps everything", escape: ShellEscapeNone, text: "a $b`c`!", escaped: "a $b`c`!"},
+		{name: "POSIX plain names are unchanged", escape: ShellEscapePOSIX, text: "notes-2024.txt", escaped: "notes-2024.txt"},
+		{name: "POSIX expansion characters", escape: ShellEscapePOSIX, text: "$HOME`id`!!", escaped: "\\$HOME\\`id\\`\\!\\!"},
+		{name: "POSIX spaces, quotes and backslashes", escape: ShellEscapePOSIX, text: `it's a\b`, escaped: `it\'s\ a\\b`},
+		{name: "PowerShell uses backticks", escape: ShellEscapePowerShell, text: "cost $5 `x`.txt", escaped: "cost` `$5` ``x``.txt"},
+		{name: "PowerShell leaves ! and backslashes alone", escape: ShellEscapePowerShell, text: `C:\tmp\hi!`, escaped: `C:\tmp\hi!`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.escaped, tt.escape.Escape(tt.text))
+			assert.Equal(t, tt.text, tt.escape.Unescape(tt.escaped), "Unescape reverses Escape")
+		})
+	}
+
+	t.Run("a trailing escape character is kept", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, `my\`, ShellEscapePOSIX.Unescape(`my\`))
+	})
+}
+
+func TestNewFileCompleterWithFileEscape(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"price $5!.txt", "plain.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+	completer := NewFileCompleter(WithFileEscape(ShellEscapePOSIX))
+	typed := ShellEscapePOSIX.Escape(dir+string(filepath.Separator)) + `price\ \$`
+
+	suggestions := completer(Document{Text: typed, CursorPosition: len(typed)})
+
+	require.Len(t, suggestions, 1)
+	want := filepath.Join(dir, "price $5!.txt")
+	assert.Equal(t, ShellEscapePOSIX.Escape(want), suggestions[0].Text, "the inserted text is escaped")
+	assert.Equal(t, want, suggestions[0].DisplayText, "the menu shows the name as is")
+}
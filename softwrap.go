@@ -0,0 +1,153 @@
+package prompt
+
+// displayRow is one terminal row of the input as the renderer draws it.
+type displayRow struct {
+	start, end int  // The runes of the input drawn on the row are input[start:end]
+	col        int  // Column of the first of them: after the prefix on the first row, 0 on the others
+	width      int  // Column just past the last of them, the terminal width when they fill the row
+	wrapped    bool // The line goes on on the next row instead of ending here
+}
+
+// inputLayout maps the input to the terminal rows it is drawn on, with long
+// lines soft-wrapped at the right edge, so the cursor can be placed and moved
+// by what the user sees rather than by the newlines of the text.
+type inputLayout struct {
+	input []rune
+	rows  []displayRow
+}
+
+// layoutInput lays out input after prefix on a terminal termWidth columns
+// wide, the way terminals wrap it: a character that does not fit at the end of
+// a row starts the next one, and each newline starts a row at column 0. A
+// prefix wider than the terminal takes rows of its own, with no input on them.
+func layoutInput(prefix string, input []rune, termWidth int) inputLayout {
+	termWidth = max(termWidth, 1)
+	prefixRows, col := wrapText([]rune(prefix), termWidth)
+	if prefix == "" {
+		prefixRows = 1
+	}
+	l := inputLayout{input: input}
+	for range prefixRows - 1 {
+		l.rows = append(l.rows, displayRow{width: termWidth, wrapped: true})
+	}
+
+	lineStart := 0
+	for lineStart <= len(input) {
+		lineEnd := lineStart
+		for lineEnd < len(input) && input[lineEnd] != '\n' {
+			lineEnd++
+		}
+		row := displayRow{start: lineStart, col: col}
+		for _, g := range graphemes(input[lineStart:lineEnd]) {
+			if col+g.width > termWidth && col > 0 {
+				row.end, row.width, row.wrapped = lineStart+g.start, col, true
+				l.rows = append(l.rows, row)
+				row = displayRow{start: lineStart + g.start}
+				col = 0
+			}
+			col += g.width
+		}
+		row.end, row.width = lineEnd, col
+		l.rows = append(l.rows, row)
+		lineStart, col = lineEnd+1, 0
+	}
+	return l
+}
+
+// locate returns the row and column the cursor at rune index cursor is drawn
+// at. A cursor between two rows of a wrapped line is drawn at the start of the
+// second one, in front of the character it comes before.
+func (l inputLayout) locate(cursor int) (row, col int) {
+	for i, r := range l.rows {
+		if cursor < r.end || (cursor == r.end && !r.wrapped) {
+			return i, r.col + runesWidth(l.input[r.start:max(r.start, cursor)])
+		}
+	}
+	last := l.rows[len(l.rows)-1]
+	return len(l.rows) - 1, last.width
+}
+
+// at returns the rune index of the cursor position on row nearest to column
+// col without going past it. Beyond the end of a wrapped row that is the last
+// character of the row, since the position after it is drawn on the next row.
+func (l inputLayout) at(row, col int) int {
+	r := l.rows[row]
+	c := r.col
+	chars := graphemes(l.input[r.start:r.end])
+	for _, g := range chars {
+		if c+g.width > col {
+			return r.start + g.start
+		}
+		c += g.width
+	}
+	if r.wrapped && len(chars) > 0 {
+		return r.start + chars[len(chars)-1].start
+	}
+	return r.end
+}
+
+// layout returns how the input is laid out on the terminal, for moving the
+// cursor by display rows. It returns false when the drawn text does not match
+// the buffer rune for rune, as with a DisplayTransform, or when horizontal
+// scrolling keeps a single line on one row.
+func (p *Prompt) layout() (inputLayout, bool) {
+	if p.config.DisplayTransform != nil || (p.config.HorizontalScroll && !p.isMultiLine()) {
+		return inputLayout{}, false
+	}
+	input := []rune(sanitizeInput(string(p.buffer)))
+	return layoutInput(p.prefix(), input, p.renderer.width()), true
+}
+
+// moveDisplayRow moves the cursor up (direction < 0) or down a display row,
+// keeping its column as far as the row allows. It reports whether Up or Down
+// was used up by the input: true when the cursor moved, and always for input
+// with several lines, which never browses history. Otherwise, on the first or
+// last row of a single line, Up and Down go on to the history.
+func (p *Prompt) moveDisplayRow(direction int) bool {
+	l, ok := p.layout()
+	if !ok {
+		if !p.isMultiLine() {
+			return false
+		}
+		p.cursor = p.findCursorVertical(direction)
+		return true
+	}
+	row, col := l.locate(p.cursor)
+	target := row + direction
+	if target < 0 || target >= len(l.rows) {
+		return p.isMultiLine()
+	}
+	p.cursor = l.at(target, col)
+	return true
+}
+
+// displayRowStart returns where Home moves the cursor: the start of the display
+// row it is on, or the start of the line when it is already there.
+func (p *Prompt) displayRowStart() int {
+	lineStart := p.findLineStart()
+	l, ok := p.layout()
+	if !ok {
+		return lineStart
+	}
+	row, _ := l.locate(p.cursor)
+	if start := l.rows[row].start; start > lineStart && p.cursor != start {
+		return start
+	}
+	return lineStart
+}
+
+// displayRowEnd returns where End moves the cursor: the end of the display row
+// it is on, or the end of the line when it is already there. The end of a row
+// that wraps is its last character, where the cursor is still drawn on it.
+func (p *Prompt) displayRowEnd() int {
+	lineEnd := p.findLineEnd()
+	l, ok := p.layout()
+	if !ok {
+		return lineEnd
+	}
+	row, _ := l.locate(p.cursor)
+	if end := l.at(row, l.rows[row].width); end < lineEnd && p.cursor != end {
+		return end
+	}
+	return lineEnd
+}
@@ -0,0 +1,126 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutInput(t *testing.T) {
+	t.Parallel()
+
+	t.Run("long lines wrap after the prefix and newlines start rows", func(t *testing.T) {
+		t.Parallel()
+
+		l := layoutInput("> ", []rune("abcdefghij\nxy"), 6)
+
+		// The line that fills its last row exactly does not take another one
+		assert.Equal(t, []displayRow{
+			{start: 0, end: 4, col: 2, width: 6, wrapped: true},
+			{start: 4, end: 10, col: 0, width: 6},
+			{start: 11, end: 13, col: 0, width: 2},
+		}, l.rows)
+	})
+
+	t.Run("a wide character that does not fit starts the next row", func(t *testing.T) {
+		t.Parallel()
+
+		l := layoutInput("> ", []rune("ab日"), 5)
+
+		require.Len(t, l.rows, 2)
+		assert.Equal(t, displayRow{start: 0, end: 2, col: 2, width: 4, wrapped: true}, l.rows[0])
+		assert.Equal(t, displayRow{start: 2, end: 3, col: 0, width: 2}, l.rows[1])
+	})
+
+	t.Run("a prefix wider than the terminal takes rows of its own", func(t *testing.T) {
+		t.Parallel()
+
+		l := layoutInput("12345678> ", []rune("ab"), 4)
+
+		assert.Len(t, l.rows, 3)
+		row, col := l.locate(1)
+		assert.Equal(t, 2, row)
+		assert.Equal(t, 3, col)
+	})
+
+	t.Run("locate puts a cursor at a wrap point on the next row", func(t *testing.T) {
+		t.Parallel()
+
+		l := layoutInput("> ", []rune("abcdefgh"), 6)
+
+		row, col := l.locate(4)
+		assert.Equal(t, 1, row)
+		assert.Equal(t, 0, col)
+		row, col = l.locate(8)
+		assert.Equal(t, 1, row)
+		assert.Equal(t, 4, col)
+	})
+
+	t.Run("at keeps the cursor on the row it asks for", func(t *testing.T) {
+		t.Parallel()
+
+		l := layoutInput("> ", []rune("abcdefgh日x"), 6)
+
+		assert.Equal(t, 0, l.at(0, 0), "columns of the prefix go to the start of the input")
+		assert.Equal(t, 3, l.at(0, 5))
+		assert.Equal(t, 3, l.at(0, 9), "past the end of a wrapped row stays on its last character")
+		assert.Equal(t, 8, l.at(1, 5), "inside a wide character goes in front of it")
+		assert.Equal(t, 8, l.at(1, 9), "the wide character ends the wrapped row")
+		assert.Equal(t, 10, l.at(2, 9), "past the end of the input goes to its end")
+	})
+}
+
+func TestSoftWrapEditing(t *testing.T) {
+	t.Parallel()
+
+	newWrapPrompt := func(t *testing.T, width int, input string) *Prompt {
+		t.Helper()
+		terminal := newMockTerminal(input)
+		terminal.terminalSize = [2]int{width, 24}
+		p, err := New("> ", WithTerminal(terminal), WithMemoryHistory(10))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = p.Close() })
+		return p
+	}
+	text := strings.Repeat("abcdefghij", 2) // Rows of 8, 10 and 2 characters on a 10 column terminal
+
+	t.Run("Up and Down keep the column across wrapped rows", func(t *testing.T) {
+		t.Parallel()
+
+		p := newWrapPrompt(t, 10, text+"\x1b[A\x1b[A1\x1b[B\x1b[B2\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "1"+text+"2", result, "the column of the prefix end leads to the start of the input and back to the end")
+	})
+
+	t.Run("Up on the first row of a single line recalls history", func(t *testing.T) {
+		t.Parallel()
+
+		p := newWrapPrompt(t, 10, text+"\x1b[A\x1b[A\x1b[A\r")
+		p.AddHistory("previous")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "previous", result)
+	})
+
+	t.Run("Home and End go to the row edges, then to the line edges", func(t *testing.T) {
+		t.Parallel()
+
+		p := newWrapPrompt(t, 10, "abcdefghijkl\x1b[H1\x1b[H\x1b[H2\x1b[F3\x1b[H\x1b[F\x1b[F4\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		// Rows of 8 and 4 characters: Home from the end goes before "i", at
+		// the start of the second row, and pressed again to the start of the
+		// line. End stops before "g", the last character of the first row, and
+		// pressed again goes to the end of the line
+		assert.Equal(t, "2abcdef3gh1ijkl4", result)
+	})
+}
@@ -0,0 +1,157 @@
+package prompt
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// WindowSize is a terminal size in columns and rows, as reported by a remote
+// client.
+type WindowSize struct {
+	Width  int
+	Height int
+}
+
+// StreamTerminal is a Terminal on top of a byte stream, such as an SSH session
+// channel, whose window size is reported separately.
+//
+// The remote client owns the real terminal, so raw mode is the client's job
+// (SSH clients switch their local terminal to raw mode when a PTY is requested)
+// and SetRaw and Restore do nothing. Keys are read from the stream and the
+// prompt is drawn by writing to it. Sizes sent on the resize channel are picked
+// up by the prompt and trigger a redraw, like SIGWINCH on a local terminal.
+type StreamTerminal struct {
+	rw     io.ReadWriter
+	reader *bufio.Reader
+
+	mu   sync.Mutex
+	size WindowSize
+
+	resize    chan struct{} // Coalesced resize notifications for the event loop
+	done      chan struct{} // Closed by Close to stop the resize forwarder
+	closeOnce sync.Once
+}
+
+var _ Terminal = (*StreamTerminal)(nil)
+
+// NewStreamTerminal returns a terminal that reads keys from and draws to rw.
+// size is the initial window size; later sizes are received from resize, which
+// may be nil when the size never changes. Sizes with a non-positive width or
+// height are ignored.
+func NewStreamTerminal(rw io.ReadWriter, size WindowSize, resize <-chan WindowSize) *StreamTerminal {
+	t := &StreamTerminal{
+		rw:     rw,
+		reader: bufio.NewReader(rw),
+		size:   size,
+		resize: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if resize != nil {
+		go t.forwardResizes(resize)
+	}
+	return t
+}
+
+// WithStream runs the prompt over a byte stream such as an SSH session
+// channel: keys are read from rw and the prompt is drawn to it. size is the
+// initial window size and resize delivers later window changes; see
+// StreamTerminal.
+//
+// Example with golang.org/x/crypto/ssh, after the "pty-req" request has been
+// accepted:
+//
+//	sizes := make(chan prompt.WindowSize, 1)
+//	go func() {
+//		for req := range requests {
+//			if req.Type == "window-change" {
+//				w, h := parseWindowChange(req.Payload)
+//				sizes <- prompt.WindowSize{Width: w, Height: h}
+//			}
+//		}
+//		close(sizes)
+//	}()
+//
+//	p, err := prompt.New("remote> ", prompt.WithStream(channel, initialSize, sizes))
+//	if err != nil {
+//		return err
+//	}
+//	defer p.Close()
+//	line, err := p.Run()
+func WithStream(rw io.ReadWriter, size WindowSize, resize <-chan WindowSize) Option {
+	return func(c *Config) {
+		terminal := NewStreamTerminal(rw, size, resize)
+		c.Terminal = terminal
+		c.Output = terminal
+	}
+}
+
+// forwardResizes records every size received from sizes and notifies the event
+// loop without blocking.
+func (t *StreamTerminal) forwardResizes(sizes <-chan WindowSize) {
+	for {
+		select {
+		case <-t.done:
+			return
+		case size, ok := <-sizes:
+			if !ok {
+				return
+			}
+			if size.Width <= 0 || size.Height <= 0 {
+				continue
+			}
+			t.mu.Lock()
+			t.size = size
+			t.mu.Unlock()
+			select {
+			case t.resize <- struct{}{}:
+			default: // A notification is already pending
+			}
+		}
+	}
+}
+
+// ResizeEvents reports window size changes received from the client.
+func (t *StreamTerminal) ResizeEvents() <-chan struct{} {
+	return t.resize
+}
+
+// SetRaw does nothing; the remote client puts its terminal in raw mode.
+func (t *StreamTerminal) SetRaw() error {
+	return nil
+}
+
+// Restore does nothing; see SetRaw.
+func (t *StreamTerminal) Restore() error {
+	return nil
+}
+
+// Size returns the last window size reported by the client, falling back to
+// 80x24 when none is known.
+func (t *StreamTerminal) Size() (width, height int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.size.Width <= 0 || t.size.Height <= 0 {
+		return 80, 24, nil
+	}
+	return t.size.Width, t.size.Height, nil
+}
+
+// ReadRune reads the next key from the stream.
+func (t *StreamTerminal) ReadRune() (rune, int, error) {
+	return t.reader.ReadRune()
+}
+
+// Write draws to the stream.
+func (t *StreamTerminal) Write(p []byte) (int, error) {
+	return t.rw.Write(p)
+}
+
+// Close stops watching for window size changes. The stream itself belongs to
+// the caller and is left open.
+func (t *StreamTerminal) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.done)
+	})
+	return nil
+}
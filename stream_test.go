@@ -0,0 +1,117 @@
+package prompt
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// session is an in-memory stand-in for an SSH session channel.
+type session struct {
+	io.Reader
+	out syncBuffer
+}
+
+func (s *session) Write(p []byte) (int, error) {
+	return s.out.Write(p)
+}
+
+func TestStreamTerminal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("prompt reads and draws over the stream", func(t *testing.T) {
+		t.Parallel()
+
+		rw := &session{Reader: strings.NewReader("uptime\r")}
+		p, err := New("remote> ", WithStream(rw, WindowSize{Width: 100, Height: 30}, nil), WithMemoryHistory(10))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "uptime", result)
+		assert.Contains(t, rw.out.String(), "remote> ")
+	})
+
+	t.Run("the end of the stream is EOF", func(t *testing.T) {
+		t.Parallel()
+
+		rw := &session{Reader: strings.NewReader("")}
+		p, err := New("> ", WithStream(rw, WindowSize{Width: 80, Height: 24}, nil), WithMemoryHistory(10))
+		require.NoError(t, err)
+		defer p.Close()
+
+		_, err = p.Run()
+
+		assert.ErrorIs(t, err, ErrEOF)
+	})
+
+	t.Run("window changes update the size and redraw", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := io.Pipe()
+		rw := &session{Reader: reader}
+		sizes := make(chan WindowSize)
+		terminal := NewStreamTerminal(rw, WindowSize{Width: 80, Height: 24}, sizes)
+		p, err := New("$ ", WithTerminal(terminal), WithOutput(terminal), WithMemoryHistory(10))
+		require.NoError(t, err)
+		defer p.Close()
+
+		type runResult struct {
+			result string
+			err    error
+		}
+		done := make(chan runResult, 1)
+		go func() {
+			result, err := p.RunWithContext(context.Background())
+			done <- runResult{result, err}
+		}()
+
+		_, err = writer.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return strings.Contains(rw.out.String(), "hello world")
+		}, 5*time.Second, time.Millisecond)
+
+		sizes <- WindowSize{Width: 5, Height: 24}
+		require.Eventually(t, func() bool {
+			width, _, _ := terminal.Size()
+			return width == 5 && strings.Contains(rw.out.String(), "\x1b[2A\r\x1b[0J")
+		}, 5*time.Second, time.Millisecond)
+
+		_, err = writer.Write([]byte("\r"))
+		require.NoError(t, err)
+		select {
+		case r := <-done:
+			require.NoError(t, r.err)
+			assert.Equal(t, "hello world", r.result)
+		case <-time.After(5 * time.Second):
+			t.Fatal("prompt did not return")
+		}
+	})
+
+	t.Run("invalid sizes are ignored", func(t *testing.T) {
+		t.Parallel()
+
+		sizes := make(chan WindowSize, 1)
+		terminal := NewStreamTerminal(&session{Reader: strings.NewReader("")}, WindowSize{}, sizes)
+		defer terminal.Close()
+
+		width, height, err := terminal.Size()
+		require.NoError(t, err)
+		assert.Equal(t, [2]int{80, 24}, [2]int{width, height})
+
+		sizes <- WindowSize{Width: 0, Height: 10}
+		close(sizes)
+		time.Sleep(10 * time.Millisecond)
+		width, height, _ = terminal.Size()
+		assert.Equal(t, [2]int{80, 24}, [2]int{width, height})
+		assert.NoError(t, terminal.Close(), "Close is safe to call twice")
+	})
+}
@@ -0,0 +1,59 @@
+package prompt
+
+// SubmitKey chooses whether Enter submits the input or starts a new line, and
+// what Enter with a modifier does. Shift+Enter and Ctrl+Enter can only be told
+// apart from Enter on terminals that report them with the kitty keyboard
+// protocol or xterm's modifyOtherKeys, which the prompt asks for whenever a
+// SubmitKey other than SubmitKeyDefault is set. Alt+Enter works everywhere.
+type SubmitKey int
+
+const (
+	// SubmitKeyDefault leaves Enter to the key map: Enter submits, and
+	// Alt+Enter previews the input.
+	SubmitKeyDefault SubmitKey = iota
+	// SubmitKeyEnter makes Enter submit and Alt+Enter, Shift+Enter and
+	// Ctrl+Enter insert a newline, as in most chat applications.
+	SubmitKeyEnter
+	// SubmitKeyAltEnter makes Enter insert a newline and Alt+Enter,
+	// Shift+Enter and Ctrl+Enter submit, for input that is usually several
+	// lines long, such as a query or a message.
+	SubmitKeyAltEnter
+)
+
+// WithSubmitKey sets which Enter key submits the input and which one inserts
+// a newline. See SubmitKey. Enter keys bound with KeyMap.BindKey keep their
+// binding, and Enter still accepts a suggestion selected in the menu.
+//
+// Example:
+//
+//	// Enter starts a new line, Alt+Enter (or Shift+Enter) sends the message
+//	prompt.New("> ", prompt.WithSubmitKey(prompt.SubmitKeyAltEnter))
+func WithSubmitKey(key SubmitKey) Option {
+	return func(c *Config) {
+		c.SubmitKey = key
+	}
+}
+
+// submitKeyAction returns the action of key under the SubmitKey config,
+// ActionSubmit or ActionNewLine, and true. It returns false for keys other
+// than Enter, for Enter keys bound with BindKey in context and under
+// SubmitKeyDefault. Plain Enter is ActionSubmit while accepting is set, so it
+// accepts the suggestion selected in the menu.
+func (p *Prompt) submitKeyAction(context KeyContext, key Key, accepting bool) (KeyAction, bool) {
+	if p.config.SubmitKey == SubmitKeyDefault || key.Code != KeyEnter || p.keyMap.GetKeyActionInContext(context, key) != ActionNone {
+		return ActionNone, false
+	}
+	if key.Mod == 0 && accepting {
+		return ActionSubmit, true
+	}
+	if (key.Mod == 0) == (p.config.SubmitKey == SubmitKeyEnter) {
+		return ActionSubmit, true
+	}
+	return ActionNewLine, true
+}
+
+// extendedKeys reports whether the terminal is asked for the extended key
+// encodings, which WithExtendedKeys and a SubmitKey both need.
+func (p *Prompt) extendedKeys() bool {
+	return p.config.ExtendedKeys || p.config.SubmitKey != SubmitKeyDefault
+}
@@ -0,0 +1,74 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitKey(t *testing.T) {
+	t.Parallel()
+
+	completer := func(Document) []Suggestion {
+		return []Suggestion{{Text: "git"}, {Text: "go"}}
+	}
+	tests := []struct {
+		name      string
+		submitKey SubmitKey
+		input     string
+		want      string
+	}{
+		{name: "default: Enter submits", submitKey: SubmitKeyDefault, input: "a\rb\r", want: "a"},
+		{name: "default: Shift+Enter is Enter", submitKey: SubmitKeyDefault, input: "a\x1b[13;2ub\r", want: "a"},
+		{name: "enter: Alt+Enter inserts a newline", submitKey: SubmitKeyEnter, input: "a\x1b\rb\r", want: "a\nb"},
+		{name: "enter: Shift+Enter inserts a newline", submitKey: SubmitKeyEnter, input: "a\x1b[13;2ub\r", want: "a\nb"},
+		{name: "enter: Ctrl+Enter inserts a newline", submitKey: SubmitKeyEnter, input: "a\x1b[27;5;13~b\r", want: "a\nb"},
+		{name: "alt+enter: Enter inserts a newline", submitKey: SubmitKeyAltEnter, input: "a\rb\x1b\r", want: "a\nb"},
+		{name: "alt+enter: Shift+Enter submits", submitKey: SubmitKeyAltEnter, input: "a\rb\x1b[13;2u", want: "a\nb"},
+		{name: "alt+enter: Enter accepts the selected suggestion", submitKey: SubmitKeyAltEnter, input: "g\t\r\x1b\r", want: "git"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{Prefix: "> ", Completer: completer, SubmitKey: tt.submitKey}, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+
+	t.Run("a BindKey binding of an Enter key is kept", func(t *testing.T) {
+		t.Parallel()
+
+		keyMap := NewDefaultKeyMap()
+		keyMap.BindKey(Key{Code: KeyEnter, Mod: ModShift}, ActionMoveHome)
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", KeyMap: keyMap, SubmitKey: SubmitKeyAltEnter}, "a\x1b[13;2ub\x1b\r")
+		p.renderer.output = &bytes.Buffer{}
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ba", result)
+	})
+
+	t.Run("asks for the extended key encodings", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		p, err := New("> ", WithTerminal(newMockTerminal("\r")), WithOutput(&out), WithSubmitKey(SubmitKeyEnter))
+		require.NoError(t, err)
+		defer p.Close()
+
+		_, err = p.Run()
+
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), extendedKeysEnableSequence)
+		assert.Contains(t, out.String(), extendedKeysDisableSequence)
+	})
+}
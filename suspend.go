@@ -0,0 +1,76 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+)
+
+// jobController is implemented by terminals that can take part in the job
+// control of a shell, so Ctrl+Z stops the program as it does in cooked mode.
+//
+// Raw mode turns off the signals the terminal driver sends for Ctrl+Z, so the
+// prompt stops the job itself. A stop the shell or kill sends from outside
+// leaves the terminal in raw mode, which the shell saves and brings back with
+// the job; watchContinue lets the event loop set raw mode up again and redraw
+// once the job runs again, whichever way it was stopped.
+type jobController interface {
+	// watchContinue reports SIGCONT until release is called. The channel is
+	// nil when the program is not a job of a shell with job control.
+	watchContinue() (cont <-chan os.Signal, release func())
+	// stopJob stops the process group of the program as Ctrl+Z in cooked mode
+	// would. It returns before the job is continued.
+	stopJob() error
+}
+
+// suspend hands the terminal back and stops the job. The frame is left on the
+// screen without the menu and with the cursor on its last row; shells start
+// their report of the stopped job on a new line below it. resume draws the
+// prompt again once the job is continued.
+func (p *Prompt) suspend(jobs jobController) error {
+	if err := p.render(); err != nil {
+		return fmt.Errorf("failed to render prompt: %w", err)
+	}
+	if below := p.renderer.lastLines - 1 - p.renderer.cursorRow; below > 0 {
+		fmt.Fprintf(p.output, "\x1b[%dB", below)
+	}
+	if err := p.renderer.showCursor(); err != nil {
+		return fmt.Errorf("failed to show the cursor: %w", err)
+	}
+	if err := p.exitRawMode(); err != nil {
+		return fmt.Errorf("failed to exit raw mode: %w", err)
+	}
+	if err := jobs.stopJob(); err != nil {
+		return fmt.Errorf("failed to stop the job: %w", err)
+	}
+	return nil
+}
+
+// resume sets up raw mode again after the job was continued. The terminal is
+// restored first, since a job stopped from outside comes back in raw mode and
+// SetRaw would otherwise take that for the mode to restore when Run returns;
+// after suspend there is nothing left to restore.
+// The next frame is drawn from scratch on the row the cursor is on, as the
+// shell printed below the old one in the meantime.
+func (p *Prompt) resume() error {
+	if err := p.terminal.Restore(); err != nil {
+		return fmt.Errorf("failed to restore the terminal: %w", err)
+	}
+	if err := p.enterRawMode(); err != nil {
+		return fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	p.renderer.forgetFrame()
+	return nil
+}
+
+// forgetFrame drops the line tracking of the last frame, whose rows are no
+// longer where the cursor is, so the next render starts on the cursor row
+// instead of moving up to erase them.
+func (r *renderer) forgetFrame() {
+	r.lastLines = 1
+	r.cursorRow = 0
+	r.suggestionsActive = false
+	r.frameRows = nil
+	r.ghostWidth = 0
+	r.rightEnd = 0
+	r.lastFrame = ""
+}
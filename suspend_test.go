@@ -0,0 +1,142 @@
+package prompt
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jobTerminal is a mockTerminal run as a job of a shell with job control.
+// stopJob records the job as stopped and the shell continues it right away.
+// Keys typed after Ctrl+Z are only read once raw mode is set up again.
+type jobTerminal struct {
+	*mockTerminal
+	mu      sync.Mutex
+	cont    chan os.Signal
+	stops   int  // Calls of stopJob
+	rawStop bool // Raw mode was still on when the job was stopped
+	resumed chan struct{}
+	raws    int // Calls of SetRaw
+}
+
+func newJobTerminal(input string) *jobTerminal {
+	return &jobTerminal{
+		mockTerminal: newMockTerminal(input),
+		cont:         make(chan os.Signal, 1),
+		resumed:      make(chan struct{}),
+	}
+}
+
+func (j *jobTerminal) watchContinue() (<-chan os.Signal, func()) {
+	return j.cont, func() {}
+}
+
+func (j *jobTerminal) stopJob() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.stops++
+	j.rawStop = j.rawMode
+	j.cont <- nil // SIGCONT, the event loop does not look at the value
+	return nil
+}
+
+func (j *jobTerminal) SetRaw() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.raws++
+	if j.raws == 2 {
+		close(j.resumed)
+	}
+	return j.mockTerminal.SetRaw()
+}
+
+func (j *jobTerminal) ReadRune() (rune, int, error) {
+	j.mu.Lock()
+	stopped := j.stops > 0
+	j.mu.Unlock()
+	if stopped {
+		<-j.resumed
+	}
+	return j.mockTerminal.ReadRune()
+}
+
+func TestSuspend(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ctrl+z stops the job and draws the prompt again", func(t *testing.T) {
+		t.Parallel()
+
+		terminal := newJobTerminal("ab\x1ac\r")
+		var out bytes.Buffer
+		p, err := New("$ ", WithTerminal(terminal), WithOutput(&out))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc", result)
+		assert.Equal(t, 1, terminal.stops)
+		assert.False(t, terminal.rawStop, "the terminal is restored before the job stops")
+		assert.Equal(t, 2, terminal.raws)
+		before, after, found := strings.Cut(out.String(), "\x1b[?2004l")
+		require.True(t, found, "the terminal is handed back")
+		assert.Contains(t, before, "ab", "the frame is left on the screen")
+		assert.True(t, strings.HasPrefix(after, "\x1b[?2004h\r\x1b[K"), "raw mode is set up again and the prompt drawn on the cursor row")
+	})
+
+	t.Run("ctrl+z closes the menu before the job stops", func(t *testing.T) {
+		t.Parallel()
+
+		completer := func(Document) []Suggestion {
+			return []Suggestion{{Text: "git"}, {Text: "go"}}
+		}
+		terminal := newJobTerminal("g\t\x1a\r")
+		var out bytes.Buffer
+		p, err := New("$ ", WithTerminal(terminal), WithOutput(&out), WithCompleter(completer))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "g", result, "Enter after resuming submits instead of accepting")
+	})
+
+	t.Run("ctrl+z is ignored without job control", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		p, err := New("$ ", WithTerminal(newMockTerminal("ab\x1ac\r")), WithOutput(&out))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc", result)
+	})
+
+	t.Run("a job continued after a stop from outside gets raw mode back", func(t *testing.T) {
+		t.Parallel()
+
+		terminal := newJobTerminal("ab\r")
+		terminal.stops = 1 // Stopped from outside, so stopJob is not called
+		terminal.cont <- nil
+		var out bytes.Buffer
+		p, err := New("$ ", WithTerminal(terminal), WithOutput(&out))
+		require.NoError(t, err)
+		defer p.Close()
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ab", result)
+		assert.Equal(t, 2, terminal.raws, "raw mode is set up again on SIGCONT")
+	})
+}
@@ -0,0 +1,33 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package prompt
+
+import (
+	"os"
+	"os/signal"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchContinue reports SIGCONT while the program is a job of a shell with job
+// control: the controlling terminal belongs to a session whose leader, the
+// shell, put the program in a process group of its own. A process group that
+// is the session's own, as under init or a shell without job control, would
+// never be continued after a stop.
+func (t *realTerminal) watchContinue() (<-chan os.Signal, func()) {
+	sid, err := unix.Getsid(0)
+	if !t.jobControl || err != nil || unix.Getpgrp() == sid {
+		return nil, func() {}
+	}
+	cont := make(chan os.Signal, 1)
+	signal.Notify(cont, unix.SIGCONT)
+	return cont, func() { signal.Stop(cont) }
+}
+
+// stopJob sends SIGTSTP to the process group, as the terminal driver does for
+// Ctrl+Z in cooked mode. SIGTSTP is never passed to signal.Notify here: once
+// it has been, the Go runtime keeps its own handler and the default action,
+// stopping the process, is lost for the rest of the program.
+func (t *realTerminal) stopJob() error {
+	return unix.Kill(0, unix.SIGTSTP)
+}
@@ -10,7 +10,7 @@ import (
 	"golang.org/x/term"
 )
 
-// terminalInterface abstracts terminal operations for testability and cross-platform compatibility.
+// Terminal abstracts terminal operations for testability and cross-platform compatibility.
 //
 // This interface provides a clean abstraction over platform-specific terminal operations,
 // allowing the prompt to work with both real terminals (via go-tty) and mock terminals
@@ -24,7 +24,13 @@ import (
 //   - Prevents file descriptor leaks through proper Close() implementation
 //   - Provides safe fallback sizes to prevent divide-by-zero panics
 //   - Supports cross-platform raw mode handling
-type terminalInterface interface {
+//
+// Applications normally never implement Terminal; New opens the controlling
+// terminal itself. Tests and alternative front ends can pass their own
+// implementation with WithTerminal. An implementation that also has a
+// ResizeEvents() <-chan struct{} method gets the prompt redrawn whenever a value
+// is sent on that channel.
+type Terminal interface {
 	SetRaw() error                        // Enter raw mode for immediate key processing
 	Restore() error                       // Restore original terminal settings
 	Size() (width, height int, err error) // Get terminal dimensions with safe fallbacks
@@ -32,7 +38,19 @@ type terminalInterface interface {
 	Close() error                         // Clean up resources and prevent fd leaks
 }
 
-// realTerminal implements terminalInterface using external libraries for production use.
+// resizeNotifier is implemented by terminals that can report size changes.
+//
+// It is kept separate from Terminal so that simple terminals (and test
+// doubles) do not have to support it. The event loop checks for it at runtime
+// and, when present, redraws the prompt for the new width whenever a value
+// arrives on the channel. Implementations must not block when nobody is
+// receiving; coalescing several resizes into one notification is fine because
+// the loop queries Size() for the current dimensions.
+type resizeNotifier interface {
+	ResizeEvents() <-chan struct{}
+}
+
+// realTerminal implements Terminal using external libraries for production use.
 //
 // This implementation leverages go-tty for cross-platform terminal handling and
 // go-colorable for Windows ANSI color support. It addresses several critical issues
@@ -47,11 +65,14 @@ type terminalInterface interface {
 // The terminal properly manages raw mode state to ensure terminal restoration
 // even when interrupted by Ctrl-C or other signals.
 type realTerminal struct {
-	tty           *tty.TTY    // TTY handle from go-tty for cross-platform terminal operations
-	output        io.Writer   // Color-capable output writer (colorable on Windows, stdout elsewhere)
-	closed        bool        // Track if terminal is already closed to prevent double-close panic on Windows
-	stdinFd       int         // File descriptor for stdin for raw mode management
-	originalState *term.State // Original terminal state to restore on exit
+	tty           *tty.TTY      // TTY handle from go-tty for cross-platform terminal operations
+	output        io.Writer     // Color-capable output writer (colorable on Windows, stdout elsewhere)
+	closed        bool          // Track if terminal is already closed to prevent double-close panic on Windows
+	stdinFd       int           // File descriptor for stdin for raw mode management
+	originalState *term.State   // Original terminal state to restore on exit
+	resize        chan struct{} // Coalesced resize notifications, created on first use
+	done          chan struct{} // Closed by Close to stop the resize forwarder
+	jobControl    bool          // The controlling terminal, whose shell can stop and continue the process
 }
 
 // newRealTerminal creates a new terminal instance following simplified design
@@ -72,13 +93,63 @@ func newRealTerminal() (*realTerminal, error) {
 	// Get stdin file descriptor for raw mode management
 	stdinFd := int(os.Stdin.Fd())
 
+	return &realTerminal{
+		tty:        t,
+		output:     output,
+		stdinFd:    stdinFd,
+		done:       make(chan struct{}),
+		jobControl: true,
+	}, nil
+}
+
+// newDeviceTerminal opens the terminal device at path instead of the
+// controlling terminal. Input, raw mode and output all use the device, so the
+// prompt can run on a terminal the process is not attached to, such as a
+// dedicated PTY of a daemon.
+func newDeviceTerminal(path string) (*realTerminal, error) {
+	t, err := tty.OpenDevice(path)
+	if err != nil {
+		return nil, err
+	}
+
 	return &realTerminal{
 		tty:     t,
-		output:  output,
-		stdinFd: stdinFd,
+		output:  t.Output(),
+		stdinFd: int(t.Input().Fd()),
+		done:    make(chan struct{}),
 	}, nil
 }
 
+// ResizeEvents reports terminal size changes (SIGWINCH on Unix, console buffer
+// size events on Windows). go-tty only delivers a change when somebody is
+// receiving at that moment, so a forwarding goroutine drains it continuously
+// into a one-slot buffer that keeps the latest notification until the event
+// loop picks it up.
+func (t *realTerminal) ResizeEvents() <-chan struct{} {
+	if t.resize == nil {
+		t.resize = make(chan struct{}, 1)
+		winch := t.tty.SIGWINCH()
+		done := t.done
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case _, ok := <-winch:
+					if !ok {
+						return
+					}
+					select {
+					case t.resize <- struct{}{}:
+					default: // A notification is already pending
+					}
+				}
+			}
+		}()
+	}
+	return t.resize
+}
+
 func (t *realTerminal) SetRaw() error {
 	// Always capture current terminal state before entering raw mode
 	// This ensures proper restoration regardless of how many times we enter/exit raw mode
@@ -133,6 +204,10 @@ func (t *realTerminal) Close() error {
 	if t.closed {
 		return nil
 	}
+	if t.done != nil {
+		close(t.done)
+		t.done = nil
+	}
 	if t.tty != nil {
 		err := t.tty.Close()
 		t.closed = true
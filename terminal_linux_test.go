@@ -0,0 +1,103 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a new pseudo-terminal pair and returns the master side and the
+// path of the slave device.
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	t.Cleanup(func() { _ = master.Close() })
+
+	if err := unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0); err != nil {
+		t.Skipf("failed to unlock pseudo-terminal: %v", err)
+	}
+	n, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Skipf("failed to get pseudo-terminal number: %v", err)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestWithTTYPath(t *testing.T) {
+	t.Parallel()
+
+	t.Run("prompt runs on the given device", func(t *testing.T) {
+		t.Parallel()
+
+		master, slave := openPTY(t)
+		p, err := New("admin> ", WithTTYPath(slave), WithMemoryHistory(10))
+		require.NoError(t, err)
+		defer p.Close()
+
+		// Collect everything the prompt draws on the device
+		var drawn syncBuffer
+		go func() {
+			buf := make([]byte, 1024)
+			for {
+				n, err := master.Read(buf)
+				if err != nil {
+					return
+				}
+				_, _ = drawn.Write(buf[:n])
+			}
+		}()
+
+		_, err = master.WriteString("status\r")
+		require.NoError(t, err)
+
+		type runResult struct {
+			result string
+			err    error
+		}
+		done := make(chan runResult, 1)
+		go func() {
+			result, err := p.Run()
+			done <- runResult{result, err}
+		}()
+
+		select {
+		case r := <-done:
+			require.NoError(t, r.err)
+			assert.Equal(t, "status", r.result)
+		case <-time.After(5 * time.Second):
+			t.Fatal("prompt did not return")
+		}
+
+		assert.Eventually(t, func() bool {
+			return strings.Contains(drawn.String(), "admin> ")
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("a missing device is reported", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := New("> ", WithTTYPath("/dev/does-not-exist"))
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "/dev/does-not-exist")
+	})
+
+	t.Run("fd variant reopens the descriptor", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{}
+		WithTTYFd(7)(&config)
+
+		assert.Equal(t, "/dev/fd/7", config.TTYPath)
+	})
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package prompt
+
+// newControllingTerminal opens the controlling terminal of the process with
+// go-tty.
+func newControllingTerminal() (Terminal, error) {
+	t, err := newRealTerminal()
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
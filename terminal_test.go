@@ -279,11 +279,11 @@ func TestRealTerminalCreation(t *testing.T) {
 func TestTerminalInterface(t *testing.T) {
 	t.Parallel()
 
-	// Test that mockTerminal implements terminalInterface
-	var _ terminalInterface = (*mockTerminal)(nil)
+	// Test that mockTerminal implements Terminal
+	var _ Terminal = (*mockTerminal)(nil)
 
-	// Test that realTerminal implements terminalInterface
-	var _ terminalInterface = (*realTerminal)(nil)
+	// Test that realTerminal implements Terminal
+	var _ Terminal = (*realTerminal)(nil)
 }
 
 func TestMockTerminalWithSpecialCharacters(t *testing.T) {
@@ -627,8 +627,8 @@ func TestTerminalSizeFallback(t *testing.T) {
 
 func TestTerminalInterfaceCompliance(_ *testing.T) {
 	// Test that both implementations satisfy the interface
-	var _ terminalInterface = &realTerminal{}
-	var _ terminalInterface = &mockTerminal{}
+	var _ Terminal = &realTerminal{}
+	var _ Terminal = &mockTerminal{}
 
 	// This test ensures the interface is properly implemented
 	// If it compiles, the interface compliance is verified
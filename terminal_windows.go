@@ -0,0 +1,225 @@
+//go:build windows
+
+package prompt
+
+import (
+	"errors"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// newControllingTerminal opens the console the process runs in. Keys are read
+// as Win32 console input events when the console allows it, so cursor and
+// function keys work in legacy consoles that do not send escape sequences.
+// Terminals that are not Win32 consoles, such as mintty, use go-tty.
+func newControllingTerminal() (Terminal, error) {
+	if console, err := newConsoleTerminal(); err == nil {
+		return console, nil
+	}
+	t, err := newRealTerminal()
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// consoleTerminal implements Terminal with the Win32 console API. Instead of
+// the characters the console would produce, it reads key events with
+// ReadConsoleInputW and translates their virtual key codes with
+// consoleKeySequence into the escape sequences xterm sends, which the KeyMap
+// turns into KeyActions. Window buffer size events become resize
+// notifications.
+type consoleTerminal struct {
+	input     windows.Handle    // CONIN$, read for key events
+	output    windows.Handle    // CONOUT$, asked for the window size
+	readInput *windows.LazyProc // ReadConsoleInputW, which golang.org/x/sys does not wrap
+	mode      uint32            // Input mode before SetRaw, restored by Restore
+	raw       bool              // SetRaw changed the input mode
+	pending   []rune            // Translated keys not returned by ReadRune yet
+	surrogate uint16            // High surrogate waiting for the key event with its low half
+	resize    chan struct{}     // Coalesced resize notifications
+	closed    bool              // Close already released the handles
+}
+
+// inputRecord is the layout of INPUT_RECORD with its event read as a
+// KEY_EVENT_RECORD. Only key events use the fields after eventType.
+type inputRecord struct {
+	eventType       uint16
+	_               uint16
+	keyDown         int32
+	repeatCount     uint16
+	virtualKeyCode  uint16
+	virtualScanCode uint16
+	unicodeChar     uint16
+	controlKeyState uint32
+}
+
+// errNotConsole is returned by newConsoleTerminal when the console input
+// cannot be read as key events, and go-tty is used instead.
+var errNotConsole = errors.New("console input is not available")
+
+// newConsoleTerminal opens the console input and output of the process. It
+// fails when there is no Win32 console, such as under mintty, or when the
+// input cannot be put in the mode it needs.
+func newConsoleTerminal() (*consoleTerminal, error) {
+	input, err := openConsole("CONIN$")
+	if err != nil {
+		return nil, err
+	}
+	var mode uint32
+	if err := windows.GetConsoleMode(input, &mode); err != nil {
+		_ = windows.CloseHandle(input)
+		return nil, errNotConsole
+	}
+	output, err := openConsole("CONOUT$")
+	if err != nil {
+		_ = windows.CloseHandle(input)
+		return nil, err
+	}
+	readInput := windows.NewLazySystemDLL("kernel32.dll").NewProc("ReadConsoleInputW")
+	if err := readInput.Find(); err != nil {
+		_ = windows.CloseHandle(input)
+		_ = windows.CloseHandle(output)
+		return nil, err
+	}
+
+	return &consoleTerminal{
+		input:     input,
+		output:    output,
+		readInput: readInput,
+		resize:    make(chan struct{}, 1),
+	}, nil
+}
+
+// openConsole opens the console device name for reading and writing.
+func openConsole(name string) (windows.Handle, error) {
+	path, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+	return windows.CreateFile(path, windows.GENERIC_READ|windows.GENERIC_WRITE,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil, windows.OPEN_EXISTING, 0, 0)
+}
+
+// SetRaw turns off line editing, echo and Ctrl+C handling by the console, and
+// asks for window size events. Virtual terminal input is turned off too, so
+// keys always arrive as key events to translate.
+func (t *consoleTerminal) SetRaw() error {
+	var mode uint32
+	if err := windows.GetConsoleMode(t.input, &mode); err != nil {
+		return err
+	}
+	raw := mode&^(windows.ENABLE_ECHO_INPUT|windows.ENABLE_LINE_INPUT|windows.ENABLE_PROCESSED_INPUT|windows.ENABLE_VIRTUAL_TERMINAL_INPUT) |
+		windows.ENABLE_WINDOW_INPUT
+	if err := windows.SetConsoleMode(t.input, raw); err != nil {
+		return err
+	}
+	t.mode, t.raw = mode, true
+	return nil
+}
+
+// Restore puts back the input mode SetRaw replaced.
+func (t *consoleTerminal) Restore() error {
+	if !t.raw {
+		return nil
+	}
+	t.raw = false
+	return windows.SetConsoleMode(t.input, t.mode)
+}
+
+// Size returns the size of the console window, which is smaller than its
+// screen buffer when the buffer scrolls.
+func (t *consoleTerminal) Size() (width, height int, err error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(t.output, &info); err != nil {
+		// Safe fallback to prevent divide by zero (addresses go-prompt issue #277)
+		return 80, 24, err
+	}
+	width = int(info.Window.Right-info.Window.Left) + 1
+	height = int(info.Window.Bottom-info.Window.Top) + 1
+	if width <= 0 || height <= 0 {
+		return 80, 24, nil
+	}
+	return width, height, nil
+}
+
+// ReadRune returns the next rune of the translated keys, waiting for key
+// events when none is left.
+func (t *consoleTerminal) ReadRune() (rune, int, error) {
+	for len(t.pending) == 0 {
+		if err := t.readEvents(); err != nil {
+			return 0, 0, err
+		}
+	}
+	r := t.pending[0]
+	t.pending = t.pending[1:]
+	return r, 1, nil
+}
+
+// readEvents waits for console input events and queues the keys they carry.
+func (t *consoleTerminal) readEvents() error {
+	var records [16]inputRecord
+	var n uint32
+	ok, _, err := t.readInput.Call(uintptr(t.input), uintptr(unsafe.Pointer(&records[0])), uintptr(len(records)), uintptr(unsafe.Pointer(&n)))
+	if ok == 0 {
+		return err
+	}
+	for _, record := range records[:n] {
+		switch record.eventType {
+		case windows.KEY_EVENT:
+			t.queueKey(record)
+		case windows.WINDOW_BUFFER_SIZE_EVENT:
+			select {
+			case t.resize <- struct{}{}:
+			default: // A notification is already pending
+			}
+		}
+	}
+	return nil
+}
+
+// queueKey translates a key event and queues the result once per repeat.
+// Key releases are ignored, except the release of Alt that ends typing a
+// character by its code on the numeric keypad, which carries the character.
+func (t *consoleTerminal) queueKey(record inputRecord) {
+	ch := record.unicodeChar
+	if record.keyDown == 0 && (record.virtualKeyCode != vkMenu || ch == 0) {
+		return
+	}
+	if utf16.IsSurrogate(rune(ch)) {
+		if ch < 0xdc00 {
+			t.surrogate = ch // The low half arrives with the next event
+			return
+		}
+		r := utf16.DecodeRune(rune(t.surrogate), rune(ch))
+		t.surrogate = 0
+		t.pending = append(t.pending, r)
+		return
+	}
+
+	state := record.controlKeyState
+	if record.keyDown == 0 {
+		state = 0 // The Alt that is being released did not modify the character
+	}
+	keys := []rune(consoleKeySequence(record.virtualKeyCode, rune(ch), state))
+	for range max(1, int(record.repeatCount)) {
+		t.pending = append(t.pending, keys...)
+	}
+}
+
+// ResizeEvents reports console window buffer size changes, which the console
+// delivers as input events while ReadRune waits for keys.
+func (t *consoleTerminal) ResizeEvents() <-chan struct{} {
+	return t.resize
+}
+
+// Close releases the console handles. It is safe to call more than once.
+func (t *consoleTerminal) Close() error {
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	return errors.Join(windows.CloseHandle(t.input), windows.CloseHandle(t.output))
+}
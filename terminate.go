@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrTerminated is returned by Run when a signal that ends the program, such
+// as SIGTERM, arrived while the prompt was running. The terminal was restored
+// and the signal raised again before Run returned, so Run only returns when
+// the program handles the signal itself, or when WithHostHandlesSignals told
+// it not to raise the signal again.
+var ErrTerminated = errors.New("terminated by signal")
+
+// WithHostHandlesSignals tells the prompt that the program catches SIGTERM,
+// SIGHUP, SIGINT or SIGQUIT itself, with signal.Notify or
+// signal.NotifyContext. Go delivers a signal to every channel registered for
+// it, so the program already received the signal that ended Run; raising it
+// again would deliver it a second time. With this option Run still restores
+// the terminal and returns ErrTerminated, but leaves the signal to the
+// program.
+//
+// Example:
+//
+//	sigs := make(chan os.Signal, 1)
+//	signal.Notify(sigs, syscall.SIGTERM)
+//	p, err := prompt.New("$ ", prompt.WithHostHandlesSignals(true))
+//	...
+//	_, err = p.Run() // ErrTerminated, and sigs receives SIGTERM once
+func WithHostHandlesSignals(enabled bool) Option {
+	return func(c *Config) {
+		c.HostHandlesSignals = enabled
+	}
+}
+
+// terminationWatcher is implemented by terminals that must not be left in raw
+// mode when a signal ends the program, such as the terminal of the process.
+//
+// Those signals end the program without running deferred calls, so the
+// terminal would stay raw with the cursor hidden. While a prompt runs they are
+// caught instead; the event loop restores the terminal, stops catching them
+// and raises the signal again, so the program ends or handles it as it would
+// without the prompt.
+type terminationWatcher interface {
+	// watchTermination reports the signals until release is called.
+	watchTermination() (signals <-chan os.Signal, release func())
+	// raise sends sig to the program again.
+	raise(sig os.Signal) error
+}
+
+// terminate leaves the prompt for a signal that ends the program: the frame is
+// left on the screen without the footer or the error row, the cursor moves to
+// a new row below it and is shown, and raw mode is turned off before sig is
+// raised again, unless the program handles it itself.
+func (p *Prompt) terminate(watcher terminationWatcher, sig os.Signal) error {
+	p.clearGhost()
+	if below := p.renderer.lastLines - 1 - p.renderer.cursorRow; below > 0 {
+		fmt.Fprintf(p.output, "\x1b[%dB", below)
+	}
+	fmt.Fprint(p.output, "\r\n")
+	var errs []error
+	if err := p.renderer.showCursor(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to show the cursor: %w", err))
+	}
+	if err := p.exitRawMode(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to exit raw mode: %w", err))
+	}
+	if !p.config.HostHandlesSignals {
+		if err := watcher.raise(sig); err != nil {
+			errs = append(errs, fmt.Errorf("failed to raise %v: %w", sig, err))
+		}
+	}
+	return errors.Join(append([]error{ErrTerminated}, errs...)...)
+}
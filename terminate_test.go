@@ -0,0 +1,140 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signalTerminal is a mockTerminal whose termination signals are sent by the
+// test. Once its keys are used up, reads wait until the test ends instead of
+// reporting EOF, so the signal is what ends the prompt.
+type signalTerminal struct {
+	*mockTerminal
+	signals  chan os.Signal
+	done     chan struct{}
+	keys     chan struct{} // Closed once every key was read
+	mu       sync.Mutex
+	released bool
+	raised   []os.Signal
+	rawRaise bool // Raw mode was still on when the signal was raised
+}
+
+func newSignalTerminal(t *testing.T, input string) *signalTerminal {
+	s := &signalTerminal{
+		mockTerminal: newMockTerminal(input),
+		signals:      make(chan os.Signal, 1),
+		done:         make(chan struct{}),
+		keys:         make(chan struct{}),
+	}
+	t.Cleanup(func() { close(s.done) })
+	return s
+}
+
+func (s *signalTerminal) ReadRune() (rune, int, error) {
+	r, n, err := s.mockTerminal.ReadRune()
+	if errors.Is(err, io.EOF) {
+		close(s.keys)
+		<-s.done
+	}
+	return r, n, err
+}
+
+func (s *signalTerminal) watchTermination() (<-chan os.Signal, func()) {
+	return s.signals, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.released = true
+	}
+}
+
+func (s *signalTerminal) raise(sig os.Signal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.released {
+		return errors.New("raised while still caught")
+	}
+	s.raised = append(s.raised, sig)
+	s.rawRaise = s.rawMode
+	return nil
+}
+
+func TestTerminate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("a signal restores the terminal and is raised again", func(t *testing.T) {
+		t.Parallel()
+
+		terminal := newSignalTerminal(t, "ab")
+		var out bytes.Buffer
+		p, err := New("$ ", WithTerminal(terminal), WithOutput(&out), WithFooter(func() []string {
+			return []string{"Tab: complete"}
+		}))
+		require.NoError(t, err)
+		defer p.Close()
+		go func() {
+			<-terminal.keys
+			terminal.signals <- syscall.SIGTERM
+		}()
+
+		result, err := p.Run()
+
+		require.ErrorIs(t, err, ErrTerminated)
+		assert.Empty(t, result)
+		assert.Equal(t, []os.Signal{syscall.SIGTERM}, terminal.raised)
+		assert.False(t, terminal.rawRaise, "raw mode is off before the signal is raised")
+		assert.False(t, terminal.rawMode)
+		assert.Contains(t, out.String(), "\x1b[?2004l", "bracketed paste is turned off")
+		assert.NotContains(t, out.String()[bytes.LastIndex(out.Bytes(), []byte("$ ")):], "Tab: complete", "the footer is erased")
+	})
+
+	t.Run("the cursor hidden by the menu is shown again", func(t *testing.T) {
+		t.Parallel()
+
+		completer := func(Document) []Suggestion {
+			return []Suggestion{{Text: "git"}, {Text: "go"}}
+		}
+		terminal := newSignalTerminal(t, "g\t")
+		var out bytes.Buffer
+		p, err := New("$ ", WithTerminal(terminal), WithOutput(&out), WithCompleter(completer))
+		require.NoError(t, err)
+		defer p.Close()
+		go func() {
+			<-terminal.keys
+			terminal.signals <- syscall.SIGTERM
+		}()
+
+		_, err = p.Run()
+
+		require.ErrorIs(t, err, ErrTerminated)
+		assert.False(t, p.renderer.cursorHidden)
+		assert.Contains(t, out.String(), "\x1b[?25l")
+		assert.Contains(t, out.String(), "\x1b[?25h")
+	})
+
+	t.Run("a signal the host handles is not raised again", func(t *testing.T) {
+		t.Parallel()
+
+		terminal := newSignalTerminal(t, "ab")
+		p, err := New("$ ", WithTerminal(terminal), WithOutput(io.Discard), WithHostHandlesSignals(true))
+		require.NoError(t, err)
+		defer p.Close()
+		go func() {
+			<-terminal.keys
+			terminal.signals <- syscall.SIGTERM
+		}()
+
+		_, err = p.Run()
+
+		require.ErrorIs(t, err, ErrTerminated)
+		assert.Empty(t, terminal.raised)
+		assert.False(t, terminal.rawMode)
+	})
+}
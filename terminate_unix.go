@@ -0,0 +1,39 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchTermination catches SIGTERM, SIGHUP, SIGINT and SIGQUIT, leaving out
+// those the program ignores, such as SIGHUP under nohup. SIGINT only arrives
+// from outside, since raw mode turns Ctrl+C into a key.
+func (t *realTerminal) watchTermination() (<-chan os.Signal, func()) {
+	var watched []os.Signal
+	for _, sig := range []os.Signal{unix.SIGTERM, unix.SIGHUP, unix.SIGINT, unix.SIGQUIT} {
+		if !signal.Ignored(sig) {
+			watched = append(watched, sig)
+		}
+	}
+	if len(watched) == 0 {
+		return nil, func() {}
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, watched...)
+	return signals, func() { signal.Stop(signals) }
+}
+
+// raise sends sig to the process. Once nothing catches it any more, the Go
+// runtime gives it its default action, which ends the program.
+func (t *realTerminal) raise(sig os.Signal) error {
+	unixSig, ok := sig.(unix.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %v", sig)
+	}
+	return unix.Kill(os.Getpid(), unixSig)
+}
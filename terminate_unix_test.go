@@ -0,0 +1,60 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package prompt
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// processSignalTerminal is a signalTerminal that catches and raises the real
+// signals of the process, as the terminal of the process does.
+type processSignalTerminal struct {
+	*signalTerminal
+	process *realTerminal
+}
+
+func (p processSignalTerminal) watchTermination() (<-chan os.Signal, func()) {
+	return p.process.watchTermination()
+}
+
+func (p processSignalTerminal) raise(sig os.Signal) error {
+	return p.process.raise(sig)
+}
+
+// TestHostSignalHandler does not run in parallel: the signal is sent to the
+// whole test process.
+func TestHostSignalHandler(t *testing.T) {
+	host := make(chan os.Signal, 2)
+	signal.Notify(host, unix.SIGTERM)
+	defer signal.Stop(host)
+
+	terminal := processSignalTerminal{signalTerminal: newSignalTerminal(t, "ab"), process: &realTerminal{}}
+	p, err := New("$ ", WithTerminal(terminal), WithOutput(io.Discard), WithHostHandlesSignals(true))
+	require.NoError(t, err)
+	defer p.Close()
+	go func() {
+		<-terminal.keys
+		_ = unix.Kill(os.Getpid(), unix.SIGTERM)
+	}()
+
+	_, err = p.Run()
+
+	require.ErrorIs(t, err, ErrTerminated)
+	select {
+	case <-host:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the host handler did not receive the signal")
+	}
+	select {
+	case <-host:
+		t.Fatal("the host handler received the signal twice")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
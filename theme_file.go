@@ -0,0 +1,346 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ThemeEnvVar is the environment variable ThemeFromEnv reads.
+const ThemeEnvVar = "PROMPT_THEME"
+
+// ErrInvalidTheme is returned, wrapped with the details, for a theme file
+// that does not follow the ColorScheme schema.
+var ErrInvalidTheme = errors.New("invalid theme")
+
+// LoadTheme reads a color scheme from a JSON or TOML file, chosen by the
+// .json or .toml extension. The keys are the JSON names of the ColorScheme
+// fields. A color is either a "#rrggbb" string or an object with r, g, b,
+// bold, italic, underline, dim, reverse and background (itself a color), which
+// default to 0, false and none. Colors left out keep the ones of ThemeDefault,
+// and name defaults to the file name. Unknown keys, values of the wrong type
+// and color components outside 0-255 are reported as ErrInvalidTheme.
+//
+// A TOML theme:
+//
+//	name = "Mine"
+//	prefix = { r = 0, g = 200, b = 120, bold = true }
+//	input = "#f8f8f2"
+//
+//	[suggestion]
+//	text = "#bd93f9"
+//	match = { r = 255, g = 184, b = 108, bold = true }
+func LoadTheme(path string) (*ColorScheme, error) {
+	expanded, err := expandHistoryPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+	case ".toml":
+		table, err := parseTOML(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidTheme, path, err)
+		}
+		if data, err = json.Marshal(table); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidTheme, path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported theme format %q: want .json or .toml", ext)
+	}
+
+	theme, err := decodeTheme(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidTheme, path, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	if theme.Name == "" {
+		theme.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return theme, nil
+}
+
+// ThemeFromEnv returns the color scheme named by the PROMPT_THEME environment
+// variable, so users of an application can pick its colors: either the name
+// of a built-in theme such as "dracula" or "solarized-dark", or the path of a
+// theme file read with LoadTheme. It returns nil when the variable is not set,
+// which WithColorScheme treats as the default theme.
+//
+// Example:
+//
+//	theme, err := prompt.ThemeFromEnv()
+//	if err != nil {
+//		log.Printf("ignoring %s: %v", prompt.ThemeEnvVar, err)
+//	}
+//	p, err := prompt.New("$ ", prompt.WithColorScheme(theme))
+func ThemeFromEnv() (*ColorScheme, error) {
+	value := strings.TrimSpace(os.Getenv(ThemeEnvVar))
+	if value == "" {
+		return nil, nil
+	}
+	if theme := builtinTheme(value); theme != nil {
+		return theme, nil
+	}
+	return LoadTheme(value)
+}
+
+// builtinTheme returns the built-in theme called name, ignoring case, spaces,
+// dashes and underscores, or nil if there is none.
+func builtinTheme(name string) *ColorScheme {
+	normalize := strings.NewReplacer(" ", "", "-", "", "_", "")
+	name = normalize.Replace(strings.ToLower(name))
+	for _, theme := range []*ColorScheme{
+		ThemeDefault, ThemeDark, ThemeLight, ThemeSolarizedDark, ThemeAccessible,
+		ThemeVSCode, ThemeNightOwl, ThemeDracula, ThemeMonokai,
+	} {
+		if normalize.Replace(strings.ToLower(theme.Name)) == name {
+			return theme
+		}
+	}
+	return nil
+}
+
+// decodeTheme decodes a JSON color scheme on top of a copy of ThemeDefault,
+// rejecting unknown keys.
+func decodeTheme(data []byte) (*ColorScheme, error) {
+	// Colors may be "#rrggbb" strings; turn them into objects first so that
+	// the strict decode below reports errors with the path of the field
+	var tree any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	tree, err := expandColors(tree, "")
+	if err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(tree); err != nil {
+		return nil, err
+	}
+
+	theme := *ThemeDefault
+	theme.Name = ""
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&theme); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the theme")
+	}
+	return &theme, nil
+}
+
+// expandColors turns the colors of a decoded JSON theme into complete color
+// objects: "#rrggbb" strings are converted, and objects get the channels they
+// leave out set to 0, the attributes to false and the background to none, so
+// that a color replaces the default one as a whole. Every string but the name is a color, and every table but
+// the root and "suggestion" a color object. key is the path of value, for
+// error messages.
+func expandColors(value any, key string) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		for name, field := range v {
+			if key == "" && name == "name" {
+				continue
+			}
+			path := name
+			if key != "" {
+				path = key + "." + name
+			}
+			expanded, err := expandColors(field, path)
+			if err != nil {
+				return nil, err
+			}
+			v[name] = expanded
+		}
+		if key != "" && key != "suggestion" {
+			for _, field := range []string{"r", "g", "b"} {
+				if _, ok := v[field]; !ok {
+					v[field] = 0
+				}
+			}
+			for _, field := range []string{"bold", "italic", "underline", "dim", "reverse"} {
+				if _, ok := v[field]; !ok {
+					v[field] = false
+				}
+			}
+			if _, ok := v["background"]; !ok {
+				v["background"] = nil
+			}
+		}
+	case string:
+		color, err := parseHexColor(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		return expandColors(map[string]any{"r": color.R, "g": color.G, "b": color.B}, key)
+	}
+	return value, nil
+}
+
+// parseHexColor parses a "#rrggbb" color.
+func parseHexColor(s string) (Color, error) {
+	value, ok := strings.CutPrefix(s, "#")
+	if !ok || len(value) != 6 {
+		return Color{}, fmt.Errorf("color %q is not in #rrggbb form", s)
+	}
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("color %q is not in #rrggbb form", s)
+	}
+	return Color{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb)}, nil
+}
+
+// parseTOML parses the subset of TOML that theme files need: comments,
+// [table] and [table.sub] headers, and key = value pairs whose keys may be
+// dotted and whose values are strings, integers, booleans or inline tables.
+func parseTOML(data string) (map[string]any, error) {
+	root := map[string]any{}
+	table := root
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if line == "" {
+			continue
+		}
+		if header, ok := strings.CutPrefix(line, "["); ok {
+			name, ok := strings.CutSuffix(header, "]")
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated table header", i+1)
+			}
+			var err error
+			if table, err = tomlTable(root, strings.Split(name, ".")); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			continue
+		}
+		if err := parseTOMLPair(table, line); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return root, nil
+}
+
+// parseTOMLPair parses a key = value pair into table.
+func parseTOMLPair(table map[string]any, pair string) error {
+	key, raw, ok := strings.Cut(pair, "=")
+	if !ok {
+		return fmt.Errorf("expected key = value, got %q", pair)
+	}
+	path := strings.Split(strings.TrimSpace(key), ".")
+	parent, err := tomlTable(table, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(path[len(path)-1])
+	if name == "" {
+		return fmt.Errorf("empty key in %q", pair)
+	}
+	if _, exists := parent[name]; exists {
+		return fmt.Errorf("duplicate key %q", name)
+	}
+	value, err := parseTOMLValue(strings.TrimSpace(raw))
+	if err != nil {
+		return fmt.Errorf("key %q: %w", name, err)
+	}
+	parent[name] = value
+	return nil
+}
+
+// parseTOMLValue parses a string, integer, boolean or inline table.
+func parseTOMLValue(raw string) (any, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, "'") && strings.HasSuffix(raw, "'") && len(raw) >= 2:
+		return raw[1 : len(raw)-1], nil
+	case raw == "true" || raw == "false":
+		return raw == "true", nil
+	case strings.HasPrefix(raw, "{"):
+		body, ok := strings.CutSuffix(raw, "}")
+		if !ok {
+			return nil, errors.New("unterminated inline table")
+		}
+		table := map[string]any{}
+		for _, pair := range splitTOMLInline(body[1:]) {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			if err := parseTOMLPair(table, pair); err != nil {
+				return nil, err
+			}
+		}
+		return table, nil
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported value %q", raw)
+	}
+	return n, nil
+}
+
+// tomlTable returns the table at path below root, creating missing tables.
+func tomlTable(root map[string]any, path []string) (map[string]any, error) {
+	table := root
+	for _, name := range path {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, errors.New("empty table name")
+		}
+		next, exists := table[name]
+		if !exists {
+			next = map[string]any{}
+			table[name] = next
+		}
+		sub, ok := next.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a table", name)
+		}
+		table = sub
+	}
+	return table, nil
+}
+
+// stripTOMLComment removes a # comment that is not inside a string.
+func stripTOMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitTOMLInline splits the body of an inline table at the commas that are
+// not inside a string.
+func splitTOMLInline(body string) []string {
+	var parts []string
+	var quote rune
+	start := 0
+	for i, r := range body {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == ',':
+			parts = append(parts, body[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, body[start:])
+}
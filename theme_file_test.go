@@ -0,0 +1,185 @@
+package prompt
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTheme writes content to a file called name in a temporary directory
+// and returns its path.
+func writeTheme(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadTheme(t *testing.T) {
+	t.Parallel()
+
+	t.Run("built-in themes round-trip through JSON", func(t *testing.T) {
+		t.Parallel()
+
+		for _, theme := range []*ColorScheme{ThemeDefault, ThemeDark, ThemeSolarizedDark, ThemeDracula, ThemeMonokai} {
+			data, err := json.Marshal(theme)
+			require.NoError(t, err)
+
+			got, err := LoadTheme(writeTheme(t, "theme.json", string(data)))
+
+			require.NoError(t, err)
+			assert.Equal(t, theme, got)
+		}
+	})
+
+	t.Run("every field is read from TOML", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeTheme(t, "mine.toml", `
+# A theme setting every field
+name = "Mine"
+prefix = { r = 1, g = 2, b = 3, bold = true }
+input = "#040506"
+selected = { r = 7, g = 8, b = 9 }
+cursor = "#0a0b0c"
+error = { r = 13, g = 14, b = 15, bold = true }
+
+[background]
+r = 16
+g = 17
+b = 18
+
+[suggestion]
+text = "#131415"
+description = { r = 22, g = 23, b = 24 }
+match = { r = 25, g = 26, b = 27, bold = true } # highlighted runes
+background = "#1c1d1e"
+`)
+
+		got, err := LoadTheme(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, &ColorScheme{
+			Name:     "Mine",
+			Prefix:   Color{R: 1, G: 2, B: 3, Bold: true},
+			Input:    Color{R: 4, G: 5, B: 6},
+			Selected: Color{R: 7, G: 8, B: 9},
+			Cursor:   Color{R: 10, G: 11, B: 12},
+			Error:    Color{R: 13, G: 14, B: 15, Bold: true},
+			Suggestion: SuggestionColors{
+				Text:        Color{R: 19, G: 20, B: 21},
+				Description: Color{R: 22, G: 23, B: 24},
+				Match:       Color{R: 25, G: 26, B: 27, Bold: true},
+				Background:  &Color{R: 28, G: 29, B: 30},
+			},
+			Background: &Color{R: 16, G: 17, B: 18},
+		}, got)
+	})
+
+	t.Run("colors left out keep the default theme", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := LoadTheme(writeTheme(t, "ocean.json", `{"prefix": "#0077be"}`))
+
+		require.NoError(t, err)
+		want := *ThemeDefault
+		want.Name = "ocean"
+		want.Prefix = Color{R: 0, G: 0x77, B: 0xbe}
+		assert.Equal(t, &want, got)
+	})
+
+	t.Run("text attributes and backgrounds are read", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := LoadTheme(writeTheme(t, "attrs.json", `{
+			"input": {"r": 1, "italic": true, "underline": true},
+			"selected": {"g": 2, "reverse": true, "background": "#030405"},
+			"cursor": {"dim": true}
+		}`))
+
+		require.NoError(t, err)
+		assert.Equal(t, Color{R: 1, Italic: true, Underline: true}, got.Input)
+		assert.Equal(t, Color{G: 2, Reverse: true, Background: &Color{R: 3, G: 4, B: 5}}, got.Selected)
+		assert.Equal(t, Color{Dim: true}, got.Cursor)
+	})
+
+	t.Run("invalid themes", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name    string
+			file    string
+			content string
+			wantErr string
+		}{
+			{name: "unknown key", file: "t.json", content: `{"prefx": "#000000"}`, wantErr: `unknown field "prefx"`},
+			{name: "unknown color key", file: "t.json", content: `{"prefix": {"red": 1}}`, wantErr: `unknown field "red"`},
+			{name: "component out of range", file: "t.json", content: `{"input": {"r": 300}}`, wantErr: "input.r"},
+			{name: "wrong type", file: "t.json", content: `{"prefix": {"bold": "yes"}}`, wantErr: "prefix.bold"},
+			{name: "malformed hex color", file: "t.toml", content: `cursor = "#12345"`, wantErr: `cursor: color "#12345" is not in #rrggbb form`},
+			{name: "TOML syntax", file: "t.toml", content: "name = \"x\"\n[suggestion\n", wantErr: "line 2: unterminated table header"},
+			{name: "duplicate TOML key", file: "t.toml", content: "input = \"#000000\"\ninput = \"#ffffff\"\n", wantErr: `line 2: duplicate key "input"`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				_, err := LoadTheme(writeTheme(t, tt.file, tt.content))
+
+				require.ErrorIs(t, err, ErrInvalidTheme)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			})
+		}
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := LoadTheme(writeTheme(t, "theme.yaml", "name: x"))
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unsupported theme format ".yaml"`)
+	})
+}
+
+// TestThemeFromEnv does not run in parallel because it sets PROMPT_THEME.
+func TestThemeFromEnv(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		t.Setenv(ThemeEnvVar, "")
+
+		theme, err := ThemeFromEnv()
+
+		require.NoError(t, err)
+		assert.Nil(t, theme)
+	})
+
+	t.Run("built-in theme name", func(t *testing.T) {
+		t.Setenv(ThemeEnvVar, "solarized-dark")
+
+		theme, err := ThemeFromEnv()
+
+		require.NoError(t, err)
+		assert.Same(t, ThemeSolarizedDark, theme)
+	})
+
+	t.Run("theme file", func(t *testing.T) {
+		t.Setenv(ThemeEnvVar, writeTheme(t, "mine.toml", `prefix = "#ff0000"`))
+
+		theme, err := ThemeFromEnv()
+
+		require.NoError(t, err)
+		assert.Equal(t, Color{R: 255}, theme.Prefix)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv(ThemeEnvVar, filepath.Join(t.TempDir(), "missing.json"))
+
+		_, err := ThemeFromEnv()
+
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
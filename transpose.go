@@ -0,0 +1,40 @@
+package prompt
+
+// transposeChars swaps the character before the cursor with the one at the
+// cursor and moves the cursor forward, as readline's Ctrl+T does. At the end
+// of the input it swaps the last two characters instead, and at the start of
+// the input or with fewer than two characters it does nothing. It reports
+// whether the buffer changed.
+func (p *Prompt) transposeChars() bool {
+	if p.cursor == 0 || len(p.buffer) < 2 {
+		return false
+	}
+	if p.cursor == len(p.buffer) {
+		p.cursor--
+	}
+	p.buffer[p.cursor-1], p.buffer[p.cursor] = p.buffer[p.cursor], p.buffer[p.cursor-1]
+	p.cursor++
+	return true
+}
+
+// transposeWords swaps the word before the cursor with the word after it and
+// moves the cursor past both, as readline's Alt+T does. Inside a word, that
+// word counts as the one after the cursor, and at the end of the input the
+// last two words are swapped. The text between the words stays in place. It
+// reports whether the buffer changed.
+func (p *Prompt) transposeWords() bool {
+	secondStart := p.wordBoundaryFrom(p.wordBoundaryFrom(p.cursor, 1), -1)
+	secondEnd := p.wordBoundaryFrom(secondStart, 1)
+	firstStart := p.wordBoundaryFrom(secondStart, -1)
+	firstEnd := p.wordBoundaryFrom(firstStart, 1)
+	if firstStart == secondStart || secondStart < firstEnd {
+		return false // Fewer than two words
+	}
+	first := string(p.buffer[firstStart:firstEnd])
+	between := string(p.buffer[firstEnd:secondStart])
+	second := string(p.buffer[secondStart:secondEnd])
+	swapped := []rune(second + between + first)
+	copy(p.buffer[firstStart:secondEnd], swapped)
+	p.cursor = secondEnd
+	return true
+}
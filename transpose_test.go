@@ -0,0 +1,47 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranspose(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "ctrl+t swaps around the cursor and moves forward", input: "abcd\x01\x1b[C\x14!\r", want: "ba!cd"},
+		{name: "ctrl+t at the end swaps the last two", input: "abcd\x14!\r", want: "abdc!"},
+		{name: "ctrl+t repeated drags a character along", input: "abcd\x01\x1b[C\x14\x14\x14\r", want: "bcda"},
+		{name: "ctrl+t at the start does nothing", input: "abcd\x01\x14\r", want: "abcd"},
+		{name: "ctrl+t on one character does nothing", input: "a\x14\r", want: "a"},
+		{name: "ctrl+t handles wide characters", input: "日本\x14\r", want: "本日"},
+		{name: "alt+t swaps the words around the cursor", input: "foo bar baz\x01\x1bf\x1bt!\r", want: "bar foo! baz"},
+		{name: "alt+t inside a word takes it as the second", input: "foo bar\x1b[D\x1bt!\r", want: "bar foo!"},
+		{name: "alt+t at the end swaps the last two words", input: "one, two\x1bt!\r", want: "two, one!"},
+		{name: "alt+t keeps trailing separators in place", input: "ab cd  \x1bt!\r", want: "cd ab!  "},
+		{name: "alt+t keeps the words' lengths", input: "a longer\x1bt\r", want: "longer a"},
+		{name: "alt+t with one word does nothing", input: "foo\x1bt!\r", want: "foo!"},
+		{name: "alt+t at the start does nothing", input: "foo bar\x01\x1bt!\r", want: "!foo bar"},
+		{name: "transposing is undone in one step", input: "foo bar\x1bt\x1f\r", want: "foo bar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{Prefix: "$ "}, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
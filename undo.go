@@ -0,0 +1,75 @@
+package prompt
+
+import "slices"
+
+// maxUndoSteps is the number of edits that can be undone.
+const maxUndoSteps = 100
+
+// editState is a snapshot of the buffer and cursor taken for undo and redo.
+type editState struct {
+	buffer []rune
+	cursor int
+}
+
+// snapshot returns a copy of the current buffer and cursor.
+func (p *Prompt) snapshot() editState {
+	return editState{buffer: slices.Clone(p.buffer), cursor: p.cursor}
+}
+
+// restore replaces the buffer and cursor with a snapshot.
+func (p *Prompt) restore(state editState) {
+	p.buffer = slices.Clone(state.buffer)
+	p.cursor = min(state.cursor, len(p.buffer))
+}
+
+// resetUndo forgets all undo and redo steps, so a new input line starts with
+// an empty edit history.
+func (p *Prompt) resetUndo() {
+	p.undoStack = nil
+	p.redoStack = nil
+	p.undoGrouping = false
+}
+
+// recordEdit saves before as an undo step if the key just handled changed the
+// buffer. Like readline, a run of typed characters is one step: insertion
+// reports whether the key inserted text, and consecutive insertions join the
+// step opened by the first one until another key, even a cursor movement,
+// ends the run. Any new edit clears the redo steps.
+func (p *Prompt) recordEdit(before editState, insertion bool) {
+	if slices.Equal(before.buffer, p.buffer) {
+		if !insertion {
+			p.undoGrouping = false
+		}
+		return
+	}
+	if !insertion || !p.undoGrouping {
+		p.undoStack = append(p.undoStack, before)
+		if len(p.undoStack) > maxUndoSteps {
+			p.undoStack = p.undoStack[len(p.undoStack)-maxUndoSteps:]
+		}
+	}
+	p.undoGrouping = insertion
+	p.redoStack = nil
+}
+
+// undo reverts the last edit step and makes it available to redo.
+func (p *Prompt) undo() {
+	if len(p.undoStack) == 0 {
+		return
+	}
+	p.redoStack = append(p.redoStack, p.snapshot())
+	p.restore(p.undoStack[len(p.undoStack)-1])
+	p.undoStack = p.undoStack[:len(p.undoStack)-1]
+	p.undoGrouping = false
+}
+
+// redo reapplies the last undone edit step.
+func (p *Prompt) redo() {
+	if len(p.redoStack) == 0 {
+		return
+	}
+	p.undoStack = append(p.undoStack, p.snapshot())
+	p.restore(p.redoStack[len(p.redoStack)-1])
+	p.redoStack = p.redoStack[:len(p.redoStack)-1]
+	p.undoGrouping = false
+}
@@ -0,0 +1,84 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoRedo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "typed characters are undone together", input: "hello\x1f\r", want: ""},
+		{name: "a cursor movement starts a new undo step", input: "hello\x1b[D\x1b[Cworld\x1f\r", want: "hello"},
+		{name: "deletions are undone one at a time", input: "hello\x7f\x7f\x1f\r", want: "hell"},
+		{name: "undo restores a killed line", input: "hello world\x15\x1f\r", want: "hello world"},
+		{name: "undo steps back through several edits", input: "hello\x17world\x1f\x1f\r", want: "hello"},
+		{name: "undo with nothing to undo does nothing", input: "\x1f\x1fok\r", want: "ok"},
+		{name: "redo reapplies an undone edit", input: "hello\x1f\x1b_\r", want: "hello"},
+		{name: "redo after several undos goes forward in order", input: "ab\x7fc\x1f\x1f\x1b_\r", want: "a"},
+		{name: "a new edit clears the redo steps", input: "hello\x1fhi\x1b_\r", want: "hi"},
+		{name: "undo puts the cursor back where it was", input: "world\x01hello \x1f!\r", want: "!world"},
+		{name: "a pasted block is one undo step", input: "x\x1b[200~a\rb\x1b[201~\x1f\r", want: "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{Prefix: "$ "}, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+
+	t.Run("u undoes in vi normal mode", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{EditMode: EditModeVi}, "hello world\x1bdbu\r")
+		p.renderer.output = &bytes.Buffer{}
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", result)
+	})
+
+	t.Run("each line starts with an empty edit history", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{}, "first\r\x1fsecond\r")
+		p.renderer.output = &bytes.Buffer{}
+
+		_, err := p.Run()
+		require.NoError(t, err)
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "second", result)
+	})
+
+	t.Run("the number of undo steps is bounded", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{}, "")
+		for range maxUndoSteps + 10 {
+			before := p.snapshot()
+			p.insertRune('x')
+			p.recordEdit(before, false)
+		}
+
+		assert.Len(t, p.undoStack, maxUndoSteps)
+	})
+}
@@ -0,0 +1,65 @@
+package prompt
+
+// WithValidator sets a function that checks the input when Enter is pressed.
+// While it returns an error, Enter does not submit: the error message is drawn
+// below the input in the theme's error color and the user keeps editing. The
+// message stays until the input passes, and is re-checked after every edit so
+// it disappears as soon as the input is fixed. An error with an empty message
+// is shown as MsgInvalidInput. The validator receives the input after any
+// InputSanitizer.
+//
+// Example:
+//
+//	prompt.New("port: ", prompt.WithValidator(func(input string) error {
+//		if _, err := strconv.Atoi(input); err != nil {
+//			return errors.New("enter a number")
+//		}
+//		return nil
+//	}))
+func WithValidator(validate func(input string) error) Option {
+	return func(c *Config) {
+		c.Validator = validate
+	}
+}
+
+// WithValidateWhileTyping runs the Validator after every edit instead of only
+// on Enter, so the error shows up while the input is being typed.
+func WithValidateWhileTyping(enabled bool) Option {
+	return func(c *Config) {
+		c.ValidateAsYouType = enabled
+	}
+}
+
+// rejectInput runs the Validator on input and reports whether it is invalid.
+// The error is kept to be drawn below the input, and cleared when the input
+// passes.
+func (p *Prompt) rejectInput(input string) bool {
+	if p.config.Validator == nil {
+		return false
+	}
+	p.invalid = ""
+	if err := p.config.Validator(input); err != nil {
+		p.invalid = err.Error()
+		if p.invalid == "" {
+			// An empty message would leave Enter doing nothing without a reason
+			p.invalid = p.message(MsgInvalidInput)
+		}
+		return true
+	}
+	return false
+}
+
+// revalidate checks the buffer again after it changed, when an error is shown
+// or the input is validated while typing.
+func (p *Prompt) revalidate() {
+	if p.unbalanced != nil {
+		// The error is the balance check's until the quotes and brackets match
+		p.invalid = ""
+		if p.rejectUnbalanced(false) {
+			return
+		}
+	}
+	if p.invalid != "" || p.config.ValidateAsYouType {
+		p.rejectInput(p.submittedInput())
+	}
+}
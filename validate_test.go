@@ -0,0 +1,100 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidator(t *testing.T) {
+	t.Parallel()
+
+	notEmpty := func(input string) error {
+		if strings.TrimSpace(input) == "" {
+			return errors.New("required")
+		}
+		return nil
+	}
+
+	t.Run("Enter is rejected until the input passes", func(t *testing.T) {
+		t.Parallel()
+
+		var checked []string
+		config := Config{Prefix: "> ", Validator: func(input string) error {
+			checked = append(checked, input)
+			return notEmpty(input)
+		}}
+		p := newForTestingWithConfig(t, config, " \r\x7fok\r")
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result)
+		assert.Equal(t, []string{" ", "", "o", "ok"}, checked, "edits are checked only while an error is shown")
+		assert.Contains(t, out.String(), ThemeDefault.Error.ToANSI()+"required", "the error is drawn in the theme's error color")
+	})
+
+	t.Run("the error is erased when the prompt is cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "> ", Validator: notEmpty}, "\r\x03")
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+		_, err := p.Run()
+
+		require.ErrorIs(t, err, ErrInterrupted)
+		assert.Empty(t, p.renderer.errorMessage)
+		assert.Equal(t, 1, p.renderer.lastLines)
+	})
+
+	t.Run("an error without a message is shown with the default text", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Prefix: "> ", Validator: func(input string) error {
+			if input == "" {
+				return errors.New("")
+			}
+			return nil
+		}}
+		p := newForTestingWithConfig(t, config, "\rx\r")
+		var out bytes.Buffer
+		p.renderer = newRenderer(&out, ThemeDefault, p.terminal)
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "x", result)
+		assert.Contains(t, out.String(), ThemeDefault.Error.ToANSI()+"invalid input")
+	})
+
+	t.Run("input is only validated on Enter by default", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		config := Config{Prefix: "> ", Validator: func(string) error {
+			calls++
+			return nil
+		}}
+		p := newForTestingWithConfig(t, config, "abc\r")
+
+		result, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc", result)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestColorSchemeErrorColor(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ThemeDracula.Error, ThemeDracula.errorColor())
+	assert.Equal(t, Color{R: 255, G: 85, B: 85}, (&ColorScheme{}).errorColor(), "schemes without an error color fall back to red")
+}
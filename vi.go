@@ -0,0 +1,291 @@
+package prompt
+
+import (
+	"time"
+	"unicode"
+)
+
+// EditMode selects how the prompt interprets printable keys.
+type EditMode int
+
+const (
+	// EditModeEmacs is the default mode: every printable key inserts text and
+	// editing commands are bound to control keys through the KeyMap.
+	EditModeEmacs EditMode = iota
+	// EditModeVi adds vi-style modal editing on top of the KeyMap. The prompt
+	// starts in insert mode, which behaves like EditModeEmacs. Escape switches
+	// to normal mode, where letters are commands:
+	//
+	//	h l          move left, right
+	//	j k          next, previous history entry (or line in multi-line input)
+	//	w b e        next word, previous word, end of word
+	//	0 ^ $        start of line, first non-blank, end of line
+	//	x            delete the character under the cursor
+	//	dw db d$ dd  delete a word forward or backward, to the end of the line, the line
+	//	cw cb c$ cc  like d, then enter insert mode
+	//	D C          delete or change to the end of the line
+	//	i a I A      enter insert mode before or after the cursor, at the start or end of the line
+	//	u            undo
+	//
+	// Enter, Ctrl+C, Ctrl+D and arrow keys work in both modes. The current mode
+	// is shown in front of the prefix as "(ins)" or "(cmd)".
+	EditModeVi
+)
+
+// prefix returns the prefix to draw, with the vi mode indicator in front of it
+// in vi mode.
+func (p *Prompt) prefix() string {
+	if p.config.EditMode != EditModeVi {
+		return p.config.Prefix
+	}
+	if p.viNormal {
+		return p.message(MsgViNormalMode) + p.config.Prefix
+	}
+	return p.message(MsgViInsertMode) + p.config.Prefix
+}
+
+// loneEscape reports whether an ESC that was just read is the Escape key
+// rather than the start of an escape sequence. Terminals send sequences in one
+// burst starting with "[" or "O", so anything else following ESC, or nothing
+// within escapeTimeout, means Escape was pressed on its own. The key read while
+// deciding is kept and returned by the next nextKey call.
+func (p *Prompt) loneEscape() bool {
+	ev, ok := p.peekKey(escapeTimeout)
+	return !ok || ev.err != nil || (ev.r != '[' && ev.r != 'O')
+}
+
+// escapeTimedOut reports whether nothing followed an ESC that was just read
+// within escapeTimeout. Unlike loneEscape, it takes ESC followed by any key for
+// a sequence, such as Alt+Y, as emacs-style editing does. The key read while
+// deciding is kept and returned by the next nextKey call.
+func (p *Prompt) escapeTimedOut() bool {
+	_, ok := p.peekKey(escapeTimeout)
+	return !ok
+}
+
+// peekKey waits up to timeout for the next key and reports whether one came.
+// The key is put back so the event loop reads it next.
+func (p *Prompt) peekKey(timeout time.Duration) (keyEvent, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return keyEvent{}, false
+	case ev := <-p.nextKey():
+		ch := make(chan keyEvent, 1)
+		ch <- ev
+		p.keyCh = ch
+		return ev, true
+	}
+}
+
+// enterViNormal switches to vi normal mode. Like vi, the cursor steps back onto
+// the last inserted character.
+func (p *Prompt) enterViNormal() {
+	p.viNormal = true
+	if p.cursor > p.findLineStart() {
+		p.cursor--
+	}
+}
+
+// clampViCursor keeps the cursor on a character in vi normal mode, where it
+// cannot rest past the end of a non-empty line.
+func (p *Prompt) clampViCursor() {
+	if p.cursor > p.findLineStart() && p.cursor == p.findLineEnd() {
+		p.cursor--
+	}
+}
+
+// viNormalKey runs the vi normal mode command for r. Commands that work the
+// same as in insert mode, such as history navigation, are returned as an action
+// for the event loop to run; everything else is done here and ActionNone is
+// returned. Operators read their motion from the terminal.
+func (p *Prompt) viNormalKey(r rune) (KeyAction, error) {
+	switch r {
+	case 'h', '\x7f', '\b':
+		if p.cursor > p.findLineStart() {
+			p.cursor--
+		}
+	case 'l':
+		if p.cursor < p.findLineEnd()-1 {
+			p.cursor++
+		}
+	case 'j':
+		return ActionMoveDown, nil
+	case 'k':
+		return ActionMoveUp, nil
+	case 'u':
+		return ActionUndo, nil
+	case 'w':
+		p.cursor = p.viNextWordStart()
+	case 'b':
+		p.cursor = p.findWordBoundary(-1)
+	case 'e':
+		p.cursor = p.viEndOfWord()
+	case '0':
+		p.cursor = p.findLineStart()
+	case '^':
+		p.cursor = p.viFirstNonBlank()
+	case '$':
+		p.cursor = p.findLineEnd()
+	case 'x':
+		if p.cursor < p.findLineEnd() {
+			p.deleteRange(p.cursor, p.cursor+1)
+		}
+	case 'D':
+		p.deleteRange(p.cursor, p.findLineEnd())
+	case 'C':
+		p.deleteRange(p.cursor, p.findLineEnd())
+		p.viNormal = false
+	case 'i':
+		p.viNormal = false
+	case 'a':
+		if p.cursor < p.findLineEnd() {
+			p.cursor++
+		}
+		p.viNormal = false
+	case 'I':
+		p.cursor = p.viFirstNonBlank()
+		p.viNormal = false
+	case 'A':
+		p.cursor = p.findLineEnd()
+		p.viNormal = false
+	case 'd', 'c':
+		motion, err := p.readRune()
+		if err != nil {
+			return ActionNone, err
+		}
+		if !p.viOperator(r, motion) {
+			return ActionNone, nil // Unknown motion: the operator is cancelled
+		}
+		if r == 'c' {
+			p.viNormal = false
+		}
+	}
+
+	if p.viNormal {
+		p.clampViCursor()
+	}
+	return ActionNone, nil
+}
+
+// viOperator applies the delete operator for the motion that followed d or c,
+// and reports whether the motion was known. Like vim, cw changes to the end of
+// the word instead of the start of the next one, and dd removes the line
+// including its line break while cc keeps an empty line to type into.
+func (p *Prompt) viOperator(operator, motion rune) bool {
+	switch motion {
+	case 'w':
+		end := p.viNextWordStart()
+		if operator == 'c' {
+			end = p.viWordEnd()
+		}
+		p.deleteRange(p.cursor, end)
+	case 'b':
+		p.deleteRange(p.findWordBoundary(-1), p.cursor)
+	case '$':
+		p.deleteRange(p.cursor, p.findLineEnd())
+	case operator:
+		start, end := p.findLineStart(), p.findLineEnd()
+		if operator == 'd' {
+			if end < len(p.buffer) {
+				end++ // Remove the line break after the line
+			} else if start > 0 {
+				start-- // Last line: remove the line break before it
+			}
+		}
+		p.deleteRange(start, end)
+		if operator == 'd' {
+			p.cursor = p.findLineStart()
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// deleteRange removes buffer[start:end] and leaves the cursor at start.
+func (p *Prompt) deleteRange(start, end int) {
+	if start >= end {
+		return
+	}
+	p.buffer = append(p.buffer[:start], p.buffer[end:]...)
+	p.cursor = start
+}
+
+// viClass groups characters the way vi words are split: a word is a run of
+// word characters or a run of other non-blank characters.
+func viClass(r rune) int {
+	switch {
+	case r == '\n':
+		return 0
+	case unicode.IsSpace(r):
+		return 1
+	case isWordChar(r):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// viWordEnd returns the position just past the word at the cursor, skipping
+// leading blanks first.
+func (p *Prompt) viWordEnd() int {
+	pos := p.cursor
+	for pos < len(p.buffer) && viClass(p.buffer[pos]) == 1 {
+		pos++
+	}
+	if pos < len(p.buffer) && viClass(p.buffer[pos]) > 1 {
+		class := viClass(p.buffer[pos])
+		for pos < len(p.buffer) && viClass(p.buffer[pos]) == class {
+			pos++
+		}
+	}
+	return pos
+}
+
+// viNextWordStart returns the start of the next word (the w motion): the end of
+// the word at the cursor plus the blanks after it. It stops at a line break.
+func (p *Prompt) viNextWordStart() int {
+	pos := p.cursor
+	if pos < len(p.buffer) {
+		if class := viClass(p.buffer[pos]); class > 1 {
+			for pos < len(p.buffer) && viClass(p.buffer[pos]) == class {
+				pos++
+			}
+		}
+	}
+	for pos < len(p.buffer) && viClass(p.buffer[pos]) == 1 {
+		pos++
+	}
+	return pos
+}
+
+// viEndOfWord returns the position of the last character of the next word end
+// after the cursor (the e motion).
+func (p *Prompt) viEndOfWord() int {
+	pos := p.cursor + 1
+	for pos < len(p.buffer) && viClass(p.buffer[pos]) <= 1 {
+		pos++
+	}
+	if pos >= len(p.buffer) {
+		return p.cursor
+	}
+	class := viClass(p.buffer[pos])
+	for pos+1 < len(p.buffer) && viClass(p.buffer[pos+1]) == class {
+		pos++
+	}
+	return pos
+}
+
+// viFirstNonBlank returns the position of the first non-blank character of the
+// current line.
+func (p *Prompt) viFirstNonBlank() int {
+	pos := p.findLineStart()
+	end := p.findLineEnd()
+	for pos < end && viClass(p.buffer[pos]) == 1 {
+		pos++
+	}
+	return pos
+}
@@ -0,0 +1,116 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		history []string
+		want    string
+	}{
+		{name: "insert mode types like emacs mode", input: "hello\r", want: "hello"},
+		{name: "h moves left and i inserts", input: "helo\x1bhil\r", want: "hello"},
+		{name: "0 and a insert after the first character", input: "hllo\x1b0ae\r", want: "hello"},
+		{name: "A appends at the end", input: "hello\x1b0A world\r", want: "hello world"},
+		{name: "I inserts at the first non-blank", input: "  world\x1bIhello \r", want: "  hello world"},
+		{name: "x deletes the character under the cursor", input: "helllo\x1bhhx\r", want: "hello"},
+		{name: "dw deletes a word and the blanks after it", input: "hello big world\x1b0wdw\r", want: "hello world"},
+		{name: "db deletes the word before the cursor", input: "hello world\x1b$db\r", want: "hello d"},
+		{name: "cw changes the word at the cursor", input: "hello world\x1b0cwbye\r", want: "bye world"},
+		{name: "dd deletes the line", input: "hello\x1bdd\r", want: ""},
+		{name: "cc replaces the line", input: "hello\x1bccbye\r", want: "bye"},
+		{name: "D deletes to the end of the line", input: "hello world\x1b0wD\r", want: "hello "},
+		{name: "C changes to the end of the line", input: "hello world\x1b0wCthere\r", want: "hello there"},
+		{name: "d$ deletes to the end of the line", input: "hello world\x1b0ed$\r", want: "hell"},
+		{name: "e moves to the end of the word", input: "hello world\x1b0eax\r", want: "hellox world"},
+		{name: "b moves to the previous word", input: "hello world\x1bbix\r", want: "hello xworld"},
+		{name: "unknown motion cancels the operator", input: "hello\x1bdzx\r", want: "hell"},
+		{name: "letters without a command are ignored", input: "hello\x1bzq\r", want: "hello"},
+		{name: "backspace moves left in normal mode", input: "hello\x1b\x7fx\r", want: "helo"},
+		{name: "k recalls the previous history entry", input: "\x1bk\r", history: []string{"git status"}, want: "git status"},
+		{name: "arrow keys still work in normal mode", input: "hello\x1b\x1b[Dx\r", want: "helo"},
+		{name: "arrow keys still work in insert mode", input: "helo\x1b[Dl\r", want: "hello"},
+		{name: "escape in normal mode stays in normal mode", input: "hello\x1b\x1bx\r", want: "hell"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := Config{Prefix: "$ "}
+			WithEditMode(EditModeVi)(&config)
+			p := newForTestingWithConfig(t, config, tt.input)
+			p.history = tt.history
+			p.renderer.output = &bytes.Buffer{}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+
+	t.Run("escape at the end of the input is EOF", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{EditMode: EditModeVi}, "hello\x1b")
+		p.renderer.output = &bytes.Buffer{}
+
+		_, err := p.Run()
+
+		assert.ErrorIs(t, err, ErrEOF)
+	})
+
+	t.Run("operators work across lines", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{EditMode: EditModeVi}, "")
+		p.buffer = []rune("one\ntwo\nthree")
+		p.cursor = 5 // "w" of "two"
+		p.viNormal = true
+
+		require.True(t, p.viOperator('d', 'd'))
+		assert.Equal(t, "one\nthree", string(p.buffer))
+		assert.Equal(t, 4, p.cursor)
+
+		p.cursor = len(p.buffer)
+		require.True(t, p.viOperator('d', 'd'))
+		assert.Equal(t, "one", string(p.buffer))
+	})
+
+	t.Run("mode indicator is shown in front of the prefix", func(t *testing.T) {
+		t.Parallel()
+
+		p := newForTestingWithConfig(t, Config{Prefix: "$ ", EditMode: EditModeVi}, "")
+		assert.Equal(t, "(ins) $ ", p.prefix())
+		p.viNormal = true
+		assert.Equal(t, "(cmd) $ ", p.prefix())
+
+		p = newForTestingWithConfig(t, Config{Prefix: "$ "}, "")
+		assert.Equal(t, "$ ", p.prefix())
+	})
+
+	t.Run("normal mode is drawn with its indicator", func(t *testing.T) {
+		t.Parallel()
+
+		config := Config{Prefix: "$ ", EditMode: EditModeVi}
+		p := newForTestingWithConfig(t, config, "ls\x1bA\r")
+		var output bytes.Buffer
+		p.renderer.output = &output
+
+		_, err := p.Run()
+
+		require.NoError(t, err)
+		assert.Contains(t, output.String(), "(cmd) $ ")
+		assert.Contains(t, output.String(), "(ins) $ ")
+	})
+}
@@ -0,0 +1,61 @@
+package prompt
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// startVirtualTerminal runs args on a new PTY of cols by rows as the session
+// leader with the PTY as its controlling terminal, as a shell started by a
+// terminal emulator would be.
+func startVirtualTerminal(t *testing.T, args []string, cols, rows int) *virtualTerminal {
+	t.Helper()
+	master, slavePath := openPTY(t)
+	resize := func(cols, rows int) error {
+		return unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, &unix.Winsize{Col: uint16(cols), Row: uint16(rows)})
+	}
+	require.NoError(t, resize(cols, rows))
+
+	slave, err := os.OpenFile(slavePath, os.O_RDWR|syscall.O_NOCTTY, 0)
+	require.NoError(t, err)
+	defer slave.Close() // The child has its own descriptors once started
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		if cmd.ProcessState == nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}
+	})
+
+	vt := &virtualTerminal{input: master, resize: resize, wait: cmd.Wait}
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := master.Read(buf)
+			if err != nil {
+				return // EIO once the child has exited
+			}
+			_, _ = vt.screen.Write(buf[:n])
+		}
+	}()
+	return vt
+}
+
+// openVirtualConsole opens the controlling terminal of the child for reading
+// and writing.
+func openVirtualConsole() (in, out *os.File, err error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tty, tty, nil
+}
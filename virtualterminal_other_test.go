@@ -0,0 +1,22 @@
+//go:build !linux && !windows
+
+package prompt
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// startVirtualTerminal skips the test: there is no pseudo-terminal backend
+// for this platform yet.
+func startVirtualTerminal(t *testing.T, _ []string, _, _ int) *virtualTerminal {
+	t.Helper()
+	t.Skip("no virtual terminal backend on this platform")
+	return nil
+}
+
+// openVirtualConsole is never reached, since no child is started.
+func openVirtualConsole() (in, out *os.File, err error) {
+	return nil, nil, errors.New("no virtual terminal backend on this platform")
+}
@@ -0,0 +1,111 @@
+package prompt
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The virtual terminal tests run the prompt in a child process attached to a
+// pseudo-terminal, a PTY on Linux and a ConPTY pseudo console on Windows, so
+// the code that opens the controlling terminal runs end to end: raw mode and
+// its restoration, the terminal size, and keys arriving as bytes through the
+// terminal driver, none of which mockTerminal exercises. They start processes
+// and take a while, so they only run when asked for:
+//
+//	PROMPT_VT_TESTS=1 go test -run VirtualTerminal .
+
+// vtChildArg is the argument that makes the test binary run the prompt as the
+// child of a virtual terminal test.
+const vtChildArg = "vt-child"
+
+// virtualTerminal is a child process running on a pseudo-terminal.
+type virtualTerminal struct {
+	input  io.Writer                  // Keys typed into the terminal
+	screen syncBuffer                 // Everything the child wrote to the terminal
+	resize func(cols, rows int) error // Changes the size of the terminal
+	wait   func() error               // Waits for the child to exit
+}
+
+// startVT starts the test binary as the child of a virtual terminal of cols by
+// rows, or skips the test when the suite was not asked for.
+func startVT(t *testing.T, cols, rows int) *virtualTerminal {
+	t.Helper()
+	if os.Getenv("PROMPT_VT_TESTS") == "" {
+		t.Skip("set PROMPT_VT_TESTS=1 to run the virtual terminal tests")
+	}
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	return startVirtualTerminal(t, []string{exe, "-test.run=^TestVirtualTerminalChild$", "--", vtChildArg}, cols, rows)
+}
+
+// waitFor waits until the child has written text.
+func (vt *virtualTerminal) waitFor(t *testing.T, text string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(vt.screen.String(), text) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%q was not written; the screen has %q", text, vt.screen.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestVirtualTerminal(t *testing.T) {
+	t.Parallel()
+
+	vt := startVT(t, 100, 30)
+	vt.waitFor(t, "size=100x30")
+	vt.waitFor(t, "vt> ")
+
+	// The size is read from the terminal again after it changes
+	require.NoError(t, vt.resize(90, 20))
+	// Left arrow arrives as an escape sequence through the terminal driver
+	_, err := io.WriteString(vt.input, "helo\x1b[Dl\r")
+	require.NoError(t, err)
+	vt.waitFor(t, `result="hello" err=<nil>`)
+	vt.waitFor(t, "size=90x20")
+
+	// Only a terminal back in cooked mode hands over a line on Enter
+	_, err = io.WriteString(vt.input, "after\r")
+	require.NoError(t, err)
+	vt.waitFor(t, `cooked="after`)
+
+	require.NoError(t, vt.wait())
+}
+
+// TestVirtualTerminalChild is the program run on the virtual terminal by
+// TestVirtualTerminal. It reports what it sees on the terminal, one
+// "name=value" line at a time.
+func TestVirtualTerminalChild(t *testing.T) {
+	if args := flag.Args(); len(args) == 0 || args[0] != vtChildArg {
+		t.Skip("run by TestVirtualTerminal")
+	}
+	in, out, err := openVirtualConsole()
+	require.NoError(t, err)
+	defer in.Close()
+	defer out.Close()
+	report := func(format string, a ...any) {
+		fmt.Fprintf(out, format+"\r\n", a...)
+	}
+
+	p, err := New("vt> ", WithOutput(out))
+	require.NoError(t, err)
+	width, height, _ := p.terminal.Size()
+	report("size=%dx%d", width, height)
+	line, err := p.Run()
+	report("result=%q err=%v", line, err)
+	width, height, _ = p.terminal.Size()
+	report("size=%dx%d", width, height)
+	require.NoError(t, p.Close())
+
+	cooked, err := bufio.NewReader(in).ReadString('\n')
+	report("cooked=%q err=%v", cooked, err)
+}
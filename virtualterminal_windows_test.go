@@ -0,0 +1,96 @@
+package prompt
+
+import (
+	"os"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows"
+)
+
+// startVirtualTerminal runs args in a new ConPTY pseudo console of cols by
+// rows, which needs Windows 10 1809 or later. The console turns what the child
+// draws into VT sequences and the keys written to it into console input.
+func startVirtualTerminal(t *testing.T, args []string, cols, rows int) *virtualTerminal {
+	t.Helper()
+	inRead, inWrite, err := os.Pipe()
+	require.NoError(t, err)
+	outRead, outWrite, err := os.Pipe()
+	require.NoError(t, err)
+
+	var console windows.Handle
+	size := windows.Coord{X: int16(cols), Y: int16(rows)}
+	if err := windows.CreatePseudoConsole(size, windows.Handle(inRead.Fd()), windows.Handle(outWrite.Fd()), 0, &console); err != nil {
+		t.Skipf("pseudo consoles are not available: %v", err)
+	}
+	// The pseudo console keeps its own copies of the ends it uses
+	_ = inRead.Close()
+	_ = outWrite.Close()
+	t.Cleanup(func() {
+		windows.ClosePseudoConsole(console)
+		_ = inWrite.Close()
+		_ = outRead.Close()
+	})
+
+	attributes, err := windows.NewProcThreadAttributeList(1)
+	require.NoError(t, err)
+	defer attributes.Delete()
+	// The attribute value is the console handle itself, not a pointer to it
+	require.NoError(t, attributes.Update(windows.PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE, *(*unsafe.Pointer)(unsafe.Pointer(&console)), unsafe.Sizeof(console)))
+
+	startup := &windows.StartupInfoEx{ProcThreadAttributeList: attributes.List()}
+	startup.Cb = uint32(unsafe.Sizeof(*startup))
+	// Without standard handles of its own, the child would inherit the ones of
+	// the test process and write there instead of to the pseudo console
+	startup.Flags = windows.STARTF_USESTDHANDLES
+	startup.StdInput, startup.StdOutput, startup.StdErr = windows.InvalidHandle, windows.InvalidHandle, windows.InvalidHandle
+
+	commandLine, err := windows.UTF16PtrFromString(windows.ComposeCommandLine(args))
+	require.NoError(t, err)
+	var info windows.ProcessInformation
+	require.NoError(t, windows.CreateProcess(nil, commandLine, nil, nil, false,
+		windows.EXTENDED_STARTUPINFO_PRESENT, nil, nil, &startup.StartupInfo, &info))
+	_ = windows.CloseHandle(info.Thread)
+	process, err := os.FindProcess(int(info.ProcessId))
+	_ = windows.CloseHandle(info.Process)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = process.Kill() })
+
+	vt := &virtualTerminal{
+		input: inWrite,
+		resize: func(cols, rows int) error {
+			return windows.ResizePseudoConsole(console, windows.Coord{X: int16(cols), Y: int16(rows)})
+		},
+		wait: func() error {
+			_, err := process.Wait()
+			return err
+		},
+	}
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := outRead.Read(buf)
+			if err != nil {
+				return
+			}
+			_, _ = vt.screen.Write(buf[:n])
+		}
+	}()
+	return vt
+}
+
+// openVirtualConsole opens the console input and output of the child. Its
+// standard handles are left out by startVirtualTerminal.
+func openVirtualConsole() (in, out *os.File, err error) {
+	in, err = os.OpenFile("CONIN$", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err = os.OpenFile("CONOUT$", os.O_RDWR, 0)
+	if err != nil {
+		_ = in.Close()
+		return nil, nil, err
+	}
+	return in, out, nil
+}
@@ -0,0 +1,151 @@
+package prompt
+
+import (
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// runeWidth returns the number of terminal columns r takes on its own: 0 for
+// combining marks, format characters such as the zero width joiner and
+// control characters, 2 for East Asian wide and fullwidth characters, which
+// include the emoji drawn as pictures, and 1 for everything else. Characters
+// of ambiguous width are counted as narrow, as most terminals draw them.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case r < 0x300:
+		return 1 // Latin text, the common case
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1160 && r <= 0x11ff:
+		return 0 // Hangul vowels and final consonants join the syllable before them
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// isRegionalIndicator reports whether r is one of the letters that make up
+// flag emoji in pairs.
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// grapheme is a character the terminal draws as one, made of runes[start:end]
+// of the text it was found in, and the columns it takes.
+type grapheme struct {
+	start, end int
+	width      int
+}
+
+// graphemes splits runes into the characters the terminal draws as one: a
+// base character with the combining marks and variation selectors after it,
+// emoji joined with the zero width joiner or followed by a skin tone, and
+// flags made of two regional indicators. A character is as wide as its base,
+// or 2 columns when emoji presentation is requested with U+FE0F or a flag is
+// complete.
+func graphemes(runes []rune) []grapheme {
+	var chars []grapheme
+	for i := 0; i < len(runes); {
+		g := grapheme{start: i, width: runeWidth(runes[i])}
+		i++
+		if isRegionalIndicator(runes[g.start]) && i < len(runes) && isRegionalIndicator(runes[i]) {
+			g.width = 2
+			i++
+		}
+		for ; i < len(runes) && extendsGrapheme(runes[i-1], runes[i]); i++ {
+			if runes[i] == 0xfe0f {
+				g.width = 2
+			}
+		}
+		g.end = i
+		chars = append(chars, g)
+	}
+	return chars
+}
+
+// extendsGrapheme reports whether ch belongs to the character drawn for the
+// runes before it, the last of which is prev.
+func extendsGrapheme(prev, ch rune) bool {
+	switch {
+	case prev == 0x200d: // Joined to the emoji before the zero width joiner
+		return true
+	case ch >= 0x1f3fb && ch <= 0x1f3ff: // Skin tone modifiers
+		return true
+	default:
+		return ch >= 0x300 && runeWidth(ch) == 0
+	}
+}
+
+// runesWidth returns the number of terminal columns runes take.
+func runesWidth(runes []rune) int {
+	total := 0
+	for _, g := range graphemes(runes) {
+		total += g.width
+	}
+	return total
+}
+
+// stringWidth returns the number of terminal columns s takes.
+func stringWidth(s string) int {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c >= 0x7f {
+			return runesWidth([]rune(s))
+		}
+	}
+	return len(s) // Printable ASCII takes a column per byte
+}
+
+// truncateWidth shortens s to at most n terminal columns so a row never wraps.
+// Characters are kept whole: a wide character that would only half fit is
+// left out.
+func truncateWidth(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if stringWidth(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	end, used := 0, 0
+	for _, g := range graphemes(runes) {
+		if used+g.width > n {
+			break
+		}
+		end, used = g.end, used+g.width
+	}
+	return string(runes[:end])
+}
+
+// ellipsize returns s when it fits in n columns, and otherwise as much of it
+// as fits in n-1 columns followed by an ellipsis.
+func ellipsize(s string, n int) string {
+	if stringWidth(s) <= n {
+		return s
+	}
+	if n <= 1 {
+		return truncateWidth("…", n)
+	}
+	return truncateWidth(s, n-1) + "…"
+}
+
+// wrapText lays out runes from the start of a row on a terminal termWidth
+// columns wide and returns the number of rows they take and the column just
+// past the last character, which is termWidth when it exactly fills the row. A
+// wide character that does not fit at the end of a row moves to the next one,
+// as terminals draw it.
+func wrapText(runes []rune, termWidth int) (rows, col int) {
+	rows = 1
+	for _, g := range graphemes(runes) {
+		if col+g.width > termWidth && col > 0 {
+			rows++
+			col = 0
+		}
+		col += g.width
+	}
+	return rows, col
+}
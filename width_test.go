@@ -0,0 +1,121 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringWidth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{name: "ASCII", input: "hello", want: 5},
+		{name: "CJK", input: "日本語", want: 6},
+		{name: "fullwidth letters", input: "ＡＢ", want: 4},
+		{name: "combining mark", input: "é", want: 1},
+		{name: "emoji", input: "😀", want: 2},
+		{name: "emoji with skin tone", input: "👍🏽", want: 2},
+		{name: "emoji joined with ZWJ", input: "👩‍💻", want: 2},
+		{name: "text presentation turned into emoji", input: "❤️", want: 2},
+		{name: "flag", input: "🇯🇵", want: 2},
+		{name: "Hangul syllable from jamo", input: "가", want: 2},
+		{name: "mixed", input: "a日b", want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, stringWidth(tt.input))
+		})
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "hel", truncateWidth("hello", 3))
+	assert.Equal(t, "日", truncateWidth("日本語", 3), "a wide character that would only half fit is left out")
+	assert.Equal(t, "é", truncateWidth("éx", 1), "combining marks stay with their base")
+	assert.Equal(t, "日本語", truncateWidth("日本語", 6))
+	assert.Empty(t, truncateWidth("日本語", 0))
+}
+
+func TestEllipsize(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "hello", ellipsize("hello", 5))
+	assert.Equal(t, "hel…", ellipsize("hello", 4))
+	assert.Equal(t, "日…", ellipsize("日本語", 4), "a wide character that would only half fit is left out")
+	assert.Equal(t, "…", ellipsize("hello", 1))
+	assert.Empty(t, ellipsize("hello", 0))
+}
+
+func TestWrapText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		width    int
+		wantRows int
+		wantCol  int
+	}{
+		{name: "empty", input: "", width: 10, wantRows: 1, wantCol: 0},
+		{name: "exactly fills the row", input: "abcd", width: 4, wantRows: 1, wantCol: 4},
+		{name: "wraps", input: "abcde", width: 4, wantRows: 2, wantCol: 1},
+		{name: "wide characters", input: "日本語", width: 6, wantRows: 1, wantCol: 6},
+		{name: "a wide character does not fit in the last column", input: "a日本", width: 4, wantRows: 2, wantCol: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rows, col := wrapText([]rune(tt.input), tt.width)
+
+			assert.Equal(t, tt.wantRows, rows)
+			assert.Equal(t, tt.wantCol, col)
+		})
+	}
+}
+
+func TestRenderWideCharacters(t *testing.T) {
+	t.Parallel()
+
+	t.Run("the cursor moves back by the columns after it", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		r := newRenderer(&out, ThemeDefault, newMockTerminal(""))
+
+		assert.NoError(t, r.render("> ", "日本語", 1))
+		assert.Contains(t, out.String(), "\x1b[4D")
+		assert.Equal(t, 4, r.frameCursorCol, "the prefix and one wide character")
+	})
+
+	t.Run("earlier lines place the cursor by width", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		r := newRenderer(&out, ThemeDefault, newMockTerminal(""))
+
+		assert.NoError(t, r.render("> ", "日本\nx", 1)) // After 日
+		assert.Contains(t, out.String(), "\x1b[1A\r\x1b[4C")
+	})
+
+	t.Run("wide input is counted by columns when it wraps", func(t *testing.T) {
+		t.Parallel()
+
+		r := newRenderer(&bytes.Buffer{}, ThemeDefault, newMockTerminal(""))
+
+		// 2 columns of prefix and 40 wide characters take 82 of the 80 columns
+		assert.Equal(t, 2, r.calculateRenderedLines("> ", strings.Repeat("日", 40)))
+		assert.Equal(t, 1, r.calculateRenderedLines("> ", "日本語"))
+	})
+}
@@ -0,0 +1,31 @@
+package prompt
+
+import "unicode"
+
+// changeWordCase applies ActionUpcaseWord, ActionDowncaseWord or
+// ActionCapitalizeWord to the text from the cursor to the end of the word, as
+// readline's Alt+U, Alt+L and Alt+C do, and moves the cursor to the end of the
+// word. It reports whether the buffer changed.
+func (p *Prompt) changeWordCase(action KeyAction) bool {
+	end := p.findWordBoundary(1)
+	changed := false
+	first := true // Capitalize uppercases the first letter of the word only
+	for i := p.cursor; i < end; i++ {
+		r := p.buffer[i]
+		switch {
+		case !isWordChar(r):
+			continue
+		case action == ActionUpcaseWord, action == ActionCapitalizeWord && first:
+			r = unicode.ToUpper(r)
+		default:
+			r = unicode.ToLower(r)
+		}
+		first = false
+		if r != p.buffer[i] {
+			p.buffer[i] = r
+			changed = true
+		}
+	}
+	p.cursor = end
+	return changed
+}
@@ -0,0 +1,42 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAltWordCommands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "alt+b moves back a word", input: "foo bar\x1bb!\r", want: "foo !bar"},
+		{name: "alt+f moves forward a word", input: "foo bar\x01\x1bf!\r", want: "foo! bar"},
+		{name: "alt+u uppercases to the end of the word", input: "foo bar\x01\x1bu!\r", want: "FOO! bar"},
+		{name: "alt+u from inside a word", input: "foo bar\x01\x1b[C\x1bu\r", want: "fOO bar"},
+		{name: "alt+l lowercases the next word", input: "FOO BAR\x01\x1bf\x1bl!\r", want: "FOO bar!"},
+		{name: "alt+c capitalizes the next word", input: "hELLO wORLD\x01\x1bc\x1bc\r", want: "Hello World"},
+		{name: "alt+c skips separators before the word", input: "a  --bc\x01\x1bf\x1bc\r", want: "a  --Bc"},
+		{name: "case changes are undone in one step", input: "foo bar\x01\x1bu\x1f\r", want: "foo bar"},
+		{name: "case commands at the end do nothing", input: "foo\x1bu\x1bc\r", want: "foo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newForTestingWithConfig(t, Config{Prefix: "$ "}, tt.input)
+			p.renderer.output = &bytes.Buffer{}
+
+			result, err := p.Run()
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}